@@ -0,0 +1,61 @@
+package debugger
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// OpenAIAccount is the OpenAI organization and project that API usage is attributed to
+type OpenAIAccount struct {
+	Organization string // OPENAI_ORG_ID, sent as the OpenAI-Organization header
+	Project      string // OPENAI_PROJECT_ID, sent as the OpenAI-Project header
+}
+
+// openAIAccountFromEnv reads the organization and project from OPENAI_ORG_ID and OPENAI_PROJECT_ID
+func openAIAccountFromEnv() OpenAIAccount {
+	return OpenAIAccount{
+		Organization: strings.TrimSpace(os.Getenv("OPENAI_ORG_ID")),
+		Project:      strings.TrimSpace(os.Getenv("OPENAI_PROJECT_ID")),
+	}
+}
+
+// String describes the account for logs, e.g. "organization org-abc, project proj_123"
+func (a OpenAIAccount) String() string {
+	var parts []string
+	if a.Organization != "" {
+		parts = append(parts, "organization "+a.Organization)
+	}
+	if a.Project != "" {
+		parts = append(parts, "project "+a.Project)
+	}
+	if len(parts) == 0 {
+		return "default organization and project of the API key"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// accountTransport sets the organization and project headers on every request to the LLM API
+// The OpenAI client only has a setting for the organization, and the model metadata requests do not
+// go through it, so both headers are set here.
+type accountTransport struct {
+	account OpenAIAccount
+	base    http.RoundTripper
+}
+
+func (t *accountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.account.Organization != "" {
+		req.Header.Set("OpenAI-Organization", t.account.Organization)
+	}
+	if t.account.Project != "" {
+		req.Header.Set("OpenAI-Project", t.account.Project)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Account returns the OpenAI organization and project API usage is attributed to; both are empty
+// with Azure OpenAI, which bills by resource
+func (d *GitHubWorkflowDebugger) Account() OpenAIAccount {
+	return d.account
+}
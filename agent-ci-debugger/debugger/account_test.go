@@ -0,0 +1,56 @@
+package debugger
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOpenAIAccountHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		org, project string
+		want         string
+	}{
+		{"none", "", "", "default organization and project of the API key"},
+		{"organization", " org-abc ", "", "organization org-abc"},
+		{"project", "", "proj_123", "project proj_123"},
+		{"both", "org-abc", "proj_123", "organization org-abc, project proj_123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &mockOpenAI{}
+			m.start(t)
+			t.Setenv("OPENAI_ORG_ID", tt.org)
+			t.Setenv("OPENAI_PROJECT_ID", tt.project)
+			d := New("test-key")
+			if got := d.Account().String(); got != tt.want {
+				t.Errorf("Account = %q, want %q", got, tt.want)
+			}
+
+			if _, err := d.AnalyzeFailure(context.Background(), testRun()); err != nil {
+				t.Fatal(err)
+			}
+			for _, h := range m.headers {
+				if got := h.Get("OpenAI-Organization"); got != d.Account().Organization {
+					t.Errorf("OpenAI-Organization = %q, want %q", got, d.Account().Organization)
+				}
+				if got := h.Get("OpenAI-Project"); got != d.Account().Project {
+					t.Errorf("OpenAI-Project = %q, want %q", got, d.Account().Project)
+				}
+			}
+			if len(m.headers) == 0 {
+				t.Error("no request reached the API")
+			}
+		})
+	}
+}
+
+func TestOpenAIAccountAzure(t *testing.T) {
+	t.Setenv("OPENAI_ORG_ID", "org-abc")
+	t.Setenv("OPENAI_PROJECT_ID", "proj_123")
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "https://example.openai.azure.com")
+	t.Setenv("AZURE_OPENAI_DEPLOYMENT", "gpt-4o")
+	if got := New("test-key").Account(); got != (OpenAIAccount{}) {
+		t.Errorf("Account = %+v, want none with Azure OpenAI", got)
+	}
+}
@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Annotation is a check-run annotation, the concise failure message GitHub shows on the run page
+type Annotation struct {
+	Job         string `json:"-"`
+	Path        string `json:"path"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StartColumn int    `json:"start_column"`
+	Level       string `json:"annotation_level"`
+	Title       string `json:"title"`
+	Message     string `json:"message"`
+}
+
+// hasLocation reports whether the annotation points at a source file line
+// Job-level annotations use the ".github" pseudo path without a line.
+func (a Annotation) hasLocation() bool {
+	return a.Path != "" && a.Path != ".github" && a.StartLine > 0
+}
+
+// fetchAnnotations returns the annotations of a job's check run
+func fetchAnnotations(repo string, jobID int64) ([]Annotation, error) {
+	output, err := gh("api", fmt.Sprintf("repos/%s/check-runs/%d/annotations", repo, jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get annotations: %w", err)
+	}
+	return parseAnnotations(output)
+}
+
+// parseAnnotations parses the JSON payload of the check-run annotations endpoint
+func parseAnnotations(payload []byte) ([]Annotation, error) {
+	var annotations []Annotation
+	if err := json.Unmarshal(payload, &annotations); err != nil {
+		return nil, fmt.Errorf("failed to parse annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+// fetchRunAnnotations collects the annotations of the failed jobs of a run
+// (or of the analyzed job only when a job URL was given)
+func fetchRunAnnotations(run *WorkflowRun, jobs []ghJob) ([]Annotation, error) {
+	var annotations []Annotation
+	for _, job := range jobs {
+		if run.JobID != "" {
+			if fmt.Sprint(job.DatabaseID) != run.JobID {
+				continue
+			}
+		} else if job.Conclusion != "failure" && job.Conclusion != "timed_out" {
+			continue
+		}
+
+		jobAnnotations, err := fetchAnnotations(run.Repository, job.DatabaseID)
+		if err != nil {
+			logWarnf("job %q: %v", job.Name, err)
+			continue
+		}
+		for _, a := range jobAnnotations {
+			a.Job = job.Name
+			annotations = append(annotations, a)
+		}
+	}
+
+	// Failures first, then warnings and notices
+	levelOrder := map[string]int{"failure": 0, "warning": 1, "notice": 2}
+	sort.SliceStable(annotations, func(i, j int) bool {
+		return levelOrder[annotations[i].Level] < levelOrder[annotations[j].Level]
+	})
+
+	return annotations, nil
+}
+
+// writeAnnotations writes the annotations section of the prompt
+func writeAnnotations(sb *strings.Builder, annotations []Annotation) {
+	if len(annotations) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("GitHub Annotations (%d total):\n", len(annotations)))
+	for i, a := range annotations {
+		if i >= 20 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(annotations)-20))
+			break
+		}
+		location := ""
+		if a.hasLocation() {
+			location = fmt.Sprintf(" %s:%d", a.Path, a.StartLine)
+		}
+		message := strings.ReplaceAll(strings.TrimSpace(a.Message), "\n", " ")
+		sb.WriteString(fmt.Sprintf("  - [%s]%s %s\n", a.Level, location, truncateText(message, 500)))
+	}
+}
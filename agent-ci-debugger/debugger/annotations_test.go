@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// annotationsPayload is a response of the check-run annotations endpoint
+const annotationsPayload = `[
+  {
+    "path": "pkg/api/handler.go",
+    "blob_href": "https://github.com/o/r/blob/abc/pkg/api/handler.go",
+    "start_line": 42,
+    "end_line": 42,
+    "start_column": 7,
+    "end_column": 7,
+    "annotation_level": "failure",
+    "title": "",
+    "message": "undefined: NewServer",
+    "raw_details": ""
+  },
+  {
+    "path": ".github",
+    "start_line": 0,
+    "end_line": 0,
+    "annotation_level": "warning",
+    "message": "Node.js 16 actions are deprecated."
+  }
+]`
+
+func TestParseAnnotations(t *testing.T) {
+	got, err := parseAnnotations([]byte(annotationsPayload))
+	if err != nil {
+		t.Fatalf("parseAnnotations() error = %v", err)
+	}
+	want := []Annotation{
+		{Path: "pkg/api/handler.go", StartLine: 42, EndLine: 42, StartColumn: 7, Level: "failure", Message: "undefined: NewServer"},
+		{Path: ".github", Level: "warning", Message: "Node.js 16 actions are deprecated."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAnnotations() = %+v, want %+v", got, want)
+	}
+	if !got[0].hasLocation() || got[1].hasLocation() {
+		t.Errorf("hasLocation() = %v, %v, want true, false", got[0].hasLocation(), got[1].hasLocation())
+	}
+
+	if _, err := parseAnnotations([]byte(`{"message":"Not Found"}`)); err == nil {
+		t.Error("parseAnnotations() of an error payload succeeded, want an error")
+	}
+}
+
+func TestFetchRunAnnotations(t *testing.T) {
+	stubGitHub(t, map[string]string{
+		"/repos/o/r/check-runs/7/annotations": annotationsPayload,
+		"/repos/o/r/check-runs/8/annotations": `[{"path":"main_test.go","start_line":3,"annotation_level":"notice","message":"slow test"},
+			{"path":"main_test.go","start_line":9,"annotation_level":"failure","message":"TestMain failed"}]`,
+	})
+	jobs := []ghJob{
+		{DatabaseID: 6, Name: "lint", Conclusion: "success"},
+		{DatabaseID: 7, Name: "build", Conclusion: "failure"},
+		{DatabaseID: 8, Name: "test", Conclusion: "timed_out"},
+	}
+
+	got, err := fetchRunAnnotations(&WorkflowRun{Repository: "o/r"}, jobs)
+	if err != nil {
+		t.Fatalf("fetchRunAnnotations() error = %v", err)
+	}
+	var levels, owners []string
+	for _, a := range got {
+		levels = append(levels, a.Level)
+		owners = append(owners, a.Job)
+	}
+	if want := []string{"failure", "failure", "warning", "notice"}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("levels = %v, want failures first %v", levels, want)
+	}
+	if want := []string{"build", "test", "build", "test"}; !reflect.DeepEqual(owners, want) {
+		t.Errorf("jobs = %v, want %v", owners, want)
+	}
+
+	// A job URL limits the annotations to its job
+	got, _ = fetchRunAnnotations(&WorkflowRun{Repository: "o/r", JobID: "8"}, jobs)
+	if len(got) != 2 || got[0].Job != "test" {
+		t.Errorf("annotations of job 8 = %+v, want the 2 annotations of test", got)
+	}
+}
+
+func TestWriteAnnotations(t *testing.T) {
+	annotations, _ := parseAnnotations([]byte(annotationsPayload))
+	var sb strings.Builder
+	writeAnnotations(&sb, annotations)
+	want := "GitHub Annotations (2 total):\n" +
+		"  - [failure] pkg/api/handler.go:42 undefined: NewServer\n" +
+		"  - [warning] Node.js 16 actions are deprecated.\n"
+	if sb.String() != want {
+		t.Errorf("writeAnnotations() =\n%q\nwant\n%q", sb.String(), want)
+	}
+}
@@ -0,0 +1,136 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ownerPseudonym replaces the owner (organization, user or GitLab group) of the run in the prompt
+const ownerPseudonym = "org"
+
+// anonymizer pseudonymizes the owner and repository names of a run in the prompt (Options.Anonymize)
+// and maps the pseudonyms in the answer back. The run's repository is always "repo-a"; other
+// repositories of the same owner found in the prompt, e.g. in Go module paths, get "repo-b",
+// "repo-c" and so on. The mapping only lives in memory for the analysis.
+type anonymizer struct {
+	owner     string
+	ownerRe   *regexp.Regexp    // "<owner>/<repo>" references
+	names     map[string]string // lowercase real repository name -> pseudonym
+	realNames map[string]string // pseudonym -> real repository name
+}
+
+// newAnonymizer returns the anonymizer of the repository ("owner/repo", or "group/subgroup/project" on GitLab)
+func newAnonymizer(repository string) *anonymizer {
+	owner, name, ok := cutLast(repository, "/")
+	if !ok {
+		owner, name = "", repository
+	}
+	a := &anonymizer{
+		owner:     owner,
+		names:     make(map[string]string),
+		realNames: make(map[string]string),
+	}
+	if owner != "" {
+		a.ownerRe = regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(owner) + `/([A-Za-z0-9_.-]*[A-Za-z0-9_-])`)
+	}
+	if name != "" {
+		a.pseudonym(name)
+	}
+	return a
+}
+
+// cutLast slices s around the last instance of sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// pseudonym returns the pseudonym of a repository name, assigning the next free one to a new name
+func (a *anonymizer) pseudonym(name string) string {
+	key := strings.ToLower(name)
+	if p, ok := a.names[key]; ok {
+		return p
+	}
+	p := "repo-" + pseudonymSuffix(len(a.names))
+	a.names[key] = p
+	a.realNames[p] = name
+	return p
+}
+
+// pseudonymSuffix returns the letters of the n-th pseudonym: a, b, ..., z, aa, ab, ...
+func pseudonymSuffix(n int) string {
+	s := ""
+	for n++; n > 0; n = (n - 1) / 26 {
+		s = string(rune('a'+(n-1)%26)) + s
+	}
+	return s
+}
+
+// byLength sorts the keys of m longest first, so a name is replaced before the names it contains
+func byLength(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// wholeWordRe matches s as a whole word, case-insensitively
+func wholeWordRe(s string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(s) + `\b`)
+}
+
+// anonymize replaces the owner and repository names in text: "<owner>/<repo>" references first, which
+// also finds the other repositories of the owner, then the bare repository names and the bare owner
+// Runner workspace paths like /home/runner/work/<repo>/<repo> are covered by the repository names.
+func (a *anonymizer) anonymize(text string) string {
+	if a.ownerRe != nil {
+		text = a.ownerRe.ReplaceAllStringFunc(text, func(ref string) string {
+			return ownerPseudonym + "/" + a.pseudonym(a.ownerRe.FindStringSubmatch(ref)[1])
+		})
+	}
+	for _, name := range byLength(a.names) {
+		text = wholeWordRe(name).ReplaceAllString(text, a.names[name])
+	}
+	if a.owner != "" {
+		text = wholeWordRe(a.owner).ReplaceAllString(text, ownerPseudonym)
+	}
+	return text
+}
+
+// deanonymize maps the pseudonyms of the repositories in text back to their names, e.g. in the file
+// references of the answer
+// A bare ownerPseudonym is left alone: "org" is too common a word to be sure it means the owner.
+func (a *anonymizer) deanonymize(text string) string {
+	for _, p := range byLength(a.realNames) {
+		name := a.realNames[p]
+		if a.owner != "" {
+			text = wholeWordRe(ownerPseudonym+"/"+p).ReplaceAllString(text, a.owner+"/"+name)
+		}
+		text = wholeWordRe(p).ReplaceAllString(text, name)
+	}
+	return text
+}
+
+// String describes the mapping for the debug log
+func (a *anonymizer) String() string {
+	var pairs []string
+	for _, p := range byLength(a.realNames) {
+		pairs = append(pairs, fmt.Sprintf("%s -> %s", a.realNames[p], p))
+	}
+	sort.Strings(pairs)
+	if a.owner != "" {
+		pairs = append([]string{fmt.Sprintf("%s -> %s", a.owner, ownerPseudonym)}, pairs...)
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,132 @@
+package debugger
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestAnonymizeRoundTrip(t *testing.T) {
+	text := "build\tRun go build\tgo: downloading github.com/mycorp/secret-lib/v2 v2.1.0\n" +
+		"build\tRun go build\t/home/runner/work/secret-svc/secret-svc/main.go:5:2: undefined: lib.Client\n" +
+		"build\tRun go build\tsee https://github.com/MyCorp/secret-svc/pull/7 and mycorp/secret-lib"
+	a := newAnonymizer("mycorp/secret-svc")
+
+	anonymized := a.anonymize(text)
+	want := "build\tRun go build\tgo: downloading github.com/org/repo-b/v2 v2.1.0\n" +
+		"build\tRun go build\t/home/runner/work/repo-a/repo-a/main.go:5:2: undefined: lib.Client\n" +
+		"build\tRun go build\tsee https://github.com/org/repo-a/pull/7 and org/repo-b"
+	if anonymized != want {
+		t.Fatalf("anonymize() =\n%s\nwant\n%s", anonymized, want)
+	}
+	if got := a.String(); got != "mycorp -> org, secret-lib -> repo-b, secret-svc -> repo-a" {
+		t.Errorf("String() = %q", got)
+	}
+
+	// the owner's case is not restored, the rest maps back
+	if got := a.deanonymize(anonymized); got != strings.ReplaceAll(text, "MyCorp", "mycorp") {
+		t.Errorf("deanonymize() =\n%s\nwant the original text", got)
+	}
+}
+
+func TestAnonymizeOwner(t *testing.T) {
+	a := newAnonymizer("mycorp/svc")
+	got := a.anonymize("mycorp builds svc; svcs and mycorporate stay")
+	if want := "org builds repo-a; svcs and mycorporate stay"; got != want {
+		t.Errorf("anonymize() = %q, want %q", got, want)
+	}
+	// a bare "org" is too common to map back
+	if back := a.deanonymize(got); back != "org builds svc; svcs and mycorporate stay" {
+		t.Errorf("deanonymize() = %q", back)
+	}
+
+	gitlab := newAnonymizer("group/sub/project")
+	if got := gitlab.anonymize("group/sub/project and group/sub/other"); got != "org/repo-a and org/repo-b" {
+		t.Errorf("anonymize() of a GitLab project = %q", got)
+	}
+}
+
+func TestPseudonymSuffix(t *testing.T) {
+	for n, want := range map[int]string{0: "a", 1: "b", 25: "z", 26: "aa", 27: "ab", 51: "az", 52: "ba", 701: "zz", 702: "aaa"} {
+		if got := pseudonymSuffix(n); got != want {
+			t.Errorf("pseudonymSuffix(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestAnalyzeFailureAnonymized(t *testing.T) {
+	var prompt string
+	api := &mockOpenAI{reply: func(req openai.ChatCompletionRequest) openai.ChatCompletionResponse {
+		for _, m := range req.Messages {
+			prompt += m.Content
+		}
+		return answer("## Root Cause\nrepo-a imports org/repo-b, whose go.sum entry is missing.\n\n" +
+			"## Proposed Fix\nRun go mod tidy in repo-a.\n\n## Files to Check\n- repo-a/go.sum\n\n## Confidence Level\nHigh")(req)
+	}}
+	d := api.start(t)
+	d.Options.Anonymize = true
+	run := testRun()
+	run.Repository = "mycorp/secret-svc"
+	run.FailedLogs += "\nbuild\tRun go build\t2024-01-01T00:00:02Z /home/runner/work/secret-svc/secret-svc/go.mod: mycorp/secret-lib missing"
+
+	proposal, err := d.AnalyzeFailure(context.Background(), run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"mycorp", "secret-svc", "secret-lib"} {
+		if strings.Contains(strings.ToLower(prompt), name) {
+			t.Errorf("prompt contains %q", name)
+		}
+	}
+	if !strings.Contains(prompt, "org/repo-a") {
+		t.Errorf("prompt does not name the pseudonymized repository")
+	}
+	if proposal.RootCause != "secret-svc imports mycorp/secret-lib, whose go.sum entry is missing." ||
+		proposal.ProposedFix != "Run go mod tidy in secret-svc." || proposal.FilesToCheck[0] != "secret-svc/go.sum" ||
+		!strings.Contains(proposal.RawResponse, "mycorp/secret-lib") {
+		t.Errorf("proposal = %+v, want the real names", proposal)
+	}
+}
+
+func TestHistoryAnonymized(t *testing.T) {
+	path := seedHistory(t)
+	api := &mockOpenAI{routes: map[string]string{
+		"/v1/embeddings": `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"model":"text-embedding-3-small"}`,
+	}}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, HistoryFile: path, Anonymize: true}
+	run := &WorkflowRun{URL: "https://github.com/mycorp/secret-svc/actions/runs/1", Repository: "mycorp/secret-svc", ErrorSummary: ErrorSummary{
+		FailedJobs:    []string{"secret-svc-build"},
+		ErrorMessages: []string{"/home/runner/work/secret-svc/secret-svc/go.mod: mycorp/secret-lib missing"},
+	}}
+
+	embedding, _, err := d.findSimilarFailures(context.Background(), run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.recordFailure(run, &FixProposal{RootCause: "missing go.sum entry"}, embedding); err != nil {
+		t.Fatal(err)
+	}
+	store, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.posted["/v1/embeddings"]) != 1 || len(store.Entries) != 1 {
+		t.Fatalf("%d embedding requests, %d history entries, want 1", len(api.posted["/v1/embeddings"]), len(store.Entries))
+	}
+	for what, text := range map[string]string{
+		"embedding input": string(api.posted["/v1/embeddings"][0]),
+		"history summary": store.Entries[0].Summary,
+	} {
+		for _, name := range []string{"mycorp", "secret-svc", "secret-lib"} {
+			if strings.Contains(text, name) {
+				t.Errorf("%s contains %q: %s", what, name, text)
+			}
+		}
+		if !strings.Contains(text, "repo-a-build") || !strings.Contains(text, "/home/runner/work/repo-a/repo-a/go.mod: org/repo-b missing") {
+			t.Errorf("%s = %s, want the pseudonyms", what, text)
+		}
+	}
+}
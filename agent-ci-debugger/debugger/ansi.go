@@ -0,0 +1,18 @@
+package debugger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ansiRe matches ANSI escape sequences: colors and cursor/erase commands (CSI, e.g. "\x1b[31m"),
+// terminal titles and hyperlinks (OSC, e.g. "\x1b]8;;url\x07") and single-character escapes
+var ansiRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// stripANSI removes ANSI escape sequences from logs
+func stripANSI(logs string) string {
+	if !strings.Contains(logs, "\x1b") {
+		return logs
+	}
+	return ansiRe.ReplaceAllString(logs, "")
+}
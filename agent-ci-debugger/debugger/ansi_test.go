@@ -0,0 +1,52 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name string
+		logs string
+		want string
+	}{
+		{name: "plain", logs: "no escapes here", want: "no escapes here"},
+		{name: "colors", logs: "\x1b[31;1mError:\x1b[0m build failed", want: "Error: build failed"},
+		{name: "erase line", logs: "\x1b[0K\x1b[2Kprogress", want: "progress"},
+		{name: "hyperlink", logs: "see \x1b]8;;https://example.com\x07the docs\x1b]8;;\x07", want: "see the docs"},
+		{name: "title with ST", logs: "\x1b]0;title\x1b\\text", want: "text"},
+		{name: "single character", logs: "\x1bMreverse", want: "reverse"},
+		{name: "private mode", logs: "\x1b[?25lhidden cursor\x1b[?25h", want: "hidden cursor"},
+	}
+	for _, tt := range tests {
+		if got := stripANSI(tt.logs); got != tt.want {
+			t.Errorf("%s: stripANSI(%q) = %q, want %q", tt.name, tt.logs, got, tt.want)
+		}
+	}
+}
+
+func TestParseLogsStripsANSI(t *testing.T) {
+	logs := "build\tRun make\t2024-01-01T00:00:00.0000000Z \x1b[31mError:\x1b[0m undefined: Foo\n" +
+		"build\tRun make\t2024-01-01T00:00:01.0000000Z \x1b[1m##[error]Process completed with exit code 2.\x1b[0m"
+
+	d := &GitHubWorkflowDebugger{}
+	run := &WorkflowRun{FailedLogs: logs}
+	d.parseLogs(run)
+	if strings.Contains(run.FailedLogs, "\x1b") {
+		t.Errorf("FailedLogs = %q, want the escapes stripped", run.FailedLogs)
+	}
+	if len(run.ErrorSummary.ErrorMessages) == 0 || strings.Contains(run.ErrorSummary.ErrorMessages[0], "\x1b") {
+		t.Errorf("ErrorMessages = %q, want the colored error line matched", run.ErrorSummary.ErrorMessages)
+	}
+	if prompt := d.buildAnalysisPrompt(run, defaultLogBudget); strings.Contains(prompt, "\x1b") {
+		t.Error("the prompt contains ANSI escapes")
+	}
+
+	d.Options.KeepANSI = true
+	run = &WorkflowRun{FailedLogs: logs}
+	d.parseLogs(run)
+	if run.FailedLogs != logs {
+		t.Errorf("FailedLogs = %q, want the escapes kept with KeepANSI", run.FailedLogs)
+	}
+}
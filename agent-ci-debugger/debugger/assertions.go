@@ -0,0 +1,117 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Assertion is a test assertion failure with the compared values
+type Assertion struct {
+	Test     string // test name, when reported
+	Location string // file:line of the assertion, when reported
+	Expected string
+	Actual   string
+	Message  string // the log line(s) the assertion was extracted from
+	Line     int    // 0-based index of the log line
+}
+
+var (
+	// testify: "expected: X" followed by "actual  : Y"
+	testifyExpectedRe = regexp.MustCompile(`^\s*expected\s*:\s?(.*)$`)
+	testifyActualRe   = regexp.MustCompile(`^\s*actual\s*:\s?(.*)$`)
+	testifyTraceRe    = regexp.MustCompile(`Error Trace:\s*(\S+)`)
+	testifyTestRe     = regexp.MustCompile(`^\s*Test:\s*(\S+)`)
+	// Go stdlib: "got X, want Y" and "want X, got Y" (also "expected" for "want")
+	gotWantRe = regexp.MustCompile(`(?i)\bgot:?\s+(.+?),?\s+(?:want|wanted|expected):?\s+(.+)$`)
+	wantGotRe = regexp.MustCompile(`(?i)\b(?:want|wanted|expected):?\s+(.+?),?\s+(?:got|but got):?\s+(.+)$`)
+	// JUnit: "expected:<X> but was:<Y>"
+	junitRe = regexp.MustCompile(`expected:\s*<(.*)> but was:\s*<(.*)>`)
+	// Go source location at the start of a test output line: "foo_test.go:42: ..."
+	goLocationRe = regexp.MustCompile(`^\s*([\w./-]+\.go:\d+):`)
+)
+
+// assertionDetector recognizes assertion failures of testify, the Go stdlib and JUnit
+type assertionDetector struct{}
+
+func (assertionDetector) Name() string { return "assertion" }
+
+func (assertionDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(lines); i++ {
+		content := logContent(lines[i])
+
+		// testify prints expected/actual on consecutive lines within the "Error:" block
+		if m := testifyExpectedRe.FindStringSubmatch(content); m != nil && i+1 < len(lines) {
+			if a := testifyActualRe.FindStringSubmatch(logContent(lines[i+1])); a != nil {
+				assertion := Assertion{
+					Expected: strings.TrimSpace(m[1]),
+					Actual:   strings.TrimSpace(a[1]),
+					Message:  strings.TrimSpace(content) + "\n" + strings.TrimSpace(logContent(lines[i+1])),
+					Line:     i,
+				}
+				for j := i - 1; j >= 0 && j >= i-5; j-- {
+					if t := testifyTraceRe.FindStringSubmatch(logContent(lines[j])); t != nil {
+						assertion.Location = runnerWorkspaceRe.ReplaceAllString(t[1], "")
+						break
+					}
+				}
+				for j := i + 2; j < len(lines) && j <= i+30; j++ {
+					if t := testifyTestRe.FindStringSubmatch(logContent(lines[j])); t != nil {
+						assertion.Test = t[1]
+						break
+					}
+				}
+				findings = append(findings, assertionFinding(assertion))
+				i++
+				continue
+			}
+		}
+
+		trimmed := strings.TrimSpace(content)
+		assertion := Assertion{Message: trimmed, Line: i}
+		if m := junitRe.FindStringSubmatch(trimmed); m != nil {
+			assertion.Expected, assertion.Actual = m[1], m[2]
+		} else if m := gotWantRe.FindStringSubmatch(trimmed); m != nil {
+			assertion.Actual, assertion.Expected = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		} else if m := wantGotRe.FindStringSubmatch(trimmed); m != nil {
+			assertion.Expected, assertion.Actual = strings.TrimSpace(m[1]), strings.TrimSpace(m[2])
+		} else {
+			continue
+		}
+		if m := goLocationRe.FindStringSubmatch(trimmed); m != nil {
+			assertion.Location = m[1]
+		}
+		findings = append(findings, assertionFinding(assertion))
+	}
+
+	return findings
+}
+
+// assertionFinding wraps an assertion into a finding
+func assertionFinding(a Assertion) Finding {
+	return Finding{Category: CategoryAssertion, Message: a.Message, Line: a.Line, Assertion: &a}
+}
+
+// writeAssertions lists the expected/actual pairs of assertion failures in the prompt
+func writeAssertions(sb *strings.Builder, assertions []Assertion) {
+	if len(assertions) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("Assertion Failures (%d total):\n", len(assertions)))
+	for i, a := range assertions {
+		if i >= 5 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(assertions)-5))
+			break
+		}
+		where := strings.TrimSpace(strings.Join([]string{a.Test, a.Location}, " "))
+		if where == "" {
+			where = "(location unknown)"
+		}
+		sb.WriteString(fmt.Sprintf("  - %s\n", where))
+		sb.WriteString(fmt.Sprintf("    expected: %s\n", truncateText(a.Expected, 300)))
+		sb.WriteString(fmt.Sprintf("    actual:   %s\n", truncateText(a.Actual, 300)))
+	}
+}
@@ -0,0 +1,99 @@
+package debugger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testifyLines is the output of a failed testify assert.Equal in TestParse
+var testifyLines = fixtureLines("test", "Run go test",
+	"=== RUN   TestParse",
+	"    parse_test.go:15: ",
+	"        \tError Trace:\t/home/runner/work/app/app/parse_test.go:15",
+	"        \tError:      \tNot equal: ",
+	"        \t            \texpected: 2",
+	"        \t            \tactual  : 1",
+	"        \tTest:       \tTestParse",
+	"--- FAIL: TestParse (0.00s)",
+	"FAIL",
+	"FAIL\texample.com/app\t0.01s",
+)
+
+func TestAssertionDetector(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []Assertion
+	}{
+		{
+			name:  "testify",
+			lines: testifyLines,
+			want: []Assertion{{
+				Test: "TestParse", Location: "parse_test.go:15", Expected: "2", Actual: "1",
+				Message: "expected: 2\nactual  : 1", Line: 4,
+			}},
+		},
+		{
+			name:  "got want",
+			lines: fixtureLines("test", "Run go test", "    parse_test.go:12: Parse(\"x\") got 1, want 2"),
+			want: []Assertion{{
+				Location: "parse_test.go:12", Expected: "2", Actual: "1",
+				Message: "parse_test.go:12: Parse(\"x\") got 1, want 2",
+			}},
+		},
+		{
+			name:  "want got",
+			lines: fixtureLines("test", "Run go test", "    config_test.go:30: wanted \"prod\" but got \"dev\""),
+			want: []Assertion{{
+				Location: "config_test.go:30", Expected: "\"prod\"", Actual: "\"dev\"",
+				Message: "config_test.go:30: wanted \"prod\" but got \"dev\"",
+			}},
+		},
+		{
+			name: "junit",
+			lines: fixtureLines("test", "Run mvn test",
+				"[ERROR] testTotal(com.example.CartTest)  Time elapsed: 0.01 s  <<< FAILURE!",
+				"org.opentest4j.AssertionFailedError: expected:<42> but was:<41>"),
+			want: []Assertion{{
+				Expected: "42", Actual: "41",
+				Message: "org.opentest4j.AssertionFailedError: expected:<42> but was:<41>", Line: 1,
+			}},
+		},
+		{
+			name:  "no assertion",
+			lines: fixtureLines("test", "Run go test", "ok  \texample.com/app\t0.01s", "expected: a value on its own"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Assertion
+			for _, f := range (assertionDetector{}).Detect(tt.lines) {
+				if f.Category != CategoryAssertion || f.Assertion == nil || f.Message != f.Assertion.Message {
+					t.Errorf("finding = %+v", f)
+					continue
+				}
+				got = append(got, *f.Assertion)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assertions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestifyFailedTest(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	run := &WorkflowRun{FailedLogs: strings.Join(testifyLines, "\n")}
+	d.parseLogs(run)
+
+	// The test is named by its "--- FAIL" line, not by testify's "Error Trace:" location
+	want := []string{"test\tRun go test\t2024-01-01T00:00:00.0000000Z --- FAIL: TestParse (0.00s)"}
+	if got := run.ErrorSummary.FailedTests; !reflect.DeepEqual(got, want) {
+		t.Errorf("FailedTests = %q, want %q", got, want)
+	}
+	if got := run.ErrorSummary.Assertions; len(got) != 1 || got[0].Test != "TestParse" || got[0].Location != "parse_test.go:15" {
+		t.Errorf("Assertions = %+v, want the testify assertion", got)
+	}
+}
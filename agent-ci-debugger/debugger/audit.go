@@ -0,0 +1,93 @@
+package debugger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// auditMu serializes appends of the analyses of a batch within this process
+var auditMu sync.Mutex
+
+// AuditRecord is one line of the audit log
+// It holds metadata and hashes only; logs, prompts and reports are never written to it.
+type AuditRecord struct {
+	Timestamp        time.Time  `json:"timestamp"`
+	User             string     `json:"user"`
+	Repository       string     `json:"repository"`
+	RunID            string     `json:"run_id"`
+	JobID            string     `json:"job_id,omitempty"`
+	Workflow         string     `json:"workflow,omitempty"`
+	Model            string     `json:"model"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	Cost             float64    `json:"cost_usd"`
+	Confidence       string     `json:"confidence"`
+	ConfidenceScore  int        `json:"confidence_score,omitempty"`
+	ReportSHA256     string     `json:"report_sha256"`
+	LogBudget        *LogBudget `json:"log_budget,omitempty"`
+}
+
+// auditUser identifies who ran the analysis: the GitHub actor in Actions, otherwise the OS user
+func auditUser() string {
+	if actor := os.Getenv("GITHUB_ACTOR"); actor != "" {
+		return actor
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// newAuditRecord describes an analysis for the audit log
+func (d *GitHubWorkflowDebugger) newAuditRecord(run *WorkflowRun, proposal *FixProposal, report string) AuditRecord {
+	hash := sha256.Sum256([]byte(report))
+	return AuditRecord{
+		Timestamp:        time.Now().UTC(),
+		User:             auditUser(),
+		Repository:       run.Repository,
+		RunID:            run.RunID,
+		JobID:            run.JobID,
+		Workflow:         run.WorkflowName,
+		Model:            d.proposalModel(proposal),
+		PromptTokens:     proposal.Usage.PromptTokens,
+		CompletionTokens: proposal.Usage.CompletionTokens,
+		Cost:             proposal.Cost,
+		Confidence:       confidenceLevel(proposal.Confidence),
+		ConfidenceScore:  proposal.ConfidenceScore,
+		ReportSHA256:     hex.EncodeToString(hash[:]),
+		LogBudget:        run.LogBudget,
+	}
+}
+
+// AppendAuditRecord appends the record as one JSON line to the audit log at path
+// The line is written with a single append-mode write, so concurrent writers (also other
+// processes) never interleave partial lines.
+func AppendAuditRecord(path string, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
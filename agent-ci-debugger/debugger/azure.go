@@ -0,0 +1,65 @@
+package debugger
+
+import (
+	"os"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// azureConfigFromEnv builds an Azure OpenAI client configuration when AZURE_OPENAI_ENDPOINT is set
+// The API key is read from AZURE_OPENAI_API_KEY, falling back to apiKey. Models are mapped onto
+// deployments with AZURE_OPENAI_DEPLOYMENT, either a single deployment name used for every model or
+// a list of model=deployment pairs ("gpt-4o=prod-gpt4o,gpt-4o-mini=cheap"); unmapped models use the
+// client's default mapping (the model name without "." and ":").
+func azureConfigFromEnv(apiKey string) (openai.ClientConfig, bool) {
+	endpoint := os.Getenv("AZURE_OPENAI_ENDPOINT")
+	if endpoint == "" {
+		return openai.ClientConfig{}, false
+	}
+	if key := os.Getenv("AZURE_OPENAI_API_KEY"); key != "" {
+		apiKey = key
+	}
+
+	config := openai.DefaultAzureConfig(apiKey, endpoint)
+	if version := os.Getenv("AZURE_OPENAI_API_VERSION"); version != "" {
+		config.APIVersion = version
+	}
+
+	defaultMapper := config.AzureModelMapperFunc
+	single, mapping := parseDeploymentMap(os.Getenv("AZURE_OPENAI_DEPLOYMENT"))
+	config.AzureModelMapperFunc = func(model string) string {
+		if deployment, ok := mapping[model]; ok {
+			return deployment
+		}
+		if single != "" {
+			return single
+		}
+		return defaultMapper(model)
+	}
+
+	logDebugf("Using Azure OpenAI endpoint %s (API version %s)", endpoint, config.APIVersion)
+	return config, true
+}
+
+// parseDeploymentMap parses AZURE_OPENAI_DEPLOYMENT into a single deployment or model=deployment pairs
+func parseDeploymentMap(value string) (single string, mapping map[string]string) {
+	mapping = make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if model, deployment, ok := strings.Cut(entry, "="); ok {
+			mapping[strings.TrimSpace(model)] = strings.TrimSpace(deployment)
+		} else {
+			single = entry
+		}
+	}
+	return single, mapping
+}
+
+// usingAzure reports whether the Azure OpenAI endpoint is configured
+func usingAzure() bool {
+	return os.Getenv("AZURE_OPENAI_ENDPOINT") != ""
+}
@@ -0,0 +1,212 @@
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is the number of runs analyzed in parallel in batch mode
+const DefaultBatchConcurrency = 4
+
+// BatchSummaryFile is the name of the summary written to the output directory in batch mode
+const BatchSummaryFile = "batch-summary.csv"
+
+// ErrBatchCostLimit is recorded for the runs skipped after the batch cost cap was reached
+var ErrBatchCostLimit = errors.New("batch cost limit reached")
+
+// BatchOptions configure a batch of analyses
+type BatchOptions struct {
+	// Concurrency is the number of runs analyzed in parallel (default 4)
+	Concurrency int
+	// MaxTotalCost stops starting new analyses once the summed USD cost exceeds it (0 = no limit)
+	MaxTotalCost float64
+	// OutputDir receives one report per run and the summary CSV
+	OutputDir string
+	// Format is the report format, "markdown" or "sarif"
+	Format string
+	// BreakerThreshold is the number of consecutive model API failures after which the remaining runs
+	// are skipped until the API recovers (0 = DefaultBreakerThreshold, negative = never)
+	BreakerThreshold int
+	// BreakerCooldown is how long runs are skipped before the API is probed again (0 = DefaultBreakerCooldown)
+	BreakerCooldown time.Duration
+}
+
+// BatchResult is the outcome of one run of a batch
+type BatchResult struct {
+	URL        string
+	Conclusion string
+	Confidence string
+	Cost       float64
+	ReportFile string
+	Err        error
+
+	answered bool // the model API answered the analysis, rather than the cache or the offline rules
+}
+
+// ReadBatchURLs reads run URLs from r, one per line; blank lines and lines starting with # are skipped
+func ReadBatchURLs(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read URLs: %w", err)
+	}
+	return urls, nil
+}
+
+// RunBatch analyzes the runs with a bounded pool of workers
+// Each worker gets its own debugger from newDebugger, so no state is shared between analyses.
+// Failed runs are recorded in their result and do not stop the batch; results are returned in
+// the order of urls. When the model API keeps failing, a circuit breaker shared by the workers skips
+// runs with ErrCircuitOpen until a probe succeeds (see BatchOptions.BreakerThreshold).
+func RunBatch(ctx context.Context, urls []string, newDebugger func() *GitHubWorkflowDebugger, opts BatchOptions) []BatchResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	results := make([]BatchResult, len(urls))
+	breaker := newCircuitBreaker(opts.BreakerThreshold, opts.BreakerCooldown)
+	jobs := make(chan int)
+	var (
+		mu        sync.Mutex
+		totalCost float64
+		done      int
+	)
+	budgetLeft := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return opts.MaxTotalCost <= 0 || totalCost < opts.MaxTotalCost
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			debugger := newDebugger()
+			for i := range jobs {
+				result := BatchResult{URL: urls[i]}
+				if !budgetLeft() {
+					result.Err = ErrBatchCostLimit
+				} else if probe, err := breaker.allow(); err != nil {
+					result.Err = err
+				} else {
+					analyzeBatchRun(ctx, debugger, &result, opts)
+					breaker.record(probe, result.Err, result.answered)
+				}
+				results[i] = result
+
+				mu.Lock()
+				totalCost += result.Cost
+				done++
+				logInfof("[%d/%d] %s: %s (total cost $%.4f)", done, len(urls), result.URL, batchStatus(result), totalCost)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// analyzeBatchRun analyzes a single run of a batch and saves its report
+func analyzeBatchRun(ctx context.Context, d *GitHubWorkflowDebugger, result *BatchResult, opts BatchOptions) {
+	ref, err := ParseRunRef(result.URL)
+	if err != nil {
+		result.Err = err
+		return
+	}
+
+	res, err := d.DebugRun(ctx, ref)
+	if res != nil && res.Run != nil {
+		result.Conclusion = res.Run.Conclusion
+	}
+	if err != nil {
+		result.Err = err
+		return
+	}
+	result.Confidence = res.Proposal.Confidence
+	result.Cost = res.Proposal.Cost
+	result.answered = !res.Proposal.Heuristic && !res.Proposal.cached
+
+	report, err := d.RenderReport(res, opts.Format)
+	if err != nil {
+		result.Err = err
+		return
+	}
+	ext := "md"
+	if opts.Format == "sarif" {
+		ext = "sarif"
+	}
+	path := ReportPath("", opts.OutputDir, ext, res.Run.Ref(), time.Now())
+	if result.ReportFile, err = SaveNewReport(path, report); err != nil {
+		result.Err = fmt.Errorf("failed to save report: %w", err)
+	}
+}
+
+// batchStatus describes the outcome of a run for the progress log and the summary
+func batchStatus(result BatchResult) string {
+	switch {
+	case errors.Is(result.Err, ErrNothingToAnalyze):
+		return "skipped"
+	case errors.Is(result.Err, ErrBatchCostLimit), errors.Is(result.Err, ErrCircuitOpen):
+		return "not analyzed"
+	case result.Err != nil:
+		return "failed"
+	default:
+		return "analyzed"
+	}
+}
+
+// WriteBatchSummary writes the results as CSV (url, status, conclusion, confidence, cost, report, error)
+func WriteBatchSummary(path string, results []BatchResult) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write batch summary: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"url", "status", "conclusion", "confidence", "cost", "report", "error"})
+	for _, r := range results {
+		errText := ""
+		if r.Err != nil {
+			errText = r.Err.Error()
+		}
+		w.Write([]string{
+			r.URL,
+			batchStatus(r),
+			r.Conclusion,
+			confidenceLevel(r.Confidence),
+			strconv.FormatFloat(r.Cost, 'f', 4, 64),
+			r.ReportFile,
+			errText,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write batch summary: %w", err)
+	}
+	return f.Close()
+}
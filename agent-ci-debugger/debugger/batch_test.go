@@ -0,0 +1,109 @@
+package debugger
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// batchRoutes are the REST routes of run id of o/r with one job concluding conclusion
+func batchRoutes(routes map[string]string, id int, conclusion string) {
+	routes[fmt.Sprintf("/repos/o/r/actions/runs/%d", id)] = fmt.Sprintf(`{"status":"completed","conclusion":%q,"name":"CI"}`, conclusion)
+	routes[fmt.Sprintf("/repos/o/r/actions/runs/%d/jobs", id)] = fmt.Sprintf(`{"total_count":1,"jobs":[
+		{"id":%d,"name":"build","status":"completed","conclusion":%q,
+		 "steps":[{"name":"Run go build","number":1,"status":"completed","conclusion":%[2]q}]}]}`, id*10, conclusion)
+	routes[fmt.Sprintf("/repos/o/r/actions/jobs/%d/logs", id*10)] = "2024-01-01T00:00:00.0000000Z main.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html\n" +
+		"2024-01-01T00:00:01.0000000Z ##[error]Process completed with exit code 1.\n"
+}
+
+func TestRunBatch(t *testing.T) {
+	routes := make(map[string]string)
+	batchRoutes(routes, 1, "failure")
+	batchRoutes(routes, 2, "success")
+	batchRoutes(routes, 3, "failure")
+	stubGitHub(t, routes)
+	api := &mockOpenAI{}
+	api.start(t)
+
+	urls := []string{
+		"https://github.com/o/r/actions/runs/1",
+		"https://github.com/o/r/actions/runs/2",
+		"https://example.com/not-a-run",
+		"https://github.com/o/r/actions/runs/3",
+	}
+	dir := t.TempDir()
+	newDebugger := func() *GitHubWorkflowDebugger {
+		d := New("test-key")
+		d.Options.Quiet = true
+		return d
+	}
+	results := RunBatch(context.Background(), urls, newDebugger, BatchOptions{Concurrency: 2, OutputDir: dir, Format: "markdown"})
+
+	var statuses []string
+	for i, r := range results {
+		if r.URL != urls[i] {
+			t.Errorf("results[%d].URL = %q, want the order of the URLs", i, r.URL)
+		}
+		statuses = append(statuses, batchStatus(r))
+	}
+	if want := []string{"analyzed", "skipped", "failed", "analyzed"}; !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("statuses = %v, want %v (errors %v, %v, %v, %v)", statuses, want, results[0].Err, results[1].Err, results[2].Err, results[3].Err)
+	}
+	if !errors.Is(results[1].Err, ErrNothingToAnalyze) {
+		t.Errorf("result of the successful run = %+v, want it skipped", results[1])
+	}
+	if n := len(api.requests(t)); n != 2 {
+		t.Errorf("API received %d requests, want one per failed run", n)
+	}
+	for _, r := range []BatchResult{results[0], results[3]} {
+		if confidenceLevel(r.Confidence) != "High" || r.ReportFile == "" {
+			t.Errorf("result = %+v, want an analysis with a report", r)
+			continue
+		}
+		report, err := os.ReadFile(r.ReportFile)
+		if err != nil {
+			t.Fatalf("report not saved: %v", err)
+		}
+		if !strings.Contains(string(report), "go.sum entry of golang.org/x/net is missing") {
+			t.Errorf("report %s does not contain the analysis", r.ReportFile)
+		}
+	}
+	if results[0].ReportFile == results[3].ReportFile {
+		t.Errorf("both runs were saved to %s", results[0].ReportFile)
+	}
+
+	summary := filepath.Join(dir, BatchSummaryFile)
+	if err := WriteBatchSummary(summary, results); err != nil {
+		t.Fatalf("WriteBatchSummary() error = %v", err)
+	}
+	f, err := os.Open(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("summary is not valid CSV: %v", err)
+	}
+	if len(records) != len(urls)+1 || records[0][0] != "url" {
+		t.Fatalf("summary = %v, want a header and one row per URL", records)
+	}
+	if row := records[1]; row[1] != "analyzed" || row[2] != "failure" || row[3] != "High" || row[5] != results[0].ReportFile {
+		t.Errorf("summary row = %v", row)
+	}
+}
+
+func TestReadBatchURLs(t *testing.T) {
+	input := "# nightly failures\nhttps://github.com/o/r/actions/runs/1\n\n  https://github.com/o/r/actions/runs/2  \n"
+	got, err := ReadBatchURLs(strings.NewReader(input))
+	want := []string{"https://github.com/o/r/actions/runs/1", "https://github.com/o/r/actions/runs/2"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBatchURLs() = %q, %v, want %q", got, err, want)
+	}
+}
@@ -0,0 +1,134 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Defaults of the circuit breaker of batch mode
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = time.Minute
+)
+
+// ErrAPICall wraps the errors of calls to the model API
+var ErrAPICall = errors.New("failed to call OpenAI API")
+
+// ErrCircuitOpen is recorded for the runs of a batch skipped while the model API is considered down
+var ErrCircuitOpen = errors.New("model API circuit breaker open")
+
+// apiOutage reports whether err is a failed model API call that says the API is unavailable: a
+// network error, a server error or a rate limit. Rejected requests (e.g. a prompt too large)
+// say nothing about the API's health.
+func apiOutage(err error) bool {
+	if !errors.Is(err, ErrAPICall) {
+		return false
+	}
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	return status == 0 || status >= 500 || status == http.StatusTooManyRequests || status == http.StatusRequestTimeout
+}
+
+// circuitBreaker stops a batch from calling a model API that keeps failing
+// After threshold consecutive outages the breaker opens and runs are skipped without being analyzed.
+// Once the cooldown has passed it is half-open: a single run probes the API, closing the breaker when
+// the call succeeds and opening it for another cooldown when it fails. Only the probe decides: runs
+// allowed before the breaker opened that finish while it is open do not close it.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu          sync.Mutex
+	consecutive int          // consecutive outages
+	openedAt    time.Time    // zero while closed
+	probe       breakerProbe // the half-open probe in flight, 0 when none
+	probes      breakerProbe // probes started, numbering them
+}
+
+// breakerProbe identifies the half-open probe allowed by circuitBreaker.allow, 0 for other runs
+type breakerProbe uint64
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold == 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether the next run may be analyzed, or why not. The probe returned is passed
+// back to record with the outcome of the run.
+func (b *circuitBreaker) allow() (breakerProbe, error) {
+	if b.threshold < 0 {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return 0, nil
+	}
+	if b.probe != 0 {
+		return 0, fmt.Errorf("%w after %d consecutive API failures, probing the API", ErrCircuitOpen, b.consecutive)
+	}
+	if b.now().Sub(b.openedAt) < b.cooldown {
+		return 0, fmt.Errorf("%w after %d consecutive API failures, retrying at %s", ErrCircuitOpen, b.consecutive,
+			b.openedAt.Add(b.cooldown).Format("15:04:05"))
+	}
+	b.probes++
+	b.probe = b.probes
+	logInfof("Model API circuit breaker half-open, probing the API")
+	return b.probe, nil
+}
+
+// record updates the breaker with the outcome of an allowed run: err is its error and answered
+// whether the model API answered its analysis. Runs that did not call the API (e.g. nothing to
+// analyze, fetch errors, cached responses, offline rules) leave it unchanged; a probe among them
+// lets the next run probe instead.
+func (b *circuitBreaker) record(probe breakerProbe, err error, answered bool) {
+	if b.threshold < 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	isProbe := probe != 0 && probe == b.probe
+	if isProbe {
+		b.probe = 0
+	}
+
+	switch {
+	case apiOutage(err):
+		b.consecutive++
+		if isProbe {
+			b.openedAt = b.now()
+			logWarnf("Model API still failing, circuit breaker open for another %s", b.cooldown)
+		} else if b.openedAt.IsZero() && b.consecutive >= b.threshold {
+			b.openedAt = b.now()
+			logWarnf("Model API failed %d times in a row, circuit breaker open: skipping runs for %s", b.consecutive, b.cooldown)
+		}
+	case answered || errors.Is(err, ErrAPICall):
+		// The API answered, even if it rejected the request
+		if !b.openedAt.IsZero() {
+			if !isProbe {
+				return
+			}
+			logInfof("Model API recovered, circuit breaker closed")
+		}
+		b.consecutive = 0
+		b.openedAt = time.Time{}
+	}
+}
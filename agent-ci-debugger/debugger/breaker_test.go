@@ -0,0 +1,159 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// apiError returns a failed model API call answered with the HTTP status, 0 for a network error
+func apiError(status int) error {
+	if status == 0 {
+		return fmt.Errorf("%w: connection refused", ErrAPICall)
+	}
+	return fmt.Errorf("%w: %w", ErrAPICall, &openai.APIError{HTTPStatusCode: status, Message: "error"})
+}
+
+func TestAPIOutage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network error", apiError(0), true},
+		{"server error", apiError(503), true},
+		{"rate limit", apiError(429), true},
+		{"timeout", apiError(408), true},
+		{"request error", fmt.Errorf("%w: %w", ErrAPICall, &openai.RequestError{HTTPStatusCode: 502}), true},
+		{"rejected request", apiError(400), false},
+		{"unauthorized", apiError(401), false},
+		{"not an API call", errors.New("failed to fetch the run"), false},
+		{"success", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := apiOutage(tt.err); got != tt.want {
+				t.Errorf("apiOutage(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// breakerAt returns a circuit breaker on a fake clock, and functions checking whether it allows a run
+func breakerAt(t *testing.T, threshold int, now *time.Time) (b *circuitBreaker, mustAllow func(string) breakerProbe, mustSkip func(string)) {
+	b = newCircuitBreaker(threshold, time.Minute)
+	b.now = func() time.Time { return *now }
+	mustAllow = func(step string) breakerProbe {
+		t.Helper()
+		probe, err := b.allow()
+		if err != nil {
+			t.Fatalf("%s: allow() = %v, want the run allowed", step, err)
+		}
+		return probe
+	}
+	mustSkip = func(step string) {
+		t.Helper()
+		if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("%s: allow() = %v, want ErrCircuitOpen", step, err)
+		}
+	}
+	return b, mustAllow, mustSkip
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b, mustAllow, mustSkip := breakerAt(t, 3, &now)
+
+	// rejected requests and other errors do not count as outages, a success resets the count
+	for _, err := range []error{apiError(500), apiError(500), nil, apiError(500), apiError(400), apiError(500), errors.New("fetch failed"), apiError(500)} {
+		b.record(mustAllow("closed"), err, err == nil)
+	}
+	b.record(mustAllow("two outages since the rejected request"), apiError(0), false)
+	mustSkip("tripped after three consecutive outages")
+
+	now = now.Add(59 * time.Second)
+	mustSkip("cooling down")
+
+	// half-open: a single probe, failing opens the breaker for another cooldown
+	now = now.Add(time.Second)
+	probe := mustAllow("half-open probe")
+	if probe == 0 {
+		t.Fatal("allow() returned no probe when half-open")
+	}
+	mustSkip("probe in flight")
+	b.record(probe, apiError(503), false)
+	mustSkip("probe failed")
+	now = now.Add(30 * time.Second)
+	mustSkip("cooling down after the failed probe")
+
+	// a successful probe closes the breaker
+	now = now.Add(30 * time.Second)
+	b.record(mustAllow("second probe"), nil, true)
+	for i := 0; i < 2; i++ {
+		b.record(mustAllow("closed again"), apiError(500), false)
+	}
+	mustAllow("count reset by the probe")
+}
+
+func TestCircuitBreakerProbeRejected(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b, mustAllow, mustSkip := breakerAt(t, 1, &now)
+	b.record(mustAllow("closed"), apiError(500), false)
+	mustSkip("open")
+
+	// the API answered the probe, even though it rejected the request
+	now = now.Add(time.Minute)
+	b.record(mustAllow("probe"), apiError(400), false)
+	mustAllow("closed by the rejected probe")
+}
+
+func TestCircuitBreakerOnlyProbeDecides(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b, mustAllow, mustSkip := breakerAt(t, 2, &now)
+
+	// Three runs start while closed, two of them trip the breaker
+	slow := mustAllow("slow run")
+	b.record(mustAllow("first outage"), apiError(500), false)
+	b.record(mustAllow("second outage"), apiError(500), false)
+	mustSkip("open")
+
+	// The slow run succeeding during the cooldown and during the probe neither closes the breaker nor
+	// frees the probe
+	b.record(slow, nil, true)
+	mustSkip("still cooling down")
+	now = now.Add(time.Minute)
+	probe := mustAllow("probe")
+	b.record(0, nil, true)
+	mustSkip("probe still in flight")
+
+	b.record(probe, apiError(502), false)
+	mustSkip("probe failed")
+}
+
+func TestCircuitBreakerProbeWithoutAPICall(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b, mustAllow, mustSkip := breakerAt(t, 1, &now)
+	b.record(mustAllow("closed"), apiError(500), false)
+	now = now.Add(time.Minute)
+
+	// A cached or offline analysis says nothing about the API, the next run probes it
+	b.record(mustAllow("cached probe"), nil, false)
+	b.record(mustAllow("probe without logs"), ErrNothingToAnalyze, false)
+	probe := mustAllow("probe calling the API")
+	mustSkip("probe in flight")
+	b.record(probe, nil, true)
+	mustAllow("closed")
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(-1, 0)
+	for i := 0; i < 10; i++ {
+		b.record(0, apiError(500), false)
+	}
+	if _, err := b.allow(); err != nil {
+		t.Errorf("allow() = %v, want a disabled breaker to never open", err)
+	}
+}
@@ -0,0 +1,100 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LogBudget describes how much of the failed logs the prompt included
+// It is the sum over the log sections of the prompt (the failed step output and the job logs).
+type LogBudget struct {
+	BudgetChars      int  `json:"budget_chars"`      // chars available for the logs
+	TotalChars       int  `json:"total_chars"`       // chars of the logs before filtering
+	IncludedChars    int  `json:"included_chars"`    // chars of the logs written to the prompt
+	TotalLines       int  `json:"total_lines"`       // lines of the logs before filtering
+	OutsideSegment   int  `json:"outside_segment"`   // lines before the analyzed segment (TailLines, SinceLastStep)
+	IgnoredLines     int  `json:"ignored_lines"`     // lines dropped by the ignore file
+	RelevantLines    int  `json:"relevant_lines"`    // lines matching error keywords and their context lines
+	ContextLines     int  `json:"context_lines"`     // relevant lines kept as context around the matching lines
+	RelevantIncluded int  `json:"relevant_included"` // relevant lines written to the prompt
+	NormalLines      int  `json:"normal_lines"`      // other lines
+	NormalIncluded   int  `json:"normal_included"`   // other lines written to the prompt, from the end of the logs
+	MiddleOmitted    bool `json:"middle_omitted"`    // normal lines between the start and the included tail were dropped
+}
+
+// truncationMarker formats the marker written where content is left out of the prompt, e.g.
+// "...[dropped 412 more error lines]...", so the model knows its view is partial
+func truncationMarker(format string, args ...any) string {
+	return "...[" + fmt.Sprintf(format, args...) + "]..."
+}
+
+// isTruncationMarker reports whether a prompt line is a truncationMarker
+func isTruncationMarker(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "...[") && strings.HasSuffix(line, "]...")
+}
+
+// droppedLinesMarker is the marker of relevant lines that did not fit the budget, counting the
+// error lines and their context lines
+func droppedLinesMarker(errors, context int) string {
+	switch {
+	case context == 0:
+		return truncationMarker("dropped %d more error lines", errors)
+	case errors == 0:
+		return truncationMarker("dropped %d context lines", context)
+	}
+	return truncationMarker("dropped %d more error lines and %d context lines", errors, context)
+}
+
+// IncludedLines returns the number of log lines written to the prompt
+func (b LogBudget) IncludedLines() int {
+	return b.RelevantIncluded + b.NormalIncluded
+}
+
+// DroppedPercent returns the share of log lines the model did not see
+func (b LogBudget) DroppedPercent() float64 {
+	if b.TotalLines == 0 {
+		return 0
+	}
+	return float64(b.TotalLines-b.IncludedLines()) / float64(b.TotalLines) * 100
+}
+
+// add accumulates the budget of another log section of the prompt
+func (b *LogBudget) add(other LogBudget) {
+	b.BudgetChars += other.BudgetChars
+	b.TotalChars += other.TotalChars
+	b.IncludedChars += other.IncludedChars
+	b.TotalLines += other.TotalLines
+	b.OutsideSegment += other.OutsideSegment
+	b.IgnoredLines += other.IgnoredLines
+	b.RelevantLines += other.RelevantLines
+	b.ContextLines += other.ContextLines
+	b.RelevantIncluded += other.RelevantIncluded
+	b.NormalLines += other.NormalLines
+	b.NormalIncluded += other.NormalIncluded
+	b.MiddleOmitted = b.MiddleOmitted || other.MiddleOmitted
+}
+
+// Summary renders the budget utilization for --show-budget
+func (b LogBudget) Summary() string {
+	var sb strings.Builder
+	sb.WriteString("Log budget:\n")
+	sb.WriteString(fmt.Sprintf("  Log size:        %d chars, %d lines\n", b.TotalChars, b.TotalLines))
+	sb.WriteString(fmt.Sprintf("  Included:        %d of %d budget chars, %d lines (%.1f%% of the lines dropped)\n",
+		b.IncludedChars, b.BudgetChars, b.IncludedLines(), b.DroppedPercent()))
+	sb.WriteString(fmt.Sprintf("  Relevant lines:  %d of %d included (%d of them context around error lines)\n", b.RelevantIncluded, b.RelevantLines, b.ContextLines))
+	sb.WriteString(fmt.Sprintf("  Other lines:     %d of %d included (from the end of the logs)\n", b.NormalIncluded, b.NormalLines))
+	if b.OutsideSegment > 0 {
+		sb.WriteString(fmt.Sprintf("  Before segment:  %d lines skipped (--tail-lines/--since-last-step)\n", b.OutsideSegment))
+	}
+	if b.IgnoredLines > 0 {
+		sb.WriteString(fmt.Sprintf("  Ignored:         %d lines matching the ignore file\n", b.IgnoredLines))
+	}
+	if b.MiddleOmitted {
+		sb.WriteString("  Middle section:  omitted\n")
+	}
+	if b.RelevantIncluded < b.RelevantLines {
+		sb.WriteString("  Not all error lines fit; raise the budget (e.g. a larger-context model) or narrow the logs (--step, --tail-lines, --ignore-file)\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDroppedLinesMarker(t *testing.T) {
+	tests := []struct {
+		errors, context int
+		want            string
+	}{
+		{errors: 3, want: "...[dropped 3 more error lines]..."},
+		{context: 4, want: "...[dropped 4 context lines]..."},
+		{errors: 3, context: 4, want: "...[dropped 3 more error lines and 4 context lines]..."},
+	}
+	for _, tt := range tests {
+		got := droppedLinesMarker(tt.errors, tt.context)
+		if got != tt.want || !isTruncationMarker("  "+got+"\r") {
+			t.Errorf("droppedLinesMarker(%d, %d) = %q, want the marker %q", tt.errors, tt.context, got, tt.want)
+		}
+	}
+	if isTruncationMarker("...[not a marker") || isTruncationMarker("log line ...[x]... in the middle of text") {
+		t.Error("isTruncationMarker() matched a log line")
+	}
+}
+
+// markerLogs are lines of normal output with error lines at the given indexes
+func markerLogs(n int, errorLines ...int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("output line %d", i)
+	}
+	for _, i := range errorLines {
+		lines[i] = fmt.Sprintf("Error: failure %d", i)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestTruncationMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		logs     string
+		maxChars int
+		want     []string
+		unwanted []string
+	}{
+		{
+			name:     "everything fits",
+			logs:     markerLogs(20, 10),
+			maxChars: 10000,
+			unwanted: []string{"...["},
+		},
+		{
+			name:     "middle of the other lines omitted",
+			logs:     markerLogs(500, 10),
+			maxChars: 1000,
+			want:     []string{"Error: failure 10", "...[middle section omitted: ", "output line 499"},
+		},
+		{
+			name:     "error lines dropped",
+			logs:     markerLogs(200, 20, 60, 100, 140, 180),
+			maxChars: 60,
+			want:     []string{"...[dropped ", "more error lines", "...[dropped 175 other log lines]..."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{Options: Options{ErrorContextLines: 2}}
+			got, budget := d.filterRelevantLogs(tt.logs, tt.maxChars)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("filtered logs do not contain %q:\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("filtered logs contain %q:\n%s", unwanted, got)
+				}
+			}
+			if budget.MiddleOmitted != (len(tt.want) > 0) {
+				t.Errorf("MiddleOmitted = %v", budget.MiddleOmitted)
+			}
+		})
+	}
+}
+
+func TestPromptExplainsTruncationMarkers(t *testing.T) {
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true}}
+	const note = "Markers like ...[dropped N more error lines]... show where content was left out"
+
+	run := &WorkflowRun{RunID: "1", Repository: "o/r", FailedLogs: markerLogs(20, 10)}
+	d.parseLogs(run)
+	if prompt := d.buildAnalysisPrompt(run, defaultLogBudget); strings.Contains(prompt, note) {
+		t.Error("the prompt explains markers it does not contain")
+	}
+
+	run = &WorkflowRun{RunID: "1", Repository: "o/r", FailedLogs: markerLogs(5000, 10)}
+	d.parseLogs(run)
+	if prompt := d.buildAnalysisPrompt(run, minLogBudget); !strings.Contains(prompt, note) || !strings.Contains(prompt, "...[middle section omitted: ") {
+		t.Errorf("the truncated prompt does not mark and explain the omitted lines:\n%s", prompt)
+	}
+}
@@ -0,0 +1,67 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Files of an analysis bundle (Options.BundleDir)
+const (
+	BundleLogsFile     = "logs.txt"
+	BundlePromptFile   = "prompt.txt"
+	BundleResponseFile = "response.txt"
+	BundleReportFile   = "report.md"
+)
+
+// WriteBundle saves the failed logs, the prompt, the raw AI response and the report of an analysis
+// into a new subdirectory of dir named after the run, and returns the subdirectory
+// The prompt and response are left out for offline analyses, which have neither. The files are
+// only readable by the user since the logs may contain sensitive output.
+func WriteBundle(dir string, run *WorkflowRun, proposal *FixProposal, report string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+	bundle, err := createUnique(filepath.Join(dir, reportBaseName(run.Ref(), now)), func(path string) error {
+		return os.Mkdir(path, 0700)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle directory: %w", err)
+	}
+
+	files := map[string]string{
+		BundleLogsFile:   run.FailedLogs,
+		BundleReportFile: report,
+	}
+	if !proposal.Heuristic && len(proposal.messages) > 0 {
+		files[BundlePromptFile] = formatPromptMessages(proposal.messages)
+		files[BundleResponseFile] = proposal.RawResponse
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(bundle, name), []byte(content), 0600); err != nil {
+			return "", fmt.Errorf("failed to write bundle: %w", err)
+		}
+	}
+	return bundle, nil
+}
+
+// formatPromptMessages renders the messages sent to the model, each under a "=== role ===" header
+// The response the conversation ends with is left out, it is saved separately.
+func formatPromptMessages(messages []openai.ChatCompletionMessage) string {
+	if last := len(messages) - 1; messages[last].Role == openai.ChatMessageRoleAssistant {
+		messages = messages[:last]
+	}
+	var sb strings.Builder
+	for i, m := range messages {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("=== %s ===\n%s", m.Role, m.Content))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
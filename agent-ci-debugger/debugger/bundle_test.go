@@ -0,0 +1,85 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestWriteBundle(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundles")
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	run := &WorkflowRun{Repository: "o/r", RunID: "1", FailedLogs: "missing go.sum entry\n"}
+	proposal := &FixProposal{
+		RawResponse: "ROOT CAUSE: go.sum",
+		messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You debug CI."},
+			{Role: openai.ChatMessageRoleUser, Content: "Why did it fail?"},
+			{Role: openai.ChatMessageRoleAssistant, Content: "ROOT CAUSE: go.sum"},
+		},
+	}
+
+	bundle, err := WriteBundle(dir, run, proposal, "# Report\n", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, reportBaseName(run.Ref(), now)); bundle != want {
+		t.Errorf("bundle = %s, want %s", bundle, want)
+	}
+	if info, err := os.Stat(bundle); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("bundle directory = %v, %v, want mode 0700", info, err)
+	}
+	want := map[string]string{
+		BundleLogsFile:     "missing go.sum entry\n",
+		BundlePromptFile:   "=== system ===\nYou debug CI.\n\n=== user ===\nWhy did it fail?\n",
+		BundleResponseFile: "ROOT CAUSE: go.sum",
+		BundleReportFile:   "# Report\n",
+	}
+	for name, content := range want {
+		path := filepath.Join(bundle, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+
+	// a second analysis of the same run at the same time gets its own bundle
+	second, err := WriteBundle(dir, run, proposal, "# Report\n", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != bundle+"-2" {
+		t.Errorf("second bundle = %s, want %s-2", second, bundle)
+	}
+}
+
+func TestWriteBundleHeuristic(t *testing.T) {
+	run := &WorkflowRun{Repository: "o/r", RunID: "1", FailedLogs: "logs\n"}
+	bundle, err := WriteBundle(t.TempDir(), run, &FixProposal{Heuristic: true}, "# Report\n", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != BundleLogsFile+","+BundleReportFile {
+		t.Errorf("files = %s, want only the logs and report", got)
+	}
+}
@@ -0,0 +1,94 @@
+package debugger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultCacheTTL is how long cached AI responses are replayed
+const DefaultCacheTTL = 24 * time.Hour
+
+// cachedResponse is the on-disk form of a cached completion
+type cachedResponse struct {
+	Created  time.Time                     `json:"created"`
+	Response openai.ChatCompletionResponse `json:"response"`
+}
+
+// responseCache stores AI responses on disk, keyed by a hash of the request
+type responseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// newResponseCache returns a cache in the user cache directory
+func newResponseCache(ttl time.Duration) (*responseCache, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &responseCache{dir: filepath.Join(base, "github-workflow-debugger", "responses"), ttl: ttl}, nil
+}
+
+// responseCacheKey hashes the parts of the request that determine the completion, with the reasoning
+// effort of reasoning models ("" for classic models)
+func responseCacheKey(req openai.ChatCompletionRequest, effort string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%g\x00", req.Model, req.Temperature)
+	if effort != "" {
+		fmt.Fprintf(h, "effort=%s\x00", effort)
+	}
+	if req.N > 1 {
+		fmt.Fprintf(h, "n=%d\x00", req.N)
+	}
+	for _, m := range req.Messages {
+		fmt.Fprintf(h, "%s\x00%s\x00", m.Role, m.Content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *responseCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get returns the cached response for key unless it is missing or expired
+func (c *responseCache) get(key string) (*openai.ChatCompletionResponse, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		logDebugf("Ignoring unreadable cache entry %s: %v", key, err)
+		return nil, false
+	}
+	if time.Since(cached.Created) > c.ttl {
+		logDebugf("Cache entry %s expired", key)
+		return nil, false
+	}
+	return &cached.Response, true
+}
+
+// put stores resp under key
+func (c *responseCache) put(key string, resp openai.ChatCompletionResponse) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(cachedResponse{Created: time.Now(), Response: resp})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
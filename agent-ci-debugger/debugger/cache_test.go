@@ -0,0 +1,93 @@
+package debugger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestAnalyzeFailureCacheHit(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, CacheResponses: true}
+
+	first, err := d.AnalyzeFailure(context.Background(), testRun())
+	if err != nil {
+		t.Fatalf("first AnalyzeFailure() error = %v", err)
+	}
+	second, err := d.AnalyzeFailure(context.Background(), testRun())
+	if err != nil {
+		t.Fatalf("second AnalyzeFailure() error = %v", err)
+	}
+
+	if n := len(api.requests(t)); n != 1 {
+		t.Errorf("API received %d chat requests, want 1: the second call is answered from the cache", n)
+	}
+	if first.cached || !second.cached {
+		t.Errorf("cached = %v, %v, want false, true", first.cached, second.cached)
+	}
+	if second.RootCause != first.RootCause || second.ProposedFix != first.ProposedFix {
+		t.Errorf("cached proposal = %+v, want the same as %+v", second, first)
+	}
+
+	// A different request misses the cache
+	run := testRun()
+	run.FailedLogs += "\nbuild\tRun go build\t2024-01-01T00:00:02Z another line"
+	if _, err := d.AnalyzeFailure(context.Background(), run); err != nil {
+		t.Fatalf("AnalyzeFailure() error = %v", err)
+	}
+	if n := len(api.requests(t)); n != 2 {
+		t.Errorf("API received %d chat requests, want 2", n)
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	cache := &responseCache{dir: t.TempDir(), ttl: time.Hour}
+	resp := openai.ChatCompletionResponse{ID: "chatcmpl-1", Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: "answer"}}}}
+	key := responseCacheKey(openai.ChatCompletionRequest{Model: "gpt-4o-mini"}, "")
+
+	if _, ok := cache.get(key); ok {
+		t.Fatal("get() of an empty cache hit")
+	}
+	if err := cache.put(key, resp); err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+	got, ok := cache.get(key)
+	if !ok || got.ID != resp.ID || got.Choices[0].Message.Content != "answer" {
+		t.Fatalf("get() = %+v, %v, want the stored response", got, ok)
+	}
+
+	// The entry records its creation time, so a shorter TTL expires it
+	time.Sleep(time.Millisecond)
+	if _, ok := (&responseCache{dir: cache.dir, ttl: time.Microsecond}).get(key); ok {
+		t.Error("get() returned an expired entry")
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "logs"}}
+	base := openai.ChatCompletionRequest{Model: "gpt-4o-mini", Messages: messages}
+	key := responseCacheKey(base, "")
+	if again := responseCacheKey(openai.ChatCompletionRequest{Model: "gpt-4o-mini", Messages: messages}, ""); again != key {
+		t.Errorf("identical requests have different keys")
+	}
+
+	other := base
+	other.Model = "gpt-4o"
+	otherMessages := base
+	otherMessages.Messages = []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "other logs"}}
+	samples := base
+	samples.N = 3
+	for name, k := range map[string]string{
+		"model":    responseCacheKey(other, ""),
+		"messages": responseCacheKey(otherMessages, ""),
+		"samples":  responseCacheKey(samples, ""),
+		"effort":   responseCacheKey(base, "high"),
+	} {
+		if k == key {
+			t.Errorf("a different %s has the same key", name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package debugger
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minCascadeErrors is the number of errors about the same missing symbol that are collapsed even
+// when the log shows no import error causing them
+const minCascadeErrors = 3
+
+// Cascade describes the compiler errors collapsed into the error that likely causes them
+type Cascade struct {
+	Symbol string // the missing package or name, e.g. "yaml"
+	Errors int    // number of collapsed errors
+	Files  int    // number of files the collapsed errors are in
+	Import bool   // the error is the failed import of Symbol, not the first of the collapsed errors
+}
+
+var (
+	// cascadeSymbolRes match errors caused by a missing package or name, capturing the name:
+	// Go "undefined: yaml" and "undefined: yaml.Node", rustc "cannot find value `x` in this scope" and
+	// "failed to resolve: use of undeclared crate or module `serde`", tsc "Cannot find name 'x'"
+	cascadeSymbolRes = []*regexp.Regexp{
+		regexp.MustCompile(`^undefined: (\w+)`),
+		regexp.MustCompile("^cannot find [\\w ]+ `(\\w+)`"),
+		regexp.MustCompile("^failed to resolve: use of undeclared (?:crate or module|type) `(\\w+)`"),
+		regexp.MustCompile(`^Cannot find name '(\w+)'`),
+	}
+	// cascadeImportRes match failed imports, capturing the import path: Go "could not import x (...)",
+	// "no required module provides package x; ...", `cannot find package "x"`, "package x is not in std",
+	// rustc "unresolved import `x::y`" and tsc "Cannot find module 'x'"
+	cascadeImportRes = []*regexp.Regexp{
+		regexp.MustCompile(`^could not import ([^\s;]+)`),
+		regexp.MustCompile(`^no required module provides package ([^\s;]+)`),
+		regexp.MustCompile(`^cannot find package "([^"]+)"`),
+		regexp.MustCompile(`^package ([^\s;]+) is not in (?:std|GOROOT)`),
+		regexp.MustCompile("^unresolved import `([\\w:]+)`"),
+		regexp.MustCompile(`^Cannot find module '([^']+)'`),
+	}
+	// goMajorVersionRe matches the major version element of a Go import path, e.g. "v3"
+	goMajorVersionRe = regexp.MustCompile(`^v\d+$`)
+)
+
+// cascadeSymbol returns the missing name an error complains about, or "" for other errors
+func cascadeSymbol(e CompilerError) string {
+	for _, re := range cascadeSymbolRes {
+		if m := re.FindStringSubmatch(e.Message); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// importSymbols returns the names a failed import would have declared, e.g. "yaml" for
+// "gopkg.in/yaml.v3", or nil when the error is not a failed import
+func importSymbols(e CompilerError) []string {
+	for _, re := range cascadeImportRes {
+		m := re.FindStringSubmatch(e.Message)
+		if m == nil {
+			continue
+		}
+		if strings.Contains(m[1], "::") {
+			// Rust: the crate and the imported item, e.g. "serde" and "Deserialize" for "serde::Deserialize"
+			parts := strings.Split(m[1], "::")
+			return []string{parts[0], parts[len(parts)-1]}
+		}
+		elems := strings.Split(strings.TrimSuffix(m[1], "/"), "/")
+		name := elems[len(elems)-1]
+		if goMajorVersionRe.MatchString(name) && len(elems) > 1 {
+			name = elems[len(elems)-2]
+		}
+		name = strings.TrimSuffix(name, path.Ext(name))
+		if !strings.HasPrefix(m[1], ".") {
+			// Package names drop the conventional prefixes and suffixes of repository names
+			name = strings.TrimSuffix(strings.TrimPrefix(name, "go-"), "-go")
+		}
+		return []string{name}
+	}
+	return nil
+}
+
+// collapseCascades collapses the errors caused by a missing package or name into the error that
+// likely causes them, and moves those errors first
+// A single missing import can make the compiler report dozens of "undefined:" errors, which would
+// crowd the cause out of the prompt. The errors about a symbol are collapsed into the failed import
+// declaring it when the log has one, otherwise into the first of them when there are at least
+// minCascadeErrors.
+func collapseCascades(errs []CompilerError) []CompilerError {
+	bySymbol := make(map[string][]int)
+	for i, e := range errs {
+		if symbol := cascadeSymbol(e); symbol != "" {
+			bySymbol[symbol] = append(bySymbol[symbol], i)
+		}
+	}
+	if len(bySymbol) == 0 {
+		return errs
+	}
+
+	// The failed import declaring each symbol, if any
+	importOf := make(map[string]int)
+	for i, e := range errs {
+		for _, symbol := range importSymbols(e) {
+			if _, ok := importOf[symbol]; !ok && len(bySymbol[symbol]) > 0 {
+				importOf[symbol] = i
+			}
+		}
+	}
+
+	symbols := make([]string, 0, len(bySymbol))
+	for symbol := range bySymbol {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	heads := make(map[int]*Cascade)
+	headFiles := make(map[int]map[string]bool)
+	collapsed := make(map[int]bool)
+	for _, symbol := range symbols {
+		members := bySymbol[symbol]
+		head, isImport := importOf[symbol]
+		if !isImport {
+			if len(members) < minCascadeErrors {
+				continue
+			}
+			head, members = members[0], members[1:]
+		}
+		c := heads[head]
+		if c == nil {
+			c = &Cascade{Symbol: symbol, Import: isImport}
+			heads[head] = c
+			headFiles[head] = make(map[string]bool)
+		}
+		// A Rust import declaring two missing names heads both cascades, a file with errors
+		// about both is counted once
+		for _, i := range members {
+			collapsed[i] = true
+			headFiles[head][errs[i].File] = true
+		}
+		c.Errors += len(members)
+		c.Files = len(headFiles[head])
+	}
+	if len(heads) == 0 {
+		return errs
+	}
+
+	var first, rest []CompilerError
+	for i, e := range errs {
+		switch {
+		case heads[i] != nil:
+			e.Cascade = heads[i]
+			first = append(first, e)
+		case !collapsed[i]:
+			rest = append(rest, e)
+		}
+	}
+	// The biggest cascades first, ties in log order
+	sort.SliceStable(first, func(i, j int) bool { return first[i].Cascade.Errors > first[j].Cascade.Errors })
+	return append(first, rest...)
+}
+
+// collapsedErrors returns the number of errors collapsed into the heads of cascades
+func collapsedErrors(errs []CompilerError) int {
+	n := 0
+	for _, e := range errs {
+		if e.Cascade != nil {
+			n += e.Cascade.Errors
+		}
+	}
+	return n
+}
+
+// String describes the collapsed errors, e.g. `causes 23 more errors about "yaml" in 7 files`
+func (c Cascade) String() string {
+	files := "1 file"
+	if c.Files != 1 {
+		files = fmt.Sprintf("%d files", c.Files)
+	}
+	if c.Import {
+		return fmt.Sprintf("causes %d more errors about %q in %s", c.Errors, c.Symbol, files)
+	}
+	return fmt.Sprintf("is followed by %d more errors about %q in %s - likely a missing import or a removed declaration of %s",
+		c.Errors, c.Symbol, files, c.Symbol)
+}
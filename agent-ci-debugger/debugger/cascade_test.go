@@ -0,0 +1,109 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollapseCascades(t *testing.T) {
+	goErr := func(file, message string) CompilerError {
+		return CompilerError{Tool: "go", Message: message, File: file, Line: 1}
+	}
+	rustErr := func(file, message string) CompilerError {
+		return CompilerError{Tool: "rustc", Message: message, File: file, Line: 1}
+	}
+	withCascade := func(e CompilerError, c Cascade) CompilerError {
+		e.Cascade = &c
+		return e
+	}
+
+	importYAML := goErr("config.go", "could not import gopkg.in/yaml.v3 (no required module provides package)")
+	undefinedYAML := goErr("config.go", "undefined: yaml")
+	undefinedYAMLNode := goErr("load.go", "undefined: yaml.Node")
+	undefinedFoo := goErr("main.go", "undefined: foo")
+	mismatch := goErr("main.go", "cannot use x (variable of type int) as string value in argument to f")
+
+	useSerde := rustErr("src/lib.rs", "unresolved import `serde::Deserialize`")
+	deriveLib := rustErr("src/lib.rs", "cannot find derive macro `Deserialize` in this scope")
+	crateLib := rustErr("src/lib.rs", "failed to resolve: use of undeclared crate or module `serde`")
+	deriveMain := rustErr("src/main.rs", "cannot find derive macro `Deserialize` in this scope")
+
+	tests := []struct {
+		name string
+		errs []CompilerError
+		want []CompilerError
+	}{
+		{
+			name: "import heads fewer errors than the threshold",
+			errs: []CompilerError{undefinedYAML, mismatch, importYAML, undefinedYAMLNode},
+			want: []CompilerError{
+				withCascade(importYAML, Cascade{Symbol: "yaml", Errors: 2, Files: 2, Import: true}),
+				mismatch,
+			},
+		},
+		{
+			name: "threshold without an import",
+			errs: []CompilerError{mismatch, undefinedFoo, undefinedFoo, undefinedFoo},
+			want: []CompilerError{
+				withCascade(undefinedFoo, Cascade{Symbol: "foo", Errors: 2, Files: 1}),
+				mismatch,
+			},
+		},
+		{
+			name: "below the threshold",
+			errs: []CompilerError{undefinedFoo, mismatch, undefinedFoo},
+			want: []CompilerError{undefinedFoo, mismatch, undefinedFoo},
+		},
+		{
+			// The crate and the imported item are both declared by the import, the file with
+			// errors about both is counted once
+			name: "rust import of two names",
+			errs: []CompilerError{useSerde, deriveLib, crateLib, deriveMain},
+			want: []CompilerError{
+				withCascade(useSerde, Cascade{Symbol: "Deserialize", Errors: 3, Files: 2, Import: true}),
+			},
+		},
+		{
+			name: "biggest cascade first",
+			errs: []CompilerError{mismatch, undefinedYAML, importYAML, undefinedFoo, undefinedFoo, undefinedFoo, undefinedFoo},
+			want: []CompilerError{
+				withCascade(undefinedFoo, Cascade{Symbol: "foo", Errors: 3, Files: 1}),
+				withCascade(importYAML, Cascade{Symbol: "yaml", Errors: 1, Files: 1, Import: true}),
+				mismatch,
+			},
+		},
+		{
+			name: "no missing symbols",
+			errs: []CompilerError{mismatch, importYAML},
+			want: []CompilerError{mismatch, importYAML},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collapseCascades(tt.errs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collapseCascades() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+			want := 0
+			for _, e := range tt.want {
+				if e.Cascade != nil {
+					want += e.Cascade.Errors
+				}
+			}
+			if n := collapsedErrors(got); n != want || len(got)+n != len(tt.errs) {
+				t.Errorf("collapsedErrors() = %d, want %d of %d errors", n, want, len(tt.errs))
+			}
+		})
+	}
+}
+
+func TestCascadeString(t *testing.T) {
+	if got := (Cascade{Symbol: "yaml", Errors: 23, Files: 7, Import: true}).String(); got != `causes 23 more errors about "yaml" in 7 files` {
+		t.Errorf("String() = %q", got)
+	}
+	want := `is followed by 2 more errors about "foo" in 1 file - likely a missing import or a removed declaration of foo`
+	if got := (Cascade{Symbol: "foo", Errors: 2, Files: 1}).String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
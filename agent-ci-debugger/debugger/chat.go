@@ -0,0 +1,94 @@
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// chatQuitCommand ends an interactive chat session
+const chatQuitCommand = "/quit"
+
+// Chat answers follow-up questions about an analyzed run
+// Questions are read line by line from in and replies are streamed to out. The conversation
+// history, including the original prompt with the logs, is kept across turns so the model
+// can refer back to it. The session ends on EOF or /quit.
+func (d *GitHubWorkflowDebugger) Chat(ctx context.Context, in io.Reader, out io.Writer, result *Result) error {
+	if result == nil || result.Proposal == nil || len(result.Proposal.messages) == 0 {
+		return fmt.Errorf("no analysis to continue from")
+	}
+
+	messages := append([]openai.ChatCompletionMessage{}, result.Proposal.messages...)
+	scanner := bufio.NewScanner(in)
+
+	fmt.Fprintf(out, "\nAsk follow-up questions about this failure (%s or Ctrl-D to exit).\n", chatQuitCommand)
+	for {
+		fmt.Fprint(out, "\n> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" {
+			continue
+		}
+		if question == chatQuitCommand {
+			return nil
+		}
+
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: question,
+		})
+
+		reply, err := d.streamReply(ctx, messages, out)
+		if err != nil {
+			// Drop the unanswered question so the user can retry
+			messages = messages[:len(messages)-1]
+			logErrorf("follow-up question failed: %v", err)
+			continue
+		}
+
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleAssistant,
+			Content: reply,
+		})
+	}
+}
+
+// streamReply sends the conversation and streams the reply to out as it arrives
+func (d *GitHubWorkflowDebugger) streamReply(ctx context.Context, messages []openai.ChatCompletionMessage, out io.Writer) (string, error) {
+	logDebugf("Sending follow-up question (%d messages in conversation)", len(messages))
+
+	stream, err := d.openaiClient.CreateChatCompletionStream(d.requestContext(ctx), d.chatRequest(messages, d.responseTokens()))
+	if err != nil {
+		return "", fmt.Errorf("failed to call OpenAI API: %w", err)
+	}
+	defer stream.Close()
+
+	var reply strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read OpenAI stream: %w", err)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta.Content
+		fmt.Fprint(out, delta)
+		reply.WriteString(delta)
+	}
+	fmt.Fprintln(out)
+
+	return reply.String(), nil
+}
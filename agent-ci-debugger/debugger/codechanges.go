@@ -0,0 +1,70 @@
+package debugger
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// diffFileRe matches the new-file header of a unified diff, e.g. "+++ b/pkg/server.go"
+	diffFileRe = regexp.MustCompile(`^\+\+\+ (?:b/)?(\S+)`)
+	// changeFileRe matches a file named in the line introducing a change, e.g. "### Change 1: pkg/server.go"
+	// or "**`pkg/server.go`**"; the name must have a directory or an extension
+	changeFileRe = regexp.MustCompile("`?([A-Za-z0-9_.-]+(?:/[A-Za-z0-9_.-]+)*\\.[A-Za-z0-9]+|[A-Za-z0-9_.-]+(?:/[A-Za-z0-9_.-]+)+)`?\\**:?\\s*$")
+)
+
+// parseCodeChanges extracts the diffs of the code changes section of the AI response
+// Every ```diff (or ```patch) block becomes a CodeChange; the file comes from its "+++" header or
+// else the line introducing it, and the prose since the previous block is the description.
+func parseCodeChanges(text string) []CodeChange {
+	var changes []CodeChange
+	var description []string
+	var snippet []string
+	file := ""
+	inDiff, inCode := false, false
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			switch {
+			case inDiff:
+				change := CodeChange{File: file, Description: strings.TrimSpace(strings.Join(description, "\n")), DiffSnippet: strings.Join(snippet, "\n")}
+				for _, l := range snippet {
+					if m := diffFileRe.FindStringSubmatch(l); m != nil {
+						change.File = m[1]
+						break
+					}
+				}
+				changes = append(changes, change)
+				description, snippet, file = nil, nil, ""
+				inDiff = false
+			case inCode:
+				inCode = false
+				description = append(description, line)
+			default:
+				lang := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "```")))
+				if lang == "diff" || lang == "patch" {
+					inDiff = true
+				} else {
+					inCode = true
+					description = append(description, line)
+				}
+			}
+			continue
+		}
+
+		if inDiff {
+			snippet = append(snippet, line)
+			continue
+		}
+		if !inCode {
+			if m := changeFileRe.FindStringSubmatch(trimmed); m != nil && (strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "*") || strings.HasPrefix(trimmed, "`")) {
+				file = m[1]
+				continue
+			}
+		}
+		description = append(description, line)
+	}
+
+	return changes
+}
@@ -0,0 +1,149 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// codeOwnersPaths are the locations GitHub reads the CODEOWNERS file from, in order
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// codeOwnersRule is a CODEOWNERS line: a path pattern and its owners
+type codeOwnersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// parseCodeOwners parses a CODEOWNERS file, skipping comments and invalid patterns
+func parseCodeOwners(content string) []codeOwnersRule {
+	var rules []codeOwnersRule
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		re, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			logDebugf("Skipping CODEOWNERS pattern %q: %v", fields[0], err)
+			continue
+		}
+		rules = append(rules, codeOwnersRule{pattern: re, owners: fields[1:]})
+	}
+	return rules
+}
+
+// codeOwnersPattern converts a gitignore-style CODEOWNERS pattern into a regular expression
+// Patterns starting with / or containing a / are anchored at the repository root, others match
+// at any depth; a pattern matches the path itself and, for directories, everything below it.
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			sb.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(trimmed[i])))
+		}
+	}
+	if dirOnly {
+		sb.WriteString("/.*$")
+	} else {
+		sb.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
+
+// ownersOf returns the owners of path; as in GitHub, the last matching rule wins
+func ownersOf(rules []codeOwnersRule, path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].pattern.MatchString(path) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// fetchCodeOwners reads the CODEOWNERS file of the repository at the run's commit
+// Returns "" when the repository has none.
+func fetchCodeOwners(run *WorkflowRun) string {
+	for _, path := range codeOwnersPaths {
+		contentsURL := fmt.Sprintf("repos/%s/contents/%s", run.Repository, path)
+		if run.HeadSHA != "" {
+			contentsURL += "?ref=" + run.HeadSHA
+		}
+		content, err := gh("api", contentsURL, "-H", "Accept: application/vnd.github.raw")
+		if err == nil {
+			logDebugf("Using %s (%d bytes)", path, len(content))
+			return string(content)
+		}
+	}
+	return ""
+}
+
+// codeOwnersEnricher maps the files to check onto their owners from CODEOWNERS
+type codeOwnersEnricher struct{}
+
+func (codeOwnersEnricher) Name() string { return "codeowners" }
+
+func (codeOwnersEnricher) Enrich(run *WorkflowRun, proposal *FixProposal) error {
+	if len(proposal.FilesToCheck) == 0 {
+		return nil
+	}
+	content := fetchCodeOwners(run)
+	if content == "" {
+		logDebugf("No CODEOWNERS file found")
+		return nil
+	}
+	rules := parseCodeOwners(content)
+
+	owners := make(map[string][]string)
+	for _, file := range proposal.FilesToCheck {
+		path, _, ok := parseSourceLocation(file)
+		if !ok {
+			continue
+		}
+		if fileOwners := ownersOf(rules, path); len(fileOwners) > 0 {
+			owners[path] = fileOwners
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(owners))
+	for path := range owners {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, path := range paths {
+		sb.WriteString(fmt.Sprintf("- `%s`: %s\n", path, strings.Join(owners[path], " ")))
+	}
+	proposal.Owners = owners
+	proposal.Sections = append(proposal.Sections, ReportSection{Title: "Owners", Content: sb.String()})
+	return nil
+}
@@ -0,0 +1,120 @@
+package debugger
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testCodeOwners = `# Default owners
+*                   @o/maintainers
+*.go                @o/go-reviewers   # Go code
+/docs/              @o/docs
+pkg/**/testdata/    @o/qa
+/build/ci.yml       @alice @bob
+[invalid            @nobody
+`
+
+func TestOwnersOf(t *testing.T) {
+	rules := parseCodeOwners(testCodeOwners)
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@o/maintainers"}},
+		{"main.go", []string{"@o/go-reviewers"}},
+		{"cmd/tool/main.go", []string{"@o/go-reviewers"}},
+		{"docs/guide.md", []string{"@o/docs"}},
+		{"docs/api/handler.go", []string{"@o/docs"}}, // the last matching rule wins
+		{"site/docs/x.md", []string{"@o/maintainers"}},
+		{"pkg/api/testdata/golden.json", []string{"@o/qa"}},
+		{"pkg/testdata/x.txt", []string{"@o/qa"}},
+		{"/build/ci.yml", []string{"@alice", "@bob"}},
+	}
+	for _, tt := range tests {
+		if got := ownersOf(rules, tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ownersOf(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCodeOwnersEnricher(t *testing.T) {
+	stubGitHub(t, map[string]string{
+		// The root CODEOWNERS is used when .github/CODEOWNERS does not exist
+		"/repos/o/r/contents/CODEOWNERS": testCodeOwners,
+	})
+	run := &WorkflowRun{Repository: "o/r", HeadSHA: "abc123"}
+	proposal := &FixProposal{FilesToCheck: []string{"pkg/api/handler.go:42", "docs/guide.md", "the workflow file"}}
+
+	if err := (codeOwnersEnricher{}).Enrich(run, proposal); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	want := map[string][]string{
+		"pkg/api/handler.go": {"@o/go-reviewers"},
+		"docs/guide.md":      {"@o/docs"},
+	}
+	if !reflect.DeepEqual(proposal.Owners, want) {
+		t.Errorf("Owners = %v, want %v", proposal.Owners, want)
+	}
+	if len(proposal.Sections) != 1 || proposal.Sections[0].Title != "Owners" {
+		t.Fatalf("Sections = %+v, want an Owners section", proposal.Sections)
+	}
+	wantSection := "- `docs/guide.md`: @o/docs\n- `pkg/api/handler.go`: @o/go-reviewers\n"
+	if proposal.Sections[0].Content != wantSection {
+		t.Errorf("Owners section = %q, want %q", proposal.Sections[0].Content, wantSection)
+	}
+}
+
+func TestCodeOwnersEnricherWithoutFile(t *testing.T) {
+	stubGitHub(t, nil)
+	proposal := &FixProposal{FilesToCheck: []string{"main.go"}}
+	if err := (codeOwnersEnricher{}).Enrich(&WorkflowRun{Repository: "o/r"}, proposal); err != nil {
+		t.Fatalf("Enrich() error = %v", err)
+	}
+	if proposal.Owners != nil || len(proposal.Sections) != 0 {
+		t.Errorf("proposal = %+v, want it unchanged", proposal)
+	}
+}
+
+// testEnricher is a ProposalEnricher adding a section, or failing
+type testEnricher struct {
+	name string
+	err  error
+}
+
+func (e testEnricher) Name() string { return e.name }
+
+func (e testEnricher) Enrich(_ *WorkflowRun, proposal *FixProposal) error {
+	if e.err != nil {
+		return e.err
+	}
+	proposal.Sections = append(proposal.Sections, ReportSection{Title: e.name, Content: "added by " + e.name})
+	return nil
+}
+
+func TestRegisterEnricher(t *testing.T) {
+	saved := enricherRegistry
+	t.Cleanup(func() { enricherRegistry = saved })
+	enricherRegistry = nil
+
+	RegisterEnricher(testEnricher{name: "runbook"})
+	RegisterEnricher(testEnricher{name: "broken", err: errors.New("tracker unreachable")})
+	RegisterEnricher(testEnricher{name: "tracker"})
+
+	proposal := &FixProposal{RootCause: "x"}
+	runEnrichers(&WorkflowRun{}, proposal)
+	var titles []string
+	for _, s := range proposal.Sections {
+		titles = append(titles, s.Title)
+	}
+	// A failing enricher is skipped, the others still run in registration order
+	if want := []string{"runbook", "tracker"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("sections = %v, want %v", titles, want)
+	}
+
+	report := (&GitHubWorkflowDebugger{}).GenerateReport(&WorkflowRun{}, proposal)
+	if !strings.Contains(report, "added by tracker") {
+		t.Errorf("report does not contain the enricher section:\n%s", report)
+	}
+}
@@ -0,0 +1,51 @@
+package debugger
+
+import (
+	"os"
+)
+
+// ANSI styles used for terminal output
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// NoColor disables styled output regardless of the terminal, set by --no-color
+var NoColor bool
+
+// colorEnabled reports whether ANSI styles may be written to f
+// Color is off with --no-color, when NO_COLOR is set (https://no-color.org), for TERM=dumb
+// and when f is not a terminal, e.g. in CI logs or when redirected to a file.
+func colorEnabled(f *os.File) bool {
+	if NoColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// colorize wraps text in the ANSI style when color is enabled for f
+// All styled output goes through colorize so it can be turned off in one place.
+func colorize(f *os.File, style, text string) string {
+	if style == "" || !colorEnabled(f) {
+		return text
+	}
+	return style + text + colorReset
+}
+
+// confidenceStyle returns the style of a confidence level
+func confidenceStyle(confidence string) string {
+	switch confidenceLevel(confidence) {
+	case "High":
+		return colorGreen
+	case "Medium":
+		return colorYellow
+	case "Low":
+		return colorRed
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,235 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Limits of the regression context included in the prompt
+const (
+	maxCompareCommits = 20
+	maxCompareFiles   = 50
+	maxCompareErrors  = 10
+)
+
+// Comparison describes what changed between a passing baseline run and the failing run
+type Comparison struct {
+	BaselineURL        string
+	BaselineRunID      string
+	BaselineSHA        string
+	BaselineConclusion string
+	Commits            []ComparedCommit
+	TotalCommits       int
+	ChangedFiles       []ChangedFile
+	NewErrors          []string // error messages of the failing run not seen in the baseline
+	NewFailedJobs      []string // failed jobs that did not fail in the baseline
+}
+
+// ComparedCommit is a commit between the baseline and the failing run
+type ComparedCommit struct {
+	SHA     string
+	Author  string
+	Message string // first line of the commit message
+}
+
+// ChangedFile is a file changed between the baseline and the failing run
+type ChangedFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// compareRuns fetches the baseline run and the commit range up to the failing run
+func (d *GitHubWorkflowDebugger) compareRuns(run *WorkflowRun, baselineURL string) (*Comparison, error) {
+	repo, runID, _, err := ParseWorkflowURL(baselineURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid baseline URL: %w", err)
+	}
+	if repo != run.Repository {
+		return nil, fmt.Errorf("baseline run is in %s, not %s", repo, run.Repository)
+	}
+
+	logDebugf("Fetching baseline run %s...", runID)
+	statusOutput, err := gh("run", "view", runID, "--repo", repo, "--json", "conclusion,headSha")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get baseline run status: %w", err)
+	}
+	var statusData struct {
+		Conclusion string `json:"conclusion"`
+		HeadSHA    string `json:"headSha"`
+	}
+	if err := json.Unmarshal(statusOutput, &statusData); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline run status: %w", err)
+	}
+
+	comparison := &Comparison{
+		BaselineURL:        baselineURL,
+		BaselineRunID:      runID,
+		BaselineSHA:        statusData.HeadSHA,
+		BaselineConclusion: statusData.Conclusion,
+	}
+	if statusData.Conclusion != "success" {
+		logWarnf("baseline run %s concluded as %q, not success", runID, statusData.Conclusion)
+	}
+
+	// Errors also printed by the passing run are noise, not the regression
+	baselineLogs, err := ghLogs(d.Options.MaxLogBytes, "run", "view", runID, "--repo", repo, "--log")
+	if err != nil {
+		logWarnf("failed to get baseline logs, error summaries not compared: %v", err)
+	} else {
+		baseline := d.parseErrorSummary(string(baselineLogs))
+		comparison.NewErrors = newErrorMessages(run.ErrorSummary.ErrorMessages, baseline.ErrorMessages)
+		comparison.NewFailedJobs = newErrorMessages(run.ErrorSummary.FailedJobs, baseline.FailedJobs)
+		logInfof("Compared with baseline run %s: %d of %d error messages are new",
+			runID, len(comparison.NewErrors), len(run.ErrorSummary.ErrorMessages))
+	}
+
+	if comparison.BaselineSHA != "" && run.HeadSHA != "" && comparison.BaselineSHA != run.HeadSHA {
+		if err := fetchCommitComparison(repo, comparison.BaselineSHA, run.HeadSHA, comparison); err != nil {
+			logWarnf("failed to compare commits: %v", err)
+		}
+	}
+
+	return comparison, nil
+}
+
+// fetchCommitComparison adds the commits and changed files between base and head to comparison
+func fetchCommitComparison(repo, base, head string, comparison *Comparison) error {
+	output, err := gh("api", fmt.Sprintf("repos/%s/compare/%s...%s", repo, base, head))
+	if err != nil {
+		return fmt.Errorf("failed to get commit comparison: %w", err)
+	}
+
+	var data struct {
+		TotalCommits int `json:"total_commits"`
+		Commits      []struct {
+			SHA    string `json:"sha"`
+			Commit struct {
+				Message string `json:"message"`
+				Author  struct {
+					Name string `json:"name"`
+				} `json:"author"`
+			} `json:"commit"`
+		} `json:"commits"`
+		Files []ChangedFile `json:"files"`
+	}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return fmt.Errorf("failed to parse commit comparison: %w", err)
+	}
+
+	comparison.TotalCommits = data.TotalCommits
+	for _, c := range data.Commits {
+		message, _, _ := strings.Cut(c.Commit.Message, "\n")
+		comparison.Commits = append(comparison.Commits, ComparedCommit{
+			SHA:     c.SHA,
+			Author:  c.Commit.Author.Name,
+			Message: message,
+		})
+	}
+	comparison.ChangedFiles = data.Files
+
+	logDebugf("Fetched commit comparison: %d commits, %d changed files", comparison.TotalCommits, len(comparison.ChangedFiles))
+	return nil
+}
+
+// normalizeLogLine strips the job/step prefix and timestamp so lines of different runs compare equal
+func normalizeLogLine(line string) string {
+	return strings.TrimSpace(logContent(line))
+}
+
+// newErrorMessages returns the messages of current that do not occur in baseline
+func newErrorMessages(current, baseline []string) []string {
+	known := make(map[string]bool, len(baseline))
+	for _, msg := range baseline {
+		known[normalizeLogLine(msg)] = true
+	}
+
+	var added []string
+	seen := make(map[string]bool)
+	for _, msg := range current {
+		key := normalizeLogLine(msg)
+		if known[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		added = append(added, msg)
+	}
+	return added
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// writeRegressionContext writes the comparison with the passing run to the prompt
+func writeRegressionContext(sb *strings.Builder, run *WorkflowRun) {
+	c := run.Comparison
+	sb.WriteString("\n## Regression Context\n")
+	sb.WriteString(fmt.Sprintf("- Last passing run: %s (run %s, commit %s, conclusion: %s)\n",
+		c.BaselineURL, c.BaselineRunID, shortSHA(c.BaselineSHA), c.BaselineConclusion))
+	sb.WriteString(fmt.Sprintf("- Failing run: %s (commit %s)\n", run.URL, shortSHA(run.HeadSHA)))
+
+	if len(c.Commits) > 0 {
+		sb.WriteString(fmt.Sprintf("\nCommits between the runs (%d total):\n", c.TotalCommits))
+		for i, commit := range c.Commits {
+			if i >= maxCompareCommits {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(c.Commits)-maxCompareCommits))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  - %s %s (%s)\n", shortSHA(commit.SHA), commit.Message, commit.Author))
+		}
+	} else if c.BaselineSHA == run.HeadSHA {
+		sb.WriteString("\nBoth runs are for the same commit, so the failure is not caused by a code change (flaky test, infrastructure, external dependency?).\n")
+	}
+
+	if len(c.ChangedFiles) > 0 {
+		sb.WriteString(fmt.Sprintf("\nChanged files (%d total):\n", len(c.ChangedFiles)))
+		for i, file := range c.ChangedFiles {
+			if i >= maxCompareFiles {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(c.ChangedFiles)-maxCompareFiles))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  - %s %s (+%d/-%d)\n", file.Status, file.Filename, file.Additions, file.Deletions))
+		}
+	}
+
+	if len(c.NewFailedJobs) > 0 {
+		sb.WriteString(fmt.Sprintf("\nNewly failing jobs: %s\n", strings.Join(c.NewFailedJobs, ", ")))
+	}
+	if len(c.NewErrors) > 0 {
+		sb.WriteString(fmt.Sprintf("\nError messages not present in the passing run (%d total):\n", len(c.NewErrors)))
+		for i, msg := range c.NewErrors {
+			if i >= maxCompareErrors {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(c.NewErrors)-maxCompareErrors))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  - %s\n", truncateText(normalizeLogLine(msg), 300)))
+		}
+	}
+}
+
+// writeRegressionReport writes the regression section of the report
+func writeRegressionReport(sb *strings.Builder, run *WorkflowRun) {
+	c := run.Comparison
+	sb.WriteString("## Regression\n\n")
+	sb.WriteString(fmt.Sprintf("**Last passing run**: %s (commit `%s`)\n", c.BaselineURL, shortSHA(c.BaselineSHA)))
+	sb.WriteString(fmt.Sprintf("**Failing run**: %s (commit `%s`)\n", run.URL, shortSHA(run.HeadSHA)))
+	sb.WriteString(fmt.Sprintf("**Commits in between**: %d, **changed files**: %d, **new error messages**: %d\n\n",
+		c.TotalCommits, len(c.ChangedFiles), len(c.NewErrors)))
+	for i, commit := range c.Commits {
+		if i >= maxCompareCommits {
+			sb.WriteString(fmt.Sprintf("- ... and %d more\n", len(c.Commits)-maxCompareCommits))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("- `%s` %s (%s)\n", shortSHA(commit.SHA), commit.Message, commit.Author))
+	}
+	if len(c.Commits) > 0 {
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,115 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Scores from which a self-reported confidence counts as High or Medium, below is Low
+const (
+	HighConfidenceScore   = 75
+	MediumConfidenceScore = 40
+)
+
+var (
+	// confidenceScoreRe matches a numeric confidence: "85", "85%", "85/100", "0.85" or
+	// "Score: 85", not years or counts beyond 100
+	confidenceScoreRe = regexp.MustCompile(`(?:^|[^\w.])(100|\d{1,2}|0?\.\d+|1\.0+)\s*(%|/\s*100\b|\s*percent\b)?(?:[^\w./]|\.\s|\.?$)`)
+	// confidenceLeadRe matches what may precede a score without a unit, e.g. "Score:" or "High ("
+	confidenceLeadRe = regexp.MustCompile(`(?i)^(?:confidence|score|level|high|medium|low|[\s:(\[*_-])*$`)
+	// confidenceReasonRe matches the separator before the justification, e.g. " - ", ": ", "because"
+	confidenceReasonRe = regexp.MustCompile(`^[\s)\]*_]*(?:[-–—:;,.]+|\bbecause\b)\s*`)
+)
+
+// confidenceBucket maps a confidence score to High, Medium or Low
+func confidenceBucket(score int) string {
+	switch {
+	case score >= HighConfidenceScore:
+		return "High"
+	case score >= MediumConfidenceScore:
+		return "Medium"
+	default:
+		return "Low"
+	}
+}
+
+// parseConfidence reads the confidence section of a response: the score from 1 to 100 (0 when the
+// model gave none) and the justification following it
+// Fractions ("0.85") are read as percentages, and a reported 0 counts as 1 so that 0 keeps meaning
+// "not given".
+func parseConfidence(text string) (score int, reason string) {
+	text = strings.TrimSpace(text)
+	line, rest, _ := strings.Cut(text, "\n")
+	line = strings.Trim(strings.TrimSpace(line), "*_ ")
+
+	// Without "%" or "/100" only a leading number is a score, not e.g. "High - fails in 2 jobs"
+	var m []int
+	for _, match := range confidenceScoreRe.FindAllStringSubmatchIndex(line, -1) {
+		if match[4] < 0 && match[2] == 0 && strings.HasPrefix(line[match[3]:], ".") {
+			continue // a list number, "1. High"
+		}
+		if match[4] >= 0 || confidenceLeadRe.MatchString(line[:match[2]]) {
+			m = match
+			break
+		}
+	}
+	if m == nil {
+		return 0, ""
+	}
+	number := line[m[2]:m[3]]
+	if strings.Contains(number, ".") {
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil || f > 1 {
+			return 0, ""
+		}
+		score = int(f*100 + 0.5)
+	} else {
+		score, _ = strconv.Atoi(number)
+	}
+	score = max(score, 1)
+
+	// The justification follows the score and the level ("85 (High) - reason"), or is on the next lines
+	after := line[m[3]:]
+	if m[5] >= 0 {
+		after = line[m[5]:]
+	}
+	after = strings.TrimSpace(after)
+	for _, level := range []string{"(high)", "(medium)", "(low)", "high", "medium", "low"} {
+		if strings.HasPrefix(strings.ToLower(after), level) {
+			after = after[len(level):]
+			break
+		}
+	}
+	after = confidenceReasonRe.ReplaceAllString(after, "")
+	reason = strings.TrimSpace(after)
+	if reason == "" {
+		reason = strings.TrimSpace(rest)
+	}
+	return score, strings.Trim(reason, "*_ ")
+}
+
+// setConfidence fills the confidence of the proposal from the confidence section
+// With a score the level is derived from it, so the High/Medium/Low gates of --create-issue and
+// --fix-branch follow the thresholds; otherwise the free-text level is kept.
+func (p *FixProposal) setConfidence(section string) {
+	if section == "" {
+		return
+	}
+	line, _, _ := strings.Cut(section, "\n")
+	p.Confidence = strings.Trim(strings.TrimSpace(line), "*_ ")
+
+	score, reason := parseConfidence(section)
+	if score == 0 {
+		return
+	}
+	p.ConfidenceScore, p.ConfidenceReason = score, reason
+	if level := confidenceLevel(p.Confidence); level != "" && level != confidenceBucket(score) {
+		logDebugf("Stated confidence %s disagrees with score %d, using %s", level, score, confidenceBucket(score))
+	}
+	p.Confidence = fmt.Sprintf("%s (%d/100)", confidenceBucket(score), score)
+	if reason != "" {
+		p.Confidence += " - " + reason
+	}
+}
@@ -0,0 +1,71 @@
+package debugger
+
+import "testing"
+
+func TestParseConfidence(t *testing.T) {
+	tests := []struct {
+		text   string
+		score  int
+		reason string
+	}{
+		{"85", 85, ""},
+		{"85%", 85, ""},
+		{"85/100", 85, ""},
+		{"85 / 100", 85, ""},
+		{"85 percent", 85, ""},
+		{"0.85", 85, ""},
+		{".9", 90, ""},
+		{"1.0", 100, ""},
+		{"100", 100, ""},
+		{"Score: 85", 85, ""},
+		{"**70**\nThe error names the module.", 70, "The error names the module."},
+		{"80 (High) - the error names the missing module", 80, "the error names the missing module"},
+		{"High (90%) because the log names it", 90, "the log names it"},
+		{"Medium: 55% - flaky network", 55, "flaky network"},
+		// a reported 0 still counts as a score
+		{"0", 1, ""},
+		{"0%", 1, ""},
+		// no score
+		{"High", 0, ""},
+		{"High - fails in 2 jobs", 0, ""},
+		{"1. High", 0, ""},
+		{"Fixed in 2023", 0, ""},
+		{"150%", 0, ""},
+		{"1.5", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			score, reason := parseConfidence(tt.text)
+			if score != tt.score || reason != tt.reason {
+				t.Errorf("parseConfidence(%q) = %d, %q, want %d, %q", tt.text, score, reason, tt.score, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSetConfidence(t *testing.T) {
+	tests := []struct {
+		section    string
+		confidence string
+		score      int
+	}{
+		{"80 (High) - the error names the missing module", "High (80/100) - the error names the missing module", 80},
+		{"0.5", "Medium (50/100)", 50},
+		{"39%", "Low (39/100)", 39},
+		{"40%", "Medium (40/100)", 40},
+		{"75%", "High (75/100)", 75},
+		// the score wins over a stated level that disagrees with it
+		{"High - 20%, only a guess", "Low (20/100) - only a guess", 20},
+		{"High - the error names the module", "High - the error names the module", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.section, func(t *testing.T) {
+			p := &FixProposal{}
+			p.setConfidence(tt.section)
+			if p.Confidence != tt.confidence || p.ConfidenceScore != tt.score {
+				t.Errorf("setConfidence(%q) = %q, %d, want %q, %d", tt.section, p.Confidence, p.ConfidenceScore, tt.confidence, tt.score)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxContextFilesChars caps the source files included in the prompt with --context-files
+const maxContextFilesChars = 15000
+
+// SourceFile is a local source file included in the prompt
+type SourceFile struct {
+	Path    string
+	Content string
+}
+
+// loadContextFiles reads the files matching patterns (paths or globs)
+// A pattern matching no file is an error, since the files were named explicitly.
+func loadContextFiles(patterns []string) ([]SourceFile, error) {
+	var files []SourceFile
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid context file pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("context file %s not found", pattern)
+		}
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read context file: %w", err)
+			}
+			files = append(files, SourceFile{Path: filepath.ToSlash(path), Content: string(data)})
+		}
+	}
+	logDebugf("Loaded %d context files", len(files))
+	return files, nil
+}
+
+// writeContextFiles writes the source files with line numbers, sharing maxChars between them
+// Files are cut at line boundaries so every line shown keeps its real line number.
+func writeContextFiles(sb *strings.Builder, files []SourceFile, maxChars int) {
+	sb.WriteString("\n## Relevant Source\n")
+	sb.WriteString("Source files the user marked as relevant, with line numbers:\n")
+
+	perFile := maxChars / len(files)
+	for _, file := range files {
+		lines := strings.Split(strings.TrimRight(file.Content, "\n"), "\n")
+		var body strings.Builder
+		shown := 0
+		for i, line := range lines {
+			numbered := fmt.Sprintf("%5d | %s\n", i+1, line)
+			if body.Len()+len(numbered) > perFile {
+				break
+			}
+			body.WriteString(numbered)
+			shown++
+		}
+
+		sb.WriteString(fmt.Sprintf("\n### %s\n", file.Path))
+		if shown < len(lines) {
+			logInfof("Context file %s truncated to %d of %d lines", file.Path, shown, len(lines))
+			sb.WriteString(fmt.Sprintf("(truncated, lines 1-%d of %d shown)\n", shown, len(lines)))
+		}
+		sb.WriteString("```\n")
+		sb.WriteString(body.String())
+		sb.WriteString("```\n")
+	}
+}
@@ -0,0 +1,77 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteContextFilesLineNumbers(t *testing.T) {
+	files := []SourceFile{{Path: "main.go", Content: "package main\n\nfunc main() {\n\tpanic(\"boom\")\n}\n"}}
+	var sb strings.Builder
+	writeContextFiles(&sb, files, maxContextFilesChars)
+	want := "\n### main.go\n```\n" +
+		"    1 | package main\n" +
+		"    2 | \n" +
+		"    3 | func main() {\n" +
+		"    4 | \tpanic(\"boom\")\n" +
+		"    5 | }\n" +
+		"```\n"
+	if !strings.HasSuffix(sb.String(), want) {
+		t.Errorf("writeContextFiles() =\n%s\nwant it to end with\n%s", sb.String(), want)
+	}
+}
+
+func TestWriteContextFilesTruncated(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 100; i++ {
+		content.WriteString("x := compute(x) // a line of about forty chars\n")
+	}
+	files := []SourceFile{{Path: "big.go", Content: content.String()}, {Path: "small.go", Content: "package small\n"}}
+	var sb strings.Builder
+	writeContextFiles(&sb, files, 2000)
+	out := sb.String()
+
+	// Each file gets half of the budget and is cut at a line boundary
+	if !strings.Contains(out, "(truncated, lines 1-") || !strings.Contains(out, " of 100 shown)") {
+		t.Errorf("output does not mark big.go as truncated:\n%s", out)
+	}
+	if !strings.Contains(out, "    1 | package small\n") {
+		t.Errorf("output does not contain small.go:\n%s", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, " | ") && !strings.HasSuffix(line, "forty chars") && !strings.HasSuffix(line, "package small") {
+			t.Errorf("line %q was cut in the middle", line)
+		}
+	}
+}
+
+func TestLoadContextFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := loadContextFiles([]string{filepath.Join(dir, "*.go"), filepath.Join(dir, "a.go")})
+	if err != nil {
+		t.Fatalf("loadContextFiles() error = %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f.Path))
+	}
+	// Directories are skipped and a file matched twice is loaded once
+	if strings.Join(names, ",") != "a.go,b.go" {
+		t.Errorf("loaded %v, want a.go and b.go", names)
+	}
+
+	if _, err := loadContextFiles([]string{filepath.Join(dir, "missing.go")}); err == nil {
+		t.Error("loadContextFiles() of a missing file succeeded")
+	}
+}
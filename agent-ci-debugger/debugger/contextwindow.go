@@ -0,0 +1,176 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultContextWindow is assumed for models missing from modelContextWindows
+const defaultContextWindow = 128000
+
+// Sources of the context window used for a model
+const (
+	windowSourceAPI     = "API model metadata"
+	windowSourceTable   = "built-in model table"
+	windowSourceDefault = "default"
+)
+
+// modelMetadataTimeout bounds the model metadata request, the analysis goes on without it
+const modelMetadataTimeout = 10 * time.Second
+
+// contextWindowFields are the model metadata fields OpenAI-compatible servers report the context window in
+// (e.g. vLLM "max_model_len", OpenRouter "context_length", LM Studio "max_context_length")
+var contextWindowFields = []string{"context_window", "context_length", "max_context_length", "max_model_len", "max_input_tokens"}
+
+// detectedWindows caches the context windows read from the API per API root and model;
+// 0 records a failed detection so it is not retried
+var detectedWindows = struct {
+	sync.Mutex
+	windows map[string]int
+}{windows: make(map[string]int)}
+
+// Log budgets of the prompt in characters; the budget is halved until the prompt fits the model
+const (
+	defaultLogBudget = 30000
+	minLogBudget     = 2000
+)
+
+// ErrPromptTooLarge is returned when the prompt does not fit the model's context window even with minimal logs
+var ErrPromptTooLarge = errors.New("prompt exceeds the model's context window")
+
+// modelContextWindows are the context window sizes (in tokens) of known models
+var modelContextWindows = map[string]int{
+	"gpt-4o-mini":   128000,
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4":         8192,
+	"gpt-4-32k":     32768,
+	"gpt-3.5-turbo": 16385,
+	"o1":            200000,
+	"o1-mini":       128000,
+	"o1-preview":    128000,
+	"o3":            200000,
+	"o3-mini":       200000,
+	"o4-mini":       200000,
+}
+
+// contextWindow returns the context window of model from the built-in table
+// Dated model versions such as "gpt-4-0613" match the longest known prefix. ok is false for unknown models.
+func contextWindow(model string) (size int, ok bool) {
+	if size, ok := modelContextWindows[model]; ok {
+		return size, true
+	}
+	best := ""
+	for name := range modelContextWindows {
+		if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best != "" {
+		return modelContextWindows[best], true
+	}
+	return 0, false
+}
+
+// modelContextWindow returns the context window of the debugger's model and where it comes from
+// The model metadata of the API is tried first, so new models need no table update; then the
+// built-in table and finally defaultContextWindow.
+func (d *GitHubWorkflowDebugger) modelContextWindow(ctx context.Context) (int, string) {
+	if d.apiBaseURL != "" {
+		key := d.apiBaseURL + "\x00" + d.model
+		detectedWindows.Lock()
+		size, cached := detectedWindows.windows[key]
+		detectedWindows.Unlock()
+		if !cached {
+			var err error
+			if size, err = d.fetchContextWindow(ctx); err != nil {
+				logDebugf("Context window of %s not detected: %v", d.model, err)
+			}
+			detectedWindows.Lock()
+			detectedWindows.windows[key] = size
+			detectedWindows.Unlock()
+		}
+		if size > 0 {
+			return size, windowSourceAPI
+		}
+	}
+	if size, ok := contextWindow(d.model); ok {
+		return size, windowSourceTable
+	}
+	return defaultContextWindow, windowSourceDefault
+}
+
+// fetchContextWindow reads the context window from the API's model metadata (GET /models/{model})
+// OpenAI itself does not report it, many OpenAI-compatible servers do.
+func (d *GitHubWorkflowDebugger) fetchContextWindow(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, modelMetadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(d.apiBaseURL, "/")+"/models/"+d.model, nil)
+	if err != nil {
+		return 0, err
+	}
+	if d.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return 0, fmt.Errorf("invalid model metadata: %w", err)
+	}
+	for _, field := range contextWindowFields {
+		var size int
+		if raw, ok := metadata[field]; ok && json.Unmarshal(raw, &size) == nil && size > 0 {
+			return size, nil
+		}
+	}
+	return 0, errors.New("the model metadata has no context window")
+}
+
+// fitPrompt builds the analysis prompt, halving the log budget until prompt and response fit
+// the model's context window
+func (d *GitHubWorkflowDebugger) fitPrompt(ctx context.Context, run *WorkflowRun) (string, error) {
+	window, source := d.modelContextWindow(ctx)
+	logInfof("Context window of %s: %d tokens (%s)", d.model, window, source)
+	available := window - d.responseTokens() - estimateTokens(d.systemPrompt())
+
+	// Start from the default budget, or less when the window is known to be too small for it
+	budget := defaultLogBudget
+	if source != windowSourceDefault {
+		budget = max(min(budget, available*5/2), minLogBudget)
+	}
+	for {
+		prompt := d.buildAnalysisPrompt(run, budget)
+		tokens := estimateTokens(prompt)
+		logInfof("Estimated tokens: %d (max: %d)", tokens, available)
+		if tokens <= available {
+			return prompt, nil
+		}
+		if budget/2 < minLogBudget {
+			return "", fmt.Errorf("%w: ~%d tokens needed, %d available for %s (context %d, response %d)",
+				ErrPromptTooLarge, tokens, available, d.model, window, d.responseTokens())
+		}
+		budget /= 2
+		logWarnf("Prompt too large for %s (~%d tokens, %d available), shrinking the log budget to %d chars",
+			d.model, tokens, available, budget)
+	}
+}
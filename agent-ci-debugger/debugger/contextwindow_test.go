@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// oversizedRun is a failed run with far more error logs than any log budget
+func oversizedRun() *WorkflowRun {
+	var logs strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&logs, "test\tRun tests\t2024-01-01T00:00:00Z --- FAIL: TestCase%d: error: expected %d, got %d\n", i, i, i+1)
+	}
+	return &WorkflowRun{RunID: "1", Repository: "o/r", Conclusion: "failure", FailedLogs: logs.String()}
+}
+
+func TestFitPromptShrinksOversizedPrompt(t *testing.T) {
+	d := &GitHubWorkflowDebugger{model: "gpt-3.5-turbo", Options: Options{Quiet: true}}
+	run := oversizedRun()
+	window, _ := d.modelContextWindow(context.Background())
+	available := window - d.responseTokens() - estimateTokens(d.systemPrompt())
+	if full := estimateTokens(d.buildAnalysisPrompt(run, defaultLogBudget)); full <= available {
+		t.Fatalf("the prompt with the default budget has %d tokens and fits %d, the test needs more logs", full, available)
+	}
+
+	prompt, err := d.fitPrompt(context.Background(), run)
+	if err != nil {
+		t.Fatalf("fitPrompt() error = %v", err)
+	}
+	if tokens := estimateTokens(prompt); tokens > available {
+		t.Errorf("prompt has %d tokens, want at most %d", tokens, available)
+	}
+	if !strings.Contains(prompt, "--- FAIL: TestCase") {
+		t.Error("the shrunk prompt has no logs left")
+	}
+}
+
+func TestFitPromptTooLarge(t *testing.T) {
+	// The response alone exceeds the 8k window of gpt-4
+	d := &GitHubWorkflowDebugger{model: "gpt-4", Options: Options{Quiet: true}}
+	if _, err := d.fitPrompt(context.Background(), oversizedRun()); !errors.Is(err, ErrPromptTooLarge) {
+		t.Errorf("fitPrompt() error = %v, want ErrPromptTooLarge", err)
+	}
+}
+
+func TestModelContextWindowFromMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		metadata string // body of GET /v1/models/<model>, 404 when empty
+		want     int
+		source   string
+	}{
+		{name: "context_length", model: "local-llm", metadata: `{"id":"local-llm","object":"model","context_length":32768}`, want: 32768, source: windowSourceAPI},
+		{name: "max_model_len", model: "local-llm", metadata: `{"id":"local-llm","max_model_len":8192}`, want: 8192, source: windowSourceAPI},
+		{name: "metadata overrides the table", model: "gpt-4", metadata: `{"id":"gpt-4","context_window":16384}`, want: 16384, source: windowSourceAPI},
+		{name: "no window in the metadata", model: "gpt-4", metadata: `{"id":"gpt-4","object":"model"}`, want: 8192, source: windowSourceTable},
+		{name: "no metadata", model: "local-llm", want: defaultContextWindow, source: windowSourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &mockOpenAI{routes: map[string]string{}}
+			if tt.metadata != "" {
+				api.routes["/v1/models/"+tt.model] = tt.metadata
+			}
+			d := api.start(t)
+			d.model = tt.model
+			for i := 0; i < 2; i++ {
+				if size, source := d.modelContextWindow(context.Background()); size != tt.want || source != tt.source {
+					t.Errorf("modelContextWindow() = %d, %q, want %d, %q", size, source, tt.want, tt.source)
+				}
+			}
+			api.mu.Lock()
+			defer api.mu.Unlock()
+			if len(api.headers) != 1 {
+				t.Errorf("API received %d requests, want the metadata fetched once", len(api.headers))
+			}
+		})
+	}
+}
+
+func TestFitPromptUsesMetadataWindow(t *testing.T) {
+	api := &mockOpenAI{routes: map[string]string{"/v1/models/local-llm": `{"id":"local-llm","context_length":16000}`}}
+	d := api.start(t)
+	d.model = "local-llm"
+	d.Options.Quiet = true
+
+	prompt, err := d.fitPrompt(context.Background(), oversizedRun())
+	if err != nil {
+		t.Fatalf("fitPrompt() error = %v", err)
+	}
+	if available := 16000 - d.responseTokens() - estimateTokens(d.systemPrompt()); estimateTokens(prompt) > available {
+		t.Errorf("prompt has %d tokens, want at most %d of the detected window", estimateTokens(prompt), available)
+	}
+}
@@ -0,0 +1,108 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ErrCostLimitExceeded is returned when the estimated cost is above --max-cost
+var ErrCostLimitExceeded = errors.New("estimated cost exceeds limit")
+
+// ModelPrice is the USD price per 1M tokens of a model
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// defaultPrices are the list prices per 1M tokens (see https://openai.com/api/pricing/)
+// Override with --prices-file when prices change.
+var defaultPrices = map[string]ModelPrice{
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60},
+	"gpt-4o":        {Input: 2.50, Output: 10.00},
+	"gpt-4-turbo":   {Input: 10.00, Output: 30.00},
+	"gpt-4":         {Input: 30.00, Output: 60.00},
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50},
+	"o1":            {Input: 15.00, Output: 60.00},
+	"o1-mini":       {Input: 1.10, Output: 4.40},
+	"o3":            {Input: 2.00, Output: 8.00},
+	"o3-mini":       {Input: 1.10, Output: 4.40},
+	"o4-mini":       {Input: 1.10, Output: 4.40},
+}
+
+// LoadPriceTable reads a JSON price table, e.g. {"gpt-4o": {"input": 2.5, "output": 10}}
+func LoadPriceTable(path string) (map[string]ModelPrice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price table: %w", err)
+	}
+	var prices map[string]ModelPrice
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("failed to parse price table %s: %w", path, err)
+	}
+	return prices, nil
+}
+
+// priceFor returns the price of a model, preferring overrides over the defaults
+// Dated model versions such as "gpt-4o-2024-08-06" match the longest known prefix.
+func (d *GitHubWorkflowDebugger) priceFor(model string) (ModelPrice, bool) {
+	for _, table := range []map[string]ModelPrice{d.Options.Prices, defaultPrices} {
+		if price, ok := table[model]; ok {
+			return price, true
+		}
+		best := ""
+		for name := range table {
+			if strings.HasPrefix(model, name+"-") && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best != "" {
+			return table[best], true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// cost returns the USD cost of the given token counts
+func (p ModelPrice) cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1_000_000
+}
+
+// checkCost estimates the cost of a request before it is sent and enforces --max-cost
+// The guard uses the worst case, i.e. a completion of maxTokens tokens.
+func (d *GitHubWorkflowDebugger) checkCost(promptTokens, maxTokens int) error {
+	price, ok := d.priceFor(d.model)
+	if !ok {
+		logWarnf("no price known for model %s, cannot estimate cost", d.model)
+		if d.Options.MaxCost > 0 && !d.Options.Yes {
+			return fmt.Errorf("%w: no price known for model %s (use --yes to proceed)", ErrCostLimitExceeded, d.model)
+		}
+		return nil
+	}
+
+	estimate := price.cost(promptTokens, 0)
+	worstCase := price.cost(promptTokens, maxTokens)
+	logInfof("Estimated cost: $%.4f for input, up to $%.4f with a full %d-token response", estimate, worstCase, maxTokens)
+
+	if d.Options.MaxCost > 0 && worstCase > d.Options.MaxCost {
+		if d.Options.Yes {
+			logWarnf("estimated cost $%.4f exceeds --max-cost $%.4f, proceeding (--yes)", worstCase, d.Options.MaxCost)
+			return nil
+		}
+		return fmt.Errorf("%w: $%.4f > $%.4f (use --yes to proceed)", ErrCostLimitExceeded, worstCase, d.Options.MaxCost)
+	}
+	return nil
+}
+
+// actualCost returns the cost of a completed request, or false when the model price is unknown
+func (d *GitHubWorkflowDebugger) actualCost(usage openai.Usage) (float64, bool) {
+	price, ok := d.priceFor(d.model)
+	if !ok {
+		return 0, false
+	}
+	return price.cost(usage.PromptTokens, usage.CompletionTokens), true
+}
@@ -0,0 +1,1815 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// WorkflowRun represents a GitHub Actions workflow run (or a GitLab pipeline)
+type WorkflowRun struct {
+	URL               string
+	Provider          string // ProviderGitLab for GitLab pipelines, empty for GitHub
+	RunID             string
+	JobID             string      // set when a specific job is analyzed
+	StepName          string      // set when a specific step is analyzed
+	StepAnchor        *StepAnchor // step and line linked by the "#step:N:line" anchor of the job URL
+	Repository        string
+	WorkflowName      string
+	WorkflowID        int64
+	WorkflowPath      string
+	WorkflowYAML      string
+	HeadSHA           string
+	CommitDiff        string // diff of the head commit, fetched with IncludeDiff
+	DiffBase          string // base commit of CommitDiff when it covers a range (Options.DiffBase)
+	Status            string
+	Conclusion        string
+	FailedLogs        string
+	FullLogs          string
+	Steps             []StepLog
+	ErrorSummary      ErrorSummary
+	Comparison        *Comparison        // set when compared with a passing run
+	ReusableWorkflows []ReusableWorkflow // reusable workflows called by the run
+	SimilarFailures   []SimilarFailure   // similar past failures from the history (UseHistory)
+	ContextFiles      []SourceFile       // local source files included in the prompt (Options.ContextFiles)
+	PriorReport       *PriorReport       // earlier analysis whose fix did not work (Options.PriorReport)
+	JobOutcomes       []JobOutcome       // conclusions and timing of all jobs of the run
+	RootFailingJob    string             // first job that failed by itself, the others failed or were skipped in its wake
+	JobClusters       []JobCluster       // failed jobs grouped by their error when more than Options.MaxJobs failed
+	LogBudget         *LogBudget         // how much of the logs the prompt included, set when the prompt is built
+	NumberedLines     []string           // log lines numbered [L1], [L2], ... in the prompt (Options.Explain)
+	TestHistory       *FlakinessHistory  // outcomes of the failed tests in earlier runs (Options.FlakyRuns)
+	Environment       *Environment       // runners and tool versions of the failed jobs
+}
+
+// ErrorSummary contains structured information about the failure
+type ErrorSummary struct {
+	FailedJobs         []string
+	Jobs               []JobInfo
+	ErrorMessages      []string
+	Timeouts           []string
+	FailedTests        []string
+	StackTraces        []string
+	ExitCodes          []int
+	Totals             map[string]int      // findings per category, including those beyond Options.MaxErrors
+	Findings           []Finding           // all detector findings in log order
+	Annotations        []Annotation        // check-run annotations of the failed jobs
+	Assertions         []Assertion         // assertion failures with expected/actual values
+	BuildFailures      []BuildFailure      // failed Dockerfile instructions of docker builds
+	DataRaces          []DataRace          // data races reported by the Go race detector
+	ResourceFailures   []ResourceFailure   // disk space, filesystem and memory failures of the runner
+	CompilerErrors     []CompilerError     // compiler errors with their source locations
+	PermissionFailures []PermissionFailure // operations rejected for token permissions, secret scanning or branch protection
+	LicenseViolations  []LicenseViolation  // dependencies rejected by license and compliance scans
+	FailedStep         string              // "job / step" where the nonzero exit occurred
+	FailedStepExitCode int
+	TimedOutStep       *StepTiming // how long the timed-out step ran, when the run timed out
+}
+
+// JobInfo describes a failed job, including its matrix dimensions when it is a matrix job
+type JobInfo struct {
+	Name     string   // full job name, e.g. "test (ubuntu-latest, 1.21)"
+	BaseName string   // job name without the matrix suffix and callers, e.g. "test"
+	Caller   string   // caller jobs of a reusable workflow job, e.g. "ci / build" of "ci / build / test"
+	Matrix   []string // all matrix values in order, e.g. ["ubuntu-latest", "1.21"]
+	OS       string   // matrix value recognized as an operating system/runner
+	Version  string   // matrix value recognized as a tool/language version
+}
+
+// FixProposal represents a proposed fix for the workflow failure
+type FixProposal struct {
+	RootCause    string
+	Analysis     string
+	ProposedFix  string
+	FilesToCheck []string
+	CodeChanges  []CodeChange
+	Confidence   string // High, Medium or Low, with the score and justification when the model gave a score
+	RawResponse  string // unparsed AI response
+	Usage        openai.Usage
+	Cost         float64             // USD cost of the analysis, 0 when the model price is unknown
+	MissingFiles []string            // files to check not found in the local checkout (VerifyFiles)
+	Owners       map[string][]string // owners of the files to check, from CODEOWNERS
+	Sections     []ReportSection     // additional report sections added by enrichers
+	Summary      string              // one-line TL;DR of at most MaxSummaryChars characters
+	Heuristic    bool                // produced by the offline rules (Options.Offline), not by an AI model
+	FixPR        string              // URL of the pull request opened with the code changes (Options.FixBranch)
+	Evidence     []Evidence          // log lines cited for the root cause and analysis (Options.Explain)
+	Model        string              // model of the analysis when it is not the configured one (Options.TriageModel)
+
+	// ConfidenceScore is the model's self-reported confidence from 1 to 100, 0 when it gave none, and
+	// ConfidenceReason its justification; Confidence holds the level derived from the score
+	ConfidenceScore  int
+	ConfidenceReason string
+
+	// Samples is the number of sampled analyses (Options.Samples) and AgreeingSamples how many of
+	// them agree with this proposal's root cause; both are 0 for a single analysis
+	Samples         int
+	AgreeingSamples int
+
+	// messages is the conversation that produced the proposal, used for follow-up questions
+	messages []openai.ChatCompletionMessage
+	// cached is set when the response came from the response cache, so nothing was spent on it
+	cached bool
+}
+
+// Result bundles everything produced by a debugging session
+type Result struct {
+	Run      *WorkflowRun
+	Proposal *FixProposal
+	Report   string
+	Metrics  *Metrics
+}
+
+// CodeChange represents a suggested code modification
+type CodeChange struct {
+	File        string
+	Description string
+	DiffSnippet string
+}
+
+// Options controls optional behavior of the debugger
+type Options struct {
+	// TailLines limits log analysis to the last N lines (0 = whole log)
+	TailLines int
+	// SinceLastStep limits log analysis to the output of the last step
+	SinceLastStep bool
+	// FailingStepsOnly limits log analysis to the steps that exited with a nonzero code and the last
+	// lines of the step before each, dropping the output of passing steps
+	FailingStepsOnly bool
+	// MaxLogBytes caps the logs of a run read from gh, only the end is kept (0 = DefaultMaxLogBytes)
+	MaxLogBytes int
+	// CreateIssue files or updates a tracking issue for High confidence analyses
+	CreateIssue bool
+	// IssueLabel is the label used for tracking issues (default "ci-failure")
+	IssueLabel string
+	// Quiet suppresses progress and status output (the report is still printed)
+	Quiet bool
+	// StatusToStderr prints status messages to stderr, keeping stdout for the report only
+	StatusToStderr bool
+	// Force analyzes runs even when their conclusion is not a genuine failure
+	Force bool
+	// IncludeWorkflow adds the workflow definition file to the prompt
+	IncludeWorkflow bool
+	// MaxCost aborts the analysis when its estimated USD cost is higher (0 = no limit)
+	MaxCost float64
+	// Yes confirms actions that would otherwise be refused, such as exceeding MaxCost
+	Yes bool
+	// SystemPrompt replaces the system message of the default persona
+	SystemPrompt string
+	// Prices overrides the built-in model price table
+	Prices map[string]ModelPrice
+	// Ignore lists log lines dropped before the logs are filtered for the prompt
+	Ignore *IgnoreList
+	// IncludeDiff adds the diff of the commit that triggered the run to the prompt
+	IncludeDiff bool
+	// DiffBase makes the included diff cover the range from this commit, branch or tag to the head
+	// commit of the run instead of the head commit alone; requires IncludeDiff
+	DiffBase string
+	// DiffPaths limits the included diff to the files matching these globs or directory prefixes
+	DiffPaths []string
+	// MaxDiffChars caps the included diff (0 = DefaultMaxDiffChars)
+	MaxDiffChars int
+	// CacheResponses replays AI responses for identical requests from the on-disk cache
+	CacheResponses bool
+	// CacheTTL is how long cached responses are used (default 24h)
+	CacheTTL time.Duration
+	// VerifyFiles checks the files to check against the local checkout and flags missing ones
+	VerifyFiles bool
+	// JobName selects the job to analyze by name when the URL has no job ID
+	JobName string
+	// StepName limits the analysis to the output of the named step
+	StepName string
+	// Wait polls runs that are still queued or in progress until they complete
+	Wait bool
+	// WaitInterval is the polling interval of Wait (default 30s)
+	WaitInterval time.Duration
+	// WaitTimeout is the maximum time to Wait (default 30m)
+	WaitTimeout time.Duration
+	// CompareURL is a passing run of the same workflow to explain the regression against
+	CompareURL string
+	// DiffOnly reports only the regression against CompareURL: the changed files, the new errors and a
+	// targeted fix, leaving out the analysis of the full logs
+	DiffOnly bool
+	// ContextFiles are local files (paths or globs) whose contents are included in the prompt
+	ContextFiles []string
+	// PriorReport is a report of an earlier analysis whose fix did not work; its root cause and fix
+	// are included in the prompt so the model proposes something else
+	PriorReport string
+	// MaxErrors is the number of findings kept per category (0 = DefaultMaxErrors, negative = no limit)
+	MaxErrors int
+	// MaxLineChars is the length beyond which log lines are shortened to their start and end
+	// (0 = DefaultMaxLineChars, negative = no limit)
+	MaxLineChars int
+	// MaxJobs is the number of failed jobs whose logs are analyzed; beyond it the jobs are grouped by
+	// their top error message and one job per group is analyzed (0 = DefaultMaxJobs, negative = no limit)
+	MaxJobs int
+	// SeverityWeights overrides entries of SeverityWeights, the severities of log line keywords
+	SeverityWeights map[string]int
+	// ErrorContextLines is the number of log lines kept around each error line in the prompt
+	// (0 = DefaultErrorContextLines, negative = only the error lines)
+	ErrorContextLines int
+	// Samples is the number of analyses sampled, the one whose root cause most others agree with is kept (0 or 1 = single analysis)
+	Samples int
+	// ReportTemplate is a text/template replacing the default Markdown report (see ReportData)
+	ReportTemplate string
+	// KeepANSI keeps ANSI escape sequences (colors) in the logs, which are stripped by default
+	KeepANSI bool
+	// KeepLogPrefixes keeps the job/step prefix and timestamp of every log line in the prompt
+	KeepLogPrefixes bool
+	// Locales selects the localized error keywords matched in the logs (nil = all built-in locales)
+	Locales []string
+	// ExtraKeywords are matched in the logs in addition to the built-in error keywords, with
+	// CustomKeywordSeverity, and their lines are reported as errors (see LoadKeywordsFile)
+	ExtraKeywords []string
+	// UsageLedger is a JSONL file every AI analysis appends its tokens and cost to, see LoadUsage ("" = none)
+	UsageLedger string
+	// DebugLLM is a file the requests to and raw responses of the LLM API are appended to, redacted and
+	// truncated, "-" for stderr ("" = none)
+	DebugLLM string
+	// Anonymize pseudonymizes the owner and repository names in the prompt ("mycorp/secret-svc" -> "org/repo-a")
+	// and maps them back in the answer; the failures embedded and stored for --use-history are anonymized too
+	Anonymize bool
+	// AuditLog is a JSONL file every analysis appends its metadata (never logs or reports) to
+	AuditLog string
+	// UseHistory adds the resolutions of similar past failures to the prompt and records the analysis
+	UseHistory bool
+	// HistoryFile is the failure history store (default in the user cache directory)
+	HistoryFile string
+	// HistoryTopK is the maximum number of similar failures included (default 3)
+	HistoryTopK int
+	// Offline triages the failure with built-in heuristic rules instead of calling the AI model
+	Offline bool
+	// FixBranch applies the proposed code changes on a branch and opens a pull request (requires Yes)
+	FixBranch bool
+	// FixBranchName is the branch of FixBranch (default "workflow-debugger/fix-run-<run ID>")
+	FixBranchName string
+	// FlakyRuns is the number of earlier runs of the workflow whose outcomes of the failed tests are
+	// added to the prompt to spot flaky tests (0 = none)
+	FlakyRuns int
+	// FlakyConcurrency is the number of earlier runs fetched at the same time (0 = DefaultFlakyConcurrency)
+	FlakyConcurrency int
+	// Explain numbers the log lines of the prompt and shows the lines the model cites as evidence in the report
+	Explain bool
+	// TimeoutAnalysis treats the run as timed out even when no timeout message is found, asking the
+	// model what made the failed step take so long; runs with timeout messages always get this
+	TimeoutAnalysis bool
+	// Triage pre-classifies failures by their ErrorSummary and gives clear infrastructure failures
+	// (out of memory, disk full, rate limits) a canned proposal without calling the AI model
+	Triage bool
+	// TriageThreshold is the classification score from which a failure counts as a clear infrastructure
+	// failure (0 = DefaultTriageThreshold); lower scores are escalated to the full analysis
+	TriageThreshold float64
+	// ReasoningEffort is the effort requested from reasoning models such as o3-mini: low, medium or high
+	// ("" = DefaultReasoningEffort); classic models ignore it
+	ReasoningEffort string
+	// TriageModel analyzes clear infrastructure failures with this cheaper model instead of skipping the
+	// AI analysis
+	TriageModel string
+	// BundleDir is a directory each analysis saves its logs, prompt, raw response and report to, in a
+	// subdirectory per run (see WriteBundle)
+	BundleDir string
+}
+
+// ErrNothingToAnalyze is returned by Debug when the run did not genuinely fail
+var ErrNothingToAnalyze = errors.New("nothing to analyze")
+
+// analyzableConclusions are the run conclusions that indicate a genuine failure
+// Other conclusions (cancelled, skipped, startup_failure, success, ...) have no failure logs worth analyzing
+var analyzableConclusions = map[string]bool{
+	"failure":   true,
+	"timed_out": true,
+}
+
+// GitHubWorkflowDebugger is the main AI agent
+type GitHubWorkflowDebugger struct {
+	openaiClient *openai.Client
+	apiKey       string
+	apiBaseURL   string       // API root queried for model metadata, empty when not supported
+	httpClient   *http.Client // client of the LLM API, enforcing the EndpointPolicy
+	endpointErr  error        // why the configured LLM endpoint is rejected, nil when allowed
+	account      OpenAIAccount
+	model        string
+	tracer       *tracer // nil without an OTLP endpoint
+	trace        *trace  // trace of the running analysis
+	Options      Options
+}
+
+// New creates a new debugger agent
+// The Azure OpenAI service is used instead of OpenAI when AZURE_OPENAI_ENDPOINT is set, an
+// OpenAI-compatible API when OPENAI_BASE_URL is set. Requests to the endpoint are subject to the
+// EndpointPolicy read from the environment; see EndpointError. Usage is attributed to the organization
+// and project of OPENAI_ORG_ID and OPENAI_PROJECT_ID, see Account. With OTEL_EXPORTER_OTLP_ENDPOINT
+// set, the stages of every analysis are exported as OpenTelemetry spans.
+func New(apiKey string) *GitHubWorkflowDebugger {
+	config := openai.DefaultConfig(apiKey)
+	if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
+		config.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+	apiBaseURL := config.BaseURL
+	account := openAIAccountFromEnv()
+	if azureConfig, ok := azureConfigFromEnv(apiKey); ok {
+		config = azureConfig
+		// Azure deployments do not report model metadata in the OpenAI format
+		apiBaseURL = ""
+		account = OpenAIAccount{}
+	}
+
+	policy, endpointErr := endpointPolicyFromEnv()
+	if endpointErr == nil {
+		endpointErr = policy.Check(config.BaseURL)
+	}
+	httpClient := newEndpointClient(policy, endpointErr)
+	httpClient.Transport = &llmDumpTransport{base: httpClient.Transport}
+	if account != (OpenAIAccount{}) {
+		httpClient.Transport = &accountTransport{account: account, base: httpClient.Transport}
+	}
+	httpClient.Transport = &reasoningTransport{base: httpClient.Transport}
+	config.HTTPClient = httpClient
+	client := openai.NewClientWithConfig(config)
+
+	// Check for model override from environment
+	model := os.Getenv("OPENAI_MODEL")
+	if model == "" {
+		model = openai.GPT4oMini // Default: GPT-4o-mini for cost efficiency
+		// Alternative models:
+		// openai.GPT4o           - Better quality, higher cost
+		// openai.GPT4Turbo       - GPT-4 Turbo
+		// "gpt-4o-mini"          - Use string directly for newer models
+	}
+
+	return &GitHubWorkflowDebugger{
+		openaiClient: client,
+		apiKey:       apiKey,
+		apiBaseURL:   apiBaseURL,
+		httpClient:   httpClient,
+		endpointErr:  endpointErr,
+		account:      account,
+		model:        model,
+		tracer:       tracerFromEnv(),
+	}
+}
+
+// EndpointError returns why the configured LLM endpoint is rejected by the endpoint policy, or nil
+// Every request to a rejected endpoint fails, so callers can report the problem before fetching any logs.
+func (d *GitHubWorkflowDebugger) EndpointError() error {
+	return d.endpointErr
+}
+
+// ParseWorkflowURL extracts repository, run ID, and optional job ID from GitHub Actions URL
+// Supports both formats:
+// - https://github.com/{owner}/{repo}/actions/runs/{run_id}
+// - https://github.com/{owner}/{repo}/actions/runs/{run_id}/job/{job_id}
+// A "#step:N:line" anchor of a job URL is ignored here; ParseRunRef parses it into RunRef.Step and StepLine.
+func ParseWorkflowURL(url string) (repo, runID, jobID string, err error) {
+	logDebugf("Parsing URL: %s", url)
+
+	// Try job URL format first (more specific)
+	jobRe := regexp.MustCompile(`github\.com/([^/]+/[^/]+)/actions/runs/(\d+)/job/(\d+)`)
+	matches := jobRe.FindStringSubmatch(url)
+
+	if len(matches) == 4 {
+		logDebugf("Detected job URL - Repo: %s, Run ID: %s, Job ID: %s", matches[1], matches[2], matches[3])
+		return matches[1], matches[2], matches[3], nil
+	}
+
+	// Try workflow run URL format
+	runRe := regexp.MustCompile(`github\.com/([^/]+/[^/]+)/actions/runs/(\d+)`)
+	matches = runRe.FindStringSubmatch(url)
+
+	if len(matches) == 3 {
+		logDebugf("Detected workflow URL - Repo: %s, Run ID: %s", matches[1], matches[2])
+		return matches[1], matches[2], "", nil
+	}
+
+	logErrorf("URL format not recognized")
+	return "", "", "", fmt.Errorf("invalid GitHub Actions URL format (expected workflow or job URL)")
+}
+
+// FetchWorkflowData retrieves workflow run data using GitHub CLI
+func (d *GitHubWorkflowDebugger) FetchWorkflowData(workflowURL string) (*WorkflowRun, error) {
+	ref, err := ParseRunRef(workflowURL)
+	if err != nil {
+		return nil, err
+	}
+	return d.FetchRun(ref)
+}
+
+// FetchRun retrieves the data of the referenced run using GitHub CLI, or the GitLab API for GitLab pipelines
+func (d *GitHubWorkflowDebugger) FetchRun(ref RunRef) (*WorkflowRun, error) {
+	logDebugf("Starting workflow data fetch...")
+	if ref.Provider == ProviderGitLab {
+		return d.fetchGitLabRun(ref)
+	}
+
+	repo, runID, jobID := ref.Repository, ref.RunID, ref.JobID
+	run := &WorkflowRun{
+		URL:        ref.WebURL(),
+		RunID:      runID,
+		JobID:      jobID,
+		Repository: repo,
+	}
+
+	logDebugf("Fetching workflow status for run %s in repo %s...", runID, repo)
+
+	// Get workflow run status
+	statusData, err := fetchRunStatus(repo, runID)
+	if err != nil {
+		return nil, err
+	}
+	if statusData.Status != "completed" {
+		if !d.Options.Wait {
+			return nil, fmt.Errorf("%w: run status is %q", ErrRunInProgress, statusData.Status)
+		}
+		if statusData, err = d.waitForCompletion(repo, runID); err != nil {
+			return nil, err
+		}
+	}
+
+	run.Status = statusData.Status
+	run.Conclusion = statusData.Conclusion
+	run.WorkflowName = statusData.WorkflowName
+	run.WorkflowID = statusData.WorkflowDatabaseID
+	run.HeadSHA = statusData.HeadSHA
+
+	logInfof("Workflow %q status: %s, conclusion: %s", run.WorkflowName, run.Status, run.Conclusion)
+
+	if d.Options.JobName != "" || d.Options.StepName != "" {
+		if err := d.selectJobAndStep(run); err != nil {
+			return nil, err
+		}
+		jobID = run.JobID
+	}
+	if ref.Step > 0 && jobID != "" && d.Options.StepName == "" {
+		run.StepAnchor = &StepAnchor{Step: ref.Step, Line: ref.StepLine}
+		selectAnchoredStep(run, ref.Step)
+	}
+
+	// Get logs - either for specific job or all failed jobs
+	var failedLogsOutput []byte
+	if jobID != "" {
+		// Fetch logs for specific job
+		logDebugf("Fetching logs for specific job: %s", jobID)
+		failedLogsOutput, err = ghLogs(d.Options.MaxLogBytes, "run", "view", runID, "--repo", repo, "--log", "--job", jobID)
+		if err != nil {
+			logWarnf("failed to get job logs: %v", err)
+			logWarnf("Falling back to all failed logs...")
+			// Fallback to failed logs
+			failedLogsOutput, _ = ghLogs(d.Options.MaxLogBytes, "run", "view", runID, "--repo", repo, "--log-failed")
+		} else {
+			logDebugf("Successfully fetched job logs (%d bytes)", len(failedLogsOutput))
+		}
+	} else {
+		// Get all failed job logs
+		logDebugf("Fetching all failed job logs...")
+		failedLogsOutput, err = ghLogs(d.Options.MaxLogBytes, "run", "view", runID, "--repo", repo, "--log-failed")
+		if err != nil {
+			logWarnf("failed to get failed logs: %v", err)
+		} else {
+			logDebugf("Successfully fetched failed logs (%d bytes)", len(failedLogsOutput))
+		}
+	}
+
+	run.FailedLogs = string(failedLogsOutput)
+	if strings.TrimSpace(run.FailedLogs) == "" {
+		if logs, err := fetchNestedJobLogs(run, d.Options.MaxLogBytes); err != nil {
+			logWarnf("failed to get reusable workflow job logs: %v", err)
+		} else if logs != "" {
+			logInfof("Using logs of reusable workflow jobs (%d bytes)", len(logs))
+			run.FailedLogs = logs
+		}
+	}
+	if run.StepName != "" {
+		run.FailedLogs = stepLogs(run.FailedLogs, run.StepName)
+		if a := run.StepAnchor; a != nil && a.Line > 0 {
+			a.Text = anchorLineText(run.FailedLogs, run.StepName, a.Line)
+		}
+	}
+
+	if d.Options.IncludeWorkflow {
+		if err := fetchWorkflowDefinition(run); err != nil {
+			logWarnf("failed to fetch workflow definition: %v", err)
+		}
+	}
+
+	if d.Options.IncludeDiff {
+		if err := d.fetchCommitDiff(run); err != nil {
+			logWarnf("failed to fetch commit diff: %v", err)
+		}
+	}
+
+	// The job conclusions tell the job that failed first from the jobs skipped or cancelled in its wake
+	if jobs, err := fetchJobs(run); err != nil {
+		logWarnf("%v", err)
+	} else {
+		run.JobOutcomes, run.RootFailingJob = jobOutcomes(jobs)
+		if run.RootFailingJob != "" {
+			logInfof("Root failing job: %s", run.RootFailingJob)
+		}
+		annotations, err := fetchRunAnnotations(run, jobs)
+		if err != nil {
+			logWarnf("failed to fetch annotations: %v", err)
+		} else {
+			logDebugf("Fetched %d annotations", len(annotations))
+		}
+		run.ErrorSummary.Annotations = annotations
+	}
+
+	d.parseLogs(run)
+
+	// The runner labels say which OS and image the failed jobs ran on when the logs do not
+	if runners, err := fetchRunners(run); err != nil {
+		logDebugf("failed to fetch the runners of the failed jobs: %v", err)
+	} else if len(runners) > 0 {
+		if run.Environment == nil {
+			run.Environment = &Environment{}
+		}
+		run.Environment.Runners = runners
+	}
+
+	// Permission failures are fixed in the workflow's permissions: block, so the model needs to see it
+	if len(run.ErrorSummary.PermissionFailures) > 0 && run.WorkflowYAML == "" {
+		logInfof("Found %d permission failures, fetching the workflow definition", len(run.ErrorSummary.PermissionFailures))
+		if err := fetchWorkflowDefinition(run); err != nil {
+			logWarnf("failed to fetch workflow definition: %v", err)
+		}
+	}
+
+	if d.Options.FlakyRuns > 0 {
+		history, err := fetchFlakinessHistory(&ghHistorySource{}, run, d.Options.FlakyRuns, d.Options.FlakyConcurrency)
+		if err != nil {
+			logWarnf("failed to fetch the test history: %v", err)
+		} else if history != nil {
+			run.TestHistory = history
+			flaky := 0
+			for _, t := range history.Tests {
+				if t.Flaky() {
+					flaky++
+				}
+			}
+			logInfof("Looked up %d failed tests in %d earlier runs, %d flaky", len(history.Tests), len(history.Runs), flaky)
+		}
+	}
+
+	// Jobs of reusable workflows are named "<caller> / <job>"; their fixes belong in the called workflow
+	for _, job := range run.ErrorSummary.Jobs {
+		if job.Caller == "" {
+			continue
+		}
+		workflows, err := fetchReusableWorkflows(run)
+		if err != nil {
+			logWarnf("failed to fetch reusable workflows: %v", err)
+		} else {
+			run.ReusableWorkflows = attributeReusableWorkflows(run, workflows)
+			logInfof("Run calls %d reusable workflows", len(run.ReusableWorkflows))
+		}
+		break
+	}
+
+	return run, nil
+}
+
+// parseLogs extracts the error summary and step structure from run.FailedLogs
+func (d *GitHubWorkflowDebugger) parseLogs(run *WorkflowRun) {
+	parse := d.startSpan(SpanParse, spanKindInternal)
+	defer parse.finish()
+	parse.set("log.bytes", len(run.FailedLogs))
+	// Colored output ("\x1b[31mError") defeats the keyword matching and wastes tokens
+	if !d.Options.KeepANSI {
+		before := len(run.FailedLogs)
+		run.FailedLogs = stripANSI(run.FailedLogs)
+		run.FullLogs = stripANSI(run.FullLogs)
+		if stripped := before - len(run.FailedLogs); stripped > 0 {
+			logDebugf("Stripped %d chars of ANSI escape sequences from the logs", stripped)
+		}
+	}
+
+	// A single huge line (minified sources, JSON dumps) would take up the prompt and slow the detectors down
+	maxLineChars := d.Options.MaxLineChars
+	if maxLineChars == 0 {
+		maxLineChars = DefaultMaxLineChars
+	}
+	var capped int
+	run.FailedLogs, capped = capLongLines(run.FailedLogs, maxLineChars)
+	run.FullLogs, _ = capLongLines(run.FullLogs, maxLineChars)
+	if capped > 0 {
+		logInfof("Shortened %d log lines longer than %d chars", capped, maxLineChars)
+	}
+
+	// Dozens of matrix jobs failing the same way repeat one error, one job per distinct error is enough
+	run.JobClusters = d.selectJobs(run)
+
+	// The passing steps of whole-job logs take up the budget the failing step needs
+	if d.Options.FailingStepsOnly {
+		var dropped int
+		run.FailedLogs, dropped = failingStepsLogs(run.FailedLogs)
+		if dropped > 0 {
+			logInfof("Dropped %d log lines of passing steps, keeping the failing steps (--failing-steps-only)", dropped)
+		} else {
+			logDebugf("No step reported a nonzero exit code, keeping the whole logs")
+		}
+	}
+
+	// Parse error summary
+	logDebugf("Parsing error summary from logs...")
+	annotations := run.ErrorSummary.Annotations
+	run.ErrorSummary = d.parseErrorSummary(run.FailedLogs)
+	run.ErrorSummary.Annotations = annotations
+	if len(run.JobClusters) > 0 {
+		// The summary lists all failed jobs, not only those whose logs are analyzed
+		run.ErrorSummary.FailedJobs = clusteredJobs(run.JobClusters)
+		run.ErrorSummary.Jobs = run.ErrorSummary.Jobs[:0]
+		for _, job := range run.ErrorSummary.FailedJobs {
+			run.ErrorSummary.Jobs = append(run.ErrorSummary.Jobs, parseJobName(job))
+		}
+	}
+
+	run.Steps = splitSteps(run.FailedLogs)
+	// The full logs hold the "Set up job" step with the runner image, which the failed logs may lack
+	details := extractEnvironmentDetails(run.FullLogs)
+	if len(details) == 0 {
+		details = extractEnvironmentDetails(run.FailedLogs)
+	}
+	if len(details) > 0 {
+		run.Environment = &Environment{Details: details}
+		logDebugf("Found %d environment lines in the logs", len(details))
+	}
+	if step := failedStep(run.Steps); step != nil {
+		run.ErrorSummary.FailedStep = step.stepLabel()
+		run.ErrorSummary.FailedStepExitCode = step.ExitCode
+		logInfof("Failed step: %s (exit code %d)", run.ErrorSummary.FailedStep, step.ExitCode)
+	}
+	analyzeTimeout(run, d.Options.TimeoutAnalysis)
+	logInfof("Found %d failed jobs, %d error messages, %d timeouts, %d failed tests",
+		len(run.ErrorSummary.FailedJobs),
+		run.ErrorSummary.Totals[CategoryError],
+		run.ErrorSummary.Totals[CategoryTimeout],
+		run.ErrorSummary.Totals[CategoryFailedTest])
+}
+
+// parseErrorSummary extracts structured error information from logs
+// Error patterns are recognized by the registered detectors (see detectors.go)
+func (d *GitHubWorkflowDebugger) parseErrorSummary(logs string) ErrorSummary {
+	summary := ErrorSummary{
+		FailedJobs:    []string{},
+		Jobs:          []JobInfo{},
+		ErrorMessages: []string{},
+		Timeouts:      []string{},
+		FailedTests:   []string{},
+		StackTraces:   []string{},
+		ExitCodes:     []int{},
+		Findings:      []Finding{},
+	}
+
+	lines := strings.Split(logs, "\n")
+
+	// Extract job names
+	jobRe := regexp.MustCompile(`^([^/\t]+) / ([^/\t]+)\s+`)
+	seenJobs := make(map[string]bool)
+
+	for _, line := range lines {
+		// Job names - taken from the "<job>\t<step>\t" prefix of gh logs, falling back to "a / b" names
+		job := ""
+		if name, _, _, ok := splitLogPrefix(line); ok {
+			job = strings.TrimSpace(name)
+		} else if matches := jobRe.FindStringSubmatch(line); len(matches) > 2 {
+			job = matches[1] + " / " + matches[2]
+		}
+		if job != "" && !seenJobs[job] {
+			summary.FailedJobs = append(summary.FailedJobs, job)
+			summary.Jobs = append(summary.Jobs, parseJobName(job))
+			seenJobs[job] = true
+		}
+	}
+
+	// Extract error patterns
+	maxErrors := d.Options.MaxErrors
+	if maxErrors == 0 {
+		maxErrors = DefaultMaxErrors
+	}
+	findings := d.keywordFindings(lines, runDetectors(lines))
+	weights := d.severityWeights()
+	for _, finding := range findings {
+		finding.Severity = lineSeverity(finding.Message, weights)
+		summary.addFinding(finding, max(maxErrors, 0))
+	}
+	if dropped := len(findings) - len(summary.Findings); dropped > 0 {
+		logInfof("Kept the first %d findings per category, %d more were only counted", maxErrors, dropped)
+	}
+	summary.CompilerErrors = collapseCascades(summary.CompilerErrors)
+	if n := collapsedErrors(summary.CompilerErrors); n > 0 {
+		logDebugf("Collapsed %d cascading compiler errors into their likely causes", n)
+	}
+
+	return summary
+}
+
+var (
+	matrixSuffixRe = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)\s*$`)
+	matrixOSRe     = regexp.MustCompile(`(?i)^(ubuntu|windows|macos|linux|darwin|win|mac|self-hosted)`)
+	matrixVerRe    = regexp.MustCompile(`(?i)^(go|node|python|java|jdk|v)?[- ]?\d+(\.\d+)*(\.x)?$`)
+)
+
+// parseJobName splits a job name such as "test (ubuntu-latest, 1.21)" into its base name and
+// matrix dimensions. Names of reusable workflow jobs ("caller / test (windows, 1.20)") are split
+// into the caller chain and the called job.
+func parseJobName(name string) JobInfo {
+	callers, job := splitJobPath(name)
+	info := JobInfo{Name: name, BaseName: job, Caller: strings.Join(callers, jobPathSeparator)}
+
+	matches := matrixSuffixRe.FindStringSubmatch(job)
+	if len(matches) != 3 || strings.TrimSpace(matches[2]) == "" {
+		return info
+	}
+
+	info.BaseName = matches[1]
+	for _, value := range strings.Split(matches[2], ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		info.Matrix = append(info.Matrix, value)
+		switch {
+		case info.OS == "" && matrixOSRe.MatchString(value):
+			info.OS = value
+		case info.Version == "" && matrixVerRe.MatchString(value):
+			info.Version = value
+		}
+	}
+
+	return info
+}
+
+// maxResponseTokens is the completion token limit of an analysis
+const maxResponseTokens = 8000
+
+// AnalyzeFailure uses OpenAI to analyze the workflow failure
+func (d *GitHubWorkflowDebugger) AnalyzeFailure(ctx context.Context, run *WorkflowRun) (*FixProposal, error) {
+	logDebugf("Building analysis prompt...")
+
+	// Build analysis prompt
+	build := d.startSpan(SpanBuildPrompt, spanKindInternal)
+	prompt, err := d.fitPrompt(ctx, run)
+	build.fail(err)
+	build.set("prompt.chars", len(prompt))
+	build.finish()
+	if err != nil {
+		return nil, err
+	}
+	var anon *anonymizer
+	if d.Options.Anonymize && run.Repository != "" {
+		anon = newAnonymizer(run.Repository)
+		prompt = anon.anonymize(prompt)
+		logDebugf("Anonymized the prompt: %s", anon)
+	}
+
+	promptTokens := estimateTokens(prompt)
+	logDebugf("Prompt size: %d characters, estimated %d tokens", len(prompt), promptTokens)
+	logInfof("Using AI model: %s", d.model)
+
+	messages := []openai.ChatCompletionMessage{
+		{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: d.systemPrompt(),
+		},
+		{
+			Role:    openai.ChatMessageRoleUser,
+			Content: prompt,
+		},
+	}
+	req := d.chatRequest(messages, d.responseTokens())
+	// Several samples are requested as choices of one completion, so the prompt is paid once
+	samples := max(d.Options.Samples, 1)
+	if samples > 1 && d.singleChoiceModel() {
+		logWarnf("%s answers with a single choice, --samples is ignored", d.model)
+		samples = 1
+	}
+	if samples > 1 {
+		req.N = samples
+		logInfof("Sampling %d analyses", samples)
+	}
+
+	// Identical requests are replayed from the cache without calling the API
+	var cache *responseCache
+	cacheKey := ""
+	if d.Options.CacheResponses {
+		var err error
+		if cache, err = newResponseCache(d.Options.CacheTTL); err != nil {
+			logWarnf("response cache disabled: %v", err)
+		} else {
+			cacheKey = responseCacheKey(req, d.reasoningEffort())
+		}
+	}
+
+	var resp openai.ChatCompletionResponse
+	cached := false
+	if cache != nil {
+		if hit, ok := cache.get(cacheKey); ok {
+			logInfof("Using cached AI response (%s)", cacheKey[:12])
+			resp, cached = *hit, true
+			d.rootSpan().set("cache_hit", true)
+		}
+	}
+
+	if !cached {
+		if err := d.checkCost(promptTokens+estimateTokens(d.systemPrompt()), d.responseTokens()*samples); err != nil {
+			return nil, err
+		}
+
+		// Call OpenAI API
+		logDebugf("Calling OpenAI API...")
+		progress := startSpinner("Waiting for AI response", d.progressEnabled())
+		call := d.startSpan(SpanAPICall, spanKindClient)
+		call.set("gen_ai.request.model", d.model)
+		var err error
+		resp, err = d.openaiClient.CreateChatCompletion(d.requestContext(ctx), req)
+		progress.Stop()
+		call.fail(err)
+		call.set("gen_ai.usage.input_tokens", resp.Usage.PromptTokens)
+		call.set("gen_ai.usage.output_tokens", resp.Usage.CompletionTokens)
+		call.finish()
+
+		if err != nil {
+			logErrorf("OpenAI API call failed: %v", err)
+			return nil, fmt.Errorf("%w: %w", ErrAPICall, err)
+		}
+		if cache != nil && len(resp.Choices) > 0 {
+			if err := cache.put(cacheKey, resp); err != nil {
+				logWarnf("%v", err)
+			}
+		}
+	}
+
+	if len(resp.Choices) == 0 {
+		logErrorf("No response from OpenAI API")
+		return nil, fmt.Errorf("no response from OpenAI API")
+	}
+
+	if reasoning := reasoningTokens(resp.Usage); reasoning > 0 {
+		logInfof("API usage - Prompt tokens: %d, Completion tokens: %d (reasoning: %d), Total: %d",
+			resp.Usage.PromptTokens,
+			resp.Usage.CompletionTokens,
+			reasoning,
+			resp.Usage.TotalTokens)
+	} else {
+		logInfof("API usage - Prompt tokens: %d, Completion tokens: %d, Total: %d",
+			resp.Usage.PromptTokens,
+			resp.Usage.CompletionTokens,
+			resp.Usage.TotalTokens)
+	}
+
+	// Parse the responses into structured fix proposals, keeping the most consistent one
+	logDebugf("Parsing fix proposal from AI response...")
+	proposals := make([]*FixProposal, len(resp.Choices))
+	for i, choice := range resp.Choices {
+		logDebugf("Received AI response (%d characters)", len(choice.Message.Content))
+		content := choice.Message.Content
+		if anon != nil {
+			content = anon.deanonymize(content)
+		}
+		proposals[i] = d.parseFixProposal(content, run)
+		proposals[i].RawResponse = content
+	}
+	chosen := 0
+	if len(proposals) > 1 {
+		var agreeing int
+		chosen, agreeing = selectConsensus(proposals)
+		logInfof("%d of %d sampled analyses agree on the root cause", agreeing, len(proposals))
+		proposals[chosen].Samples = len(proposals)
+		proposals[chosen].AgreeingSamples = agreeing
+	}
+	proposal := proposals[chosen]
+	proposal.Usage = resp.Usage
+	proposal.cached = cached
+	if cost, ok := d.actualCost(resp.Usage); ok && !cached {
+		proposal.Cost = cost
+		logInfof("API cost: $%.4f", cost)
+	}
+	proposal.messages = append(messages, resp.Choices[chosen].Message)
+
+	return proposal, nil
+}
+
+// estimateTokens estimates the number of tokens in a string
+// Conservative approximation: 1 token ~= 2.5 characters for code/logs
+// (English prose is ~4 chars/token, but logs/code are denser)
+func estimateTokens(text string) int {
+	return int(float64(len(text)) / 2.5)
+}
+
+// splitLogPrefix splits a `gh run view --log` line into its job, step and content parts
+// Lines have the form "<job>\t<step>\t<timestamp> <content>"; ok is false for other lines
+func splitLogPrefix(line string) (job, step, content string, ok bool) {
+	parts := strings.SplitN(line, "\t", 3)
+	if len(parts) != 3 {
+		return "", "", line, false
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// recentLogSegment narrows logs to the most recent failure segment according to the options
+// Earlier, recovered errors in long-running jobs otherwise compete with the fatal failure at the end
+func (d *GitHubWorkflowDebugger) recentLogSegment(logs string) string {
+	lines := strings.Split(logs, "\n")
+	start := 0
+
+	if d.Options.SinceLastStep {
+		// A step starts where the job/step prefix changes or where a "##[group]Run" marker appears
+		prevKey := ""
+		for i, line := range lines {
+			job, step, content, ok := splitLogPrefix(line)
+			if ok {
+				key := job + "\t" + step
+				if key != prevKey {
+					start = i
+					prevKey = key
+				}
+			}
+			if strings.Contains(content, "##[group]Run ") {
+				start = i
+			}
+		}
+		logDebugf("Limiting analysis to last step - keeping %d of %d lines", len(lines)-start, len(lines))
+	}
+
+	if d.Options.TailLines > 0 && len(lines)-start > d.Options.TailLines {
+		start = len(lines) - d.Options.TailLines
+		logDebugf("Limiting analysis to last %d lines", d.Options.TailLines)
+	}
+
+	if start == 0 {
+		return logs
+	}
+	return strings.Join(lines[start:], "\n")
+}
+
+// DefaultErrorContextLines is the number of lines kept before and after each error line of the logs
+const DefaultErrorContextLines = 3
+
+// contextSeparator separates error context windows that are not adjacent in the logs, as in grep -C
+const contextSeparator = "--\n"
+
+// errorContextLines returns the number of context lines around error lines (Options.ErrorContextLines)
+func (d *GitHubWorkflowDebugger) errorContextLines() int {
+	switch {
+	case d.Options.ErrorContextLines == 0:
+		return DefaultErrorContextLines
+	case d.Options.ErrorContextLines < 0:
+		return 0
+	}
+	return d.Options.ErrorContextLines
+}
+
+// filterRelevantLogs extracts the most relevant parts of logs
+// The returned budget tells how much of the logs was included (see --show-budget).
+func (d *GitHubWorkflowDebugger) filterRelevantLogs(logs string, maxChars int) (string, LogBudget) {
+	logDebugf("Filtering logs - input: %d chars, max: %d chars", len(logs), maxChars)
+	budget := LogBudget{BudgetChars: maxChars, TotalChars: len(logs), TotalLines: strings.Count(logs, "\n") + 1}
+
+	logs = d.recentLogSegment(logs)
+	lines := strings.Split(logs, "\n")
+	budget.OutsideSegment = budget.TotalLines - len(lines)
+
+	// Drop known noise listed in the ignore file
+	if d.Options.Ignore != nil {
+		var ignored int
+		lines, ignored = d.Options.Ignore.Filter(lines)
+		budget.IgnoredLines = ignored
+		if ignored > 0 {
+			logInfof("Ignored %d log lines matching ignore patterns", ignored)
+		}
+	}
+
+	// Priority keywords that indicate important information
+	errorKeywords := d.errorKeywords()
+
+	// Lines matching a keyword are relevant together with the lines around them, which show the
+	// command that ran and the details that follow; overlapping windows merge
+	promptLines := d.promptLines(lines)
+	contextLines := d.errorContextLines()
+	weights := d.severityWeights()
+	inWindow := make([]bool, len(promptLines))
+	severities := make(map[int]int) // severity of each line matching a keyword
+	for i, line := range promptLines {
+		if !matchesKeyword(line.text, errorKeywords) {
+			continue
+		}
+		severities[i] = lineSeverity(line.text, weights)
+		for j := max(0, i-contextLines); j <= min(len(promptLines)-1, i+contextLines); j++ {
+			inWindow[j] = true
+		}
+	}
+
+	var relevantLines []int // indexes into promptLines
+	var normalLines []promptLine
+
+	// Separate high-priority lines from normal lines
+	for i, line := range promptLines {
+		if inWindow[i] {
+			relevantLines = append(relevantLines, i)
+		} else {
+			normalLines = append(normalLines, line)
+		}
+	}
+
+	var result strings.Builder
+	currentSize := 0
+
+	// Add the relevant lines first: the most severe windows are selected when not all fit the
+	// budget, then written in log order, separating windows that are not adjacent in the logs
+	windows := errorWindows(relevantLines, severities)
+	selected := selectWindows(windows, maxChars, func(i int, first bool) int {
+		if first {
+			return promptLines[i].size("") + len(contextSeparator)
+		}
+		return promptLines[i].size(promptLines[i-1].group)
+	})
+	// Relevant lines that did not fit are replaced by a marker with their count
+	group := ""
+	last := -1
+	droppedErrors, droppedContext := 0, 0
+	writeDropped := func() {
+		if droppedErrors+droppedContext == 0 {
+			return
+		}
+		marker := droppedLinesMarker(droppedErrors, droppedContext) + "\n"
+		result.WriteString(marker)
+		currentSize += len(marker)
+		droppedErrors, droppedContext = 0, 0
+		group = ""
+	}
+	for _, i := range relevantLines {
+		if !selected[i] {
+			if _, isError := severities[i]; isError {
+				droppedErrors++
+			} else {
+				droppedContext++
+			}
+			continue
+		}
+		if droppedErrors+droppedContext > 0 {
+			writeDropped()
+		} else if last >= 0 && i > last+1 {
+			result.WriteString(contextSeparator)
+			currentSize += len(contextSeparator)
+		}
+		currentSize += promptLines[i].write(&result, &group)
+		budget.RelevantIncluded++
+		last = i
+	}
+	writeDropped()
+	budget.RelevantLines, budget.NormalLines = len(relevantLines), len(normalLines)
+	budget.ContextLines = len(relevantLines) - len(severities)
+	budget.IncludedChars = currentSize
+
+	logDebugf("Added %d of %d relevant/error lines in %d windows (%d chars)", budget.RelevantIncluded, len(relevantLines), len(windows), currentSize)
+
+	// Add context from end of logs (usually contains the actual failure)
+	remainingChars := maxChars - currentSize
+	if remainingChars > 0 && len(normalLines) > 0 {
+		// Take the last lines that fit
+		start, size := len(normalLines), 0
+		for start > 0 {
+			prevGroup := ""
+			if start > 1 {
+				prevGroup = normalLines[start-2].group
+			}
+			lineSize := normalLines[start-1].size(prevGroup)
+			if size+lineSize > remainingChars {
+				break
+			}
+			size += lineSize
+			start--
+		}
+		budget.NormalIncluded = len(normalLines) - start
+		budget.IncludedChars += size
+		if start > 0 {
+			budget.MiddleOmitted = true
+			result.WriteString("\n" + truncationMarker("middle section omitted: %d lines", start) + "\n\n")
+			logDebugf("Added %d chars from end of logs (%d of %d normal lines)", size, len(normalLines)-start, len(normalLines))
+		} else {
+			logDebugf("Added all %d normal lines (%d chars)", len(normalLines), size)
+		}
+		group = ""
+		for _, line := range normalLines[start:] {
+			line.write(&result, &group)
+		}
+	} else if len(normalLines) > 0 {
+		budget.MiddleOmitted = true
+		result.WriteString("\n" + truncationMarker("dropped %d other log lines", len(normalLines)) + "\n")
+	}
+
+	filteredResult := result.String()
+	logDebugf("Log filtering complete - output: %d chars (%.1f%% of input)",
+		len(filteredResult),
+		float64(len(filteredResult))/float64(len(logs))*100)
+
+	return filteredResult, budget
+}
+
+// buildAnalysisPrompt creates the prompt for the AI
+// maxLogChars is the size the prompt is filled up to with the workflow context and logs.
+func (d *GitHubWorkflowDebugger) buildAnalysisPrompt(run *WorkflowRun, maxLogChars int) string {
+	var sb strings.Builder
+
+	if run.Comparison != nil {
+		sb.WriteString("This GitHub Actions workflow passed in an earlier run and fails now. Explain the regression: which change between the two runs caused the failure.\n\n")
+	} else {
+		sb.WriteString("Analyze this GitHub Actions workflow failure and provide a comprehensive diagnosis.\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("## Workflow Information\n"))
+	sb.WriteString(fmt.Sprintf("- URL: %s\n", run.URL))
+	sb.WriteString(fmt.Sprintf("- Repository: %s\n", run.Repository))
+	sb.WriteString(fmt.Sprintf("- Run ID: %s\n", run.RunID))
+	sb.WriteString(fmt.Sprintf("- Status: %s\n", run.Status))
+	sb.WriteString(fmt.Sprintf("- Conclusion: %s\n\n", run.Conclusion))
+	writeEnvironment(&sb, run.Environment)
+
+	sb.WriteString("## Error Summary\n")
+	writeAnnotations(&sb, run.ErrorSummary.Annotations)
+	if run.ErrorSummary.FailedStep != "" {
+		sb.WriteString(fmt.Sprintf("Failed Step: %s (exit code %d)\n", run.ErrorSummary.FailedStep, run.ErrorSummary.FailedStepExitCode))
+	}
+	if t := run.ErrorSummary.TimedOutStep; t != nil {
+		sb.WriteString(fmt.Sprintf("TIMED-OUT STEP: %s\n", t))
+	}
+	if a := run.StepAnchor; a != nil && a.Text != "" {
+		sb.WriteString(fmt.Sprintf("Linked Log Line (the user pointed at line %d of step %q): %s\n", a.Line, run.StepName, truncateText(a.Text, 500)))
+	}
+	if len(run.ErrorSummary.FailedJobs) > 0 {
+		sb.WriteString(fmt.Sprintf("Failed Jobs (%d total):\n", len(run.ErrorSummary.FailedJobs)))
+		// Show only first 5 job names, not the full details
+		for i, job := range run.ErrorSummary.FailedJobs {
+			if i >= 5 {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(run.ErrorSummary.FailedJobs)-5))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  - %s\n", job))
+		}
+	}
+
+	writeJobClusters(&sb, run.JobClusters)
+	writeJobOutcomes(&sb, run)
+
+	if len(run.ReusableWorkflows) > 0 {
+		writeReusableWorkflows(&sb, run.ReusableWorkflows)
+	}
+
+	// Matrix dimensions help spot "only fails on windows/go1.20" patterns
+	var failingOS, failingVersions []string
+	seenDims := make(map[string]bool)
+	for _, job := range run.ErrorSummary.Jobs {
+		if job.OS != "" && !seenDims["os:"+job.OS] {
+			failingOS = append(failingOS, job.OS)
+			seenDims["os:"+job.OS] = true
+		}
+		if job.Version != "" && !seenDims["version:"+job.Version] {
+			failingVersions = append(failingVersions, job.Version)
+			seenDims["version:"+job.Version] = true
+		}
+	}
+	if len(failingOS) > 0 || len(failingVersions) > 0 {
+		sb.WriteString("Matrix dimensions of failed jobs:\n")
+		if len(failingOS) > 0 {
+			sb.WriteString(fmt.Sprintf("  - OS/runner: %s\n", strings.Join(failingOS, ", ")))
+		}
+		if len(failingVersions) > 0 {
+			sb.WriteString(fmt.Sprintf("  - Version: %s\n", strings.Join(failingVersions, ", ")))
+		}
+	}
+
+	// Skip detailed lists - just show counts
+	if len(run.ErrorSummary.ErrorMessages) < run.ErrorSummary.Totals[CategoryError] {
+		sb.WriteString(fmt.Sprintf("Error messages: %s\n", run.ErrorSummary.countLabel(CategoryError, len(run.ErrorSummary.ErrorMessages))))
+	}
+	if len(run.ErrorSummary.Timeouts) > 0 {
+		sb.WriteString(fmt.Sprintf("Timeout messages: %s\n", run.ErrorSummary.countLabel(CategoryTimeout, len(run.ErrorSummary.Timeouts))))
+	}
+	if len(run.ErrorSummary.FailedTests) > 0 {
+		sb.WriteString(fmt.Sprintf("Failed tests: %s\n", run.ErrorSummary.countLabel(CategoryFailedTest, len(run.ErrorSummary.FailedTests))))
+	}
+	writeDroppedFindings(&sb, run.ErrorSummary)
+	compilerErrors := run.ErrorSummary.CompilerErrors
+	writeCompilerErrors(&sb, compilerErrors, run.ErrorSummary.countLabel(CategoryCompilerError, len(compilerErrors)+collapsedErrors(compilerErrors)))
+	writeExceptions(&sb, run.ErrorSummary.Findings)
+	writeAssertions(&sb, run.ErrorSummary.Assertions)
+	writeBuildFailures(&sb, run.ErrorSummary.BuildFailures)
+	writeDataRaces(&sb, run.ErrorSummary.DataRaces)
+	writeResourceFailures(&sb, run.ErrorSummary.ResourceFailures)
+	writePermissionFailures(&sb, run.ErrorSummary.PermissionFailures, run.WorkflowPath)
+	writeLicenseViolations(&sb, run.ErrorSummary.LicenseViolations)
+	writeFlakinessHistory(&sb, run.TestHistory)
+	if len(run.ErrorSummary.ExitCodes) > 0 {
+		sb.WriteString(fmt.Sprintf("Exit Codes: %v\n", run.ErrorSummary.UniqueExitCodes()))
+	}
+
+	if run.Comparison != nil {
+		writeRegressionContext(&sb, run)
+	}
+	if len(run.SimilarFailures) > 0 {
+		writeSimilarFailures(&sb, run.SimilarFailures)
+	}
+
+	// Calculate how much space we have for logs
+	// OpenAI limit: 128k tokens total
+	// Reserve for response: 8k tokens (MaxTokens setting)
+	// Available for input: 120k tokens
+	// 1 token ~= 2.5 chars for logs/code (conservative)
+	//
+	// Token budget breakdown:
+	// - System message: ~50 tokens
+	// - Workflow info: ~50 tokens
+	// - Error summary: ~500 tokens
+	// - Task instructions: ~200 tokens
+	// - Log overhead (formatting): ~200 tokens
+	// - Total overhead: ~1000 tokens
+	//
+	// Safe budget for actual logs: 118k - 1k = 117k tokens
+	// 117k tokens * 2.5 chars/token = ~292k chars
+	// Be very conservative: use 30k chars (~12k tokens) to ensure we stay safe (defaultLogBudget);
+	// fitPrompt lowers the budget for models with smaller context windows
+
+	// The workflow definition counts against the same budget, so it is written before measuring
+	if run.WorkflowYAML != "" {
+		workflowYAML := truncateText(trimWorkflowYAML(run.WorkflowYAML), maxWorkflowChars)
+		sb.WriteString(fmt.Sprintf("\n## Workflow Definition (%s)\n", run.WorkflowPath))
+		sb.WriteString("```yaml\n")
+		sb.WriteString(workflowYAML)
+		sb.WriteString("\n```\n")
+	}
+
+	// Source files named by the user may take up to half of the remaining budget
+	if len(run.ContextFiles) > 0 {
+		filesBudget := (maxLogChars - sb.Len()) / 2
+		if filesBudget > maxContextFilesChars {
+			filesBudget = maxContextFilesChars
+		}
+		if filesBudget > 0 {
+			writeContextFiles(&sb, run.ContextFiles, filesBudget)
+		}
+	}
+
+	if run.PriorReport != nil {
+		writePriorReport(&sb, run.PriorReport)
+	}
+
+	// The diff may take up to a third of the remaining budget, the logs matter more
+	if run.CommitDiff != "" {
+		maxDiff := d.Options.MaxDiffChars
+		if maxDiff <= 0 {
+			maxDiff = DefaultMaxDiffChars
+		}
+		diffBudget := min((maxLogChars-sb.Len())/3, maxDiff)
+		if diffBudget > 0 {
+			writeCommitDiff(&sb, run, diffBudget)
+		}
+	}
+
+	// The failed step's output goes first and gets up to half of the remaining budget;
+	// the job logs below then only contain the other steps
+	var logBudget LogBudget
+	var numbered []string
+	jobLogs := run.FailedLogs
+	if step := failedStep(run.Steps); step != nil {
+		stepBudget := (maxLogChars - sb.Len()) / 2
+		sb.WriteString(fmt.Sprintf("\n## Failed Step Output: %s\n", step.stepLabel()))
+		sb.WriteString("```\n")
+		stepOutput, budget := d.filterRelevantLogs(strings.Join(step.Lines, "\n"), stepBudget)
+		if d.Options.Explain {
+			stepOutput, numbered = numberLogLines(stepOutput, numbered)
+		}
+		sb.WriteString(stepOutput)
+		sb.WriteString("\n```\n")
+		logBudget.add(budget)
+		jobLogs = otherStepsLogs(run.Steps, step)
+	}
+
+	currentPromptSize := sb.Len()
+	remainingChars := maxLogChars - currentPromptSize
+
+	sb.WriteString("\n## Failed Job Logs\n")
+	sb.WriteString("```\n")
+
+	filteredLogs, budget := d.filterRelevantLogs(jobLogs, remainingChars)
+	if d.Options.Explain {
+		filteredLogs, numbered = numberLogLines(filteredLogs, numbered)
+	}
+	sb.WriteString(filteredLogs)
+	logBudget.add(budget)
+	run.LogBudget = &logBudget
+	run.NumberedLines = numbered
+
+	sb.WriteString("\n```\n\n")
+
+	sb.WriteString("## Task\n")
+	sb.WriteString("Please analyze this workflow failure and provide:\n\n")
+	if run.Comparison != nil {
+		sb.WriteString("1. **Root Cause**: Which change since the passing run caused the failure? Name the commit and changed file when possible\n")
+	} else if t := run.ErrorSummary.TimedOutStep; t != nil {
+		sb.WriteString(fmt.Sprintf("1. **Root Cause**: What made step %q take more than %d minutes? Tell a hang (deadlock, waiting for input, "+
+			"an unreachable service) from legitimately slow work, using the silence in the output and the last lines before it\n", t.Step, t.Minutes()))
+	} else {
+		sb.WriteString("1. **Root Cause**: What is the fundamental issue causing the failure?\n")
+	}
+	sb.WriteString("2. **Detailed Analysis**: Explain what went wrong, including:\n")
+	sb.WriteString("   - Which component/test failed (the failed step, if identified, is shown first)\n")
+	sb.WriteString("   - Why it failed (timeout, assertion, error, etc.)\n")
+	sb.WriteString("   - Any relevant context from the logs\n")
+	sb.WriteString("3. **Proposed Fix**: Specific, actionable steps to resolve the issue\n")
+	sb.WriteString("4. **Files to Check**: Which files should be examined or modified\n")
+	sb.WriteString("5. **Code Changes**: If applicable, suggest specific code modifications (including workflow YAML edits) as unified diffs in ```diff blocks with ---/+++ file headers\n")
+	sb.WriteString(fmt.Sprintf("6. **Confidence Level**: Rate your confidence in this diagnosis from 1 to 100 (High is %d and above, Medium %d and above, "+
+		"Low below), then give a one-sentence justification, e.g. \"80 (High) - the error names the missing module\"\n", HighConfidenceScore, MediumConfidenceScore))
+	sb.WriteString(fmt.Sprintf("7. **TL;DR**: One plain sentence of at most %d characters stating the failure and the fix\n\n", MaxSummaryChars))
+	sb.WriteString("Format your response with clear markdown sections using the headers above.\n")
+	if d.Options.DiffOnly && run.Comparison != nil {
+		sb.WriteString("Focus on the regression: base the fix on the changed files and the errors not present in the passing run, and keep it as small as possible.\n")
+	}
+	if strings.Contains(sb.String(), "...[") {
+		sb.WriteString("Markers like ...[dropped N more error lines]... show where content was left out of this prompt: your view of the logs is partial, so say when the cause may lie in what was left out.\n")
+	}
+	if d.Options.Explain {
+		sb.WriteString("The log lines are numbered [L1], [L2], ...: cite the lines your Root Cause and Detailed Analysis rely on by their numbers, e.g. [L12] or [L12, L15].\n")
+	}
+
+	return sb.String()
+}
+
+// parseFixProposal extracts structured information from AI response
+func (d *GitHubWorkflowDebugger) parseFixProposal(response string, run *WorkflowRun) *FixProposal {
+	proposal := &FixProposal{}
+
+	// Extract sections, tolerating header variations (see sections.go)
+	sections := splitSections(response)
+	proposal.RootCause = sections[sectionRootCause]
+	proposal.Analysis = sections[sectionAnalysis]
+	proposal.ProposedFix = sections[sectionFix]
+	proposal.CodeChanges = parseCodeChanges(sections[sectionCodeChanges])
+
+	if filesText := sections[sectionFiles]; filesText != "" {
+		// Extract file paths (list items starting with -, * or a number)
+		for _, line := range strings.Split(filesText, "\n") {
+			line = strings.TrimSpace(line)
+			file := ""
+			if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") {
+				file = strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*")
+			} else if m := numberedPrefixRe.FindString(line); m != "" {
+				file = line[len(m):]
+			}
+			file = strings.TrimSpace(file)
+			if file != "" {
+				proposal.FilesToCheck = append(proposal.FilesToCheck, file)
+			}
+		}
+	}
+
+	proposal.FilesToCheck = normalizeFilesToCheck(proposal.FilesToCheck)
+	if d.Options.VerifyFiles {
+		proposal.MissingFiles = missingFiles(proposal.FilesToCheck)
+		if len(proposal.MissingFiles) > 0 {
+			logWarnf("%d files to check do not exist in the local checkout: %s",
+				len(proposal.MissingFiles), strings.Join(proposal.MissingFiles, ", "))
+		}
+	}
+
+	proposal.setConfidence(sections[sectionConfidence])
+
+	// Never leave the report empty when the response has no recognizable sections
+	if proposal.RootCause == "" && proposal.Analysis == "" && proposal.ProposedFix == "" {
+		logWarnf("AI response has no recognizable sections, reporting it as the analysis")
+		proposal.Analysis = strings.TrimSpace(response)
+	}
+	proposal.Summary = oneLineSummary(sections[sectionSummary], proposal)
+
+	proposal.addLocatedFiles(run)
+	if len(run.NumberedLines) > 0 {
+		proposal.Evidence = citedEvidence(proposal.RootCause+"\n"+proposal.Analysis, run.NumberedLines)
+		logDebugf("The analysis cites %d log lines", len(proposal.Evidence))
+	}
+
+	return proposal
+}
+
+// addLocatedFiles adds the files pinpointed by annotations and compiler errors to FilesToCheck,
+// and the workflow file when permission failures point at its permissions,
+// they are always worth checking
+func (p *FixProposal) addLocatedFiles(run *WorkflowRun) {
+	for _, a := range run.ErrorSummary.Annotations {
+		if a.hasLocation() {
+			p.addFileToCheck(a.Path, a.StartLine)
+		}
+	}
+	for _, e := range run.ErrorSummary.CompilerErrors {
+		if e.File != "" {
+			p.addFileToCheck(e.File, e.Line)
+		}
+	}
+	if len(run.ErrorSummary.PermissionFailures) > 0 && run.WorkflowPath != "" {
+		p.addFileToCheck(run.WorkflowPath, 0)
+	}
+}
+
+// addFileToCheck appends path:line (path alone for line 0) to FilesToCheck unless the file is already listed
+// Files are compared by their workspace-relative path, a listing without a line gets the line.
+func (p *FixProposal) addFileToCheck(path string, line int) {
+	path = workspacePath(path)
+	entry := path
+	if line > 0 {
+		entry = fmt.Sprintf("%s:%d", path, line)
+	}
+	for i, existing := range p.FilesToCheck {
+		file, _, hasLine := strings.Cut(existing, ":")
+		if file != path {
+			continue
+		}
+		if !hasLine && line > 0 {
+			p.FilesToCheck[i] = entry
+		}
+		return
+	}
+	p.FilesToCheck = append(p.FilesToCheck, entry)
+}
+
+// GenerateReport creates a formatted report of the analysis
+// The report is rendered with the default Markdown template or Options.ReportTemplate (see report.go).
+func (d *GitHubWorkflowDebugger) GenerateReport(run *WorkflowRun, proposal *FixProposal) string {
+	return d.renderTemplate(ReportData{Run: run, Proposal: proposal, Model: d.proposalModel(proposal), GeneratedAt: time.Now()})
+}
+
+// proposalModel returns the model that produced the proposal
+func (d *GitHubWorkflowDebugger) proposalModel(proposal *FixProposal) string {
+	if proposal.Model != "" {
+		return proposal.Model
+	}
+	return d.model
+}
+
+// Debug is the main entry point for the agent
+// It returns the formatted report; use DebugWorkflow for the structured results.
+func (d *GitHubWorkflowDebugger) Debug(ctx context.Context, workflowURL string) (string, error) {
+	result, err := d.DebugWorkflow(ctx, workflowURL)
+	if err != nil {
+		return "", err
+	}
+	return result.Report, nil
+}
+
+// DebugWorkflow fetches, analyzes and reports on a workflow run given by its URL
+func (d *GitHubWorkflowDebugger) DebugWorkflow(ctx context.Context, workflowURL string) (*Result, error) {
+	ref, err := ParseRunRef(workflowURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workflow data: %w", err)
+	}
+	return d.DebugRun(ctx, ref)
+}
+
+// DebugRun fetches, analyzes and reports on the referenced workflow run
+func (d *GitHubWorkflowDebugger) DebugRun(ctx context.Context, ref RunRef) (*Result, error) {
+	logInfof("=== GitHub Workflow Debugger Started ===")
+	logDebugf("Workflow URL: %s", ref.WebURL())
+
+	root := d.startTrace(ref.Repository, ref.RunID)
+	defer d.endTrace(ctx)
+
+	d.statusf("Fetching workflow data...\n")
+	started := time.Now()
+	fetch := d.startSpan(SpanFetch, spanKindClient)
+	run, err := d.FetchRun(ref)
+	fetch.fail(err)
+	fetch.finish()
+	if err != nil {
+		err = fmt.Errorf("failed to fetch workflow data: %w", err)
+		root.fail(err)
+		return nil, err
+	}
+	result, err := d.debugRun(ctx, run, started)
+	if !errors.Is(err, ErrNothingToAnalyze) {
+		root.fail(err)
+	}
+	return result, err
+}
+
+// DebugLogsZip analyzes the logs zip of a run downloaded from the Actions UI, without fetching
+// anything (see LoadLogsZip)
+func (d *GitHubWorkflowDebugger) DebugLogsZip(ctx context.Context, path, repo string) (*Result, error) {
+	logInfof("=== GitHub Workflow Debugger Started ===")
+	logDebugf("Logs zip: %s", path)
+
+	root := d.startTrace(repo, "")
+	defer d.endTrace(ctx)
+
+	d.statusf("Reading logs zip...\n")
+	started := time.Now()
+	fetch := d.startSpan(SpanFetch, spanKindInternal)
+	fetch.set("logs_zip", path)
+	run, err := d.LoadLogsZip(path, repo)
+	fetch.fail(err)
+	fetch.finish()
+	if err != nil {
+		root.fail(err)
+		return nil, err
+	}
+	result, err := d.debugRun(ctx, run, started)
+	if !errors.Is(err, ErrNothingToAnalyze) {
+		root.fail(err)
+	}
+	return result, err
+}
+
+// debugRun analyzes and reports on a run whose data was fetched at started
+func (d *GitHubWorkflowDebugger) debugRun(ctx context.Context, run *WorkflowRun, started time.Time) (*Result, error) {
+	var err error
+	metrics := &Metrics{
+		Repository:    run.Repository,
+		Workflow:      run.WorkflowName,
+		FetchDuration: time.Since(started),
+		Timestamp:     started,
+	}
+
+	d.statusf("Workflow Status: %s (%s)\n", run.Status, run.Conclusion)
+	logDebugf("Workflow data fetched successfully")
+
+	if !analyzableConclusions[run.Conclusion] {
+		if !d.Options.Force {
+			d.statusf("Run conclusion is %q, not a failure - nothing to analyze (use --force to analyze anyway)\n", run.Conclusion)
+			return nil, fmt.Errorf("%w: run conclusion is %q", ErrNothingToAnalyze, run.Conclusion)
+		}
+		logInfof("Run conclusion is %q, analyzing anyway (--force)", run.Conclusion)
+	}
+
+	if d.Options.CompareURL != "" && run.Provider != ProviderGitLab {
+		d.statusf("Comparing with passing run...\n")
+		comparison, err := d.compareRuns(run, d.Options.CompareURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare runs: %w", err)
+		}
+		run.Comparison = comparison
+	}
+
+	if strings.TrimSpace(run.FailedLogs) == "" {
+		d.statusf("No failed job logs found, looking for other sources...\n")
+		if !d.gatherFallbackLogs(run) {
+			logWarnf("no logs could be retrieved for run %s, skipping AI analysis", run.RunID)
+			proposal := noLogsProposal(run)
+			report := d.GenerateReport(run, proposal)
+			metrics.Success = true
+			return &Result{Run: run, Proposal: proposal, Report: report, Metrics: metrics}, nil
+		}
+	}
+
+	if len(d.Options.ContextFiles) > 0 {
+		files, err := loadContextFiles(d.Options.ContextFiles)
+		if err != nil {
+			return nil, err
+		}
+		run.ContextFiles = files
+	}
+	if d.Options.PriorReport != "" {
+		prior, err := loadPriorReport(d.Options.PriorReport)
+		if err != nil {
+			return nil, err
+		}
+		run.PriorReport = prior
+	}
+
+	// The history is optional context, the analysis goes on without it
+	var embedding []float32
+	if d.Options.UseHistory && !d.Options.Offline {
+		d.statusf("Searching failure history...\n")
+		var err error
+		if embedding, run.SimilarFailures, err = d.findSimilarFailures(ctx, run); err != nil {
+			logWarnf("failure history not used: %v", err)
+		}
+	}
+
+	analysisStarted := time.Now()
+	var proposal *FixProposal
+	if d.Options.Offline {
+		d.statusf("Analyzing failure with heuristic rules (offline)...\n")
+		proposal = heuristicProposal(run)
+	} else {
+		analyzer := d
+		if d.Options.Triage {
+			if c := d.clearInfraFailure(run); c != nil && d.Options.TriageModel == "" {
+				d.statusf("Pre-classified as a clear infrastructure failure: %s, skipping the AI analysis\n", c)
+				proposal = triageProposal(run, c)
+			} else if c != nil {
+				d.statusf("Pre-classified as a clear infrastructure failure: %s, analyzing with %s\n", c, d.Options.TriageModel)
+				cheaper := *d
+				cheaper.model = d.Options.TriageModel
+				analyzer = &cheaper
+			}
+		}
+		if proposal == nil {
+			d.statusf("Analyzing failure with AI...\n")
+			if proposal, err = analyzer.AnalyzeFailure(ctx, run); err != nil {
+				return nil, fmt.Errorf("failed to analyze failure: %w", err)
+			}
+			if analyzer != d {
+				proposal.Model = analyzer.model
+			}
+		}
+	}
+	metrics.AnalysisDuration = time.Since(analysisStarted)
+	if embedding != nil {
+		if err := d.recordFailure(run, proposal, embedding); err != nil {
+			logWarnf("failed to record the failure in the history: %v", err)
+		}
+	}
+	runEnrichers(run, proposal)
+	metrics.PromptTokens = proposal.Usage.PromptTokens
+	metrics.CompletionTokens = proposal.Usage.CompletionTokens
+	metrics.Cost = proposal.Cost
+	metrics.Confidence = proposal.Confidence
+	metrics.ConfidenceScore = proposal.ConfidenceScore
+	metrics.Success = true
+
+	root := d.rootSpan()
+	root.set("heuristic", proposal.Heuristic)
+	if !proposal.Heuristic {
+		root.set("model", d.proposalModel(proposal))
+		root.set("gen_ai.usage.input_tokens", proposal.Usage.PromptTokens)
+		root.set("gen_ai.usage.output_tokens", proposal.Usage.CompletionTokens)
+		root.set("cost_usd", proposal.Cost)
+	}
+
+	if !proposal.Heuristic {
+		logInfof("AI analysis completed successfully")
+	}
+	if level := confidenceLevel(proposal.Confidence); level != "" {
+		d.statusf("Analysis complete, confidence: %s\n", colorize(d.statusOutput(), colorBold+confidenceStyle(level), level))
+	}
+	logDebugf("Generating final report...")
+
+	report := d.GenerateReport(run, proposal)
+
+	// The pull request gets the report as its body, the final report then links the pull request
+	if d.Options.FixBranch && run.Provider != ProviderGitLab {
+		if proposal.FixPR, err = d.CreateFixBranch(run, proposal, report); err != nil {
+			logWarnf("fix pull request not opened: %v", err)
+		} else if proposal.FixPR != "" {
+			d.statusf("Opened fix pull request: %s\n", proposal.FixPR)
+			report = d.GenerateReport(run, proposal)
+		}
+	}
+
+	logDebugf("Report generated (%d characters)", len(report))
+
+	if d.Options.BundleDir != "" {
+		if bundle, err := WriteBundle(d.Options.BundleDir, run, proposal, report, time.Now()); err != nil {
+			logWarnf("%v", err)
+		} else {
+			d.statusf("Analysis bundle saved to %s\n", bundle)
+		}
+	}
+
+	if d.Options.UsageLedger != "" && !proposal.Heuristic && !proposal.cached {
+		if err := AppendUsage(d.Options.UsageLedger, d.newUsageEntry(run, proposal)); err != nil {
+			logWarnf("%v", err)
+		}
+	}
+
+	if d.Options.AuditLog != "" {
+		if err := AppendAuditRecord(d.Options.AuditLog, d.newAuditRecord(run, proposal, report)); err != nil {
+			logWarnf("%v", err)
+		}
+	}
+
+	if d.Options.CreateIssue && run.Provider != ProviderGitLab {
+		if err := d.ReportIssue(run, proposal, report); err != nil {
+			logWarnf("failed to create GitHub issue: %v", err)
+		}
+	}
+	logInfof("=== GitHub Workflow Debugger Completed Successfully ===")
+
+	return &Result{Run: run, Proposal: proposal, Report: report, Metrics: metrics}, nil
+}
+
+// unsafeFilenameRe matches the characters replaced in filenames derived from repository names
+var unsafeFilenameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// defaultReportName returns the default report filename of a run
+// The repository and run (and job) ID keep the names of runs analyzed within the same second apart;
+// the timestamp comes last so reports of the same run sort chronologically.
+func defaultReportName(ref RunRef, ext string, now time.Time) string {
+	return reportBaseName(ref, now) + "." + ext
+}
+
+// filenameSlug makes s safe to use in a filename
+func filenameSlug(s string) string {
+	return strings.Trim(unsafeFilenameRe.ReplaceAllString(s, "_"), "._-")
+}
+
+// reportBaseName returns the default report filename of a run without its extension
+// Logs analyzed from a local archive (--logs-zip) have no run ID; the archive's name takes its place.
+// The timestamp has milliseconds, and createUnique handles analyses of a run within the same millisecond.
+func reportBaseName(ref RunRef, now time.Time) string {
+	name := "workflow-debug"
+	if slug := filenameSlug(strings.ReplaceAll(ref.Repository, "/", "-")); slug != "" {
+		name += "-" + slug
+	}
+	switch {
+	case ref.RunID != "":
+		name += "-" + ref.RunID
+	case ref.URL != "" && !strings.Contains(ref.URL, "://"):
+		if slug := filenameSlug(strings.TrimSuffix(filepath.Base(ref.URL), filepath.Ext(ref.URL))); slug != "" {
+			name += "-" + slug
+		}
+	}
+	if ref.JobID != "" {
+		name += "-" + ref.JobID
+	}
+	return fmt.Sprintf("%s-%s-%03d", name, now.Format("20060102-150405"), now.Nanosecond()/int(time.Millisecond))
+}
+
+// maxUniqueAttempts bounds the numbered names createUnique tries
+const maxUniqueAttempts = 100
+
+// createUnique calls create with path, or with "-2", "-3", ... inserted before the extension while
+// create fails with os.ErrExist, and returns the path created
+func createUnique(path string, create func(path string) error) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 2; ; i++ {
+		err := create(candidate)
+		if !errors.Is(err, os.ErrExist) {
+			return candidate, err
+		}
+		if i > maxUniqueAttempts {
+			return "", fmt.Errorf("%s and %d numbered variants already exist", path, maxUniqueAttempts)
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// ReportPath resolves where the report is saved
+// output overrides the default filename (with extension ext); relative paths are placed in outputDir.
+func ReportPath(output, outputDir, ext string, ref RunRef, now time.Time) string {
+	if output == "" {
+		output = defaultReportName(ref, ext, now)
+	}
+	if outputDir != "" && !filepath.IsAbs(output) {
+		output = filepath.Join(outputDir, output)
+	}
+	return output
+}
+
+// SaveReport writes the report to path, creating the parent directory if missing
+func SaveReport(path, report string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return os.WriteFile(path, []byte(report), 0644)
+}
+
+// SaveNewReport writes the report to a new file at path, never overwriting an existing file: a numbered
+// name ("-2", "-3", ...) is used instead. It returns the path written, e.g. for default report names
+// of a run analyzed twice at once.
+func SaveNewReport(path, report string) (string, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	return createUnique(path, func(path string) error {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.WriteString(report); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// RenderReport returns the report of result in format ("markdown", "sarif", or "github" for the
+// workflow commands of WorkflowCommands)
+func (d *GitHubWorkflowDebugger) RenderReport(result *Result, format string) (string, error) {
+	if format == "github" {
+		commands := WorkflowCommands(result.Run, result.Proposal)
+		if len(commands) == 0 {
+			return "", nil
+		}
+		return strings.Join(commands, "\n") + "\n", nil
+	}
+	if format != "sarif" {
+		return result.Report, nil
+	}
+	sarif, err := d.GenerateSARIF(result.Run, result.Proposal)
+	if err != nil {
+		return "", err
+	}
+	return string(sarif), nil
+}
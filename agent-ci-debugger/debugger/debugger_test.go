@@ -0,0 +1,395 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// segmentLogs is a job log with a recovered error in an early step and the fatal one in the last step
+const segmentLogs = "build\tSet up job\t2024-01-01T00:00:00.0000000Z Preparing runner\n" +
+	"build\tRun tests\t2024-01-01T00:00:01.0000000Z ##[group]Run go test ./...\n" +
+	"build\tRun tests\t2024-01-01T00:00:02.0000000Z error: flaky download, retrying\n" +
+	"build\tRun tests\t2024-01-01T00:00:03.0000000Z ok  example.com/pkg\n" +
+	"build\tRun lint\t2024-01-01T00:00:04.0000000Z ##[group]Run golangci-lint run\n" +
+	"build\tRun lint\t2024-01-01T00:00:05.0000000Z main.go:3:1: error: unused variable\n" +
+	"build\tRun lint\t2024-01-01T00:00:06.0000000Z ##[error]Process completed with exit code 1."
+
+func TestRecentLogSegment(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    []string // lines of the segment
+	}{
+		{
+			name: "whole log",
+			want: strings.Split(segmentLogs, "\n"),
+		},
+		{
+			name:    "tail lines",
+			options: Options{TailLines: 2},
+			want:    strings.Split(segmentLogs, "\n")[5:],
+		},
+		{
+			name:    "tail longer than the log",
+			options: Options{TailLines: 100},
+			want:    strings.Split(segmentLogs, "\n"),
+		},
+		{
+			name:    "since last step",
+			options: Options{SinceLastStep: true},
+			want:    strings.Split(segmentLogs, "\n")[4:],
+		},
+		{
+			name:    "tail within the last step",
+			options: Options{SinceLastStep: true, TailLines: 1},
+			want:    strings.Split(segmentLogs, "\n")[6:],
+		},
+		{
+			name:    "tail longer than the last step",
+			options: Options{SinceLastStep: true, TailLines: 5},
+			want:    strings.Split(segmentLogs, "\n")[4:],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{Options: tt.options}
+			got := d.recentLogSegment(segmentLogs)
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("recentLogSegment() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestRecentLogSegmentGroupMarker(t *testing.T) {
+	// Logs without job/step prefixes, e.g. from a downloaded archive, are split at "##[group]Run" markers
+	logs := "##[group]Run make deps\nerror: old failure\n##[group]Run make test\nFAIL: TestX"
+	d := &GitHubWorkflowDebugger{Options: Options{SinceLastStep: true}}
+	if got, want := d.recentLogSegment(logs), "##[group]Run make test\nFAIL: TestX"; got != want {
+		t.Errorf("recentLogSegment() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterRelevantLogsSegment(t *testing.T) {
+	tests := []struct {
+		name           string
+		options        Options
+		wantOutside    int
+		wantRecovered  bool // the earlier, recovered error is in the prompt
+		wantFatalError bool
+	}{
+		{name: "whole log", wantRecovered: true, wantFatalError: true},
+		{name: "tail lines", options: Options{TailLines: 3}, wantOutside: 4, wantFatalError: true},
+		{name: "since last step", options: Options{SinceLastStep: true}, wantOutside: 4, wantFatalError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{Options: tt.options}
+			got, budget := d.filterRelevantLogs(segmentLogs, 10000)
+			if budget.OutsideSegment != tt.wantOutside {
+				t.Errorf("OutsideSegment = %d, want %d", budget.OutsideSegment, tt.wantOutside)
+			}
+			if budget.TotalLines != 7 {
+				t.Errorf("TotalLines = %d, want 7", budget.TotalLines)
+			}
+			if recovered := strings.Contains(got, "flaky download"); recovered != tt.wantRecovered {
+				t.Errorf("recovered error included = %v, want %v:\n%s", recovered, tt.wantRecovered, got)
+			}
+			if fatal := strings.Contains(got, "unused variable"); fatal != tt.wantFatalError {
+				t.Errorf("fatal error included = %v, want %v:\n%s", fatal, tt.wantFatalError, got)
+			}
+		})
+	}
+}
+
+// contextLogs are 40 numbered lines with error lines at the given indexes
+func contextLogs(errorLines ...int) string {
+	lines := make([]string, 40)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("step %d", i)
+	}
+	for _, i := range errorLines {
+		lines[i] = fmt.Sprintf("Error: failure at %d", i)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestFilterRelevantLogsErrorContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		logs         string
+		contextLines int
+		want         string // the relevant section the prompt starts with
+		wantContext  int
+	}{
+		{
+			name: "default",
+			logs: contextLogs(10, 30),
+			want: "step 7\nstep 8\nstep 9\nError: failure at 10\nstep 11\nstep 12\nstep 13\n--\n" +
+				"step 27\nstep 28\nstep 29\nError: failure at 30\nstep 31\nstep 32\nstep 33\n",
+			wantContext: 12,
+		},
+		{
+			name:         "one line",
+			logs:         contextLogs(10, 30),
+			contextLines: 1,
+			want:         "step 9\nError: failure at 10\nstep 11\n--\nstep 29\nError: failure at 30\nstep 31\n",
+			wantContext:  4,
+		},
+		{
+			name:         "overlapping windows merge",
+			logs:         contextLogs(10, 13),
+			contextLines: 2,
+			want:         "step 8\nstep 9\nError: failure at 10\nstep 11\nstep 12\nError: failure at 13\nstep 14\nstep 15\nstep 0\n",
+			wantContext:  6,
+		},
+		{
+			name:         "only error lines",
+			logs:         contextLogs(10, 30),
+			contextLines: -1,
+			want:         "Error: failure at 10\n--\nError: failure at 30\nstep 0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{Options: Options{ErrorContextLines: tt.contextLines}}
+			got, budget := d.filterRelevantLogs(tt.logs, 10000)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("filterRelevantLogs() =\n%s\nwant it to start with\n%s", got, tt.want)
+			}
+			if budget.ContextLines != tt.wantContext || budget.RelevantLines != tt.wantContext+2 {
+				t.Errorf("ContextLines = %d, RelevantLines = %d, want %d context lines around 2 errors", budget.ContextLines, budget.RelevantLines, tt.wantContext)
+			}
+		})
+	}
+}
+
+func TestParseJobName(t *testing.T) {
+	tests := []struct {
+		name string
+		want JobInfo
+	}{
+		{
+			name: "build",
+			want: JobInfo{Name: "build", BaseName: "build"},
+		},
+		{
+			name: "test (ubuntu-latest, 1.21)",
+			want: JobInfo{Name: "test (ubuntu-latest, 1.21)", BaseName: "test", Matrix: []string{"ubuntu-latest", "1.21"}, OS: "ubuntu-latest", Version: "1.21"},
+		},
+		{
+			name: "unit-tests (macos-14, node-20.x)",
+			want: JobInfo{Name: "unit-tests (macos-14, node-20.x)", BaseName: "unit-tests", Matrix: []string{"macos-14", "node-20.x"}, OS: "macos-14", Version: "node-20.x"},
+		},
+		{
+			name: "e2e (chrome, 3)",
+			want: JobInfo{Name: "e2e (chrome, 3)", BaseName: "e2e", Matrix: []string{"chrome", "3"}, Version: "3"},
+		},
+		{
+			name: "lint (windows-2022)",
+			want: JobInfo{Name: "lint (windows-2022)", BaseName: "lint", Matrix: []string{"windows-2022"}, OS: "windows-2022"},
+		},
+		{
+			name: "caller / job (windows, 1.20)",
+			want: JobInfo{Name: "caller / job (windows, 1.20)", BaseName: "job", Caller: "caller", Matrix: []string{"windows", "1.20"}, OS: "windows", Version: "1.20"},
+		},
+		{
+			name: "ci / build / test (ubuntu-22.04, python 3.12)",
+			want: JobInfo{Name: "ci / build / test (ubuntu-22.04, python 3.12)", BaseName: "test", Caller: "ci / build", Matrix: []string{"ubuntu-22.04", "python 3.12"}, OS: "ubuntu-22.04", Version: "python 3.12"},
+		},
+		{
+			name: "release ()",
+			want: JobInfo{Name: "release ()", BaseName: "release ()"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseJobName(tt.name); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJobName(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugRunConclusions(t *testing.T) {
+	tests := []struct {
+		conclusion string
+		analyzable bool
+	}{
+		{"failure", true},
+		{"timed_out", true},
+		{"cancelled", false},
+		{"skipped", false},
+		{"success", false},
+		{"neutral", false},
+		{"action_required", false},
+		{"startup_failure", false},
+		{"stale", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		for _, force := range []bool{false, true} {
+			name := tt.conclusion
+			if force {
+				name += " forced"
+			}
+			t.Run(name, func(t *testing.T) {
+				if analyzableConclusions[tt.conclusion] != tt.analyzable {
+					t.Fatalf("analyzableConclusions[%q] = %v, want %v", tt.conclusion, !tt.analyzable, tt.analyzable)
+				}
+				d := &GitHubWorkflowDebugger{Options: Options{Offline: true, Force: force, Quiet: true}}
+				run := &WorkflowRun{
+					RunID:      "1",
+					Status:     "completed",
+					Conclusion: tt.conclusion,
+					FailedLogs: "build\tRun tests\t2024-01-01T00:00:00.0000000Z ##[error]Process completed with exit code 1.",
+				}
+				result, err := d.debugRun(context.Background(), run, time.Now())
+				if tt.analyzable || force {
+					if err != nil {
+						t.Fatalf("debugRun() error = %v, want an analysis", err)
+					}
+					if result.Proposal == nil || result.Report == "" {
+						t.Errorf("debugRun() = %+v, want a proposal and a report", result)
+					}
+					return
+				}
+				if !errors.Is(err, ErrNothingToAnalyze) {
+					t.Fatalf("debugRun() error = %v, want ErrNothingToAnalyze", err)
+				}
+				if result != nil {
+					t.Errorf("debugRun() result = %+v, want nil", result)
+				}
+				if !strings.Contains(err.Error(), fmt.Sprintf("%q", tt.conclusion)) {
+					t.Errorf("error %q does not name the conclusion %q", err, tt.conclusion)
+				}
+			})
+		}
+	}
+}
+
+func TestReportBaseName(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6789000, time.UTC)
+	tests := []struct {
+		ref  RunRef
+		want string
+	}{
+		{RunRef{Repository: "konveyor/ci", RunID: "19353355807"}, "workflow-debug-konveyor-ci-19353355807-20240102-030405-006"},
+		{RunRef{Repository: "o/r", RunID: "1", JobID: "2"}, "workflow-debug-o-r-1-2-20240102-030405-006"},
+		// --logs-zip, with and without --repo: the archive tells the runs apart
+		{RunRef{URL: "/home/me/Downloads/logs_42.zip"}, "workflow-debug-logs_42-20240102-030405-006"},
+		{RunRef{Repository: "o/r", URL: "/tmp/logs (1).zip"}, "workflow-debug-o-r-logs_1-20240102-030405-006"},
+		{RunRef{}, "workflow-debug-20240102-030405-006"},
+	}
+	for _, tt := range tests {
+		if got := reportBaseName(tt.ref, now); got != tt.want {
+			t.Errorf("reportBaseName(%+v) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+
+	ref := RunRef{Repository: "o/r", RunID: "1"}
+	if reportBaseName(ref, now) == reportBaseName(ref, now.Add(time.Millisecond)) {
+		t.Error("analyses of a run within the same second have the same report name")
+	}
+}
+
+func TestSaveNewReportUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reports", defaultReportName(RunRef{Repository: "o/r", RunID: "1"}, "md", time.Now()))
+
+	// The same run analyzed several times at once, in the same millisecond
+	const saves = 10
+	paths := make(chan string, saves)
+	var wg sync.WaitGroup
+	for i := 0; i < saves; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			saved, err := SaveNewReport(path, fmt.Sprintf("report %d", i))
+			if err != nil {
+				t.Errorf("SaveNewReport() error = %v", err)
+			}
+			paths <- saved
+		}(i)
+	}
+	wg.Wait()
+	close(paths)
+
+	seen := make(map[string]bool)
+	contents := make(map[string]bool)
+	for saved := range paths {
+		if seen[saved] {
+			t.Errorf("two reports were saved to %s", saved)
+		}
+		seen[saved] = true
+		if filepath.Ext(saved) != ".md" {
+			t.Errorf("saved to %s, want the extension kept", saved)
+		}
+		data, err := os.ReadFile(saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[string(data)] = true
+	}
+	if !seen[path] || !seen[strings.TrimSuffix(path, ".md")+"-2.md"] {
+		t.Errorf("saved to %v, want %s and numbered variants", seen, path)
+	}
+	if len(contents) != saves {
+		t.Errorf("%d distinct reports on disk, want %d: a report was overwritten", len(contents), saves)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.md")
+	if err := os.WriteFile(path, []byte("earlier report"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var status strings.Builder
+	if err := (FileSink{Path: path, Status: &status, New: true}).Write(context.Background(), nil, nil, "new report"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	numbered := filepath.Join(dir, "report-2.md")
+	if data, _ := os.ReadFile(path); string(data) != "earlier report" {
+		t.Errorf("existing report overwritten with %q", data)
+	}
+	if data, _ := os.ReadFile(numbered); string(data) != "new report" {
+		t.Errorf("%s = %q, want the new report", numbered, data)
+	}
+	if !strings.Contains(status.String(), "Report saved to: "+numbered) {
+		t.Errorf("status = %q, want the numbered path", status.String())
+	}
+
+	// An explicit --output is overwritten
+	if err := (FileSink{Path: path}).Write(context.Background(), nil, nil, "replaced"); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "replaced" {
+		t.Errorf("report = %q, want it replaced", data)
+	}
+}
+
+func TestAddFileToCheck(t *testing.T) {
+	p := &FixProposal{FilesToCheck: []string{"pkg/data.go:12", "go.mod"}}
+	p.addFileToCheck("a.go", 3)                               // a suffix of pkg/data.go, but another file
+	p.addFileToCheck("data.go", 0)                            // not pkg/data.go either
+	p.addFileToCheck("/home/runner/work/r/r/pkg/data.go", 40) // listed already
+	p.addFileToCheck("./go.mod", 5)                           // listed without a line
+	p.addFileToCheck(".github/workflows/ci.yml", 0)           // new, without a line
+	p.addFileToCheck(".github/workflows/ci.yml", 0)           // listed already
+	p.addFileToCheck("D:\\a\\r\\r\\cmd\\main.go", 7)          // Windows runner path
+	p.addFileToCheck("/__w/r/r/cmd/main.go", 9)               // the same file in a container job
+
+	want := []string{"pkg/data.go:12", "go.mod:5", "a.go:3", "data.go", ".github/workflows/ci.yml", "cmd/main.go:7"}
+	if !reflect.DeepEqual(p.FilesToCheck, want) {
+		t.Errorf("FilesToCheck = %q, want %q", p.FilesToCheck, want)
+	}
+}
@@ -0,0 +1,250 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Finding categories map findings onto the ErrorSummary fields
+const (
+	CategoryError           = "error"
+	CategoryTimeout         = "timeout"
+	CategoryFailedTest      = "failed_test"
+	CategoryStackTrace      = "stack_trace"
+	CategoryExitCode        = "exit_code"
+	CategoryAssertion       = "assertion"
+	CategoryBuildFailure    = "build_failure"
+	CategoryResourceFailure = "resource_failure"
+	CategoryCompilerError   = "compiler_error"
+	CategoryPermission      = "permission_failure"
+	CategoryLicense         = "license_failure"
+)
+
+// DefaultMaxErrors is the number of findings kept per category; the rest are only counted
+const DefaultMaxErrors = 200
+
+// Finding is a single piece of failure information extracted from the logs
+type Finding struct {
+	Detector string // name of the detector that produced the finding
+	Category string // one of the Category* constants
+	Severity int    // severity of the finding's log line, see SeverityWeights
+	Message  string // the log line or block describing the finding
+	Line     int    // 0-based index of the (first) log line of the finding
+	ExitCode int    // process exit code, set for CategoryExitCode
+
+	Exception       string             // exception type and message, e.g. "ValueError: invalid literal"
+	Frames          []StackFrame       // stack frames, outermost first, set for CategoryStackTrace
+	Assertion       *Assertion         // expected/actual values, set for CategoryAssertion
+	BuildFailure    *BuildFailure      // failing Dockerfile instruction, set for CategoryBuildFailure
+	DataRace        *DataRace          // conflicting accesses, set for race detector reports (CategoryStackTrace)
+	ResourceFailure *ResourceFailure   // disk, filesystem or memory failure of the runner, set for CategoryResourceFailure
+	CompilerError   *CompilerError     // compiler diagnostic with its location, set for CategoryCompilerError
+	Permission      *PermissionFailure // rejected operation of the workflow, set for CategoryPermission
+	License         *LicenseViolation  // dependency rejected by a license scan, set for CategoryLicense
+}
+
+// Detector extracts findings of a particular language or tool from log lines
+type Detector interface {
+	// Name identifies the detector in logs and findings
+	Name() string
+	// Detect returns the findings present in lines
+	Detect(lines []string) []Finding
+}
+
+// detectorRegistry holds the detectors run by parseErrorSummary, in registration order
+var detectorRegistry = []Detector{
+	genericDetector{},
+	goTestDetector{},
+	pythonDetector{},
+	assertionDetector{},
+	dockerDetector{},
+	goRaceDetector{},
+	diskDetector{},
+	rustDetector{},
+	permissionDetector{},
+	licenseDetector{},
+	typescriptDetector{},
+	goBuildDetector{},
+}
+
+// RegisterDetector adds a detector to the registry used for all subsequent parsing
+func RegisterDetector(detector Detector) {
+	detectorRegistry = append(detectorRegistry, detector)
+}
+
+// runDetectors runs all registered detectors over lines
+// Findings are returned in log order so each category keeps the order of the log.
+func runDetectors(lines []string) []Finding {
+	var findings []Finding
+	for _, detector := range detectorRegistry {
+		for _, finding := range detector.Detect(lines) {
+			finding.Detector = detector.Name()
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings
+}
+
+// addFinding merges a finding into the matching ErrorSummary field
+// Beyond maxPerCategory findings of a category (0 = no limit) the finding is only counted in Totals,
+// which bounds the memory and prompt size of pathological logs.
+func (s *ErrorSummary) addFinding(finding Finding, maxPerCategory int) {
+	if s.Totals == nil {
+		s.Totals = make(map[string]int)
+	}
+	s.Totals[finding.Category]++
+	if maxPerCategory > 0 && s.Totals[finding.Category] > maxPerCategory {
+		return
+	}
+	s.Findings = append(s.Findings, finding)
+
+	switch finding.Category {
+	case CategoryError:
+		s.ErrorMessages = append(s.ErrorMessages, finding.Message)
+	case CategoryTimeout:
+		s.Timeouts = append(s.Timeouts, finding.Message)
+	case CategoryFailedTest:
+		s.FailedTests = append(s.FailedTests, finding.Message)
+	case CategoryStackTrace:
+		s.StackTraces = append(s.StackTraces, finding.Message)
+		if finding.DataRace != nil {
+			s.DataRaces = append(s.DataRaces, *finding.DataRace)
+		}
+	case CategoryExitCode:
+		s.ExitCodes = append(s.ExitCodes, finding.ExitCode)
+	case CategoryAssertion:
+		if finding.Assertion != nil {
+			s.Assertions = append(s.Assertions, *finding.Assertion)
+		}
+	case CategoryBuildFailure:
+		if finding.BuildFailure != nil {
+			s.BuildFailures = append(s.BuildFailures, *finding.BuildFailure)
+		}
+	case CategoryResourceFailure:
+		if finding.ResourceFailure != nil {
+			s.ResourceFailures = append(s.ResourceFailures, *finding.ResourceFailure)
+		}
+	case CategoryCompilerError:
+		if finding.CompilerError != nil {
+			s.CompilerErrors = append(s.CompilerErrors, *finding.CompilerError)
+		}
+	case CategoryPermission:
+		if finding.Permission != nil {
+			s.PermissionFailures = append(s.PermissionFailures, *finding.Permission)
+		}
+	case CategoryLicense:
+		if finding.License != nil {
+			s.LicenseViolations = append(s.LicenseViolations, *finding.License)
+		}
+	}
+}
+
+// countLabel describes the number of findings of a category, e.g. "showing 200 of 54321"
+// when only the first shown of them were kept
+func (s ErrorSummary) countLabel(category string, shown int) string {
+	total := s.Totals[category]
+	if total <= shown {
+		return fmt.Sprint(shown)
+	}
+	return fmt.Sprintf("showing %d of %d", shown, total)
+}
+
+// writeDroppedFindings writes a truncation marker for every category with findings beyond
+// Options.MaxErrors, which the summaries of the prompt do not show
+func writeDroppedFindings(sb *strings.Builder, s ErrorSummary) {
+	kept := make(map[string]int)
+	for _, f := range s.Findings {
+		kept[f.Category]++
+	}
+	categories := make([]string, 0, len(s.Totals))
+	for category := range s.Totals {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		if dropped := s.Totals[category] - kept[category]; dropped > 0 {
+			sb.WriteString(truncationMarker("dropped %d more %s findings beyond the per-category limit",
+				dropped, strings.ReplaceAll(category, "_", " ")) + "\n")
+		}
+	}
+}
+
+// UniqueExitCodes returns the distinct exit codes in ascending order
+// The other ErrorSummary slices keep log order, so identical logs always give identical summaries.
+func (s ErrorSummary) UniqueExitCodes() []int {
+	seen := make(map[int]bool)
+	var codes []int
+	for _, code := range s.ExitCodes {
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// genericDetector recognizes tool-agnostic error, timeout and exit code lines
+type genericDetector struct{}
+
+var exitCodeRe = regexp.MustCompile(`exit code (\d+)`)
+
+func (genericDetector) Name() string { return "generic" }
+
+func (genericDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i, line := range lines {
+		// Timeout messages
+		if strings.Contains(line, "Timed out") || strings.Contains(line, "timeout") {
+			findings = append(findings, Finding{Category: CategoryTimeout, Message: strings.TrimSpace(line), Line: i})
+		}
+
+		// Error messages
+		if strings.Contains(line, "Error:") || strings.Contains(line, "ERROR") {
+			findings = append(findings, Finding{Category: CategoryError, Message: strings.TrimSpace(line), Line: i})
+		}
+
+		// Exit codes
+		if matches := exitCodeRe.FindStringSubmatch(line); len(matches) > 1 {
+			var code int
+			fmt.Sscanf(matches[1], "%d", &code)
+			findings = append(findings, Finding{Category: CategoryExitCode, Message: strings.TrimSpace(line), Line: i, ExitCode: code})
+		}
+	}
+
+	return findings
+}
+
+// goTestDetector recognizes failures reported by `go test`
+// The "--- FAIL: TestName" lines name the failed tests. testify's "Error Trace:" line is only the
+// location of an assertion, which the assertion detector reads.
+type goTestDetector struct{}
+
+func (goTestDetector) Name() string { return "go-test" }
+
+func (goTestDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i, line := range lines {
+		content := logContent(line)
+		switch {
+		case goTestFailRe.MatchString(content):
+		case testifyTraceRe.MatchString(content):
+			continue
+		case strings.Contains(line, ".go:") && (strings.Contains(line, "FAIL") || strings.Contains(line, "Error")):
+		default:
+			continue
+		}
+		findings = append(findings, Finding{Category: CategoryFailedTest, Message: strings.TrimSpace(line), Line: i})
+	}
+
+	return findings
+}
@@ -0,0 +1,130 @@
+package debugger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mixedLogs are failed logs of several jobs and tools, so every part of the ErrorSummary is filled
+const mixedLogs = "test (ubuntu-latest, 1.21)\tRun tests\t2024-01-01T00:00:00.0000000Z --- FAIL: TestParse (0.00s)\n" +
+	"test (ubuntu-latest, 1.21)\tRun tests\t2024-01-01T00:00:00.0000000Z     parse_test.go:12: got 1, want 2\n" +
+	"test (ubuntu-latest, 1.21)\tRun tests\t2024-01-01T00:00:01.0000000Z FAIL example.com/app/parse\n" +
+	"test (ubuntu-latest, 1.21)\tRun tests\t2024-01-01T00:00:02.0000000Z ##[error]Process completed with exit code 2.\n" +
+	"py\tRun pytest\t2024-01-01T00:00:03.0000000Z Traceback (most recent call last):\n" +
+	"py\tRun pytest\t2024-01-01T00:00:03.0000000Z   File \"/home/runner/work/app/app/app/main.py\", line 3, in <module>\n" +
+	"py\tRun pytest\t2024-01-01T00:00:03.0000000Z     run()\n" +
+	"py\tRun pytest\t2024-01-01T00:00:03.0000000Z ValueError: invalid literal for int() with base 10: 'x'\n" +
+	"py\tRun pytest\t2024-01-01T00:00:04.0000000Z Error: The operation was canceled after timeout of 10 minutes\n" +
+	"py\tRun pytest\t2024-01-01T00:00:05.0000000Z ##[error]Process completed with exit code 1.\n" +
+	"rust\tcargo build\t2024-01-01T00:00:06.0000000Z error[E0308]: mismatched types\n" +
+	"rust\tcargo build\t2024-01-01T00:00:06.0000000Z  --> src/lib.rs:9:5\n" +
+	"rust\tcargo build\t2024-01-01T00:00:07.0000000Z ##[error]Process completed with exit code 101.\n" +
+	"lint\tRun lint\t2024-01-01T00:00:08.0000000Z ##[error]Process completed with exit code 1."
+
+func TestErrorSummaryDeterministic(t *testing.T) {
+	var first []byte
+	var firstPrompt string
+	for i := 0; i < 20; i++ {
+		d := &GitHubWorkflowDebugger{}
+		run := &WorkflowRun{RunID: "1", Repository: "o/r", Conclusion: "failure", FailedLogs: mixedLogs}
+		d.parseLogs(run)
+		summary, err := json.Marshal(run.ErrorSummary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prompt := d.buildAnalysisPrompt(run, defaultLogBudget)
+		if i == 0 {
+			first, firstPrompt = summary, prompt
+			if len(run.ErrorSummary.FailedJobs) < 3 || len(run.ErrorSummary.Findings) == 0 {
+				t.Fatalf("ErrorSummary = %s, want the jobs and findings of the logs", summary)
+			}
+			continue
+		}
+		if !bytes.Equal(summary, first) {
+			t.Fatalf("parse %d gave a different ErrorSummary:\n%s\nwant\n%s", i+1, summary, first)
+		}
+		if prompt != firstPrompt {
+			t.Fatalf("parse %d gave a different prompt", i+1)
+		}
+	}
+}
+
+func TestUniqueExitCodes(t *testing.T) {
+	s := ErrorSummary{ExitCodes: []int{101, 1, 2, 1, 101}}
+	if got, want := s.UniqueExitCodes(), []int{1, 2, 101}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueExitCodes() = %v, want %v", got, want)
+	}
+}
+
+func TestAttributeReusableWorkflowsStable(t *testing.T) {
+	workflow := `on: push
+jobs:
+  zeta:
+    name: Zeta build
+    uses: ./.github/workflows/build.yml
+  alpha:
+    uses: ./.github/workflows/build.yml@main
+  mid:
+    uses: o/shared/.github/workflows/lint.yml@v1
+`
+	for i := 0; i < 20; i++ {
+		run := &WorkflowRun{WorkflowYAML: workflow}
+		got := attributeReusableWorkflows(run, []ReusableWorkflow{
+			{Path: "o/r/.github/workflows/build.yml"},
+			{Path: "o/shared/.github/workflows/lint.yml"},
+		})
+		if want := []string{"Zeta build", "alpha", "zeta"}; !reflect.DeepEqual(got[0].CallerJobs, want) {
+			t.Fatalf("CallerJobs = %q, want %q in sorted order", got[0].CallerJobs, want)
+		}
+		if want := []string{"mid"}; !reflect.DeepEqual(got[1].CallerJobs, want) {
+			t.Fatalf("CallerJobs = %q, want %q", got[1].CallerJobs, want)
+		}
+	}
+}
+
+func TestMaxErrorsCap(t *testing.T) {
+	var logs strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&logs, "build\tRun make\t2024-01-01T00:00:00.0000000Z Error: undefined reference to `symbol_%d'\n", i)
+	}
+	tests := []struct {
+		name      string
+		maxErrors int
+		want      int
+	}{
+		{name: "default", want: DefaultMaxErrors},
+		{name: "custom", maxErrors: 50, want: 50},
+		{name: "no limit", maxErrors: -1, want: 10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{Options: Options{MaxErrors: tt.maxErrors}}
+			run := &WorkflowRun{FailedLogs: logs.String()}
+			d.parseLogs(run)
+			s := run.ErrorSummary
+			if len(s.ErrorMessages) != tt.want {
+				t.Errorf("kept %d error messages, want %d", len(s.ErrorMessages), tt.want)
+			}
+			if s.Totals[CategoryError] != 10000 {
+				t.Errorf("Totals[%s] = %d, want all 10000 counted", CategoryError, s.Totals[CategoryError])
+			}
+			if !strings.Contains(s.ErrorMessages[0], "symbol_0'") {
+				t.Errorf("first kept error = %q, want the first of the logs", s.ErrorMessages[0])
+			}
+
+			var sb strings.Builder
+			writeDroppedFindings(&sb, s)
+			marker := fmt.Sprintf("dropped %d more error findings beyond the per-category limit", 10000-tt.want)
+			if dropped := tt.want < 10000; strings.Contains(sb.String(), marker) != dropped {
+				t.Errorf("markers = %q, want %q: %v", sb.String(), marker, dropped)
+			}
+			if label := s.countLabel(CategoryError, len(s.ErrorMessages)); tt.want < 10000 && label != fmt.Sprintf("showing %d of 10000", tt.want) {
+				t.Errorf("countLabel() = %q", label)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package debugger
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxDiffChars caps the commit diff included in the prompt (Options.MaxDiffChars)
+const DefaultMaxDiffChars = 8000
+
+// minPartialDiffChars is the smallest budget worth showing the start of a file diff that does not fit whole
+const minPartialDiffChars = 400
+
+// maxOmittedDiffFiles caps the omitted files listed under the diff
+const maxOmittedDiffFiles = 20
+
+// diffBaseRe matches the commits and refs accepted by --since-commit
+var diffBaseRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// ValidateDiffBase checks a --since-commit value: a commit SHA, branch or tag
+func ValidateDiffBase(base string) error {
+	if !diffBaseRe.MatchString(base) || strings.Contains(base, "..") {
+		return fmt.Errorf("invalid commit %q, expected a commit SHA, branch or tag", base)
+	}
+	return nil
+}
+
+// fetchCommitDiff reads the diff of the commit that triggered the run, or of the range from
+// Options.DiffBase to it, keeping the files of Options.DiffPaths
+func (d *GitHubWorkflowDebugger) fetchCommitDiff(run *WorkflowRun) error {
+	if run.HeadSHA == "" {
+		return fmt.Errorf("head commit of run %s is unknown", run.RunID)
+	}
+
+	endpoint := fmt.Sprintf("repos/%s/commits/%s", run.Repository, run.HeadSHA)
+	if base := d.Options.DiffBase; base != "" {
+		logDebugf("Fetching diff of %s...%s...", base, run.HeadSHA)
+		endpoint = fmt.Sprintf("repos/%s/compare/%s...%s", run.Repository, base, run.HeadSHA)
+	} else {
+		logDebugf("Fetching diff of commit %s...", run.HeadSHA)
+	}
+	output, err := gh("api", endpoint, "-H", "Accept: application/vnd.github.diff")
+	if err != nil {
+		return fmt.Errorf("failed to get commit diff: %w", err)
+	}
+	logDebugf("Fetched commit diff (%d bytes)", len(output))
+
+	diff := string(output)
+	if len(d.Options.DiffPaths) > 0 {
+		files := splitDiff(diff)
+		kept := filterDiffPaths(files, d.Options.DiffPaths)
+		logInfof("Kept the diff of %d of %d changed files (--diff-paths)", len(kept), len(files))
+		diff = joinDiff(kept)
+	}
+	run.CommitDiff = diff
+	run.DiffBase = d.Options.DiffBase
+	return nil
+}
+
+// fileDiff is the part of a diff changing one file
+type fileDiff struct {
+	Path string // path of the file after the change
+	Text string // the "diff --git" header and hunks
+}
+
+// splitDiff splits a git diff into the diffs of its files; text before the first file header
+// (a commit message, for instance) is dropped
+func splitDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var current *strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			files = append(files, fileDiff{Path: diffFilePath(line)})
+			current = &strings.Builder{}
+		}
+		if current != nil {
+			current.WriteString(line)
+			files[len(files)-1].Text = current.String()
+		}
+	}
+	return files
+}
+
+// diffFilePath returns the new path of a "diff --git a/<old> b/<new>" header
+func diffFilePath(header string) string {
+	header = strings.TrimSpace(strings.TrimPrefix(header, "diff --git "))
+	if i := strings.LastIndex(header, " b/"); i >= 0 {
+		return header[i+len(" b/"):]
+	}
+	return strings.TrimPrefix(header, "a/")
+}
+
+// joinDiff joins file diffs back into one diff
+func joinDiff(files []fileDiff) string {
+	var sb strings.Builder
+	for _, f := range files {
+		sb.WriteString(f.Text)
+	}
+	return sb.String()
+}
+
+// filterDiffPaths keeps the files matching one of the patterns: a glob matched against the whole path
+// or the file name (e.g. "*.go"), or a directory prefix (e.g. "pkg/api/")
+func filterDiffPaths(files []fileDiff, patterns []string) []fileDiff {
+	var kept []fileDiff
+	for _, f := range files {
+		for _, pattern := range patterns {
+			if matchDiffPath(f.Path, pattern) {
+				kept = append(kept, f)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// matchDiffPath reports whether the diff path p matches a --diff-paths pattern
+func matchDiffPath(p, pattern string) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if ok, _ := path.Match(pattern, p); ok {
+		return true
+	}
+	if !strings.Contains(pattern, "/") {
+		if ok, _ := path.Match(pattern, path.Base(p)); ok {
+			return true
+		}
+	}
+	dir := strings.TrimSuffix(pattern, "/")
+	return dir != "" && strings.HasPrefix(p, dir+"/")
+}
+
+// referencedFiles returns the source files the error summary points at: annotations, compiler
+// errors, stack frames and assertion locations
+func referencedFiles(summary ErrorSummary) []string {
+	var files []string
+	for _, a := range summary.Annotations {
+		if a.hasLocation() {
+			files = append(files, a.Path)
+		}
+	}
+	for _, e := range summary.CompilerErrors {
+		if e.File != "" {
+			files = append(files, e.File)
+		}
+	}
+	for _, f := range summary.Findings {
+		for _, frame := range f.Frames {
+			if frame.File != "" {
+				files = append(files, frame.File)
+			}
+		}
+	}
+	for _, a := range summary.Assertions {
+		if file, _, ok := cutLast(a.Location, ":"); ok && file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// isReferencedFile reports whether the diff path p is one of the referenced files, which may be
+// absolute runner paths (/home/runner/work/repo/repo/pkg/x.go) or relative to a module or package
+func isReferencedFile(p string, refs []string) bool {
+	for _, ref := range refs {
+		ref = strings.TrimPrefix(strings.ReplaceAll(ref, "\\", "/"), "./")
+		if ref == p || strings.HasSuffix(ref, "/"+p) || strings.HasSuffix(p, "/"+ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// prioritizeDiff moves the diffs of the files referenced by the errors first, keeping the order
+// otherwise, and returns the paths of those files
+func prioritizeDiff(files []fileDiff, refs []string) ([]fileDiff, []string) {
+	var referenced, others []fileDiff
+	var paths []string
+	for _, f := range files {
+		if isReferencedFile(f.Path, refs) {
+			referenced = append(referenced, f)
+			paths = append(paths, f.Path)
+		} else {
+			others = append(others, f)
+		}
+	}
+	return append(referenced, others...), paths
+}
+
+// writeCommitDiff writes the commit diff section of the prompt within maxChars. The diffs of the
+// files the errors point at come first, then the others while they fit whole; the start of the
+// first one that does not fit is shown when enough budget is left, the rest are only listed.
+func writeCommitDiff(sb *strings.Builder, run *WorkflowRun, maxChars int) {
+	files, referenced := prioritizeDiff(splitDiff(run.CommitDiff), referencedFiles(run.ErrorSummary))
+
+	var body strings.Builder
+	var omitted []string
+	truncated := false
+	if len(files) == 0 {
+		// Not a git diff, keep it as it is
+		body.WriteString(truncateText(strings.TrimSpace(run.CommitDiff), maxChars))
+		truncated = len(strings.TrimSpace(run.CommitDiff)) > maxChars
+	}
+	for _, f := range files {
+		text := strings.TrimRight(f.Text, "\n") + "\n"
+		remaining := maxChars - body.Len()
+		switch {
+		case len(text) <= remaining:
+			body.WriteString(text)
+		case !truncated && remaining >= minPartialDiffChars:
+			body.WriteString(truncateText(text, remaining) + "\n")
+			truncated = true
+		default:
+			omitted = append(omitted, f.Path)
+		}
+	}
+	if truncated || len(omitted) > 0 {
+		logInfof("Commit diff truncated to %d chars, %d files not shown", maxChars, len(omitted))
+	}
+
+	if run.DiffBase != "" {
+		sb.WriteString(fmt.Sprintf("\n## Changes since %s (up to %s)\n", shortSHA(run.DiffBase), shortSHA(run.HeadSHA)))
+	} else {
+		sb.WriteString(fmt.Sprintf("\n## Changes in Commit %s\n", shortSHA(run.HeadSHA)))
+	}
+	if len(referenced) > 0 {
+		sb.WriteString(fmt.Sprintf("(changed files referenced by the errors shown first: %s)\n", strings.Join(referenced, ", ")))
+	}
+	if truncated {
+		sb.WriteString(fmt.Sprintf("(diff truncated, %d chars shown)\n", maxChars))
+	}
+	sb.WriteString("```diff\n")
+	sb.WriteString(strings.TrimRight(body.String(), "\n"))
+	sb.WriteString("\n```\n")
+	if len(omitted) > 0 {
+		listed := omitted
+		if len(listed) > maxOmittedDiffFiles {
+			listed = listed[:maxOmittedDiffFiles]
+		}
+		sb.WriteString(fmt.Sprintf("Diff of %d more changed files not shown: %s", len(omitted), strings.Join(listed, ", ")))
+		if len(omitted) > len(listed) {
+			sb.WriteString(", ...")
+		}
+		sb.WriteString("\n")
+	}
+}
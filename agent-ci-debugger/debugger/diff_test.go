@@ -0,0 +1,226 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testFileDiff returns the diff of a file adding n lines
+func testFileDiff(p string, n int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -0,0 +1,%d @@\n", p, p, p, p, n))
+	for i := 1; i <= n; i++ {
+		sb.WriteString(fmt.Sprintf("+line %d of %s\n", i, p))
+	}
+	return sb.String()
+}
+
+func TestSplitDiff(t *testing.T) {
+	a, b := testFileDiff("a.go", 2), testFileDiff("pkg/b.go", 1)
+	renamed := "diff --git a/old.go b/new/name.go\nsimilarity index 100%\n"
+	files := splitDiff("commit message\n\n" + a + b + renamed)
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if want := []string{"a.go", "pkg/b.go", "new/name.go"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("paths = %q, want %q", paths, want)
+	}
+	if files[0].Text != a || files[1].Text != b || files[2].Text != renamed {
+		t.Errorf("file diffs = %q", files)
+	}
+	if got := joinDiff(files); got != a+b+renamed {
+		t.Errorf("joinDiff() = %q, want the diff without the commit message", got)
+	}
+	if files := splitDiff("not a diff\n"); len(files) != 0 {
+		t.Errorf("splitDiff(not a diff) = %q, want none", files)
+	}
+}
+
+func TestMatchDiffPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		pattern string
+		want    bool
+	}{
+		{"pkg/api/server.go", "*.go", true},
+		{"pkg/api/server.go", "pkg/api/*.go", true},
+		{"pkg/api/server.go", "pkg/api/", true},
+		{"pkg/api/server.go", "./pkg", true},
+		{"pkg/api/server.go", "pkg/ap", false},
+		{"pkg/api/server.go", "cmd/*.go", false},
+		{"README.md", "*.go", false},
+	}
+	for _, tt := range tests {
+		if got := matchDiffPath(tt.path, tt.pattern); got != tt.want {
+			t.Errorf("matchDiffPath(%q, %q) = %v, want %v", tt.path, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestReferencedFiles(t *testing.T) {
+	summary := ErrorSummary{
+		Annotations: []Annotation{
+			{Path: "pkg/a.go", StartLine: 3},
+			{Path: ".github", StartLine: 1},
+			{Path: "pkg/no_line.go"},
+		},
+		CompilerErrors: []CompilerError{{File: "src/main.rs", Line: 4}, {Message: "no file"}},
+		Findings: []Finding{
+			{Frames: []StackFrame{{File: "/home/runner/work/r/r/pkg/b.py", Line: 7}, {Function: "<module>"}}},
+		},
+		Assertions: []Assertion{{Location: "pkg/c_test.go:42"}, {Location: "no location"}},
+	}
+
+	want := []string{"pkg/a.go", "src/main.rs", "/home/runner/work/r/r/pkg/b.py", "pkg/c_test.go"}
+	if got := referencedFiles(summary); !reflect.DeepEqual(got, want) {
+		t.Errorf("referencedFiles() = %q, want %q", got, want)
+	}
+}
+
+func TestIsReferencedFile(t *testing.T) {
+	tests := []struct {
+		path string
+		ref  string
+		want bool
+	}{
+		{"pkg/api/server.go", "pkg/api/server.go", true},
+		{"pkg/api/server.go", "/home/runner/work/r/r/pkg/api/server.go", true},
+		{"pkg/api/server.go", `D:\a\r\r\pkg\api\server.go`, true},
+		{"pkg/api/server.go", "./pkg/api/server.go", true},
+		{"pkg/api/server.go", "server.go", true},
+		{"pkg/api/server.go", "xserver.go", false},
+		{"pkg/api/server.go", "/home/runner/work/r/r/pkg/api/server.go.orig", false},
+		{"pkg/api/server.go", "cmd/server.go", false},
+	}
+	for _, tt := range tests {
+		if got := isReferencedFile(tt.path, []string{tt.ref}); got != tt.want {
+			t.Errorf("isReferencedFile(%q, %q) = %v, want %v", tt.path, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestPrioritizeDiff(t *testing.T) {
+	files := splitDiff(testFileDiff("README.md", 1) + testFileDiff("pkg/api/server.go", 1) +
+		testFileDiff("go.mod", 1) + testFileDiff("cmd/main.go", 1))
+	refs := []string{"/home/runner/work/r/r/cmd/main.go", "pkg/api/server.go"}
+
+	files, referenced := prioritizeDiff(files, refs)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	// Referenced files keep the diff order, not the order of the references
+	if want := []string{"pkg/api/server.go", "cmd/main.go", "README.md", "go.mod"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+	if want := []string{"pkg/api/server.go", "cmd/main.go"}; !reflect.DeepEqual(referenced, want) {
+		t.Errorf("referenced = %q, want %q", referenced, want)
+	}
+
+	files, referenced = prioritizeDiff(files, nil)
+	if len(referenced) != 0 || files[0].Path != "pkg/api/server.go" {
+		t.Errorf("prioritizeDiff() without references reordered the files: %q, %q", files, referenced)
+	}
+}
+
+func TestWriteCommitDiff(t *testing.T) {
+	readme, server := testFileDiff("README.md", 40), testFileDiff("pkg/api/server.go", 3)
+	gomod, main := testFileDiff("go.mod", 2), testFileDiff("cmd/main.go", 2)
+	run := &WorkflowRun{
+		HeadSHA:    "abcdef1234567890",
+		CommitDiff: "Fix the server\n\n" + readme + server + gomod + main,
+		ErrorSummary: ErrorSummary{
+			CompilerErrors: []CompilerError{{Tool: "go", File: "/home/runner/work/r/r/pkg/api/server.go", Line: 2}},
+			Assertions:     []Assertion{{Location: "main.go:12"}},
+		},
+	}
+
+	var sb strings.Builder
+	writeCommitDiff(&sb, run, 10000)
+	want := "\n## Changes in Commit abcdef1\n" +
+		"(changed files referenced by the errors shown first: pkg/api/server.go, cmd/main.go)\n" +
+		"```diff\n" + server + main + readme + strings.TrimRight(gomod, "\n") + "\n```\n"
+	if got := sb.String(); got != want {
+		t.Errorf("writeCommitDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The referenced file fits whole, the start of README.md is shown and the others are listed
+	maxChars := len(server) + len(main) + 500
+	sb.Reset()
+	writeCommitDiff(&sb, run, maxChars)
+	got := sb.String()
+	if !strings.HasPrefix(got, "\n## Changes in Commit abcdef1\n"+
+		"(changed files referenced by the errors shown first: pkg/api/server.go, cmd/main.go)\n"+
+		fmt.Sprintf("(diff truncated, %d chars shown)\n", maxChars)+
+		"```diff\n"+server+main+truncateText(readme, 500)+"\n```\n") {
+		t.Errorf("truncated diff =\n%s", got)
+	}
+	if !strings.HasSuffix(got, "```\nDiff of 1 more changed files not shown: go.mod\n") {
+		t.Errorf("truncated diff does not list go.mod:\n%s", got)
+	}
+
+	// Below minPartialDiffChars README.md is only listed, go.mod still fits whole
+	maxChars = len(server) + len(main) + minPartialDiffChars - 1
+	sb.Reset()
+	writeCommitDiff(&sb, run, maxChars)
+	got = sb.String()
+	if strings.Contains(got, "diff truncated") || strings.Contains(got, "+line 1 of README.md") {
+		t.Errorf("diff shows part of README.md below %d chars:\n%s", minPartialDiffChars, got)
+	}
+	if !strings.Contains(got, "```diff\n"+server+main+strings.TrimRight(gomod, "\n")+"\n```\n") ||
+		!strings.HasSuffix(got, "Diff of 1 more changed files not shown: README.md\n") {
+		t.Errorf("diff =\n%s", got)
+	}
+}
+
+func TestWriteCommitDiffOmittedFiles(t *testing.T) {
+	var diff strings.Builder
+	for i := 0; i < maxOmittedDiffFiles+5; i++ {
+		diff.WriteString(testFileDiff(fmt.Sprintf("f%02d.go", i), 1))
+	}
+	run := &WorkflowRun{HeadSHA: "abcdef1234567890", DiffBase: "1234567890abcdef", CommitDiff: diff.String()}
+
+	var sb strings.Builder
+	writeCommitDiff(&sb, run, 0)
+	got := sb.String()
+	if !strings.HasPrefix(got, "\n## Changes since 1234567 (up to abcdef1)\n```diff\n") {
+		t.Errorf("header =\n%s", got)
+	}
+	var listed []string
+	for i := 0; i < maxOmittedDiffFiles; i++ {
+		listed = append(listed, fmt.Sprintf("f%02d.go", i))
+	}
+	want := fmt.Sprintf("Diff of %d more changed files not shown: %s, ...\n", maxOmittedDiffFiles+5, strings.Join(listed, ", "))
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("omitted files =\n%s\nwant suffix\n%s", got, want)
+	}
+}
+
+func TestWriteCommitDiffNotGit(t *testing.T) {
+	run := &WorkflowRun{HeadSHA: "abc", CommitDiff: strings.Repeat("x", 50) + "\n"}
+
+	var sb strings.Builder
+	writeCommitDiff(&sb, run, 20)
+	want := "\n## Changes in Commit abc\n(diff truncated, 20 chars shown)\n```diff\n" +
+		truncateText(strings.Repeat("x", 50), 20) + "\n```\n"
+	if got := sb.String(); got != want {
+		t.Errorf("writeCommitDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestValidateDiffBase(t *testing.T) {
+	for _, base := range []string{"main", "v1.2.3", "release/1.x", "abcdef1234567890"} {
+		if err := ValidateDiffBase(base); err != nil {
+			t.Errorf("ValidateDiffBase(%q) = %v", base, err)
+		}
+	}
+	for _, base := range []string{"", "main..dev", "--output=x", "a b"} {
+		if err := ValidateDiffBase(base); err == nil {
+			t.Errorf("ValidateDiffBase(%q) succeeded, want an error", base)
+		}
+	}
+}
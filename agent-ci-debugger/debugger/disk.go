@@ -0,0 +1,107 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of resource failures
+const (
+	ResourceDiskFull    = "disk_full"     // the runner ran out of disk space or inodes
+	ResourceFilesystem  = "filesystem"    // read-only file system, I/O errors, quotas
+	ResourceOutOfMemory = "out_of_memory" // the OOM killer or a runtime ran out of memory
+)
+
+// ResourceFailure is a failure of the runner's resources rather than of the code under test
+type ResourceFailure struct {
+	Kind    string // one of the Resource* constants
+	Message string // the log line reporting the failure
+	Line    int    // 0-based index of the log line
+}
+
+// diskFullRe matches out-of-space errors of Linux/macOS tools and Windows
+var diskFullRe = regexp.MustCompile(`(?i)(no space left on device|` +
+	`write error: no space left|` +
+	`\bENOSPC\b|` +
+	`disk quota exceeded|\bEDQUOT\b|` +
+	`there is not enough space on the disk|` +
+	`the disk is full|\bERROR_DISK_FULL\b|` +
+	`not enough disk space|insufficient disk space|` +
+	`you are running out of disk space)`)
+
+// filesystemRe matches filesystem errors that are not caused by the code under test
+var filesystemRe = regexp.MustCompile(`(?i)(read-only file system|\bEROFS\b|` +
+	`input/output error|\bEIO\b|` +
+	`too many open files|\bEMFILE\b|` +
+	`the device is not ready|a device attached to the system is not functioning)`)
+
+// outOfMemoryRe matches the kernel's OOM killer and the out-of-memory errors of runtimes and compilers
+var outOfMemoryRe = regexp.MustCompile(`(?i)(out of memory: kill(?:ed)? process|invoked oom-killer|\boom-kill\b|` +
+	`memory cgroup out of memory|\boomkilled\b|` +
+	`fatal error: runtime: out of memory|javascript heap out of memory|java\.lang\.OutOfMemoryError|` +
+	`cannot allocate memory|killed signal terminated program)`)
+
+// diskDetector recognizes disk exhaustion, filesystem errors and memory exhaustion of the runner
+// These need an infrastructure fix (cleanup, a larger runner), so they are collected into
+// ResourceFailures instead of reading as ordinary errors.
+type diskDetector struct{}
+
+func (diskDetector) Name() string { return "disk" }
+
+func (diskDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i, line := range lines {
+		content := strings.TrimSpace(logContent(line))
+		kind := ""
+		switch {
+		case diskFullRe.MatchString(content):
+			kind = ResourceDiskFull
+		case filesystemRe.MatchString(content):
+			kind = ResourceFilesystem
+		case outOfMemoryRe.MatchString(content):
+			kind = ResourceOutOfMemory
+		default:
+			continue
+		}
+		failure := ResourceFailure{Kind: kind, Message: content, Line: i}
+		findings = append(findings, Finding{Category: CategoryResourceFailure, Message: content, Line: i, ResourceFailure: &failure})
+	}
+
+	return findings
+}
+
+// writeResourceFailures steers the analysis toward infrastructure fixes for runner resource failures
+func writeResourceFailures(sb *strings.Builder, failures []ResourceFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	diskFull, outOfMemory := false, false
+	for _, f := range failures {
+		diskFull = diskFull || f.Kind == ResourceDiskFull
+		outOfMemory = outOfMemory || f.Kind == ResourceOutOfMemory
+	}
+	switch {
+	case diskFull:
+		sb.WriteString(fmt.Sprintf("RUNNER RESOURCE FAILURES (%d total) - the runner ran out of disk space. This is an infrastructure problem, "+
+			"not a bug in the code: propose freeing disk space before the failing step (e.g. `docker system prune -af`, removing unused "+
+			"preinstalled toolchains such as /usr/share/dotnet or /opt/ghc, cleaning build caches), reducing what the job writes, "+
+			"or a runner with a larger disk. Do not propose application code changes unless the logs show the code itself filling the disk:\n", len(failures)))
+	case outOfMemory:
+		sb.WriteString(fmt.Sprintf("RUNNER RESOURCE FAILURES (%d total) - the runner ran out of memory and processes were killed. This is "+
+			"usually a resource limit rather than a bug: propose lowering the parallelism of the failing step, raising or capping tool "+
+			"heap limits (NODE_OPTIONS=--max-old-space-size, -Xmx, GOMEMLIMIT) or a runner with more memory, unless the logs show a leak:\n", len(failures)))
+	default:
+		sb.WriteString(fmt.Sprintf("RUNNER RESOURCE FAILURES (%d total) - filesystem errors of the runner. These usually point to the runner "+
+			"environment (read-only or failing disk, file descriptor limits) rather than the code; prefer infrastructure fixes:\n", len(failures)))
+	}
+	for i, f := range failures {
+		if i >= 3 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(failures)-3))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  - %s\n", truncateText(f.Message, 300)))
+	}
+}
@@ -0,0 +1,77 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiskDetector(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		kind    string
+	}{
+		{"linux ENOSPC", "cp: error writing '/home/runner/work/app/app/dist/app.tar': No space left on device", ResourceDiskFull},
+		{"write error", "tar: write error: No space left on device", ResourceDiskFull},
+		{"errno", "npm ERR! code ENOSPC", ResourceDiskFull},
+		{"docker", "failed to register layer: write /usr/lib/x86_64-linux-gnu/libLLVM.so: no space left on device", ResourceDiskFull},
+		{"runner warning", "You are running out of disk space. The runner will stop working when the machine runs out of disk space.", ResourceDiskFull},
+		{"windows IOException", "System.IO.IOException: There is not enough space on the disk. : 'D:\\a\\app\\app\\obj\\app.dll'", ResourceDiskFull},
+		{"windows error code", "error MSB3021: Unable to copy file. ERROR_DISK_FULL", ResourceDiskFull},
+		{"read-only", "mkdir: cannot create directory '/opt/cache': Read-only file system", ResourceFilesystem},
+		{"open files", "accept4: too many open files; retrying in 1s", ResourceFilesystem},
+		{"oom killer", "Out of memory: Killed process 4242 (java) total-vm:8123456kB, anon-rss:6912345kB", ResourceOutOfMemory},
+		{"oom killer invoked", "[ 1234.567] node invoked oom-killer: gfp_mask=0x100cca(GFP_HIGHUSER_MOVABLE), order=0", ResourceOutOfMemory},
+		{"container", "Error: container exited: OOMKilled", ResourceOutOfMemory},
+		{"go runtime", "fatal error: runtime: out of memory", ResourceOutOfMemory},
+		{"node", "FATAL ERROR: Reached heap limit Allocation failed - JavaScript heap out of memory", ResourceOutOfMemory},
+		{"gcc", "c++: fatal error: Killed signal terminated program cc1plus", ResourceOutOfMemory},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := diskDetector{}.Detect(fixtureLines("build", "Run make", "ok", tt.content))
+			if len(findings) != 1 {
+				t.Fatalf("findings = %+v, want one", findings)
+			}
+			f := findings[0]
+			if f.Category != CategoryResourceFailure || f.ResourceFailure == nil || f.Line != 1 {
+				t.Fatalf("finding = %+v, want a resource failure at line 1", f)
+			}
+			if *f.ResourceFailure != (ResourceFailure{Kind: tt.kind, Message: tt.content, Line: 1}) {
+				t.Errorf("ResourceFailure = %+v, want kind %q", *f.ResourceFailure, tt.kind)
+			}
+		})
+	}
+
+	for _, content := range []string{
+		"Disk usage: 41% of 84G used",
+		"--- FAIL: TestSpaceLeft (0.00s)",
+		"memory: 7.8 GiB available",
+	} {
+		if findings := (diskDetector{}).Detect(fixtureLines("build", "Run make", content)); len(findings) != 0 {
+			t.Errorf("Detect(%q) = %+v, want none", content, findings)
+		}
+	}
+}
+
+func TestWriteResourceFailures(t *testing.T) {
+	tests := []struct {
+		kinds []string
+		want  string
+	}{
+		{[]string{ResourceFilesystem, ResourceDiskFull}, "the runner ran out of disk space"},
+		{[]string{ResourceFilesystem, ResourceOutOfMemory}, "the runner ran out of memory"},
+		{[]string{ResourceFilesystem}, "filesystem errors of the runner"},
+	}
+	for _, tt := range tests {
+		var failures []ResourceFailure
+		for _, kind := range tt.kinds {
+			failures = append(failures, ResourceFailure{Kind: kind, Message: kind + " message"})
+		}
+		var sb strings.Builder
+		writeResourceFailures(&sb, failures)
+		if !strings.Contains(sb.String(), tt.want) || !strings.Contains(sb.String(), "  - "+tt.kinds[len(tt.kinds)-1]+" message\n") {
+			t.Errorf("writeResourceFailures(%q) =\n%s\nwant %q", tt.kinds, sb.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Package debugger analyzes failed GitHub Actions runs and proposes fixes with an OpenAI model.
+//
+// The github-workflow-debugger command is a thin wrapper around this package; other programs
+// can embed the same analysis:
+//
+//	d := debugger.New(os.Getenv("OPENAI_API_KEY"))
+//	d.Options.IncludeDiff = true
+//	result, err := d.DebugWorkflow(ctx, "https://github.com/org/repo/actions/runs/123")
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(result.Proposal.RootCause)
+//
+// Result carries the fetched *WorkflowRun and the parsed *FixProposal along with the rendered
+// Markdown report, so callers can render the analysis their own way. GitHub is accessed through
+// the gh CLI, or the REST API when only GITHUB_TOKEN is set.
+package debugger
@@ -0,0 +1,153 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxBuildOutputLines limits the output kept per failing build step
+const maxBuildOutputLines = 20
+
+// BuildFailure is a failed Dockerfile instruction of a docker build
+type BuildFailure struct {
+	Instruction string   // the failing instruction, e.g. "RUN go build ./..."
+	Stage       string   // build stage and step, e.g. "build 4/6" (BuildKit) or "5/8" (classic builder)
+	Location    string   // Dockerfile:line, when reported
+	ExitCode    int      // exit code of the instruction
+	Output      []string // output of the instruction preceding the failure
+	Message     string   // the log line reporting the failure
+	Line        int      // 0-based index of the log line
+}
+
+var (
+	// BuildKit: `ERROR: failed to solve: process "/bin/sh -c make" did not complete successfully: exit code: 2`
+	// and the older `failed to solve: executor failed running [/bin/sh -c make]: exit code: 2`
+	buildKitFailureRe = regexp.MustCompile(`failed to solve: .*?(?:process "(.*)" did not complete successfully|executor failed running \[(.*)\]): exit code: (\d+)`)
+	// BuildKit repeats the failing step as " > [build 4/6] RUN make:" followed by its output up to "------"
+	buildKitStepRe = regexp.MustCompile(`^\s*> \[([^\]]+)\] (.+?):?\s*$`)
+	// BuildKit output lines carry the elapsed time: "0.512 main.go:5:2: undefined: foo"
+	buildKitElapsedRe = regexp.MustCompile(`^\d+\.\d+ `)
+	dockerfileLineRe  = regexp.MustCompile(`^\s*(\S*Dockerfile\S*):(\d+)\s*$`)
+	// Classic builder: "Step 5/8 : RUN make" ... "The command '/bin/sh -c make' returned a non-zero code: 2"
+	classicFailureRe = regexp.MustCompile(`The command '(.*)' returned a non-zero code: (\d+)`)
+	classicStepRe    = regexp.MustCompile(`^\s*Step (\d+/\d+) : (.+)$`)
+)
+
+// dockerDetector recognizes failed Dockerfile instructions of BuildKit and the classic builder
+type dockerDetector struct{}
+
+func (dockerDetector) Name() string { return "docker" }
+
+func (dockerDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i, line := range lines {
+		content := strings.TrimSpace(logContent(line))
+		if m := buildKitFailureRe.FindStringSubmatch(content); m != nil {
+			command := m[1]
+			if command == "" {
+				command = m[2]
+			}
+			failure := BuildFailure{Instruction: runInstruction(command), Message: content, Line: i}
+			failure.ExitCode, _ = strconv.Atoi(m[3])
+			buildKitStep(lines, i, &failure)
+			findings = append(findings, buildFailureFinding(failure))
+			continue
+		}
+		if m := classicFailureRe.FindStringSubmatch(content); m != nil {
+			failure := BuildFailure{Instruction: runInstruction(m[1]), Message: content, Line: i}
+			failure.ExitCode, _ = strconv.Atoi(m[2])
+			classicStep(lines, i, &failure)
+			findings = append(findings, buildFailureFinding(failure))
+		}
+	}
+
+	return findings
+}
+
+// buildKitStep fills in the failing step, its output and the Dockerfile location from the
+// error block BuildKit prints before "failed to solve"
+func buildKitStep(lines []string, failureLine int, failure *BuildFailure) {
+	for j := failureLine - 1; j >= 0 && j >= failureLine-200; j-- {
+		content := strings.TrimSpace(logContent(lines[j]))
+		if m := dockerfileLineRe.FindStringSubmatch(content); m != nil && failure.Location == "" {
+			failure.Location = m[1] + ":" + m[2]
+			continue
+		}
+		m := buildKitStepRe.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+		failure.Stage, failure.Instruction = m[1], m[2]
+		for k := j + 1; k < failureLine && k <= j+maxBuildOutputLines; k++ {
+			output := strings.TrimSpace(logContent(lines[k]))
+			if strings.HasPrefix(output, "------") {
+				break
+			}
+			failure.Output = append(failure.Output, buildKitElapsedRe.ReplaceAllString(output, ""))
+		}
+		return
+	}
+}
+
+// classicStep fills in the failing step and its output from the classic builder's "Step N/M" header
+func classicStep(lines []string, failureLine int, failure *BuildFailure) {
+	for j := failureLine - 1; j >= 0; j-- {
+		m := classicStepRe.FindStringSubmatch(logContent(lines[j]))
+		if m == nil {
+			continue
+		}
+		failure.Stage, failure.Instruction = m[1], strings.TrimSpace(m[2])
+		start := j + 1
+		if failureLine-start > maxBuildOutputLines {
+			start = failureLine - maxBuildOutputLines
+		}
+		for k := start; k < failureLine; k++ {
+			output := strings.TrimSpace(logContent(lines[k]))
+			if output == "" || strings.HasPrefix(output, "--->") {
+				continue
+			}
+			failure.Output = append(failure.Output, output)
+		}
+		return
+	}
+}
+
+// runInstruction turns the shell command of a failed step back into its RUN instruction
+func runInstruction(command string) string {
+	command = strings.TrimPrefix(command, "/bin/sh -c ")
+	return "RUN " + command
+}
+
+// buildFailureFinding wraps a build failure into a finding
+func buildFailureFinding(b BuildFailure) Finding {
+	return Finding{Category: CategoryBuildFailure, Message: b.Message, Line: b.Line, BuildFailure: &b}
+}
+
+// writeBuildFailures lists the failing Dockerfile instructions in the prompt
+func writeBuildFailures(sb *strings.Builder, failures []BuildFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("Docker Build Failures (%d total):\n", len(failures)))
+	for i, b := range failures {
+		if i >= 3 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(failures)-3))
+			break
+		}
+		where := b.Location
+		if where == "" {
+			where = "Dockerfile"
+		}
+		if b.Stage != "" {
+			where += fmt.Sprintf(" [%s]", b.Stage)
+		}
+		sb.WriteString(fmt.Sprintf("  - %s: `%s` failed with exit code %d\n", where, truncateText(b.Instruction, 300), b.ExitCode))
+		for _, output := range b.Output {
+			sb.WriteString(fmt.Sprintf("    %s\n", truncateText(output, 300)))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// checkTimeout bounds each API call of the self-check
+const checkTimeout = 30 * time.Second
+
+// checkResult is one item of the self-check
+type checkResult struct {
+	Name   string
+	Err    error
+	Detail string // shown after the name on success, e.g. the gh version
+}
+
+// APIKeyFromEnv returns the API key for the configured provider
+// With AZURE_OPENAI_ENDPOINT set, AZURE_OPENAI_API_KEY is preferred over OPENAI_API_KEY.
+func APIKeyFromEnv() (string, error) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if usingAzure() {
+		if key := os.Getenv("AZURE_OPENAI_API_KEY"); key != "" {
+			apiKey = key
+		}
+		if apiKey == "" {
+			return "", errors.New("AZURE_OPENAI_API_KEY environment variable is required with AZURE_OPENAI_ENDPOINT")
+		}
+		return apiKey, nil
+	}
+	if apiKey == "" {
+		return "", errors.New("OPENAI_API_KEY environment variable is required")
+	}
+	return apiKey, nil
+}
+
+// RunChecks verifies the prerequisites of an analysis: the gh CLI (or a GitHub token), its authentication, the API
+// key and the configured model. Each check is printed to out as it completes; the checks
+// depending on a failed one are skipped. It returns false if any check failed.
+func RunChecks(ctx context.Context, out *os.File) bool {
+	ok := true
+	report := func(result checkResult) {
+		printCheck(out, result)
+		if result.Err != nil {
+			ok = false
+		}
+	}
+
+	ghPath, err := exec.LookPath("gh")
+	if err != nil && githubToken() != "" {
+		report(checkResult{Name: "GitHub access", Detail: "REST API with GITHUB_TOKEN, gh not found on PATH"})
+	} else if err != nil {
+		report(checkResult{Name: "gh CLI installed", Err: ErrNoGitHubAccess})
+	} else {
+		version := ""
+		if output, err := exec.Command(ghPath, "--version").Output(); err == nil {
+			version, _, _ = strings.Cut(strings.TrimSpace(string(output)), "\n")
+		}
+		report(checkResult{Name: "gh CLI installed", Detail: version})
+
+		output, err := exec.Command(ghPath, "auth", "status").CombinedOutput()
+		if err != nil {
+			report(checkResult{Name: "gh authenticated", Err: fmt.Errorf("gh auth status failed, run `gh auth login`: %s", lastLine(string(output)))})
+		} else {
+			report(checkResult{Name: "gh authenticated"})
+		}
+	}
+
+	apiKey, keySource, err := resolveAPIKey(ctx)
+	if err != nil {
+		report(checkResult{Name: "API key set", Err: err})
+		return false
+	}
+	provider := "OpenAI"
+	if usingAzure() {
+		provider = "Azure OpenAI (" + os.Getenv("AZURE_OPENAI_ENDPOINT") + ")"
+	} else if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
+		provider = "OpenAI-compatible API (" + baseURL + ")"
+	}
+	report(checkResult{Name: "API key set", Detail: provider + ", key from " + keySource})
+
+	d := New(apiKey)
+	if err := d.EndpointError(); err != nil {
+		report(checkResult{Name: "API endpoint allowed", Err: err})
+		return false
+	}
+	if !usingAzure() {
+		report(checkResult{Name: "API usage attributed", Detail: d.Account().String()})
+	}
+
+	// Azure OpenAI lists models per resource rather than per deployment, the model check covers it
+	if !usingAzure() {
+		pingCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		_, err := d.openaiClient.ListModels(pingCtx)
+		cancel()
+		if err != nil {
+			report(checkResult{Name: "API reachable", Err: err})
+			return false
+		}
+		report(checkResult{Name: "API reachable"})
+	}
+
+	// A one-token completion proves the model (or Azure deployment) accepts requests
+	modelCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	ping := d.chatRequest([]openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "ping"}}, 1)
+	ping.Temperature = 0
+	_, err = d.openaiClient.CreateChatCompletion(modelCtx, ping)
+	if err != nil {
+		report(checkResult{Name: "model " + d.model + " available", Err: err})
+	} else {
+		report(checkResult{Name: "model " + d.model + " available"})
+	}
+
+	return ok
+}
+
+// printCheck prints a checklist line, green for a passed and red for a failed check
+func printCheck(out *os.File, result checkResult) {
+	if result.Err != nil {
+		fmt.Fprintf(out, "%s %s: %v\n", colorize(out, colorRed, "✗"), result.Name, result.Err)
+		return
+	}
+	if result.Detail != "" {
+		fmt.Fprintf(out, "%s %s (%s)\n", colorize(out, colorGreen, "✓"), result.Name, result.Detail)
+		return
+	}
+	fmt.Fprintf(out, "%s %s\n", colorize(out, colorGreen, "✓"), result.Name)
+}
+
+// lastLine returns the last non-empty line of output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,147 @@
+package debugger
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrEndpointRejected is returned for requests to an LLM endpoint the endpoint policy does not allow
+var ErrEndpointRejected = errors.New("LLM endpoint rejected")
+
+// EndpointPolicy restricts where prompts, and the logs in them, may be sent
+// By default only HTTPS endpoints are allowed. The policy is read from the environment:
+// OPENAI_ALLOW_HTTP allows plain HTTP (e.g. a local model server), OPENAI_ALLOWED_HOSTS lists the
+// allowed hosts ("api.openai.com,*.openai.azure.com") and OPENAI_CERT_SHA256 pins the SHA-256
+// fingerprints of certificates, one of which the server's chain must contain. Pinned certificates
+// require HTTPS, even with OPENAI_ALLOW_HTTP.
+type EndpointPolicy struct {
+	AllowHTTP    bool
+	AllowedHosts []string // exact host names or "*.domain" wildcards; empty allows every host
+	CertSHA256   []string // lowercase hex SHA-256 fingerprints of DER certificates; empty disables pinning
+}
+
+// endpointPolicyFromEnv reads the endpoint policy from the environment
+func endpointPolicyFromEnv() (EndpointPolicy, error) {
+	var policy EndpointPolicy
+	if value := os.Getenv("OPENAI_ALLOW_HTTP"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid OPENAI_ALLOW_HTTP %q: %w", value, err)
+		}
+		policy.AllowHTTP = allow
+	}
+	for _, host := range strings.Split(os.Getenv("OPENAI_ALLOWED_HOSTS"), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			policy.AllowedHosts = append(policy.AllowedHosts, host)
+		}
+	}
+	for _, pin := range strings.Split(os.Getenv("OPENAI_CERT_SHA256"), ",") {
+		if pin = strings.TrimSpace(pin); pin == "" {
+			continue
+		}
+		fingerprint, err := parseFingerprint(pin)
+		if err != nil {
+			return policy, err
+		}
+		policy.CertSHA256 = append(policy.CertSHA256, fingerprint)
+	}
+	return policy, nil
+}
+
+// parseFingerprint normalizes a SHA-256 fingerprint such as "AB:CD:..." (openssl x509 -fingerprint -sha256)
+func parseFingerprint(pin string) (string, error) {
+	fingerprint := strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(pin))
+	if b, err := hex.DecodeString(fingerprint); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid OPENAI_CERT_SHA256 fingerprint %q, expected 64 hex digits", pin)
+	}
+	return fingerprint, nil
+}
+
+// Check returns an error wrapping ErrEndpointRejected when the policy does not allow rawURL
+func (p EndpointPolicy) Check(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid URL %q: %v", ErrEndpointRejected, rawURL, err)
+	}
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !p.AllowHTTP {
+			return fmt.Errorf("%w: %s uses plain HTTP, set OPENAI_ALLOW_HTTP=true to allow it", ErrEndpointRejected, u.Redacted())
+		}
+		// Without TLS there is no certificate to check, the pins would silently not apply
+		if len(p.CertSHA256) > 0 {
+			return fmt.Errorf("%w: %s uses plain HTTP, which OPENAI_CERT_SHA256 pinning does not allow", ErrEndpointRejected, u.Redacted())
+		}
+	default:
+		return fmt.Errorf("%w: %s is not an HTTP(S) URL", ErrEndpointRejected, u.Redacted())
+	}
+	if len(p.AllowedHosts) > 0 && !p.hostAllowed(u.Hostname()) {
+		return fmt.Errorf("%w: host %s is not in OPENAI_ALLOWED_HOSTS", ErrEndpointRejected, u.Hostname())
+	}
+	return nil
+}
+
+// hostAllowed reports whether host matches one of the AllowedHosts
+func (p EndpointPolicy) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range p.AllowedHosts {
+		if host == allowed {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyPins rejects TLS connections whose certificate chain contains none of the pinned certificates
+// The regular certificate verification still applies; pinning only narrows it.
+func (p EndpointPolicy) verifyPins(state tls.ConnectionState) error {
+	for _, cert := range state.PeerCertificates {
+		sum := sha256.Sum256(cert.Raw)
+		if containsString(p.CertSHA256, hex.EncodeToString(sum[:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: certificate of %s matches none of the OPENAI_CERT_SHA256 fingerprints", ErrEndpointRejected, state.ServerName)
+}
+
+// guardedTransport checks every request, including redirects, against the endpoint policy
+type guardedTransport struct {
+	policy EndpointPolicy
+	err    error // configuration error of the policy, fails every request
+	base   http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if err := t.policy.Check(req.URL.String()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newEndpointClient returns the HTTP client of the LLM API, which refuses requests the policy does not
+// allow and, when fingerprints are pinned, connections to servers presenting other certificates
+func newEndpointClient(policy EndpointPolicy, err error) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(policy.CertSHA256) > 0 {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.VerifyConnection = policy.verifyPins
+	}
+	return &http.Client{Transport: &guardedTransport{policy: policy, err: err, base: transport}}
+}
@@ -0,0 +1,36 @@
+package debugger
+
+// ProposalEnricher post-processes a parsed FixProposal before the report is generated,
+// e.g. to link runbooks, tag owners or add issue tracker references
+type ProposalEnricher interface {
+	// Name identifies the enricher in logs
+	Name() string
+	// Enrich adds information to proposal
+	Enrich(run *WorkflowRun, proposal *FixProposal) error
+}
+
+// ReportSection is an additional report section contributed by an enricher
+type ReportSection struct {
+	Title   string
+	Content string // markdown
+}
+
+// enricherRegistry holds the enrichers run after each analysis, in registration order
+var enricherRegistry = []ProposalEnricher{
+	codeOwnersEnricher{},
+}
+
+// RegisterEnricher adds an enricher to the registry used for all subsequent analyses
+func RegisterEnricher(enricher ProposalEnricher) {
+	enricherRegistry = append(enricherRegistry, enricher)
+}
+
+// runEnrichers applies all registered enrichers to proposal
+// A failing enricher is logged and skipped, it never fails the analysis.
+func runEnrichers(run *WorkflowRun, proposal *FixProposal) {
+	for _, enricher := range enricherRegistry {
+		if err := enricher.Enrich(run, proposal); err != nil {
+			logWarnf("enricher %s failed: %v", enricher.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxEnvironmentDetails bounds the environment lines of the logs shown in the prompt
+const maxEnvironmentDetails = 12
+
+// RunnerInfo describes the runner a failed job ran on
+type RunnerInfo struct {
+	Job         string
+	Labels      []string // runs-on labels, e.g. ["ubuntu-22.04"] or ["self-hosted", "linux", "gpu"]
+	RunnerName  string
+	RunnerGroup string
+	SelfHosted  bool
+}
+
+// Environment is what is known about the platform and tools of the failed jobs
+type Environment struct {
+	Runners []RunnerInfo // runners of the failed jobs, from the jobs API
+	Details []string     // environment lines of the logs, e.g. "Runner Image: ubuntu-22.04" or "go version go1.21.5 linux/amd64"
+}
+
+var (
+	// toolVersionRes match the version output of common tools and setup actions
+	toolVersionRes = []*regexp.Regexp{
+		regexp.MustCompile(`^Current runner version: '?[\d.]+'?$`),
+		regexp.MustCompile(`\bgo version go\d\S* \S+/\S+`),
+		regexp.MustCompile(`^(?:node|npm|yarn|pnpm|bun|deno): v?\d+\.\d+\S*$`),
+		regexp.MustCompile(`^Python \d+\.\d+\.\d+\S*$`),
+		regexp.MustCompile(`^Successfully set up (?:CPython|PyPy) \(.+\)$`),
+		regexp.MustCompile(`^(?:openjdk|java) version "[^"]+"`),
+		regexp.MustCompile(`^rustc \d+\.\d+\.\d+\S*(?: \(.*\))?$`),
+		regexp.MustCompile(`^cargo \d+\.\d+\.\d+\S*(?: \(.*\))?$`),
+		regexp.MustCompile(`^Docker version \d+\.\d+\.\d+`),
+		regexp.MustCompile(`^Apache Maven \d+\.\d+\.\d+`),
+		regexp.MustCompile(`^Gradle \d+\.\d+`),
+		regexp.MustCompile(`^ruby \d+\.\d+\.\d+\S*`),
+	}
+	// versionOnlyRe matches the bare output of "node -v" style commands, e.g. "v20.10.0"
+	versionOnlyRe = regexp.MustCompile(`^v?\d+\.\d+\.\d+\S*$`)
+	// versionCommandRe matches the command printing a bare version, e.g. "$ node -v" or "+ node --version"
+	versionCommandRe = regexp.MustCompile(`(?:^|[\s$+>])([\w.-]+) (?:-v|-V|--version|version)\s*$`)
+)
+
+// environmentBlocks are the "Set up job" blocks of GitHub-hosted runners whose indented lines are joined,
+// e.g. "Runner Image" followed by "  Image: ubuntu-22.04" and "  Version: 20231217.2.0"
+var environmentBlocks = []string{"Operating System", "Runner Image", "Virtual Environment"}
+
+// extractEnvironmentDetails returns the lines of the logs describing the runner and tool versions,
+// in log order without duplicates
+func extractEnvironmentDetails(logs string) []string {
+	var details []string
+	seen := make(map[string]bool)
+	add := func(detail string) {
+		detail = strings.Join(strings.Fields(detail), " ")
+		if detail != "" && !seen[detail] && len(details) < maxEnvironmentDetails {
+			seen[detail] = true
+			details = append(details, detail)
+		}
+	}
+
+	lines := strings.Split(logs, "\n")
+	for i := 0; i < len(lines); i++ {
+		content := strings.TrimRight(logContent(lines[i]), " \r")
+		trimmed := strings.TrimSpace(content)
+
+		// "Runner Image" and similar headings with their indented values below
+		if isEnvironmentBlock(trimmed) {
+			var values []string
+			for i+1 < len(lines) {
+				next := strings.TrimRight(logContent(lines[i+1]), " \r")
+				if !strings.HasPrefix(next, " ") || strings.TrimSpace(next) == "" {
+					break
+				}
+				values = append(values, strings.TrimSpace(next))
+				i++
+			}
+			if len(values) > 0 {
+				add(trimmed + ": " + strings.Join(values, ", "))
+			}
+			continue
+		}
+
+		// Bare version printed by the command on the line before, e.g. "node -v" -> "v20.10.0"
+		if versionOnlyRe.MatchString(trimmed) && i > 0 {
+			if m := versionCommandRe.FindStringSubmatch(strings.TrimSpace(logContent(lines[i-1]))); m != nil {
+				add(m[1] + " " + trimmed)
+				continue
+			}
+		}
+
+		for _, re := range toolVersionRes {
+			if m := re.FindString(trimmed); m != "" {
+				add(m)
+				break
+			}
+		}
+	}
+	return details
+}
+
+// isEnvironmentBlock reports whether a line is the heading of one of the environmentBlocks
+func isEnvironmentBlock(line string) bool {
+	for _, block := range environmentBlocks {
+		if line == block {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchRunners returns the runners of the failed jobs of the run (or of its analyzed job)
+func fetchRunners(run *WorkflowRun) ([]RunnerInfo, error) {
+	output, err := gh("api", fmt.Sprintf("repos/%s/actions/runs/%s/jobs?per_page=100", run.Repository, run.RunID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+
+	var data struct {
+		Jobs []struct {
+			ID              int64    `json:"id"`
+			Name            string   `json:"name"`
+			Conclusion      string   `json:"conclusion"`
+			Labels          []string `json:"labels"`
+			RunnerName      string   `json:"runner_name"`
+			RunnerGroupName string   `json:"runner_group_name"`
+		} `json:"jobs"`
+	}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs: %w", err)
+	}
+
+	var runners []RunnerInfo
+	for _, job := range data.Jobs {
+		if run.JobID != "" && fmt.Sprint(job.ID) != run.JobID {
+			continue
+		}
+		if run.JobID == "" && job.Conclusion != "failure" && job.Conclusion != "timed_out" {
+			continue
+		}
+		info := RunnerInfo{Job: job.Name, Labels: job.Labels, RunnerName: job.RunnerName, RunnerGroup: job.RunnerGroupName}
+		info.SelfHosted = containsString(job.Labels, "self-hosted") ||
+			(job.RunnerGroupName != "" && job.RunnerGroupName != "GitHub Actions")
+		runners = append(runners, info)
+	}
+	return runners, nil
+}
+
+// writeEnvironment writes the environment section of the prompt
+func writeEnvironment(sb *strings.Builder, env *Environment) {
+	if env == nil || (len(env.Runners) == 0 && len(env.Details) == 0) {
+		return
+	}
+
+	sb.WriteString("## Environment\n")
+	for i, r := range env.Runners {
+		if i >= 5 {
+			sb.WriteString(fmt.Sprintf("- ... and %d more failed jobs\n", len(env.Runners)-5))
+			break
+		}
+		kind := "GitHub-hosted"
+		if r.SelfHosted {
+			kind = "self-hosted"
+		}
+		line := fmt.Sprintf("- Job %q: runs-on [%s], %s runner", r.Job, strings.Join(r.Labels, ", "), kind)
+		if r.RunnerName != "" {
+			line += fmt.Sprintf(" %q", r.RunnerName)
+		}
+		if r.SelfHosted && r.RunnerGroup != "" {
+			line += fmt.Sprintf(" (group %q)", r.RunnerGroup)
+		}
+		sb.WriteString(line + "\n")
+	}
+	for _, detail := range env.Details {
+		sb.WriteString(fmt.Sprintf("- %s\n", truncateText(detail, 200)))
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,75 @@
+package debugger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setupLogs are the "Set up job" and setup step lines of a job on a GitHub-hosted runner
+const setupLogs = "build\tSet up job\t2024-01-01T00:00:00.0000000Z Current runner version: '2.311.0'\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z Operating System\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z   Ubuntu\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z   22.04.3\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z   LTS\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z Runner Image\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z   Image: ubuntu-22.04\n" +
+	"build\tSet up job\t2024-01-01T00:00:00.0000000Z   Version: 20231217.2.0\n" +
+	"build\tSet up job\t2024-01-01T00:00:01.0000000Z Prepare workflow directory\n" +
+	"build\tRun actions/setup-go@v5\t2024-01-01T00:00:02.0000000Z go version go1.21.5 linux/amd64\n" +
+	"build\tRun node -v\t2024-01-01T00:00:03.0000000Z $ node -v\n" +
+	"build\tRun node -v\t2024-01-01T00:00:03.0000000Z v20.10.0\n" +
+	"build\tRun tests\t2024-01-01T00:00:04.0000000Z v1.2.3 released\n" +
+	"build\tRun tests\t2024-01-01T00:00:05.0000000Z go version go1.21.5 linux/amd64\n" +
+	"build\tRun tests\t2024-01-01T00:00:06.0000000Z Python 3.12.1"
+
+func TestExtractEnvironmentDetails(t *testing.T) {
+	want := []string{
+		"Current runner version: '2.311.0'",
+		"Operating System: Ubuntu, 22.04.3, LTS",
+		"Runner Image: Image: ubuntu-22.04, Version: 20231217.2.0",
+		"go version go1.21.5 linux/amd64",
+		"node v20.10.0",
+		"Python 3.12.1",
+	}
+	if got := extractEnvironmentDetails(setupLogs); !reflect.DeepEqual(got, want) {
+		t.Errorf("extractEnvironmentDetails() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	var many []string
+	for i := 0; i < 2*maxEnvironmentDetails; i++ {
+		many = append(many, "Python 3.12."+strings.Repeat("1", i+1))
+	}
+	if got := extractEnvironmentDetails(strings.Join(many, "\n")); len(got) != maxEnvironmentDetails {
+		t.Errorf("extracted %d details, want at most %d", len(got), maxEnvironmentDetails)
+	}
+}
+
+func TestFetchRunners(t *testing.T) {
+	stubGitHub(t, map[string]string{"/repos/o/r/actions/runs/1/jobs": `{"total_count":3,"jobs":[
+		{"id":1,"name":"lint","conclusion":"success","labels":["ubuntu-latest"],"runner_group_name":"GitHub Actions"},
+		{"id":2,"name":"build","conclusion":"failure","labels":["ubuntu-22.04"],"runner_name":"GitHub Actions 4","runner_group_name":"GitHub Actions"},
+		{"id":3,"name":"gpu","conclusion":"timed_out","labels":["self-hosted","linux","gpu"],"runner_name":"gpu-1","runner_group_name":"ml"}]}`})
+
+	runners, err := fetchRunners(&WorkflowRun{Repository: "o/r", RunID: "1"})
+	if err != nil {
+		t.Fatalf("fetchRunners() error = %v", err)
+	}
+	want := []RunnerInfo{
+		{Job: "build", Labels: []string{"ubuntu-22.04"}, RunnerName: "GitHub Actions 4", RunnerGroup: "GitHub Actions"},
+		{Job: "gpu", Labels: []string{"self-hosted", "linux", "gpu"}, RunnerName: "gpu-1", RunnerGroup: "ml", SelfHosted: true},
+	}
+	if !reflect.DeepEqual(runners, want) {
+		t.Errorf("fetchRunners() = %+v, want %+v", runners, want)
+	}
+
+	var sb strings.Builder
+	writeEnvironment(&sb, &Environment{Runners: runners, Details: []string{"go version go1.21.5 linux/amd64"}})
+	wantSection := "## Environment\n" +
+		"- Job \"build\": runs-on [ubuntu-22.04], GitHub-hosted runner \"GitHub Actions 4\"\n" +
+		"- Job \"gpu\": runs-on [self-hosted, linux, gpu], self-hosted runner \"gpu-1\" (group \"ml\")\n" +
+		"- go version go1.21.5 linux/amd64\n\n"
+	if sb.String() != wantSection {
+		t.Errorf("writeEnvironment() =\n%s\nwant\n%s", sb.String(), wantSection)
+	}
+}
@@ -0,0 +1,93 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// stubExampleAPIs serves the example's run from stand-ins for the GitHub and OpenAI APIs and
+// returns a function restoring the environment
+func stubExampleAPIs() func() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/app/actions/runs/42":
+			fmt.Fprint(w, `{"status":"completed","conclusion":"failure","name":"CI"}`)
+		case "/repos/octo/app/actions/runs/42/jobs":
+			fmt.Fprint(w, `{"total_count":1,"jobs":[{"id":7,"name":"build","status":"completed","conclusion":"failure",
+				"steps":[{"name":"Run go build","number":1,"status":"completed","conclusion":"failure"}]}]}`)
+		case "/repos/octo/app/actions/jobs/7/logs":
+			fmt.Fprint(w, "2024-01-01T00:00:00.0000000Z main.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html\n"+
+				"2024-01-01T00:00:01.0000000Z ##[error]Process completed with exit code 1.\n")
+		case "/v1/chat/completions":
+			json.NewEncoder(w).Encode(answer(testAnswer)(openai.ChatCompletionRequest{}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	}))
+
+	env := map[string]string{
+		"GITHUB_API_URL":    srv.URL,
+		"GITHUB_TOKEN":      "example-token",
+		"OPENAI_BASE_URL":   srv.URL + "/v1",
+		"OPENAI_ALLOW_HTTP": "true",
+	}
+	saved := make(map[string]*string)
+	for key, value := range env {
+		if old, ok := os.LookupEnv(key); ok {
+			saved[key] = &old
+		} else {
+			saved[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+	backendOnce.Do(func() {})
+	savedBackend, savedErr := backend, backendErr
+	backend, backendErr = backendREST, nil
+
+	return func() {
+		backend, backendErr = savedBackend, savedErr
+		for key, old := range saved {
+			if old == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *old)
+			}
+		}
+		srv.Close()
+	}
+}
+
+func ExampleGitHubWorkflowDebugger_DebugRun() {
+	// Stand-ins for the GitHub and OpenAI APIs; against the real ones, the gh CLI (or GITHUB_TOKEN)
+	// and an OpenAI API key are all that is needed
+	defer stubExampleAPIs()()
+
+	d := New("sk-example")
+	d.Options.Quiet = true
+	ref, err := NewRunRef("octo/app", "42", "")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	result, err := d.DebugRun(context.Background(), ref)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("Root cause:", result.Proposal.RootCause)
+	fmt.Println("Fix:", result.Proposal.ProposedFix)
+	fmt.Println("Files:", result.Proposal.FilesToCheck)
+	fmt.Println("Confidence:", result.Proposal.Confidence)
+	// Output:
+	// Root cause: The go.sum entry of golang.org/x/net is missing.
+	// Fix: Run go mod tidy and commit go.sum.
+	// Files: [go.sum main.go:5]
+	// Confidence: High - the error names the module.
+}
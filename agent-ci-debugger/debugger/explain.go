@@ -0,0 +1,80 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxEvidenceLines is the maximum number of cited log lines shown in the report
+const maxEvidenceLines = 10
+
+// citedLineRe matches a reference to a numbered log line of the prompt, e.g. "[L12]" or "[L12, L15]"
+var citedLineRe = regexp.MustCompile(`\bL(\d+)\b`)
+
+// citationRe matches the bracketed citations the model is asked to write, e.g. "[L12]", "[L12, L15]" or "[L12-L14]"
+var citationRe = regexp.MustCompile(`\[\s*L\d+(?:\s*[,-]\s*L?\d+)*\s*\]`)
+
+// Evidence is a log line the model cited for its conclusion (Options.Explain)
+type Evidence struct {
+	Line int    // number of the line in the prompt, as cited
+	Text string // the log line
+}
+
+// numberLogLines prefixes the log lines of a prompt section with "[L<n>] ", continuing after the
+// lines already in numbered, and returns the numbered section and all numbered lines so far
+// Group headers, truncation markers, context separators and blank lines are not numbered.
+func numberLogLines(section string, numbered []string) (string, []string) {
+	var sb strings.Builder
+	lines := strings.Split(section, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(line, "==> ") && !isTruncationMarker(line) && line != "--" {
+			numbered = append(numbered, line)
+			sb.WriteString(fmt.Sprintf("[L%d] ", len(numbered)))
+		}
+		sb.WriteString(line)
+		if i < len(lines)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String(), numbered
+}
+
+// citedEvidence maps the line citations of text back to the numbered log lines of the prompt
+// Lines are returned in log order without duplicates; numbers outside the prompt are dropped.
+func citedEvidence(text string, numbered []string) []Evidence {
+	var evidence []Evidence
+	seen := make(map[int]bool)
+	for _, citation := range citationRe.FindAllString(text, -1) {
+		var refs []int
+		for _, m := range citedLineRe.FindAllStringSubmatch(citation, -1) {
+			n, _ := strconv.Atoi(m[1])
+			refs = append(refs, n)
+		}
+		// "[L12-L14]" cites the whole range
+		if len(refs) == 2 && strings.Contains(citation, "-") && refs[0] < refs[1] && refs[1]-refs[0] < maxEvidenceLines {
+			for n := refs[0] + 1; n < refs[1]; n++ {
+				refs = append(refs, n)
+			}
+		}
+		for _, n := range refs {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			if n < 1 || n > len(numbered) {
+				logDebugf("Ignoring citation of log line L%d, the prompt has %d numbered lines", n, len(numbered))
+				continue
+			}
+			evidence = append(evidence, Evidence{Line: n, Text: strings.TrimSpace(numbered[n-1])})
+		}
+	}
+	sort.Slice(evidence, func(i, j int) bool { return evidence[i].Line < evidence[j].Line })
+	if len(evidence) > maxEvidenceLines {
+		evidence = evidence[:maxEvidenceLines]
+	}
+	return evidence
+}
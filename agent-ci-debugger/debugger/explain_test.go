@@ -0,0 +1,80 @@
+package debugger
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestNumberLogLines(t *testing.T) {
+	section := "==> build / Run tests <==\nfirst\n\n" + truncationMarker("10 lines omitted") + "\nsecond\n--\nthird"
+	got, numbered := numberLogLines(section, []string{"earlier"})
+	want := "==> build / Run tests <==\n[L2] first\n\n" + truncationMarker("10 lines omitted") + "\n[L3] second\n--\n[L4] third"
+	if got != want {
+		t.Errorf("numberLogLines() =\n%s\nwant\n%s", got, want)
+	}
+	if want := []string{"earlier", "first", "second", "third"}; !reflect.DeepEqual(numbered, want) {
+		t.Errorf("numbered = %q, want %q", numbered, want)
+	}
+}
+
+func TestCitedEvidence(t *testing.T) {
+	numbered := []string{"  a", "b", "c", "d", "e"}
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{text: "The build fails [L2].", want: []int{2}},
+		{text: "See [L4, L1] and again [L1].", want: []int{1, 4}},
+		{text: "The range [L2-L4] shows it.", want: []int{2, 3, 4}},
+		{text: "Out of the prompt [L9], [L0].", want: nil},
+		{text: "No citation, only a bare L3.", want: nil},
+	}
+	for _, tt := range tests {
+		var lines []int
+		for _, e := range citedEvidence(tt.text, numbered) {
+			lines = append(lines, e.Line)
+			if e.Text != strings.TrimSpace(numbered[e.Line-1]) {
+				t.Errorf("citedEvidence(%q) line %d text = %q", tt.text, e.Line, e.Text)
+			}
+		}
+		if !reflect.DeepEqual(lines, tt.want) {
+			t.Errorf("citedEvidence(%q) lines = %v, want %v", tt.text, lines, tt.want)
+		}
+	}
+}
+
+// numberedErrorRe finds the number of the go.sum error line in an explain prompt
+var numberedErrorRe = regexp.MustCompile(`\[L(\d+)\] [^\n]*missing go\.sum entry`)
+
+func TestExplainCitedLinesMapBack(t *testing.T) {
+	var cited string
+	api := &mockOpenAI{reply: func(req openai.ChatCompletionRequest) openai.ChatCompletionResponse {
+		m := numberedErrorRe.FindStringSubmatch(req.Messages[len(req.Messages)-1].Content)
+		if m == nil {
+			t.Errorf("the prompt has no numbered go.sum error line")
+			return answer(testAnswer)(req)
+		}
+		cited = m[1]
+		return answer(strings.Replace(testAnswer, "is missing.", "is missing [L"+cited+"].", 1))(req)
+	}}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, Explain: true}
+
+	run := testRun()
+	proposal, err := d.AnalyzeFailure(context.Background(), run)
+	if err != nil {
+		t.Fatalf("AnalyzeFailure() error = %v", err)
+	}
+	if len(proposal.Evidence) != 1 || !strings.Contains(proposal.Evidence[0].Text, "missing go.sum entry for module providing package golang.org/x/net/html") {
+		t.Fatalf("Evidence = %+v, want the cited line L%s", proposal.Evidence, cited)
+	}
+	report := d.GenerateReport(run, proposal)
+	if !strings.Contains(report, "**Evidence from the logs**") || !strings.Contains(report, "[L"+cited+"] ") {
+		t.Errorf("report does not show the evidence:\n%s", report)
+	}
+}
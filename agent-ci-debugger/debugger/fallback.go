@@ -0,0 +1,126 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ghJob is the subset of a job in `gh run view --json jobs` output
+type ghJob struct {
+	DatabaseID  int64     `json:"databaseId"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+	Steps       []struct {
+		Name       string `json:"name"`
+		Number     int    `json:"number"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"steps"`
+}
+
+// fetchJobs returns the jobs of a run with their conclusions and steps
+func fetchJobs(run *WorkflowRun) ([]ghJob, error) {
+	output, err := gh("run", "view", run.RunID, "--repo", run.Repository, "--json", "jobs")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+
+	var data struct {
+		Jobs []ghJob `json:"jobs"`
+	}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse jobs: %w", err)
+	}
+	return data.Jobs, nil
+}
+
+// gatherFallbackLogs collects something to analyze when no failed logs are available,
+// e.g. when the failure happened during job setup. Full logs are tried first, then the
+// job/step conclusions and check-run annotations are summarized as text.
+// Returns false when nothing could be retrieved.
+func (d *GitHubWorkflowDebugger) gatherFallbackLogs(run *WorkflowRun) bool {
+	if run.Provider == ProviderGitLab {
+		// The traces of the failed jobs are all GitLab has
+		return false
+	}
+	logDebugf("Fetching full logs as fallback...")
+	args := []string{"run", "view", run.RunID, "--repo", run.Repository, "--log"}
+	if run.JobID != "" {
+		args = append(args, "--job", run.JobID)
+	}
+	if output, err := ghLogs(d.Options.MaxLogBytes, args...); err != nil {
+		logWarnf("failed to get full logs: %v", err)
+	} else if strings.TrimSpace(string(output)) != "" {
+		logInfof("Using full logs as fallback (%d bytes)", len(output))
+		run.FullLogs = string(output)
+		run.FailedLogs = run.FullLogs
+		d.parseLogs(run)
+		return true
+	}
+
+	logDebugf("Fetching job conclusions and annotations as fallback...")
+	jobs, err := fetchJobs(run)
+	if err != nil {
+		logWarnf("%v", err)
+		return false
+	}
+
+	var sb strings.Builder
+	for _, job := range jobs {
+		if job.Conclusion == "success" || job.Conclusion == "skipped" {
+			continue
+		}
+		if run.JobID != "" && fmt.Sprint(job.DatabaseID) != run.JobID {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("Job %q: status %s, conclusion %s\n", job.Name, job.Status, job.Conclusion))
+		for _, step := range job.Steps {
+			if step.Conclusion != "success" && step.Conclusion != "skipped" {
+				sb.WriteString(fmt.Sprintf("  Step %d %q: status %s, conclusion %s\n", step.Number, step.Name, step.Status, step.Conclusion))
+			}
+		}
+
+		annotations, err := fetchAnnotations(run.Repository, job.DatabaseID)
+		if err != nil {
+			logWarnf("%v", err)
+			continue
+		}
+		for _, a := range annotations {
+			a.Job = job.Name
+			run.ErrorSummary.Annotations = append(run.ErrorSummary.Annotations, a)
+			sb.WriteString(fmt.Sprintf("  Annotation [%s] %s:%d: %s\n", a.Level, a.Path, a.StartLine, a.Message))
+		}
+	}
+
+	if sb.Len() == 0 {
+		return false
+	}
+
+	logInfof("Using job conclusions and annotations as fallback (%d chars)", sb.Len())
+	run.FailedLogs = sb.String()
+	d.parseLogs(run)
+	return true
+}
+
+// noLogsProposal is the report content used when nothing could be retrieved for a run
+func noLogsProposal(run *WorkflowRun) *FixProposal {
+	proposal := &FixProposal{
+		RootCause: "No logs could be retrieved for this run, so no AI analysis was performed.",
+		Analysis: "Neither the failed job logs, the full logs, nor the job conclusions and annotations " +
+			"returned any content. This usually means the run failed before any job started " +
+			"(e.g. an invalid workflow file or a runner allocation problem) or the logs have expired.",
+		ProposedFix: fmt.Sprintf("Check the run manually: %s\n\n"+
+			"- Look for a workflow file error banner at the top of the run page\n"+
+			"- Verify the logs have not expired (retention period)\n"+
+			"- Re-run the workflow to reproduce the failure with fresh logs", run.URL),
+		Confidence: "Low",
+	}
+	proposal.Summary = oneLineSummary("", proposal)
+	return proposal
+}
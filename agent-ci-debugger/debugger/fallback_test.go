@@ -0,0 +1,89 @@
+package debugger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fallbackRoutes are the REST routes of run 1 of o/r, with one failed job (7) that has no logs
+func fallbackRoutes() map[string]string {
+	return map[string]string{
+		"/repos/o/r/actions/runs/1": `{"status":"completed","conclusion":"failure","name":"CI"}`,
+		"/repos/o/r/actions/runs/1/jobs": `{"total_count":2,"jobs":[
+			{"id":6,"name":"lint","status":"completed","conclusion":"success"},
+			{"id":7,"name":"build","status":"completed","conclusion":"failure",
+			 "steps":[{"name":"Set up job","number":1,"status":"completed","conclusion":"success"},
+			          {"name":"Run make","number":2,"status":"completed","conclusion":"failure"}]}]}`,
+	}
+}
+
+func TestGatherFallbackLogsFullLogs(t *testing.T) {
+	routes := fallbackRoutes()
+	routes["/repos/o/r/actions/jobs/6/logs"] = "2024-01-01T00:00:00.0000000Z lint ok\n"
+	routes["/repos/o/r/actions/jobs/7/logs"] = "2024-01-01T00:00:00.0000000Z make: *** [all] Error 2\n"
+	stubGitHub(t, routes)
+
+	d := &GitHubWorkflowDebugger{}
+	run := &WorkflowRun{RunID: "1", Repository: "o/r"}
+	if !d.gatherFallbackLogs(run) {
+		t.Fatal("gatherFallbackLogs() = false, want the full logs")
+	}
+	if !strings.Contains(run.FullLogs, "make: *** [all] Error 2") || run.FailedLogs != run.FullLogs {
+		t.Errorf("FailedLogs = %q, FullLogs = %q, want the full logs", run.FailedLogs, run.FullLogs)
+	}
+}
+
+func TestGatherFallbackLogsConclusions(t *testing.T) {
+	routes := fallbackRoutes()
+	routes["/repos/o/r/check-runs/7/annotations"] = `[{"path":".github","start_line":0,"annotation_level":"failure",
+		"message":"The job running on runner GitHub Actions 2 has exceeded the maximum execution time"}]`
+	stubGitHub(t, routes)
+
+	d := &GitHubWorkflowDebugger{}
+	run := &WorkflowRun{RunID: "1", Repository: "o/r"}
+	if !d.gatherFallbackLogs(run) {
+		t.Fatal("gatherFallbackLogs() = false, want the job conclusions")
+	}
+	for _, want := range []string{
+		`Job "build": status completed, conclusion failure`,
+		`Step 2 "Run make": status completed, conclusion failure`,
+		"Annotation [failure] .github:0: The job running on runner",
+	} {
+		if !strings.Contains(run.FailedLogs, want) {
+			t.Errorf("FailedLogs = %q, want it to contain %q", run.FailedLogs, want)
+		}
+	}
+	if strings.Contains(run.FailedLogs, "lint") {
+		t.Errorf("FailedLogs = %q, want the successful job left out", run.FailedLogs)
+	}
+	if len(run.ErrorSummary.Annotations) != 1 || run.ErrorSummary.Annotations[0].Job != "build" {
+		t.Errorf("Annotations = %+v, want the annotation of the build job", run.ErrorSummary.Annotations)
+	}
+}
+
+func TestDebugRunWithoutLogs(t *testing.T) {
+	stubGitHub(t, map[string]string{
+		"/repos/o/r/actions/runs/1":      `{"status":"completed","conclusion":"failure"}`,
+		"/repos/o/r/actions/runs/1/jobs": `{"total_count":0,"jobs":[]}`,
+	})
+
+	// No AI client is configured: the analysis must not be attempted
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true}}
+	run := &WorkflowRun{RunID: "1", Repository: "o/r", URL: "https://github.com/o/r/actions/runs/1", Status: "completed", Conclusion: "failure"}
+	result, err := d.debugRun(context.Background(), run, time.Now())
+	if err != nil {
+		t.Fatalf("debugRun() error = %v", err)
+	}
+	want := noLogsProposal(run)
+	if result.Proposal.RootCause != want.RootCause || result.Proposal.Confidence != "Low" {
+		t.Errorf("Proposal = %+v, want the no-logs proposal", result.Proposal)
+	}
+	if !strings.Contains(result.Report, run.URL) {
+		t.Errorf("Report does not link the run:\n%s", result.Report)
+	}
+	if !result.Metrics.Success {
+		t.Error("Metrics.Success = false, want true")
+	}
+}
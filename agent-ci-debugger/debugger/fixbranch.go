@@ -0,0 +1,166 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrFixBranchNotConfirmed is returned when a fix branch would be pushed without Options.Yes
+var ErrFixBranchNotConfirmed = errors.New("--fix-branch commits, pushes and opens a pull request; confirm with --yes")
+
+// fixBranchName returns the branch of the fix PR, derived from the run so reruns reuse it
+func fixBranchName(run *WorkflowRun, name string) string {
+	if name != "" {
+		return name
+	}
+	branch := "workflow-debugger/fix-run-" + run.RunID
+	if run.JobID != "" {
+		branch += "-job-" + run.JobID
+	}
+	return branch
+}
+
+// git runs git in the current directory, the local checkout of the repository
+func git(args ...string) (string, error) {
+	return gitWithInput("", args...)
+}
+
+// gitWithInput runs git with input on stdin
+func gitWithInput(input string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// changesPatch joins the diffs of the code changes into one patch
+// Models often write diffs without file headers, those are added from CodeChange.File.
+func changesPatch(changes []CodeChange) (string, error) {
+	var sb strings.Builder
+	for i, change := range changes {
+		snippet := strings.Trim(change.DiffSnippet, "\n")
+		if snippet == "" {
+			continue
+		}
+		if !strings.Contains(snippet, "\n+++ ") && !strings.HasPrefix(snippet, "--- ") {
+			if change.File == "" {
+				return "", fmt.Errorf("change %d has no file to apply it to", i+1)
+			}
+			sb.WriteString(fmt.Sprintf("--- a/%s\n+++ b/%s\n", change.File, change.File))
+		}
+		sb.WriteString(snippet)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// CreateFixBranch applies the proposal's code changes on a new branch, pushes it and opens a pull request
+// with the report as its body. It runs in the current directory, which must be a clean checkout of the
+// run's repository. Nothing is changed unless all diffs apply cleanly and the confidence is at least
+// Medium. It returns the URL of the pull request.
+func (d *GitHubWorkflowDebugger) CreateFixBranch(run *WorkflowRun, proposal *FixProposal, report string) (string, error) {
+	if !d.Options.Yes {
+		return "", ErrFixBranchNotConfirmed
+	}
+	level := confidenceLevel(proposal.Confidence)
+	if level != "High" && level != "Medium" {
+		logInfof("Skipping fix branch - confidence is %q, at least Medium required", proposal.Confidence)
+		return "", nil
+	}
+	patch, err := changesPatch(proposal.CodeChanges)
+	if err != nil {
+		return "", err
+	}
+	if patch == "" {
+		logInfof("Skipping fix branch - the analysis proposes no code changes as diffs")
+		return "", nil
+	}
+
+	if status, err := git("status", "--porcelain", "--untracked-files=no"); err != nil {
+		return "", fmt.Errorf("--fix-branch must run in a checkout of %s: %w", run.Repository, err)
+	} else if status != "" {
+		return "", fmt.Errorf("the working tree has uncommitted changes, commit or stash them before --fix-branch")
+	}
+	if _, err := gitWithInput(patch, "apply", "--check", "--recount", "-"); err != nil {
+		return "", fmt.Errorf("the proposed changes do not apply cleanly, no branch created: %w", err)
+	}
+
+	// Branch off the commit that failed when the checkout has it, so the PR fixes exactly that commit
+	base := "HEAD"
+	if run.HeadSHA != "" {
+		if _, err := git("cat-file", "-e", run.HeadSHA+"^{commit}"); err == nil {
+			base = run.HeadSHA
+		} else {
+			logWarnf("commit %s of the run is not in the checkout, branching off HEAD", shortSHA(run.HeadSHA))
+		}
+	}
+	previous, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if previous == "HEAD" {
+		// Detached, e.g. in actions/checkout
+		if previous, err = git("rev-parse", "HEAD"); err != nil {
+			return "", err
+		}
+	}
+
+	// -B resets the branch of an earlier run of the same failure to the base
+	branch := fixBranchName(run, d.Options.FixBranchName)
+	logInfof("Creating branch %s from %s", branch, base)
+	if _, err := git("checkout", "-B", branch, base); err != nil {
+		return "", err
+	}
+	restore := func() {
+		if _, err := git("checkout", previous); err != nil {
+			logWarnf("failed to switch back to %s: %v", previous, err)
+		}
+	}
+	// abandon drops the staged changes and the branch when nothing was committed on it
+	abandon := func() {
+		git("reset", "--hard")
+		restore()
+		git("branch", "-D", branch)
+	}
+
+	if _, err := gitWithInput(patch, "apply", "--index", "--recount", "-"); err != nil {
+		abandon()
+		return "", fmt.Errorf("failed to apply the proposed changes on %s: %w", base, err)
+	}
+	title := fixPRTitle(run)
+	if _, err := git("commit", "-m", title, "-m", proposal.RootCause+"\n\nProposed for "+run.URL); err != nil {
+		abandon()
+		return "", err
+	}
+	logInfof("Committed the proposed changes on %s", branch)
+
+	// The branch of an earlier run is replaced, it only ever holds a proposed fix
+	if _, err := git("push", "--force-with-lease", "--set-upstream", "origin", branch); err != nil {
+		restore()
+		return "", err
+	}
+	output, err := ghWithInput(report, "pr", "create", "--repo", run.Repository, "--head", branch,
+		"--title", title, "--body-file", "-")
+	restore()
+	if err != nil {
+		return "", fmt.Errorf("failed to open the pull request: %w", err)
+	}
+	url := strings.TrimSpace(string(output))
+	logInfof("Opened fix pull request %s", url)
+	return url, nil
+}
+
+// fixPRTitle returns the title of the fix commit and pull request
+func fixPRTitle(run *WorkflowRun) string {
+	if run.WorkflowName != "" {
+		return fmt.Sprintf("Fix %s failure (run %s)", run.WorkflowName, run.RunID)
+	}
+	return fmt.Sprintf("Fix workflow run %s failure", run.RunID)
+}
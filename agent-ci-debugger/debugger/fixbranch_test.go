@@ -0,0 +1,240 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixDiff changes the version in main.go of the repository of gitRepo, without file headers as models write it
+const fixDiff = "@@ -1,3 +1,3 @@\n package main\n \n-var version = \"1.0\"\n+var version = \"1.1\""
+
+// gitRepo creates a checkout with one commit and a bare origin, and makes it the working directory of the test
+func gitRepo(t *testing.T) (checkout, origin string) {
+	t.Helper()
+	dir := t.TempDir()
+	checkout, origin = filepath.Join(dir, "checkout"), filepath.Join(dir, "origin.git")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	for _, args := range [][]string{
+		{"init", "--bare", "-b", "main", origin},
+		{"init", "-b", "main", checkout},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(checkout, "main.go"), []byte("package main\n\nvar version = \"1.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(checkout); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	for _, args := range [][]string{
+		{"add", "main.go"},
+		{"commit", "-m", "initial"},
+		{"remote", "add", "origin", origin},
+	} {
+		if _, err := git(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return checkout, origin
+}
+
+// stubPullRequests serves the REST calls of gh pr create, recording the pull requests opened
+func stubPullRequests(t *testing.T) func() []map[string]interface{} {
+	var mu sync.Mutex
+	var prs []map[string]interface{}
+	stubGitHubHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/o/r":
+			fmt.Fprint(w, `{"default_branch":"main"}`)
+		case "POST /repos/o/r/pulls":
+			var pr map[string]interface{}
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &pr)
+			mu.Lock()
+			prs = append(prs, pr)
+			mu.Unlock()
+			fmt.Fprint(w, `{"html_url":"https://github.com/o/r/pull/9"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	}))
+	return func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return prs
+	}
+}
+
+func TestCreateFixBranch(t *testing.T) {
+	checkout, origin := gitRepo(t)
+	pullRequests := stubPullRequests(t)
+	run := &WorkflowRun{RunID: "1", Repository: "o/r", WorkflowName: "CI", URL: "https://github.com/o/r/actions/runs/1"}
+	proposal := &FixProposal{
+		RootCause:   "The version is outdated.",
+		Confidence:  "High",
+		CodeChanges: []CodeChange{{File: "main.go", DiffSnippet: fixDiff}},
+	}
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true, FixBranch: true, Yes: true}}
+
+	url, err := d.CreateFixBranch(run, proposal, "the report")
+	if err != nil {
+		t.Fatalf("CreateFixBranch() error = %v", err)
+	}
+	if url != "https://github.com/o/r/pull/9" {
+		t.Errorf("CreateFixBranch() = %q, want the pull request URL", url)
+	}
+
+	if branch, _ := git("rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("checked out %q after the fix branch, want main back", branch)
+	}
+	if data, _ := os.ReadFile(filepath.Join(checkout, "main.go")); strings.Contains(string(data), "1.1") {
+		t.Error("the fix was applied to main")
+	}
+	const branch = "workflow-debugger/fix-run-1"
+	if message, err := git("log", "-1", "--format=%s%n%b", branch); err != nil || !strings.HasPrefix(message, "Fix CI failure (run 1)\nThe version is outdated.") {
+		t.Errorf("commit of %s = %q, %v", branch, message, err)
+	}
+	if content, err := git("show", branch+":main.go"); err != nil || !strings.Contains(content, `var version = "1.1"`) {
+		t.Errorf("main.go on %s = %q, %v, want the fix applied", branch, content, err)
+	}
+	if _, err := git("--git-dir", origin, "rev-parse", "--verify", branch); err != nil {
+		t.Errorf("%s was not pushed: %v", branch, err)
+	}
+	prs := pullRequests()
+	if len(prs) != 1 || prs[0]["head"] != branch || prs[0]["base"] != "main" || prs[0]["body"] != "the report" {
+		t.Errorf("pull requests = %v", prs)
+	}
+}
+
+func TestCreateFixBranchRefused(t *testing.T) {
+	checkout, _ := gitRepo(t)
+	pullRequests := stubPullRequests(t)
+	run := &WorkflowRun{RunID: "1", Repository: "o/r"}
+	changes := []CodeChange{{File: "main.go", DiffSnippet: fixDiff}}
+
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true, FixBranch: true}}
+	if _, err := d.CreateFixBranch(run, &FixProposal{Confidence: "High", CodeChanges: changes}, ""); !errors.Is(err, ErrFixBranchNotConfirmed) {
+		t.Errorf("CreateFixBranch() without Yes error = %v", err)
+	}
+	d.Options.Yes = true
+	if url, err := d.CreateFixBranch(run, &FixProposal{Confidence: "Low", CodeChanges: changes}, ""); url != "" || err != nil {
+		t.Errorf("CreateFixBranch() of a low confidence proposal = %q, %v, want it skipped", url, err)
+	}
+	conflicting := []CodeChange{{File: "main.go", DiffSnippet: strings.Replace(fixDiff, "1.0", "0.9", 1)}}
+	if _, err := d.CreateFixBranch(run, &FixProposal{Confidence: "High", CodeChanges: conflicting}, ""); err == nil || !strings.Contains(err.Error(), "do not apply cleanly") {
+		t.Errorf("CreateFixBranch() of a conflicting diff error = %v", err)
+	}
+	os.WriteFile(filepath.Join(checkout, "main.go"), []byte("package main\n"), 0644)
+	if _, err := d.CreateFixBranch(run, &FixProposal{Confidence: "High", CodeChanges: changes}, ""); err == nil || !strings.Contains(err.Error(), "uncommitted changes") {
+		t.Errorf("CreateFixBranch() in a dirty checkout error = %v", err)
+	}
+
+	if branches, _ := git("branch", "--list", "workflow-debugger/*"); branches != "" {
+		t.Errorf("branches created: %s", branches)
+	}
+	if prs := pullRequests(); len(prs) != 0 {
+		t.Errorf("pull requests opened: %v", prs)
+	}
+}
+
+func TestCreateFixBranchRerun(t *testing.T) {
+	_, origin := gitRepo(t)
+	stubPullRequests(t)
+	run := &WorkflowRun{RunID: "1", Repository: "o/r", WorkflowName: "CI", URL: "https://github.com/o/r/actions/runs/1"}
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true, FixBranch: true, Yes: true}}
+
+	// The branch of the first run holds another proposal, the rerun replaces it
+	for _, root := range []string{"First analysis.", "Second analysis."} {
+		proposal := &FixProposal{RootCause: root, Confidence: "High", CodeChanges: []CodeChange{{File: "main.go", DiffSnippet: fixDiff}}}
+		if _, err := d.CreateFixBranch(run, proposal, "the report"); err != nil {
+			t.Fatalf("CreateFixBranch() error = %v", err)
+		}
+	}
+
+	const branch = "workflow-debugger/fix-run-1"
+	if count, err := git("rev-list", "--count", "main.."+branch); err != nil || count != "1" {
+		t.Errorf("%s has %q commits on main, %v, want only the fix of the rerun", branch, count, err)
+	}
+	if message, _ := git("log", "-1", "--format=%b", branch); !strings.HasPrefix(message, "Second analysis.") {
+		t.Errorf("commit of %s = %q, want the second analysis", branch, message)
+	}
+	local, _ := git("rev-parse", branch)
+	if pushed, err := git("--git-dir", origin, "rev-parse", branch); err != nil || pushed != local {
+		t.Errorf("origin has %s at %q, %v, want %q", branch, pushed, err, local)
+	}
+}
+
+func TestCreateFixBranchCommitFails(t *testing.T) {
+	checkout, _ := gitRepo(t)
+	pullRequests := stubPullRequests(t)
+	hook := filepath.Join(checkout, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho rejected >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	run := &WorkflowRun{RunID: "1", Repository: "o/r"}
+	proposal := &FixProposal{Confidence: "High", CodeChanges: []CodeChange{{File: "main.go", DiffSnippet: fixDiff}}}
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true, FixBranch: true, Yes: true}}
+
+	if _, err := d.CreateFixBranch(run, proposal, ""); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("CreateFixBranch() error = %v, want the commit failure", err)
+	}
+	if branch, _ := git("rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("checked out %q, want main back", branch)
+	}
+	if status, _ := git("status", "--porcelain"); status != "" {
+		t.Errorf("the fix was carried onto main:\n%s", status)
+	}
+	if branches, _ := git("branch", "--list", "workflow-debugger/*"); branches != "" {
+		t.Errorf("branches left behind: %s", branches)
+	}
+	if prs := pullRequests(); len(prs) != 0 {
+		t.Errorf("pull requests opened: %v", prs)
+	}
+}
+
+func TestDebugRunReportLinksFixPR(t *testing.T) {
+	gitRepo(t)
+	pullRequests := stubPullRequests(t)
+	api := &mockOpenAI{reply: answer(testAnswer + "\n\n## Code Changes\n### Change 1: main.go\nBump the version.\n```diff\n" + fixDiff + "\n```")}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, FixBranch: true, Yes: true}
+
+	result, err := d.debugRun(context.Background(), testRun(), time.Now())
+	if err != nil {
+		t.Fatalf("debugRun() error = %v", err)
+	}
+	if result.Proposal.FixPR != "https://github.com/o/r/pull/9" {
+		t.Fatalf("FixPR = %q", result.Proposal.FixPR)
+	}
+	if !strings.Contains(result.Report, "**Fix Pull Request**: https://github.com/o/r/pull/9") {
+		t.Errorf("report does not link the pull request:\n%s", result.Report)
+	}
+	if prs := pullRequests(); len(prs) != 1 || !strings.Contains(prs[0]["body"].(string), "## Suggested Code Changes") {
+		t.Errorf("pull requests = %v, want one with the report as body", prs)
+	}
+}
@@ -0,0 +1,307 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFlakyConcurrency is the number of earlier runs whose logs are fetched at the same time
+const DefaultFlakyConcurrency = 4
+
+// Backoff of the history requests when GitHub rate limits them
+const (
+	maxRateLimitRetries  = 4
+	initialRateLimitWait = 2 * time.Second
+	maxRateLimitWait     = time.Minute
+)
+
+// Outcomes of a test in an earlier run
+const (
+	TestPassed  = "pass"
+	TestFailed  = "fail"
+	TestUnknown = "" // the run failed without reporting the test, or its logs were not available
+)
+
+var (
+	// rateLimitRe matches the errors of rate limited GitHub requests
+	rateLimitRe = regexp.MustCompile(`(?i)rate limit|HTTP 429|too many requests|abuse detection`)
+
+	// Names of failed tests in the logs: go test, pytest, cargo test and jest
+	goTestFailRe = regexp.MustCompile(`^\s*--- FAIL: (\S+)`)
+	jestFailRe   = regexp.MustCompile(`^\s*● (.+ › .+)$`)
+)
+
+// HistoryRun is an earlier completed run of the same workflow
+type HistoryRun struct {
+	ID         string
+	Conclusion string
+	HeadSHA    string
+	CreatedAt  time.Time
+}
+
+// TestHistory holds the outcomes of a failed test of the analyzed run in the earlier runs
+type TestHistory struct {
+	Name     string
+	Outcomes []string // outcome in each run of FlakinessHistory.Runs, one of the Test* constants
+	Passes   int
+	Failures int
+}
+
+// Flaky reports whether the test both passed and failed in the earlier runs
+func (t TestHistory) Flaky() bool {
+	return t.Passes > 0 && t.Failures > 0
+}
+
+// FlakinessHistory is the pass/fail matrix of the analyzed run's failed tests over earlier runs
+type FlakinessHistory struct {
+	Runs  []HistoryRun  // earlier runs, newest first
+	Tests []TestHistory // failed tests of the analyzed run
+}
+
+// runHistorySource lists earlier runs of a workflow and the tests that failed in them
+type runHistorySource interface {
+	recentRuns(run *WorkflowRun, n int) ([]HistoryRun, error)
+	failedTests(repo string, run HistoryRun) ([]string, error)
+}
+
+// rateLimitBackoff makes all workers of the history fetch wait once GitHub rate limits one of them,
+// doubling the wait with every consecutive limit
+type rateLimitBackoff struct {
+	mu    sync.Mutex
+	until time.Time
+	wait  time.Duration
+}
+
+// call runs gh, retrying rate limited requests after the shared backoff
+func (b *rateLimitBackoff) call(args ...string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		b.mu.Lock()
+		pause := time.Until(b.until)
+		b.mu.Unlock()
+		if pause > 0 {
+			time.Sleep(pause)
+		}
+
+		output, err := gh(args...)
+		if err == nil {
+			b.mu.Lock()
+			b.wait = 0
+			b.mu.Unlock()
+			return output, nil
+		}
+		if !rateLimitRe.MatchString(err.Error()) || attempt >= maxRateLimitRetries {
+			return output, err
+		}
+
+		b.mu.Lock()
+		if b.wait == 0 {
+			b.wait = initialRateLimitWait
+		} else {
+			b.wait = min(b.wait*2, maxRateLimitWait)
+		}
+		if until := time.Now().Add(b.wait); until.After(b.until) {
+			b.until = until
+		}
+		wait := b.wait
+		b.mu.Unlock()
+		logWarnf("GitHub rate limit hit, history requests back off for %s", wait)
+	}
+}
+
+// ghHistorySource reads the run history with the GitHub CLI (or the REST fallback)
+type ghHistorySource struct {
+	backoff rateLimitBackoff
+}
+
+func (s *ghHistorySource) recentRuns(run *WorkflowRun, n int) ([]HistoryRun, error) {
+	if run.WorkflowID == 0 {
+		return nil, fmt.Errorf("workflow ID of run %s is unknown", run.RunID)
+	}
+	// Cancelled and skipped runs say nothing about the tests, so more runs are listed than needed
+	output, err := s.backoff.call("api", fmt.Sprintf("repos/%s/actions/workflows/%d/runs?status=completed&per_page=%d",
+		run.Repository, run.WorkflowID, min(n*2+1, 100)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflow runs: %w", err)
+	}
+
+	var data struct {
+		WorkflowRuns []struct {
+			ID         int64     `json:"id"`
+			Conclusion string    `json:"conclusion"`
+			HeadSHA    string    `json:"head_sha"`
+			CreatedAt  time.Time `json:"created_at"`
+		} `json:"workflow_runs"`
+	}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow runs: %w", err)
+	}
+
+	var runs []HistoryRun
+	for _, r := range data.WorkflowRuns {
+		id := fmt.Sprint(r.ID)
+		if id == run.RunID || (r.Conclusion != "success" && r.Conclusion != "failure") {
+			continue
+		}
+		runs = append(runs, HistoryRun{ID: id, Conclusion: r.Conclusion, HeadSHA: r.HeadSHA, CreatedAt: r.CreatedAt})
+		if len(runs) == n {
+			break
+		}
+	}
+	return runs, nil
+}
+
+func (s *ghHistorySource) failedTests(repo string, run HistoryRun) ([]string, error) {
+	output, err := s.backoff.call("run", "view", run.ID, "--repo", repo, "--log-failed")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of run %s: %w", run.ID, err)
+	}
+	return failedTestNames(string(output)), nil
+}
+
+// failedTestNames returns the names of the tests reported as failed in the logs, in log order
+func failedTestNames(logs string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(logs, "\n") {
+		content := strings.TrimRight(logContent(line), " \r")
+		name := ""
+		if m := goTestFailRe.FindStringSubmatch(content); m != nil {
+			name = m[1]
+		} else if m := pytestSummaryRe.FindStringSubmatch(strings.TrimSpace(content)); m != nil && m[1] == "FAILED" {
+			name = m[2]
+		} else if m := cargoTestFailedRe.FindStringSubmatch(content); m != nil {
+			name = m[1]
+		} else if m := jestFailRe.FindStringSubmatch(content); m != nil {
+			name = m[1]
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fetchFlakinessHistory looks up the failed tests of run in up to n earlier runs of its workflow
+// The logs of the failed runs are fetched by at most concurrency workers, which share one
+// rate-limit backoff. Successful runs count as passes of every test.
+func fetchFlakinessHistory(source runHistorySource, run *WorkflowRun, n, concurrency int) (*FlakinessHistory, error) {
+	tests := failedTestNames(run.FailedLogs)
+	if len(tests) == 0 {
+		logDebugf("No failed test names found, skipping the test history")
+		return nil, nil
+	}
+	runs, err := source.recentRuns(run, n)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultFlakyConcurrency
+	}
+
+	failed := make([][]string, len(runs))
+	errs := make([]error, len(runs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, r := range runs {
+		if r.Conclusion != "failure" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, r HistoryRun) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			failed[i], errs[i] = source.failedTests(run.Repository, r)
+		}(i, r)
+	}
+	wg.Wait()
+
+	return aggregateTestHistory(tests, runs, failed, errs), nil
+}
+
+// aggregateTestHistory builds the pass/fail matrix of tests over runs from the failed tests of each run
+func aggregateTestHistory(tests []string, runs []HistoryRun, failed [][]string, errs []error) *FlakinessHistory {
+	history := &FlakinessHistory{Runs: runs}
+	unavailable := 0
+	for _, err := range errs {
+		if err != nil {
+			logDebugf("%v", err)
+			unavailable++
+		}
+	}
+	if unavailable > 0 {
+		logWarnf("logs of %d of %d earlier runs were not available, their test outcomes are unknown", unavailable, len(runs))
+	}
+
+	for _, name := range tests {
+		t := TestHistory{Name: name, Outcomes: make([]string, len(runs))}
+		for i, r := range runs {
+			switch {
+			case r.Conclusion == "success":
+				t.Outcomes[i] = TestPassed
+			case errs[i] == nil && containsString(failed[i], name):
+				t.Outcomes[i] = TestFailed
+			default:
+				t.Outcomes[i] = TestUnknown
+			}
+			switch t.Outcomes[i] {
+			case TestPassed:
+				t.Passes++
+			case TestFailed:
+				t.Failures++
+			}
+		}
+		history.Tests = append(history.Tests, t)
+	}
+	// Flaky tests first, they explain the failure best
+	sort.SliceStable(history.Tests, func(i, j int) bool {
+		return history.Tests[i].Flaky() && !history.Tests[j].Flaky()
+	})
+	return history
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFlakinessHistory writes the test history to the prompt
+func writeFlakinessHistory(sb *strings.Builder, history *FlakinessHistory) {
+	if history == nil || len(history.Tests) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("TEST HISTORY (last %d completed runs of this workflow, newest first; P = passed, F = failed, ? = unknown):\n", len(history.Runs)))
+	for i, t := range history.Tests {
+		if i >= 10 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(history.Tests)-10))
+			break
+		}
+		var marks strings.Builder
+		for _, outcome := range t.Outcomes {
+			switch outcome {
+			case TestPassed:
+				marks.WriteString("P")
+			case TestFailed:
+				marks.WriteString("F")
+			default:
+				marks.WriteString("?")
+			}
+		}
+		label := ""
+		if t.Flaky() {
+			label = " - FLAKY: consider a non-deterministic cause (timing, ordering, shared state, external services)"
+		}
+		sb.WriteString(fmt.Sprintf("  - %s: %s (failed %d, passed %d)%s\n", t.Name, marks.String(), t.Failures, t.Passes, label))
+	}
+}
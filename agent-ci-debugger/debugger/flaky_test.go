@@ -0,0 +1,101 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mockHistorySource serves a fixed run history, recording how many failedTests calls run at once
+type mockHistorySource struct {
+	runs   []HistoryRun
+	failed map[string][]string // failed tests by run ID, runs missing here have no logs
+
+	running, peak atomic.Int32
+	mu            sync.Mutex
+	calls         []string
+}
+
+func (s *mockHistorySource) recentRuns(run *WorkflowRun, n int) ([]HistoryRun, error) {
+	return s.runs[:min(n, len(s.runs))], nil
+}
+
+func (s *mockHistorySource) failedTests(repo string, run HistoryRun) ([]string, error) {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	s.mu.Lock()
+	s.calls = append(s.calls, run.ID)
+	s.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+
+	tests, ok := s.failed[run.ID]
+	if !ok {
+		return nil, errors.New("logs expired")
+	}
+	return tests, nil
+}
+
+func TestFetchFlakinessHistoryConcurrent(t *testing.T) {
+	source := &mockHistorySource{failed: map[string][]string{}}
+	for i := 1; i <= 12; i++ {
+		id := fmt.Sprint(i)
+		conclusion := "failure"
+		if i%3 == 0 {
+			conclusion = "success"
+		}
+		source.runs = append(source.runs, HistoryRun{ID: id, Conclusion: conclusion})
+		if conclusion == "failure" && i != 11 {
+			source.failed[id] = []string{"TestOther"}
+			if i%2 == 1 {
+				source.failed[id] = append(source.failed[id], "TestFlaky")
+			}
+		}
+	}
+	run := &WorkflowRun{Repository: "o/r", FailedLogs: "test\tRun tests\t2024-01-01T00:00:00Z --- FAIL: TestFlaky (0.01s)\n" +
+		"test\tRun tests\t2024-01-01T00:00:00Z --- FAIL: TestStable (0.01s)"}
+
+	history, err := fetchFlakinessHistory(source, run, 12, 3)
+	if err != nil {
+		t.Fatalf("fetchFlakinessHistory() error = %v", err)
+	}
+	if peak := source.peak.Load(); peak > 3 || peak < 2 {
+		t.Errorf("%d logs fetched at once, want up to the concurrency of 3", peak)
+	}
+	if len(source.calls) != 8 {
+		t.Errorf("logs of %d runs fetched, want the 8 failed ones: %v", len(source.calls), source.calls)
+	}
+
+	// Runs 1..12: 3, 6, 9 and 12 passed, 11 has no logs, the odd failed runs failed TestFlaky
+	want := []TestHistory{
+		{Name: "TestFlaky", Outcomes: []string{"fail", "", "pass", "", "fail", "pass", "fail", "", "pass", "", "", "pass"}, Passes: 4, Failures: 3},
+		{Name: "TestStable", Outcomes: []string{"", "", "pass", "", "", "pass", "", "", "pass", "", "", "pass"}, Passes: 4},
+	}
+	if !reflect.DeepEqual(history.Tests, want) {
+		t.Errorf("Tests =\n%+v\nwant\n%+v", history.Tests, want)
+	}
+	if !history.Tests[0].Flaky() || history.Tests[1].Flaky() {
+		t.Errorf("Flaky() = %v, %v, want only TestFlaky flaky", history.Tests[0].Flaky(), history.Tests[1].Flaky())
+	}
+}
+
+func TestFailedTestNames(t *testing.T) {
+	logs := "test\tRun tests\t2024-01-01T00:00:00Z --- FAIL: TestParse (0.00s)\n" +
+		"test\tRun tests\t2024-01-01T00:00:00Z     --- FAIL: TestParse/empty (0.00s)\n" +
+		"test\tRun tests\t2024-01-01T00:00:00Z --- FAIL: TestParse (0.00s)\n" +
+		"py\tRun pytest\t2024-01-01T00:00:00Z FAILED tests/test_app.py::test_login - AssertionError\n" +
+		"js\tRun jest\t2024-01-01T00:00:00Z   ● Cart › adds an item"
+	want := []string{"TestParse", "TestParse/empty", "tests/test_app.py::test_login", "Cart › adds an item"}
+	if got := failedTestNames(logs); !reflect.DeepEqual(got, want) {
+		t.Errorf("failedTestNames() = %q, want %q", got, want)
+	}
+}
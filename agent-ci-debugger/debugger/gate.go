@@ -0,0 +1,42 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseConfidenceThreshold parses the --fail-on-confidence value, high, medium or low, into its level
+func ParseConfidenceThreshold(value string) (string, error) {
+	for _, level := range []string{"High", "Medium", "Low"} {
+		if strings.EqualFold(strings.TrimSpace(value), level) {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("invalid confidence %q, expected high, medium or low", value)
+}
+
+// ConfidenceGate decides whether an analysis fails a CI gate (--fail-on-confidence): the failure is a
+// code failure diagnosed with at least the threshold confidence, one of High, Medium or Low. reason
+// explains the decision either way.
+// Infrastructure failures (out of memory, disk full, rate limits, see Options.Triage) never fail the
+// gate, whatever the confidence: no change to the code would fix them. Neither does an analysis without
+// a recognizable confidence.
+func (d *GitHubWorkflowDebugger) ConfidenceGate(result *Result, threshold string) (fail bool, reason string) {
+	level := confidenceLevel(result.Proposal.Confidence)
+	if level == "" {
+		return false, "the analysis gave no confidence level"
+	}
+	if c := classifyFailure(result.Run); c != nil {
+		triageThreshold := d.Options.TriageThreshold
+		if triageThreshold == 0 {
+			triageThreshold = DefaultTriageThreshold
+		}
+		if c.Score >= triageThreshold {
+			return false, fmt.Sprintf("infrastructure failure %s, not a code failure", c)
+		}
+	}
+	if confidenceValue(level) < confidenceValue(threshold) {
+		return false, fmt.Sprintf("code failure diagnosed with %s confidence, below the %s threshold", level, threshold)
+	}
+	return true, fmt.Sprintf("code failure diagnosed with %s confidence, meeting the %s threshold", level, threshold)
+}
@@ -0,0 +1,67 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseConfidenceThreshold(t *testing.T) {
+	for value, want := range map[string]string{"high": "High", " Medium ": "Medium", "LOW": "Low"} {
+		if got, err := ParseConfidenceThreshold(value); err != nil || got != want {
+			t.Errorf("ParseConfidenceThreshold(%q) = %q, %v, want %s", value, got, err, want)
+		}
+	}
+	if _, err := ParseConfidenceThreshold("certain"); err == nil {
+		t.Error("ParseConfidenceThreshold(certain) succeeded")
+	}
+}
+
+func TestConfidenceGate(t *testing.T) {
+	d := New("test-key")
+	codeFailure := triageRun(d, "main.go:5:2: undefined: foo", "##[error]Process completed with exit code 1.")
+	levels := []string{"High", "Medium", "Low"}
+	for ti, threshold := range levels {
+		for ci, confidence := range levels {
+			t.Run(confidence+" confidence, "+threshold+" threshold", func(t *testing.T) {
+				result := &Result{Run: codeFailure, Proposal: &FixProposal{Confidence: confidence + " (80/100) - reason"}}
+				fail, reason := d.ConfidenceGate(result, threshold)
+				if want := ci <= ti; fail != want {
+					t.Errorf("fail = %v, want %v (%s)", fail, want, reason)
+				}
+				wantReason := fmt.Sprintf("code failure diagnosed with %s confidence", confidence)
+				if !strings.HasPrefix(reason, wantReason) {
+					t.Errorf("reason = %q, want %q", reason, wantReason)
+				}
+			})
+		}
+	}
+}
+
+func TestConfidenceGatePasses(t *testing.T) {
+	d := New("test-key")
+	infra := triageRun(d, "API rate limit exceeded for installation", "##[error]Process completed with exit code 1.")
+	code := triageRun(d, "main.go:5:2: undefined: foo")
+	tests := []struct {
+		name       string
+		run        *WorkflowRun
+		confidence string
+		threshold  float64 // Options.TriageThreshold
+		fail       bool
+		reason     string
+	}{
+		{"infrastructure failure", infra, "High", 0, false, "infrastructure failure rate-limit (score 1.00), not a code failure"},
+		{"no confidence", code, "certain", 0, false, "the analysis gave no confidence level"},
+		// with a threshold above any score the rate limit counts as a code failure
+		{"ambiguous infrastructure failure", infra, "High", 1.5, true, "code failure diagnosed with High confidence, meeting the Low threshold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d.Options.TriageThreshold = tt.threshold
+			fail, reason := d.ConfidenceGate(&Result{Run: tt.run, Proposal: &FixProposal{Confidence: tt.confidence}}, "Low")
+			if fail != tt.fail || reason != tt.reason {
+				t.Errorf("ConfidenceGate() = %v, %q, want %v, %q", fail, reason, tt.fail, tt.reason)
+			}
+		})
+	}
+}
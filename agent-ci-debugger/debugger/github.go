@@ -0,0 +1,127 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// ErrNoGitHubAccess is returned when neither the gh CLI nor a GitHub token is available
+var ErrNoGitHubAccess = errors.New("gh CLI not found on PATH and GITHUB_TOKEN is not set; " +
+	"either install gh (https://cli.github.com) and run `gh auth login`, " +
+	"or set GITHUB_TOKEN (or GH_TOKEN) to a token with read access to Actions (and write access to issues for --create-issue)")
+
+// GitHub access backends
+const (
+	backendCLI  = "gh"
+	backendREST = "rest"
+)
+
+var (
+	backendOnce sync.Once
+	backend     string
+	backendErr  error
+)
+
+// githubToken returns the token used by the REST backend
+func githubToken() string {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GH_TOKEN")
+}
+
+// githubBackend detects once how GitHub is accessed
+// The gh CLI is preferred; without it, the REST API is used when a token is set.
+func githubBackend() (string, error) {
+	backendOnce.Do(func() {
+		if _, err := exec.LookPath("gh"); err == nil {
+			backend = backendCLI
+			return
+		}
+		if githubToken() == "" {
+			backendErr = ErrNoGitHubAccess
+			return
+		}
+		backend = backendREST
+		logInfof("gh CLI not found, using the GitHub REST API with GITHUB_TOKEN")
+	})
+	return backend, backendErr
+}
+
+// gh runs a gh CLI command and returns its output
+// Without the gh CLI, the command is served by the REST API (see restgh.go).
+func gh(args ...string) ([]byte, error) {
+	return ghWithInput("", args...)
+}
+
+// ghWithInput runs a gh CLI command with input on stdin, e.g. for --body-file -
+func ghWithInput(input string, args ...string) ([]byte, error) {
+	b, err := githubBackend()
+	if err != nil {
+		return nil, err
+	}
+	if b == backendREST {
+		return restGH(args, input)
+	}
+
+	cmd := exec.Command("gh", args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return output, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return output, err
+	}
+	return output, nil
+}
+
+// ghLogs runs a gh command printing logs, like `gh run view --log`, streaming its output through a
+// tailBuffer so that only the last maxBytes are kept (DefaultMaxLogBytes when 0)
+func ghLogs(maxBytes int, args ...string) ([]byte, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxLogBytes
+	}
+	b, err := githubBackend()
+	if err != nil {
+		return nil, err
+	}
+	tail := newTailBuffer(maxBytes)
+	if b == backendREST {
+		err = restGHTo(tail, args)
+	} else {
+		stderr := newTailBuffer(maxStderrBytes)
+		cmd := exec.Command("gh", args...)
+		cmd.Stdout = tail
+		cmd.Stderr = stderr
+		if err = cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(string(stderr.Bytes())); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+		}
+	}
+	if tail.dropped() > 0 {
+		logWarnf("logs are %d MB, only the last %d MB are kept (--max-log-mb)", tail.written>>20, maxBytes>>20)
+	}
+	return tail.Bytes(), err
+}
+
+// restGHTo serves a gh command from the REST API like restGH, writing the output to w; the logs
+// of `run view` are streamed to w one job at a time
+func restGHTo(w io.Writer, args []string) error {
+	positional, flags := splitGHArgs(args)
+	if len(positional) > 2 && positional[0] == "run" && positional[1] == "view" && flags["--json"] == "" {
+		return restRunLogs(w, flags["--repo"], positional[2], flags)
+	}
+	output, err := restGH(args, "")
+	w.Write(output)
+	return err
+}
@@ -0,0 +1,37 @@
+package debugger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubGitHub serves the gh commands of a test from the REST backend (see restgh.go) against a
+// server answering the paths of routes with their bodies, and 404 to anything else
+func stubGitHub(t *testing.T, routes map[string]string) {
+	t.Helper()
+	stubGitHubHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+}
+
+// stubGitHubHandler serves the gh commands of a test from the REST backend against handler
+func stubGitHubHandler(t *testing.T, handler http.Handler) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	backendOnce.Do(func() {})
+	savedBackend, savedErr := backend, backendErr
+	backend, backendErr = backendREST, nil
+	t.Cleanup(func() { backend, backendErr = savedBackend, savedErr })
+}
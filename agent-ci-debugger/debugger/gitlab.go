@@ -0,0 +1,354 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CI providers of a RunRef
+const (
+	ProviderGitHub = "github" // GitHub Actions, the default
+	ProviderGitLab = "gitlab" // GitLab CI pipelines
+)
+
+// gitlabURLRe matches GitLab pipeline and job URLs, e.g. https://gitlab.com/group/sub/project/-/pipelines/123
+// Self-managed instances are recognized by the "/-/" path separator GitLab puts before project routes.
+var gitlabURLRe = regexp.MustCompile(`^(?:https?://)?([^/]+)/([^/]+(?:/[^/]+)+?)/-/(pipelines|jobs)/(\d+)`)
+
+// gitlabProjectRe validates a GitLab project path, "group/project" with optional subgroups
+var gitlabProjectRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)+$`)
+
+var (
+	// gitlabSectionRe matches the collapsible section markers of GitLab job traces,
+	// e.g. "section_start:1700000000:step_script\r" (the marker is followed by the section header)
+	gitlabSectionRe = regexp.MustCompile(`section_(start|end):\d+:([A-Za-z0-9_.-]+)(?:\[[^\]]*\])?\r?`)
+)
+
+// gitlabPipeline is the subset of a GitLab pipeline used by the debugger
+type gitlabPipeline struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Ref    string `json:"ref"`
+	SHA    string `json:"sha"`
+	WebURL string `json:"web_url"`
+}
+
+// gitlabJob is the subset of a GitLab job used by the debugger
+type gitlabJob struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Stage        string `json:"stage"`
+	Status       string `json:"status"`
+	AllowFailure bool   `json:"allow_failure"`
+	Pipeline     struct {
+		ID int64 `json:"id"`
+	} `json:"pipeline"`
+}
+
+// parseGitLabURL parses a GitLab pipeline or job URL into a RunRef
+// For job URLs the pipeline ID (RunID) is resolved when the run is fetched.
+func parseGitLabURL(rawURL string) (RunRef, bool) {
+	m := gitlabURLRe.FindStringSubmatch(rawURL)
+	if m == nil || strings.EqualFold(m[1], "github.com") || !gitlabProjectRe.MatchString(m[2]) {
+		return RunRef{}, false
+	}
+	ref := RunRef{Provider: ProviderGitLab, Host: m[1], Repository: m[2], URL: rawURL}
+	if m[3] == "jobs" {
+		ref.JobID = m[4]
+	} else {
+		ref.RunID = m[4]
+	}
+	return ref, true
+}
+
+// gitlabToken returns the GitLab token, public projects can be read without one
+func gitlabToken() string {
+	return os.Getenv("GITLAB_TOKEN")
+}
+
+// gitlabAPIBase returns the REST API base URL of a GitLab instance, overridable with GITLAB_API_URL
+func gitlabAPIBase(host string) string {
+	if base := os.Getenv("GITLAB_API_URL"); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	return "https://" + host + "/api/v4"
+}
+
+// gitlabRequest performs a GET request against the GitLab API of the run's instance
+func gitlabRequest(ref RunRef, endpoint string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, gitlabAPIBase(ref.Host)+"/"+strings.TrimPrefix(endpoint, "/"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if token := gitlabToken(); token != "" {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: failed to read response: %w", endpoint, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message interface{} `json:"message"`
+			Error   string      `json:"error"`
+		}
+		json.Unmarshal(data, &apiErr)
+		message := apiErr.Error
+		if apiErr.Message != nil {
+			message = fmt.Sprint(apiErr.Message)
+		}
+		if resp.StatusCode == http.StatusNotFound && gitlabToken() == "" {
+			message += " (set GITLAB_TOKEN for private projects)"
+		}
+		return nil, fmt.Errorf("GET %s: HTTP %d: %s", endpoint, resp.StatusCode, message)
+	}
+	return data, nil
+}
+
+// gitlabGet decodes the JSON response of a GitLab API GET request into v
+func gitlabGet(ref RunRef, endpoint string, v interface{}) error {
+	data, err := gitlabRequest(ref, endpoint)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// gitlabProjectEndpoint returns the API path of the run's project
+func gitlabProjectEndpoint(ref RunRef) string {
+	return "projects/" + url.PathEscape(ref.Repository)
+}
+
+// gitlabRunStatus maps a GitLab pipeline status onto the GitHub run status and conclusion
+func gitlabRunStatus(status string) (runStatus, conclusion string) {
+	switch status {
+	case "success":
+		return "completed", "success"
+	case "failed":
+		return "completed", "failure"
+	case "canceled":
+		return "completed", "cancelled"
+	case "skipped":
+		return "completed", "skipped"
+	case "manual":
+		// Waiting for a manual job, nothing more runs on its own
+		return "completed", "action_required"
+	default: // created, waiting_for_resource, preparing, pending, running, scheduled
+		return "in_progress", ""
+	}
+}
+
+// fetchGitLabRun retrieves the referenced GitLab pipeline and the traces of its failed jobs
+// The traces are converted to the "<job>\t<step>\t<line>" format of gh logs, so the parsing,
+// prompt and report work unchanged; the sections of a job become its steps.
+func (d *GitHubWorkflowDebugger) fetchGitLabRun(ref RunRef) (*WorkflowRun, error) {
+	project := gitlabProjectEndpoint(ref)
+
+	// Job URLs name the job only, its pipeline is looked up
+	var urlJob *gitlabJob
+	if ref.JobID != "" {
+		var job gitlabJob
+		if err := gitlabGet(ref, fmt.Sprintf("%s/jobs/%s", project, ref.JobID), &job); err != nil {
+			return nil, fmt.Errorf("failed to get job: %w", err)
+		}
+		ref.RunID = fmt.Sprint(job.Pipeline.ID)
+		urlJob = &job
+	}
+
+	pipeline, err := d.fetchGitLabPipeline(ref)
+	if err != nil {
+		return nil, err
+	}
+	status, conclusion := gitlabRunStatus(pipeline.Status)
+
+	run := &WorkflowRun{
+		URL:          ref.WebURL(),
+		Provider:     ProviderGitLab,
+		RunID:        ref.RunID,
+		JobID:        ref.JobID,
+		Repository:   ref.Repository,
+		WorkflowName: pipeline.Name,
+		HeadSHA:      pipeline.SHA,
+		Status:       status,
+		Conclusion:   conclusion,
+	}
+	if run.WorkflowName == "" {
+		run.WorkflowName = "pipeline " + pipeline.Ref
+	}
+	logInfof("GitLab pipeline %s of %s status: %s", run.RunID, run.Repository, pipeline.Status)
+
+	for _, unsupported := range []struct {
+		set  bool
+		name string
+	}{
+		{d.Options.IncludeWorkflow, "--include-workflow"},
+		{d.Options.FlakyRuns > 0, "--flaky-runs"},
+		{d.Options.IncludeDiff, "--include-diff"},
+		{d.Options.CompareURL != "", "--compare"},
+		{d.Options.CreateIssue, "--create-issue"},
+	} {
+		if unsupported.set {
+			logWarnf("%s is not supported for GitLab pipelines, ignoring it", unsupported.name)
+		}
+	}
+
+	var jobs []gitlabJob
+	if urlJob != nil {
+		// The job of a job URL is analyzed even when it did not fail the pipeline
+		jobs = []gitlabJob{*urlJob}
+	} else if jobs, err = fetchGitLabFailedJobs(ref); err != nil {
+		return nil, err
+	}
+	if d.Options.JobName != "" && run.JobID == "" {
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.Name)
+		}
+		name, err := resolveName("job", d.Options.JobName, names)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			if job.Name == name || fmt.Sprint(job.ID) == d.Options.JobName {
+				run.JobID = fmt.Sprint(job.ID)
+				logInfof("Selected job %q (ID %s)", job.Name, run.JobID)
+				break
+			}
+		}
+		if run.JobID == "" {
+			logWarnf("no failed job matches %q, analyzing all failed jobs", d.Options.JobName)
+		}
+	}
+
+	var logs strings.Builder
+	for _, job := range jobs {
+		if run.JobID != "" && fmt.Sprint(job.ID) != run.JobID {
+			continue
+		}
+		trace, err := gitlabRequest(ref, fmt.Sprintf("%s/jobs/%d/trace", project, job.ID))
+		if err != nil {
+			logWarnf("failed to get the trace of job %s: %v", job.Name, err)
+			continue
+		}
+		logDebugf("Fetched trace of job %s (%d bytes)", job.Name, len(trace))
+		logs.WriteString(gitlabTraceLogs(job.Name, string(trace)))
+	}
+	run.FailedLogs = logs.String()
+
+	if d.Options.StepName != "" {
+		run.StepName = d.Options.StepName
+		run.FailedLogs = stepLogs(run.FailedLogs, run.StepName)
+	}
+
+	d.parseLogs(run)
+	return run, nil
+}
+
+// fetchGitLabPipeline reads the pipeline, waiting for it to complete when Options.Wait is set
+func (d *GitHubWorkflowDebugger) fetchGitLabPipeline(ref RunRef) (*gitlabPipeline, error) {
+	interval := d.Options.WaitInterval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+	timeout := d.Options.WaitTimeout
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var pipeline gitlabPipeline
+		if err := gitlabGet(ref, fmt.Sprintf("%s/pipelines/%s", gitlabProjectEndpoint(ref), ref.RunID), &pipeline); err != nil {
+			return nil, fmt.Errorf("failed to get pipeline status: %w", err)
+		}
+		if status, _ := gitlabRunStatus(pipeline.Status); status == "completed" {
+			return &pipeline, nil
+		}
+		if !d.Options.Wait {
+			return nil, fmt.Errorf("%w: pipeline status is %q", ErrRunInProgress, pipeline.Status)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("%w: still %q after waiting %s", ErrRunInProgress, pipeline.Status, timeout)
+		}
+
+		d.statusf("Pipeline is %s, checking again in %s...\n", pipeline.Status, interval)
+		time.Sleep(interval)
+	}
+}
+
+// fetchGitLabFailedJobs lists the failed jobs of the pipeline that failed it, in job order
+// Jobs allowed to fail do not fail the pipeline, so they are left out.
+func fetchGitLabFailedJobs(ref RunRef) ([]gitlabJob, error) {
+	var failed []gitlabJob
+	for page := 1; ; page++ {
+		var jobs []gitlabJob
+		endpoint := fmt.Sprintf("%s/pipelines/%s/jobs?scope[]=failed&per_page=100&page=%d", gitlabProjectEndpoint(ref), ref.RunID, page)
+		if err := gitlabGet(ref, endpoint, &jobs); err != nil {
+			return nil, fmt.Errorf("failed to get jobs: %w", err)
+		}
+		for _, job := range jobs {
+			if !job.AllowFailure {
+				failed = append(failed, job)
+			}
+		}
+		if len(jobs) < 100 {
+			break
+		}
+	}
+	sort.Slice(failed, func(i, j int) bool { return failed[i].ID < failed[j].ID })
+	return failed, nil
+}
+
+// gitlabTraceLogs converts a GitLab job trace to prefixed log lines
+// ANSI sequences and carriage-return overwrites are removed. Lines outside a section (e.g. the
+// final "ERROR: Job failed: exit code 1") are attributed to the script section before them.
+func gitlabTraceLogs(job, trace string) string {
+	var sb strings.Builder
+	step, scriptStep := "prepare", ""
+
+	for _, line := range strings.Split(trace, "\n") {
+		// GitLab traces are full of colors, which hide the section markers
+		line = stripANSI(line)
+		if m := gitlabSectionRe.FindStringSubmatch(line); m != nil {
+			if m[1] == "start" {
+				step = m[2]
+				if step == "step_script" || step == "build_script" {
+					scriptStep = step
+				}
+			} else if scriptStep != "" {
+				step = scriptStep
+			}
+			line = gitlabSectionRe.ReplaceAllString(line, "")
+		}
+		// Progress output rewrites the line after "\r", only its final state matters
+		if i := strings.LastIndex(strings.TrimRight(line, "\r"), "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s\t%s\t%s\n", job, step, line))
+	}
+
+	return sb.String()
+}
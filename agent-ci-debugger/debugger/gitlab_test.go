@@ -0,0 +1,137 @@
+package debugger
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGitLabURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want RunRef
+		ok   bool
+	}{
+		{
+			url:  "https://gitlab.com/group/project/-/pipelines/123",
+			want: RunRef{Provider: ProviderGitLab, Host: "gitlab.com", Repository: "group/project", RunID: "123"},
+			ok:   true,
+		},
+		{
+			url:  "https://gitlab.com/group/sub/project/-/pipelines/123/failures",
+			want: RunRef{Provider: ProviderGitLab, Host: "gitlab.com", Repository: "group/sub/project", RunID: "123"},
+			ok:   true,
+		},
+		{
+			url:  "gitlab.example.com/team/app/-/jobs/456",
+			want: RunRef{Provider: ProviderGitLab, Host: "gitlab.example.com", Repository: "team/app", JobID: "456"},
+			ok:   true,
+		},
+		{url: "https://github.com/o/r/-/pipelines/1"},
+		{url: "https://gitlab.com/project/-/pipelines/1"},
+		{url: "https://github.com/o/r/actions/runs/1"},
+	}
+	for _, tt := range tests {
+		got, ok := parseGitLabURL(tt.url)
+		if ok != tt.ok {
+			t.Errorf("parseGitLabURL(%q) ok = %v, want %v", tt.url, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tt.want.URL = tt.url
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGitLabURL(%q) = %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+
+	ref, err := ParseRunRef("https://gitlab.com/group/project/-/pipelines/123")
+	if err != nil || ref.Provider != ProviderGitLab {
+		t.Errorf("ParseRunRef() = %+v, %v, want a GitLab ref", ref, err)
+	}
+}
+
+// gitlabTrace is a job trace with colors, collapsible sections and a progress line rewritten with "\r"
+const gitlabTrace = "\x1b[0KRunning with gitlab-runner 16.0\n" +
+	"section_start:1700000000:prepare_script\r\x1b[0K\x1b[0K\x1b[36;1mPreparing environment\x1b[0;m\n" +
+	"Running on runner-1\n" +
+	"section_end:1700000001:prepare_script\r\x1b[0K\n" +
+	"section_start:1700000002:step_script\r\x1b[0K\x1b[0K\x1b[36;1mExecuting \"step_script\" stage\x1b[0;m\n" +
+	"$ go build ./...\n" +
+	"downloading 10%\rdownloading 100%\n" +
+	"\x1b[31mmain.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html\x1b[0m\n" +
+	"section_end:1700000003:step_script\r\x1b[0K\n" +
+	"\x1b[31;1mERROR: Job failed: exit code 1\x1b[0;m\n"
+
+func TestFetchGitLabRun(t *testing.T) {
+	const project = "/projects/group%2Fapp"
+	routes := map[string]string{
+		project + "/pipelines/9": `{"id":9,"status":"failed","ref":"main","sha":"abc123","web_url":"https://gitlab.com/group/app/-/pipelines/9"}`,
+		project + "/pipelines/9/jobs": `[{"id":12,"name":"lint","stage":"test","status":"failed","allow_failure":true},
+			{"id":11,"name":"build","stage":"build","status":"failed","pipeline":{"id":9}}]`,
+		project + "/jobs/11":       `{"id":11,"name":"build","stage":"build","status":"failed","pipeline":{"id":9}}`,
+		project + "/jobs/11/trace": gitlabTrace,
+	}
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("PRIVATE-TOKEN"))
+		body, ok := routes[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"404 Project Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	t.Setenv("GITLAB_API_URL", srv.URL)
+	t.Setenv("GITLAB_TOKEN", "gl-token")
+
+	wantLogs := "build\tprepare\tRunning with gitlab-runner 16.0\n" +
+		"build\tprepare_script\tPreparing environment\n" +
+		"build\tprepare_script\tRunning on runner-1\n" +
+		"build\tstep_script\tExecuting \"step_script\" stage\n" +
+		"build\tstep_script\t$ go build ./...\n" +
+		"build\tstep_script\tdownloading 100%\n" +
+		"build\tstep_script\tmain.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html\n" +
+		"build\tstep_script\tERROR: Job failed: exit code 1\n"
+
+	for _, url := range []string{"https://gitlab.com/group/app/-/pipelines/9", "https://gitlab.com/group/app/-/jobs/11"} {
+		t.Run(url, func(t *testing.T) {
+			ref, err := ParseRunRef(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := &GitHubWorkflowDebugger{Options: Options{Quiet: true}}
+			run, err := d.fetchGitLabRun(ref)
+			if err != nil {
+				t.Fatalf("fetchGitLabRun() error = %v", err)
+			}
+			if run.RunID != "9" || run.Provider != ProviderGitLab || run.Conclusion != "failure" ||
+				run.HeadSHA != "abc123" || run.WorkflowName != "pipeline main" {
+				t.Errorf("run = %+v", run)
+			}
+			if run.FailedLogs != wantLogs {
+				t.Errorf("FailedLogs =\n%s\nwant\n%s", run.FailedLogs, wantLogs)
+			}
+			if len(run.ErrorSummary.FailedJobs) != 1 || run.ErrorSummary.FailedJobs[0] != "build" {
+				t.Errorf("FailedJobs = %q, want the job not allowed to fail", run.ErrorSummary.FailedJobs)
+			}
+		})
+	}
+	for _, token := range tokens {
+		if token != "gl-token" {
+			t.Errorf("PRIVATE-TOKEN = %q, want GITLAB_TOKEN", token)
+		}
+	}
+
+	t.Setenv("GITLAB_TOKEN", "")
+	ref, _ := ParseRunRef("https://gitlab.com/group/private/-/pipelines/1")
+	if _, err := (&GitHubWorkflowDebugger{}).fetchGitLabRun(ref); err == nil || !strings.Contains(err.Error(), "set GITLAB_TOKEN for private projects") {
+		t.Errorf("fetchGitLabRun() of a private project error = %v, want a hint at GITLAB_TOKEN", err)
+	}
+}
@@ -0,0 +1,48 @@
+package debugger
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// goDiagnosticRe matches Go compiler and vet diagnostics, e.g. "./pkg/api/server.go:12:5: undefined: yaml"
+	// The column tells them apart from test output such as "server_test.go:12: got 1, want 2".
+	goDiagnosticRe = regexp.MustCompile(`^(\S+\.go):(\d+):(\d+): (.+)$`)
+	// goHintRe matches the indented command suggested below a diagnostic, e.g. "	go get github.com/x/y"
+	goHintRe = regexp.MustCompile(`^\s+(go (?:get|mod) .+)$`)
+)
+
+// goBuildDetector recognizes errors of `go build`, `go vet` and the other go commands that type-check
+// Like the rustc and tsc errors they become compiler errors with their file:line:col.
+type goBuildDetector struct{}
+
+func (goBuildDetector) Name() string { return "go-build" }
+
+func (goBuildDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(lines); i++ {
+		content := strings.TrimRight(stripANSI(logContent(lines[i])), " \r")
+		m := goDiagnosticRe.FindStringSubmatch(content)
+		if m == nil || strings.HasSuffix(m[4], "too many errors") {
+			continue
+		}
+
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		ce := CompilerError{Tool: "go", Message: strings.TrimSpace(m[4]), File: workspacePath(m[1]),
+			Line: line, Column: column, LogLine: i}
+		if i+1 < len(lines) {
+			if hm := goHintRe.FindStringSubmatch(logContent(lines[i+1])); hm != nil {
+				ce.Help = append(ce.Help, "help: "+strings.TrimSpace(hm[1]))
+				i++
+			}
+		}
+
+		findings = append(findings, Finding{Category: CategoryCompilerError, Message: ce.String(), Line: ce.LogLine, CompilerError: &ce})
+	}
+
+	return findings
+}
@@ -0,0 +1,121 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxRaceReportLines caps the lines collected for a single data race report
+const maxRaceReportLines = 150
+
+// DataRace is a data race reported by the Go race detector
+type DataRace struct {
+	Access         string       // e.g. "Write at 0x00c0000b4010 by goroutine 8"
+	Frames         []StackFrame // stack of the access, innermost first
+	PreviousAccess string       // e.g. "Previous read at 0x00c0000b4010 by goroutine 7"
+	PreviousFrames []StackFrame // stack of the conflicting earlier access, innermost first
+	Message        string       // the full report
+	Line           int          // 0-based index of the "WARNING: DATA RACE" line
+}
+
+var (
+	raceAccessRe = regexp.MustCompile(`^(?:(Previous )?(?:[Ww]rite|[Rr]ead)|(Previous )?atomic (?:write|read)) at 0x[0-9a-f]+ by (?:goroutine \d+|main goroutine):$`)
+	// Go stack frames: the function followed by an indented "file.go:42 +0x1d" line
+	goFrameFileRe = regexp.MustCompile(`^\s+(\S+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// goRaceDetector recognizes the multi-line reports of `go test -race`
+// Each "WARNING: DATA RACE" block up to the closing "==================" becomes a stack trace
+// finding carrying the two conflicting accesses.
+type goRaceDetector struct{}
+
+func (goRaceDetector) Name() string { return "go-race" }
+
+func (goRaceDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(logContent(lines[i])) != "WARNING: DATA RACE" {
+			continue
+		}
+
+		race := DataRace{Line: i}
+		block := []string{"WARNING: DATA RACE"}
+		var frames *[]StackFrame
+		function := ""
+		end := i
+		for j := i + 1; j < len(lines) && j-i < maxRaceReportLines; j++ {
+			raw := strings.TrimRight(logContent(lines[j]), " \r")
+			line := strings.TrimSpace(raw)
+			end = j
+			if strings.HasPrefix(line, "==================") {
+				break
+			}
+			block = append(block, raw)
+
+			switch m := raceAccessRe.FindStringSubmatch(line); {
+			case m != nil && (m[1] != "" || m[2] != ""):
+				race.PreviousAccess = strings.TrimSuffix(line, ":")
+				frames = &race.PreviousFrames
+			case m != nil:
+				race.Access = strings.TrimSuffix(line, ":")
+				frames = &race.Frames
+			case !strings.HasPrefix(raw, " ") && !strings.HasPrefix(raw, "\t"):
+				// "Goroutine 8 (running) created at:" and other sections end the access stacks
+				frames = nil
+			case frames != nil:
+				if f := goFrameFileRe.FindStringSubmatch(raw); f != nil {
+					lineNo, _ := strconv.Atoi(f[2])
+					*frames = append(*frames, StackFrame{File: runnerWorkspaceRe.ReplaceAllString(f[1], ""), Line: lineNo, Function: function})
+				} else {
+					function = line
+				}
+			}
+		}
+		race.Message = strings.Join(block, "\n")
+
+		findings = append(findings, Finding{
+			Category: CategoryStackTrace,
+			Message:  race.Message,
+			Line:     i,
+			Frames:   race.Frames,
+			DataRace: &race,
+		})
+		i = end
+	}
+
+	return findings
+}
+
+// raceFrame describes the innermost frame of an access for the prompt
+func raceFrame(frames []StackFrame) string {
+	if len(frames) == 0 {
+		return "unknown location"
+	}
+	f := frames[0]
+	return fmt.Sprintf("%s:%d in %s", f.File, f.Line, f.Function)
+}
+
+// writeDataRaces flags the run as a race condition in the prompt
+// Races need synchronization rather than a change of the test's logic, so they are called out
+// separately from ordinary test failures.
+func writeDataRaces(sb *strings.Builder, races []DataRace) {
+	if len(races) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("DATA RACES detected by the Go race detector (%d total) - this is a concurrency bug, "+
+		"fix it with proper synchronization (mutex, channel, atomic, or not sharing the data), not by changing expected values:\n", len(races)))
+	for i, r := range races {
+		if i >= 3 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(races)-3))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  - %s at %s\n", r.Access, raceFrame(r.Frames)))
+		if r.PreviousAccess != "" {
+			sb.WriteString(fmt.Sprintf("    conflicts with: %s at %s\n", r.PreviousAccess, raceFrame(r.PreviousFrames)))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package debugger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// raceLines is a data race report of `go test -race`, between the goroutine started by Get and the test
+var raceLines = fixtureLines("test", "Run go test -race",
+	"=== RUN   TestCache",
+	"==================",
+	"WARNING: DATA RACE",
+	"Write at 0x00c0000b4010 by goroutine 8:",
+	"  example.com/app/cache.(*Cache).Set()",
+	"      /home/runner/work/app/app/cache/cache.go:42 +0x1d",
+	"  example.com/app/cache.(*Cache).refresh()",
+	"      /home/runner/work/app/app/cache/refresh.go:17 +0x84",
+	"",
+	"Previous read at 0x00c0000b4010 by goroutine 7:",
+	"  example.com/app/cache.(*Cache).Get()",
+	"      /home/runner/work/app/app/cache/cache.go:30 +0x3a",
+	"  example.com/app/cache.TestCache()",
+	"      /__w/app/app/cache/cache_test.go:12 +0x5c",
+	"",
+	"Goroutine 8 (running) created at:",
+	"  example.com/app/cache.(*Cache).Get()",
+	"      /home/runner/work/app/app/cache/cache.go:28 +0x2f",
+	"==================",
+	"    testing.go:1465: race detected during execution of test",
+	"--- FAIL: TestCache (0.00s)",
+)
+
+func TestGoRaceDetector(t *testing.T) {
+	findings := goRaceDetector{}.Detect(raceLines)
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one race", findings)
+	}
+	f := findings[0]
+	if f.Category != CategoryStackTrace || f.Line != 2 || f.DataRace == nil {
+		t.Fatalf("finding = %+v, want a stack trace with the race", f)
+	}
+	race := f.DataRace
+
+	if race.Access != "Write at 0x00c0000b4010 by goroutine 8" || race.PreviousAccess != "Previous read at 0x00c0000b4010 by goroutine 7" {
+		t.Errorf("accesses = %q, %q", race.Access, race.PreviousAccess)
+	}
+	// Frames are relative to the workspace, also in container jobs; the creation stack is left out
+	wantFrames := []StackFrame{
+		{File: "cache/cache.go", Line: 42, Function: "example.com/app/cache.(*Cache).Set()"},
+		{File: "cache/refresh.go", Line: 17, Function: "example.com/app/cache.(*Cache).refresh()"},
+	}
+	if !reflect.DeepEqual(race.Frames, wantFrames) || !reflect.DeepEqual(f.Frames, wantFrames) {
+		t.Errorf("Frames = %+v, want %+v", race.Frames, wantFrames)
+	}
+	wantPrevious := []StackFrame{
+		{File: "cache/cache.go", Line: 30, Function: "example.com/app/cache.(*Cache).Get()"},
+		{File: "cache/cache_test.go", Line: 12, Function: "example.com/app/cache.TestCache()"},
+	}
+	if !reflect.DeepEqual(race.PreviousFrames, wantPrevious) {
+		t.Errorf("PreviousFrames = %+v, want %+v", race.PreviousFrames, wantPrevious)
+	}
+	if !strings.HasPrefix(race.Message, "WARNING: DATA RACE\nWrite at 0x00c0000b4010 by goroutine 8:\n") ||
+		!strings.HasSuffix(race.Message, "cache/cache.go:28 +0x2f") {
+		t.Errorf("Message =\n%s", race.Message)
+	}
+
+	var sb strings.Builder
+	writeDataRaces(&sb, []DataRace{*race})
+	for _, want := range []string{
+		"  - Write at 0x00c0000b4010 by goroutine 8 at cache/cache.go:42 in example.com/app/cache.(*Cache).Set()\n",
+		"    conflicts with: Previous read at 0x00c0000b4010 by goroutine 7 at cache/cache.go:30 in example.com/app/cache.(*Cache).Get()\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("prompt section does not contain %q:\n%s", want, sb.String())
+		}
+	}
+}
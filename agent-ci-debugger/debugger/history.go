@@ -0,0 +1,271 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Defaults of the failure history
+const (
+	DefaultHistoryTopK    = 3
+	minHistorySimilarity  = 0.75 // cosine similarity below which past failures are not considered similar
+	maxHistoryEntries     = 1000 // oldest entries are dropped beyond this
+	historyEmbeddingModel = openai.SmallEmbedding3
+)
+
+// HistoryEntry is an analyzed failure kept for retrieving similar failures later
+type HistoryEntry struct {
+	URL        string    `json:"url"`
+	Repository string    `json:"repository"`
+	Summary    string    `json:"summary"`
+	RootCause  string    `json:"root_cause"`
+	Fix        string    `json:"fix"`
+	Confidence string    `json:"confidence"`
+	Embedding  []float32 `json:"embedding"`
+	Created    time.Time `json:"created"`
+}
+
+// SimilarFailure is a past failure similar to the analyzed one
+type SimilarFailure struct {
+	Entry      HistoryEntry
+	Similarity float64
+}
+
+// historyStore is the local vector store of past failures, a JSON file
+type historyStore struct {
+	path    string
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// defaultHistoryPath returns the history file in the user cache directory
+func defaultHistoryPath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(base, "github-workflow-debugger", "history.json"), nil
+}
+
+// loadHistory reads the store at path; a missing file is an empty store
+func loadHistory(path string) (*historyStore, error) {
+	store := &historyStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse history %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// add stores entry, replacing an earlier analysis of the same run
+func (s *historyStore) add(entry HistoryEntry) {
+	kept := s.Entries[:0]
+	for _, e := range s.Entries {
+		if e.URL != entry.URL {
+			kept = append(kept, e)
+		}
+	}
+	s.Entries = append(kept, entry)
+	if len(s.Entries) > maxHistoryEntries {
+		s.Entries = s.Entries[len(s.Entries)-maxHistoryEntries:]
+	}
+}
+
+// save writes the store, replacing the file atomically
+func (s *historyStore) save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// similar returns up to k entries most similar to embedding, excluding the run at url
+func (s *historyStore) similar(embedding []float32, url string, k int) []SimilarFailure {
+	var matches []SimilarFailure
+	for _, e := range s.Entries {
+		if e.URL == url {
+			continue
+		}
+		similarity := cosineSimilarity(embedding, e.Embedding)
+		if similarity >= minHistorySimilarity {
+			matches = append(matches, SimilarFailure{Entry: e, Similarity: similarity})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// cosineSimilarity of two vectors, 0 if their dimensions differ (e.g. another embedding model)
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// failureSummary is the text embedded for a failure: what failed and the distinctive error lines
+func failureSummary(run *WorkflowRun) string {
+	var sb strings.Builder
+	if len(run.ErrorSummary.FailedJobs) > 0 {
+		sb.WriteString("Failed jobs: " + strings.Join(run.ErrorSummary.FailedJobs, ", ") + "\n")
+	}
+	for _, f := range run.ErrorSummary.Findings {
+		if f.Exception != "" {
+			sb.WriteString(f.Exception + "\n")
+		}
+	}
+	for _, b := range run.ErrorSummary.BuildFailures {
+		sb.WriteString(b.Instruction + "\n")
+	}
+	seen := make(map[string]bool)
+	for _, msg := range run.ErrorSummary.ErrorMessages {
+		line := normalizeLogLine(msg)
+		if seen[line] || len(seen) >= 20 {
+			continue
+		}
+		seen[line] = true
+		sb.WriteString(truncateText(line, 300) + "\n")
+	}
+	return sb.String()
+}
+
+// historySummary is the failureSummary of the run stored in the history and embedded, anonymized
+// like the prompt with Options.Anonymize
+func (d *GitHubWorkflowDebugger) historySummary(run *WorkflowRun) string {
+	summary := failureSummary(run)
+	if d.Options.Anonymize && run.Repository != "" {
+		summary = newAnonymizer(run.Repository).anonymize(summary)
+	}
+	return summary
+}
+
+// embed returns the embedding of text
+func (d *GitHubWorkflowDebugger) embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := d.openaiClient.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{text},
+		Model: historyEmbeddingModel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("failed to create embedding: empty response")
+	}
+	return resp.Data[0].Embedding, nil
+}
+
+// historyPath returns the configured history file or the default one
+func (d *GitHubWorkflowDebugger) historyPath() (string, error) {
+	if d.Options.HistoryFile != "" {
+		return d.Options.HistoryFile, nil
+	}
+	return defaultHistoryPath()
+}
+
+// findSimilarFailures embeds the failure and looks up similar past failures
+// The embedding is returned so the failure can be added to the history after the analysis.
+func (d *GitHubWorkflowDebugger) findSimilarFailures(ctx context.Context, run *WorkflowRun) ([]float32, []SimilarFailure, error) {
+	summary := d.historySummary(run)
+	if strings.TrimSpace(summary) == "" {
+		return nil, nil, nil
+	}
+	path, err := d.historyPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := loadHistory(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embedding, err := d.embed(ctx, summary)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(store.Entries) == 0 {
+		logInfof("Failure history is empty, it is built up as runs are analyzed")
+		return embedding, nil, nil
+	}
+
+	topK := d.Options.HistoryTopK
+	if topK <= 0 {
+		topK = DefaultHistoryTopK
+	}
+	similar := store.similar(embedding, run.URL, topK)
+	logInfof("Found %d similar failures in history (%d entries)", len(similar), len(store.Entries))
+	return embedding, similar, nil
+}
+
+// recordFailure adds the analyzed failure to the history
+func (d *GitHubWorkflowDebugger) recordFailure(run *WorkflowRun, proposal *FixProposal, embedding []float32) error {
+	path, err := d.historyPath()
+	if err != nil {
+		return err
+	}
+	store, err := loadHistory(path)
+	if err != nil {
+		return err
+	}
+	store.add(HistoryEntry{
+		URL:        run.URL,
+		Repository: run.Repository,
+		Summary:    d.historySummary(run),
+		RootCause:  proposal.RootCause,
+		Fix:        proposal.ProposedFix,
+		Confidence: proposal.Confidence,
+		Embedding:  embedding,
+		Created:    time.Now(),
+	})
+	return store.save()
+}
+
+// writeSimilarFailures writes the resolutions of similar past failures to the prompt
+func writeSimilarFailures(sb *strings.Builder, similar []SimilarFailure) {
+	sb.WriteString("\n## Similar Past Failures\n")
+	sb.WriteString("These earlier failures had similar errors. Consider whether the same root cause applies, but do not assume it.\n")
+	for _, s := range similar {
+		sb.WriteString(fmt.Sprintf("\n### %s (similarity %.2f, %s)\n", s.Entry.URL, s.Similarity, s.Entry.Created.Format("2006-01-02")))
+		sb.WriteString(fmt.Sprintf("Root cause: %s\n", truncateText(strings.TrimSpace(s.Entry.RootCause), 500)))
+		sb.WriteString(fmt.Sprintf("Previously, a similar failure was fixed by: %s\n", truncateText(strings.TrimSpace(s.Entry.Fix), 800)))
+	}
+}
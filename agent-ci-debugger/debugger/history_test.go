@@ -0,0 +1,100 @@
+package debugger
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedHistory writes a history store with entries at the given embeddings
+func seedHistory(t *testing.T, entries ...HistoryEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.json")
+	store := &historyStore{path: path, Entries: entries}
+	if err := store.save(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindSimilarFailures(t *testing.T) {
+	created := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	path := seedHistory(t,
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/10", RootCause: "go.sum out of date", Fix: "go mod tidy", Embedding: []float32{0.9, 0.1, 0}, Created: created},
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/11", RootCause: "flaky network", Fix: "retry", Embedding: []float32{0, 1, 0}, Created: created},
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/12", RootCause: "missing go.sum entry", Fix: "commit go.sum", Embedding: []float32{1, 0, 0}, Created: created},
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/1", RootCause: "an earlier analysis of this run", Embedding: []float32{1, 0, 0}, Created: created},
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/13", RootCause: "other embedding model", Embedding: []float32{1, 0}, Created: created},
+	)
+	api := &mockOpenAI{routes: map[string]string{
+		"/v1/embeddings": `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1,0,0]}],"model":"text-embedding-3-small"}`,
+	}}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, HistoryFile: path, HistoryTopK: 5}
+
+	run := &WorkflowRun{URL: "https://github.com/o/r/actions/runs/1", Repository: "o/r", ErrorSummary: ErrorSummary{
+		FailedJobs:    []string{"build"},
+		ErrorMessages: []string{"main.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html"},
+	}}
+	embedding, similar, err := d.findSimilarFailures(context.Background(), run)
+	if err != nil {
+		t.Fatalf("findSimilarFailures() error = %v", err)
+	}
+	if len(embedding) != 3 {
+		t.Errorf("embedding = %v, want the embedding of the API", embedding)
+	}
+	var got []string
+	for _, s := range similar {
+		got = append(got, s.Entry.URL)
+	}
+	// The run itself, dissimilar failures and embeddings of another dimension are left out
+	want := []string{"https://github.com/o/r/actions/runs/12", "https://github.com/o/r/actions/runs/10"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("similar = %v, want %v", got, want)
+	}
+	if similar[0].Similarity != 1 || math.Abs(similar[1].Similarity-0.9939) > 0.001 {
+		t.Errorf("similarities = %v, %v", similar[0].Similarity, similar[1].Similarity)
+	}
+
+	var sb strings.Builder
+	writeSimilarFailures(&sb, similar)
+	for _, s := range []string{
+		"### https://github.com/o/r/actions/runs/12 (similarity 1.00, 2024-03-01)",
+		"Root cause: missing go.sum entry",
+		"Previously, a similar failure was fixed by: commit go.sum",
+	} {
+		if !strings.Contains(sb.String(), s) {
+			t.Errorf("prompt section does not contain %q:\n%s", s, sb.String())
+		}
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	path := seedHistory(t,
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/1", RootCause: "old analysis", Embedding: []float32{0, 1}},
+		HistoryEntry{URL: "https://github.com/o/r/actions/runs/2", RootCause: "other run", Embedding: []float32{1, 1}},
+	)
+	d := &GitHubWorkflowDebugger{Options: Options{HistoryFile: path}}
+	run := &WorkflowRun{URL: "https://github.com/o/r/actions/runs/1", Repository: "o/r"}
+	if err := d.recordFailure(run, &FixProposal{RootCause: "new analysis", ProposedFix: "fix", Confidence: "High"}, []float32{1, 0}); err != nil {
+		t.Fatalf("recordFailure() error = %v", err)
+	}
+	store, err := loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The new analysis of run 1 replaces the old one
+	if len(store.Entries) != 2 || store.Entries[0].RootCause != "other run" || store.Entries[1].RootCause != "new analysis" {
+		t.Errorf("entries = %+v", store.Entries)
+	}
+}
+
+func TestLoadHistoryMissingFile(t *testing.T) {
+	store, err := loadHistory(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil || len(store.Entries) != 0 {
+		t.Errorf("loadHistory() = %+v, %v, want an empty store", store, err)
+	}
+}
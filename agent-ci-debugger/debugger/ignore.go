@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultIgnoreFile is read from the working directory when no ignore file is given
+const DefaultIgnoreFile = ".debugignore"
+
+// ignoreRegexPrefix marks an ignore pattern as a regular expression, other patterns are literal substrings
+const ignoreRegexPrefix = "re:"
+
+// IgnoreList holds the patterns of log lines dropped before analysis
+type IgnoreList struct {
+	literals []string
+	regexps  []*regexp.Regexp
+}
+
+// LoadIgnoreFile reads ignore patterns from path, one per line
+// Blank lines and lines starting with # are skipped; "re:" introduces a regular expression.
+// A missing file is not an error unless required is set.
+func LoadIgnoreFile(path string, required bool) (*IgnoreList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	defer f.Close()
+
+	list := &IgnoreList{}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if expr, ok := strings.CutPrefix(pattern, ignoreRegexPrefix); ok {
+			re, err := regexp.Compile(strings.TrimSpace(expr))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid pattern: %w", path, lineNo, err)
+			}
+			list.regexps = append(list.regexps, re)
+			continue
+		}
+		list.literals = append(list.literals, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+
+	logDebugf("Loaded %d ignore patterns from %s", len(list.literals)+len(list.regexps), path)
+	return list, nil
+}
+
+// Match reports whether a log line matches any ignore pattern
+// Patterns are matched against the line without the job/step prefix of gh logs.
+func (l *IgnoreList) Match(line string) bool {
+	if l == nil {
+		return false
+	}
+	if _, _, content, ok := splitLogPrefix(line); ok {
+		line = content
+	}
+	for _, literal := range l.literals {
+		if strings.Contains(line, literal) {
+			return true
+		}
+	}
+	for _, re := range l.regexps {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter drops the ignored lines, returning the remaining lines and the number dropped
+func (l *IgnoreList) Filter(lines []string) ([]string, int) {
+	if l == nil {
+		return lines, 0
+	}
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !l.Match(line) {
+			kept = append(kept, line)
+		}
+	}
+	return kept, len(lines) - len(kept)
+}
@@ -0,0 +1,89 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeIgnoreFile writes content to a .debugignore in a temporary directory and returns its path
+func writeIgnoreFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultIgnoreFile)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIgnoreListFilter(t *testing.T) {
+	path := writeIgnoreFile(t, `# noise of the dependency download
+npm WARN deprecated
+
+re: ^Downloading .* \(\d+ kB\)$
+re:warning: .*is deprecated
+`)
+	list, err := LoadIgnoreFile(path, true)
+	if err != nil {
+		t.Fatalf("LoadIgnoreFile() error = %v", err)
+	}
+
+	lines := []string{
+		"npm WARN deprecated request@2.88.2: request has been deprecated",
+		"build\tInstall\t2024-01-01T00:00:00.0000000Z npm WARN deprecated uuid@3.4.0",
+		"Downloading numpy-1.26.0.tar.gz (15 kB)",
+		"Downloading numpy-1.26.0.tar.gz (15 kB) failed",
+		"build\tCompile\t2024-01-01T00:00:01.0000000Z warning: io/ioutil is deprecated",
+		"build\tCompile\t2024-01-01T00:00:02.0000000Z error: cannot find package",
+		"# not a comment in the logs",
+	}
+	kept, dropped := list.Filter(lines)
+	want := []string{
+		"Downloading numpy-1.26.0.tar.gz (15 kB) failed",
+		"build\tCompile\t2024-01-01T00:00:02.0000000Z error: cannot find package",
+		"# not a comment in the logs",
+	}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("Filter() kept %q, want %q", kept, want)
+	}
+	if dropped != 4 {
+		t.Errorf("Filter() dropped %d lines, want 4", dropped)
+	}
+}
+
+func TestIgnoreListInFilterRelevantLogs(t *testing.T) {
+	list, err := LoadIgnoreFile(writeIgnoreFile(t, "error: retrying download\n"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := "error: retrying download\nerror: retrying download\nerror: build failed"
+	d := &GitHubWorkflowDebugger{Options: Options{Ignore: list}}
+	got, budget := d.filterRelevantLogs(logs, 10000)
+	if strings.Contains(got, "retrying") || !strings.Contains(got, "build failed") {
+		t.Errorf("filterRelevantLogs() = %q, want only the build failure", got)
+	}
+	if budget.IgnoredLines != 2 {
+		t.Errorf("IgnoredLines = %d, want 2", budget.IgnoredLines)
+	}
+}
+
+func TestLoadIgnoreFileErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if list, err := LoadIgnoreFile(missing, false); list != nil || err != nil {
+		t.Errorf("LoadIgnoreFile(missing, false) = %v, %v, want nil, nil", list, err)
+	}
+	if _, err := LoadIgnoreFile(missing, true); err == nil {
+		t.Error("LoadIgnoreFile(missing, true) succeeded, want an error")
+	}
+	_, err := LoadIgnoreFile(writeIgnoreFile(t, "ok\nre: (unclosed\n"), true)
+	if err == nil || !strings.Contains(err.Error(), ":2: invalid pattern") {
+		t.Errorf("LoadIgnoreFile() error = %v, want the line of the invalid pattern", err)
+	}
+
+	var nilList *IgnoreList
+	if lines, dropped := nilList.Filter([]string{"a"}); len(lines) != 1 || dropped != 0 {
+		t.Errorf("nil IgnoreList dropped lines")
+	}
+}
@@ -0,0 +1,119 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultIssueLabel is the label applied to tracking issues unless overridden
+const DefaultIssueLabel = "ci-failure"
+
+// ghIssue is the subset of `gh issue list --json` output used for de-duplication
+type ghIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+// confidenceLevel normalizes the free-text confidence into High, Medium or Low
+// Returns an empty string when no level can be recognized
+func confidenceLevel(confidence string) string {
+	// The earliest mentioned level wins, e.g. "High (could be Medium if ...)" is High
+	lower := strings.ToLower(confidence)
+	best, bestIdx := "", -1
+	for _, level := range []string{"High", "Medium", "Low"} {
+		idx := strings.Index(lower, strings.ToLower(level))
+		if idx >= 0 && (bestIdx < 0 || idx < bestIdx) {
+			best, bestIdx = level, idx
+		}
+	}
+	return best
+}
+
+// issueTitle returns the title of the tracking issue for the failed workflow
+func issueTitle(run *WorkflowRun) string {
+	if run.WorkflowName != "" {
+		return fmt.Sprintf("CI failure: %s", run.WorkflowName)
+	}
+	return fmt.Sprintf("CI failure: workflow run %s", run.RunID)
+}
+
+// issueMarker returns the hidden marker identifying the tracking issue of a workflow
+// The marker is keyed by workflow rather than run so repeated failures land on the same issue
+func issueMarker(run *WorkflowRun) string {
+	name := run.WorkflowName
+	if name == "" {
+		name = run.RunID
+	}
+	return fmt.Sprintf("<!-- github-workflow-debugger: %s/%s -->", run.Repository, name)
+}
+
+// findTrackingIssue looks for an open issue carrying the marker or title of this workflow
+func findTrackingIssue(run *WorkflowRun, label string) (*ghIssue, error) {
+	output, err := gh("issue", "list", "--repo", run.Repository,
+		"--state", "open", "--label", label, "--limit", "100", "--json", "number,title,body")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	var issues []ghIssue
+	if err := json.Unmarshal(output, &issues); err != nil {
+		return nil, fmt.Errorf("failed to parse issue list: %w", err)
+	}
+
+	marker := issueMarker(run)
+	title := issueTitle(run)
+	for i := range issues {
+		if strings.Contains(issues[i].Body, marker) || issues[i].Title == title {
+			return &issues[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ReportIssue files a GitHub tracking issue with the report, or comments on an existing one
+// Only High confidence proposals are reported to keep unattended runs from creating noise
+func (d *GitHubWorkflowDebugger) ReportIssue(run *WorkflowRun, proposal *FixProposal, report string) error {
+	level := confidenceLevel(proposal.Confidence)
+	if level != "High" {
+		logInfof("Skipping issue creation - confidence is %q, High required", proposal.Confidence)
+		return nil
+	}
+
+	label := d.Options.IssueLabel
+	if label == "" {
+		label = DefaultIssueLabel
+	}
+
+	logDebugf("Looking for an open tracking issue labeled %q...", label)
+	existing, err := findTrackingIssue(run, label)
+	if err != nil {
+		return err
+	}
+
+	body := issueMarker(run) + "\n\n" + report
+
+	var output []byte
+	if existing != nil {
+		logInfof("Found existing issue #%d, adding a comment", existing.Number)
+		output, err = ghWithInput(body, "issue", "comment", fmt.Sprint(existing.Number),
+			"--repo", run.Repository, "--body-file", "-")
+	} else {
+		logInfof("No existing issue found, creating a new one")
+		output, err = ghWithInput(body, "issue", "create", "--repo", run.Repository,
+			"--title", issueTitle(run), "--label", label, "--body-file", "-")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to report issue: %w", err)
+	}
+
+	issueURL := strings.TrimSpace(string(output))
+	logInfof("Issue updated: %s", issueURL)
+	if existing != nil {
+		d.statusf("Commented on existing issue #%d\n", existing.Number)
+	} else {
+		d.statusf("Created issue: %s\n", issueURL)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxJobs is the number of failed jobs whose logs are analyzed (Options.MaxJobs)
+const DefaultMaxJobs = 10
+
+// maxSignatureChars caps the error message of a job cluster in the prompt and the report
+const maxSignatureChars = 200
+
+// Volatile parts of error messages that differ between the jobs of a matrix failing the same way
+var (
+	signatureHexRe    = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{7,}\b`)
+	signatureNumberRe = regexp.MustCompile(`\d+(?:\.\d+)*`)
+	signatureSpaceRe  = regexp.MustCompile(`\s+`)
+)
+
+// JobCluster is a group of failed jobs with the same top error message
+// Only the logs of the exemplar are analyzed; it stands for the other jobs of the cluster.
+type JobCluster struct {
+	Exemplar string   // the job whose logs are analyzed
+	Jobs     []string // all jobs of the cluster, the exemplar first
+	Message  string   // the top error message of the exemplar
+	Analyzed bool     // false when the cluster was beyond Options.MaxJobs and none of its logs were analyzed
+}
+
+// jobLogs are the log lines of one job, in log order
+type jobLogs struct {
+	name  string
+	lines []string
+}
+
+// splitJobLogs splits gh logs by the job of their "<job>\t<step>\t" prefix, in the order the jobs
+// first appear; lines without a prefix belong to the job before them
+func splitJobLogs(logs string) []jobLogs {
+	var jobs []jobLogs
+	index := make(map[string]int)
+	current := -1
+	for _, line := range strings.Split(logs, "\n") {
+		if name, _, _, ok := splitLogPrefix(line); ok {
+			name = strings.TrimSpace(name)
+			i, seen := index[name]
+			if !seen {
+				i = len(jobs)
+				index[name] = i
+				jobs = append(jobs, jobLogs{name: name})
+			}
+			current = i
+		}
+		if current < 0 {
+			index[""] = 0
+			jobs = append(jobs, jobLogs{})
+			current = 0
+		}
+		jobs[current].lines = append(jobs[current].lines, line)
+	}
+	return jobs
+}
+
+// errorSignature reduces an error message to what jobs failing the same way share: numbers,
+// hashes and durations vary between the jobs of a matrix
+func errorSignature(message string) string {
+	s := strings.ToLower(normalizeLogLine(message))
+	s = signatureHexRe.ReplaceAllString(s, "<hex>")
+	s = signatureNumberRe.ReplaceAllString(s, "<n>")
+	return strings.TrimSpace(signatureSpaceRe.ReplaceAllString(s, " "))
+}
+
+// topErrorMessage returns the most severe line of a job's logs containing an error keyword, the
+// first on ties, falling back to the last non-empty line for jobs whose logs have no error line
+// The "Process completed with exit code" line every failed step ends with tells no jobs apart.
+func topErrorMessage(lines []string, keywords []string, weights map[string]int) string {
+	top, severity := "", -1
+	for _, line := range lines {
+		content := logContent(line)
+		if !matchesKeyword(content, keywords) || stepExitRe.MatchString(content) {
+			continue
+		}
+		if s := lineSeverity(content, weights); s > severity {
+			top, severity = line, s
+		}
+	}
+	if top != "" {
+		return top
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if content := strings.TrimSpace(logContent(lines[i])); content != "" && !stepExitRe.MatchString(content) {
+			return lines[i]
+		}
+	}
+	return ""
+}
+
+// clusterJobs groups the jobs by the signature of their top error message, largest cluster first
+// The first job of a cluster is its exemplar; clusters of the same size keep the log order.
+func (d *GitHubWorkflowDebugger) clusterJobs(jobs []jobLogs) []JobCluster {
+	keywords, weights := d.errorKeywords(), d.severityWeights()
+	var clusters []JobCluster
+	index := make(map[string]int)
+	for _, job := range jobs {
+		message := topErrorMessage(job.lines, keywords, weights)
+		signature := errorSignature(message)
+		if i, ok := index[signature]; ok {
+			clusters[i].Jobs = append(clusters[i].Jobs, job.name)
+			continue
+		}
+		index[signature] = len(clusters)
+		clusters = append(clusters, JobCluster{
+			Exemplar: job.name,
+			Jobs:     []string{job.name},
+			Message:  truncateText(normalizeLogLine(message), maxSignatureChars),
+		})
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].Jobs) > len(clusters[j].Jobs) })
+	return clusters
+}
+
+// selectJobs keeps the logs of one exemplar job per error cluster when more than Options.MaxJobs
+// jobs failed, e.g. a large matrix failing the same way: their logs would repeat the same error
+// and crowd out the other failures. The exemplars of the largest clusters are kept, up to MaxJobs.
+// It returns nil when the logs are analyzed in full.
+func (d *GitHubWorkflowDebugger) selectJobs(run *WorkflowRun) []JobCluster {
+	maxJobs := d.Options.MaxJobs
+	if maxJobs == 0 {
+		maxJobs = DefaultMaxJobs
+	}
+	jobs := splitJobLogs(run.FailedLogs)
+	if maxJobs < 0 || len(jobs) <= maxJobs {
+		return nil
+	}
+
+	clusters := d.clusterJobs(jobs)
+	exemplars := make(map[string]bool)
+	for i := range clusters {
+		if i < maxJobs {
+			clusters[i].Analyzed = true
+			exemplars[clusters[i].Exemplar] = true
+		}
+	}
+	var sb strings.Builder
+	for _, job := range jobs {
+		if exemplars[job.name] {
+			sb.WriteString(strings.Join(job.lines, "\n"))
+			sb.WriteString("\n")
+		}
+	}
+	run.FailedLogs = strings.TrimSuffix(sb.String(), "\n")
+
+	logInfof("%d jobs failed with %d distinct errors, analyzing the logs of %d of them (--max-jobs %d)",
+		len(jobs), len(clusters), len(exemplars), maxJobs)
+	for _, c := range clusters {
+		logDebugf("Job cluster of %q: %d jobs, %s", c.Exemplar, len(c.Jobs), c.Message)
+	}
+	return clusters
+}
+
+// clusteredJobs returns the names of all failed jobs of the clusters, exemplars first
+func clusteredJobs(clusters []JobCluster) []string {
+	var names []string
+	for _, c := range clusters {
+		names = append(names, c.Jobs...)
+	}
+	return names
+}
+
+// writeJobClusters tells the model which jobs the analyzed logs stand for
+func writeJobClusters(sb *strings.Builder, clusters []JobCluster) {
+	if len(clusters) == 0 {
+		return
+	}
+	total := 0
+	for _, c := range clusters {
+		total += len(c.Jobs)
+	}
+	sb.WriteString(fmt.Sprintf("The %d failed jobs were grouped by their error; the logs below are those of one job per group:\n", total))
+	skipped, skippedJobs := 0, 0
+	for _, c := range clusters {
+		if !c.Analyzed {
+			skipped++
+			skippedJobs += len(c.Jobs)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  - %q (jobs with this error: %d): %s\n", c.Exemplar, len(c.Jobs), c.Message))
+	}
+	if skipped > 0 {
+		sb.WriteString(fmt.Sprintf("  - groups with other errors not included (--max-jobs): %d, with %d jobs\n", skipped, skippedJobs))
+	}
+}
+
+// writeJobClustersReport lists the analyzed exemplar jobs and the jobs they cover in the report header
+func writeJobClustersReport(sb *strings.Builder, clusters []JobCluster) {
+	for _, c := range clusters {
+		if !c.Analyzed {
+			sb.WriteString(fmt.Sprintf("**Not Analyzed**: `%s` (jobs with this error: %d): %s\n", c.Exemplar, len(c.Jobs), c.Message))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("**Analyzed Job**: `%s` (jobs with this error: %d)\n", c.Exemplar, len(c.Jobs)))
+	}
+}
@@ -0,0 +1,115 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// matrixLogs are the logs of failed matrix jobs "test (N)", one per message, each job ending with
+// the exit code line
+func matrixLogs(messages ...string) string {
+	var lines []string
+	for i, message := range messages {
+		job := fmt.Sprintf("test (%d)", i+1)
+		lines = append(lines,
+			fmt.Sprintf("%s\tRun tests\t2024-01-01T00:00:%02d.0000000Z go test ./...", job, i),
+			fmt.Sprintf("%s\tRun tests\t2024-01-01T00:00:%02d.0000000Z %s", job, i, message),
+			fmt.Sprintf("%s\tRun tests\t2024-01-01T00:00:%02d.0000000Z ##[error]Process completed with exit code 1.", job, i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestErrorSignature(t *testing.T) {
+	same := []string{
+		"Error: dial tcp 10.0.0.1:5432: connection refused after 3s (commit 1a2b3c4d5e)",
+		"Error: dial tcp 10.0.0.7:5432: connection refused after 12s (commit 9f8e7d6c5b)",
+	}
+	if a, b := errorSignature(same[0]), errorSignature(same[1]); a != b {
+		t.Errorf("signatures differ:\n%s\n%s", a, b)
+	}
+	if a, b := errorSignature(same[0]), errorSignature("main.go:12:3: undefined: Foo"); a == b {
+		t.Errorf("different errors share the signature %s", a)
+	}
+}
+
+func TestSelectJobs(t *testing.T) {
+	var messages []string
+	for i := 0; i < 7; i++ {
+		messages = append(messages, fmt.Sprintf("Error: dial tcp 10.0.0.%d:5432: connection refused after %ds", i, i+2))
+	}
+	messages = append(messages, "main.go:12:3: undefined: Foo", "panic: assignment to entry in nil map")
+	for i := 0; i < 3; i++ {
+		messages = append(messages, fmt.Sprintf("main.go:%d:3: undefined: Foo", 12+i))
+	}
+
+	d := New("test-key")
+	d.Options.MaxJobs = 2
+	run := &WorkflowRun{FailedLogs: matrixLogs(messages...)}
+	clusters := d.selectJobs(run)
+
+	type cluster struct {
+		exemplar string
+		jobs     int
+		analyzed bool
+	}
+	var got []cluster
+	for _, c := range clusters {
+		got = append(got, cluster{c.Exemplar, len(c.Jobs), c.Analyzed})
+	}
+	want := []cluster{{"test (1)", 7, true}, {"test (8)", 4, true}, {"test (9)", 1, false}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("clusters = %+v, want %+v", got, want)
+	}
+	if clusters[1].Message != "main.go:12:3: undefined: Foo" || clusters[1].Jobs[3] != "test (12)" {
+		t.Errorf("cluster = %+v, want the compiler error of test (8), test (10) to (12)", clusters[1])
+	}
+	jobs := splitJobLogs(run.FailedLogs)
+	if len(jobs) != 2 || jobs[0].name != "test (1)" || jobs[1].name != "test (8)" {
+		t.Errorf("analyzed jobs = %+v, want the exemplars", jobs)
+	}
+	if len(clusteredJobs(clusters)) != 12 {
+		t.Errorf("clustered jobs = %q, want all 12", clusteredJobs(clusters))
+	}
+
+	var prompt strings.Builder
+	writeJobClusters(&prompt, clusters)
+	wantPrompt := "The 12 failed jobs were grouped by their error; the logs below are those of one job per group:\n" +
+		"  - \"test (1)\" (jobs with this error: 7): Error: dial tcp 10.0.0.0:5432: connection refused after 2s\n" +
+		"  - \"test (8)\" (jobs with this error: 4): main.go:12:3: undefined: Foo\n" +
+		"  - groups with other errors not included (--max-jobs): 1, with 1 jobs\n"
+	if prompt.String() != wantPrompt {
+		t.Errorf("prompt =\n%s\nwant\n%s", prompt.String(), wantPrompt)
+	}
+	var report strings.Builder
+	writeJobClustersReport(&report, clusters)
+	if !strings.Contains(report.String(), "**Not Analyzed**: `test (9)` (jobs with this error: 1): panic: assignment to entry in nil map") {
+		t.Errorf("report =\n%s\nwant the cluster that was not analyzed", report.String())
+	}
+}
+
+func TestSelectJobsWithinLimit(t *testing.T) {
+	logs := matrixLogs("main.go:12:3: undefined: Foo", "main.go:12:3: undefined: Foo", "panic: boom")
+	for _, maxJobs := range []int{0, 3, -1} {
+		d := New("test-key")
+		d.Options.MaxJobs = maxJobs
+		run := &WorkflowRun{FailedLogs: logs}
+		if clusters := d.selectJobs(run); clusters != nil || run.FailedLogs != logs {
+			t.Errorf("MaxJobs %d: clusters = %+v, want the logs analyzed in full", maxJobs, clusters)
+		}
+	}
+}
+
+func TestParseLogsListsClusteredJobs(t *testing.T) {
+	d := New("test-key")
+	d.Options.MaxJobs = 1
+	run := &WorkflowRun{FailedLogs: matrixLogs("main.go:12:3: undefined: Foo", "main.go:13:3: undefined: Foo", "panic: boom")}
+	d.parseLogs(run)
+	if want := []string{"test (1)", "test (2)", "test (3)"}; !reflect.DeepEqual(run.ErrorSummary.FailedJobs, want) {
+		t.Errorf("FailedJobs = %q, want %q", run.ErrorSummary.FailedJobs, want)
+	}
+	if strings.Contains(run.FailedLogs, "test (2)") || strings.Contains(run.FailedLogs, "panic: boom") {
+		t.Errorf("FailedLogs = %q, want only the exemplar", run.FailedLogs)
+	}
+}
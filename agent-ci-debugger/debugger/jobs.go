@@ -0,0 +1,133 @@
+package debugger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxJobOutcomes caps the jobs listed in the prompt
+const maxJobOutcomes = 20
+
+// JobOutcome is the conclusion and timing of a job of the run
+type JobOutcome struct {
+	Name       string
+	Conclusion string        // e.g. "failure", "skipped", "cancelled"
+	StartDelay time.Duration // from the start of the run's first job to the start of this job
+	Duration   time.Duration
+	Ran        bool // at least one step ran; skipped jobs and jobs failing at startup ran none
+	Cascading  bool // skipped or cancelled after the root failing job failed, e.g. as a dependent job or by fail-fast
+}
+
+// genuineFailure reports whether the job failed by itself rather than in the wake of another job
+func (j JobOutcome) genuineFailure() bool {
+	return j.Conclusion == "failure" || j.Conclusion == "timed_out" || j.Conclusion == "startup_failure"
+}
+
+// String describes the outcome for the prompt, e.g. `"test": skipped, did not run (after the root failure)`
+func (j JobOutcome) String() string {
+	s := fmt.Sprintf("%q: %s", j.Name, j.Conclusion)
+	if j.Ran {
+		s += fmt.Sprintf(", started +%s, ran %s", j.StartDelay.Round(time.Second), j.Duration.Round(time.Second))
+	} else {
+		s += ", did not run"
+	}
+	if j.Cascading {
+		s += " (after the root failure)"
+	}
+	return s
+}
+
+// jobOutcomes returns the outcomes of the jobs and the name of the root failing job
+// Without the dependency graph of the workflow, the order of the jobs is told by their timing: a job
+// starts after the jobs it needs have completed, so the genuine failure completing first is the root
+// of the others. Jobs skipped or cancelled once it failed are cascading: dependent jobs that never
+// ran, or matrix jobs stopped by fail-fast.
+func jobOutcomes(jobs []ghJob) ([]JobOutcome, string) {
+	var first time.Time
+	for _, job := range jobs {
+		if !job.StartedAt.IsZero() && (first.IsZero() || job.StartedAt.Before(first)) {
+			first = job.StartedAt
+		}
+	}
+
+	outcomes := make([]JobOutcome, 0, len(jobs))
+	root := -1
+	for _, job := range jobs {
+		o := JobOutcome{Name: job.Name, Conclusion: job.Conclusion}
+		if o.Conclusion == "" {
+			o.Conclusion = job.Status
+		}
+		for _, step := range job.Steps {
+			if step.Conclusion != "" && step.Conclusion != "skipped" {
+				o.Ran = true
+				break
+			}
+		}
+		if !job.StartedAt.IsZero() {
+			o.StartDelay = job.StartedAt.Sub(first)
+			if job.CompletedAt.After(job.StartedAt) {
+				o.Duration = job.CompletedAt.Sub(job.StartedAt)
+			}
+		}
+		outcomes = append(outcomes, o)
+
+		if o.genuineFailure() && (root < 0 || completedBefore(job, jobs[root])) {
+			root = len(outcomes) - 1
+		}
+	}
+	if root < 0 {
+		return outcomes, ""
+	}
+
+	rootCompleted := jobs[root].CompletedAt
+	for i, job := range jobs {
+		if (job.Conclusion == "skipped" || job.Conclusion == "cancelled") && !rootCompleted.IsZero() && !job.CompletedAt.Before(rootCompleted) {
+			outcomes[i].Cascading = true
+		}
+	}
+	return outcomes, outcomes[root].Name
+}
+
+// completedBefore reports whether job a completed before b, jobs without a completion time last
+func completedBefore(a, b ghJob) bool {
+	if a.CompletedAt.IsZero() != b.CompletedAt.IsZero() {
+		return b.CompletedAt.IsZero()
+	}
+	return a.CompletedAt.Before(b.CompletedAt)
+}
+
+// writeJobOutcomes writes the outcomes of the run's jobs, the root failing job first
+// Nothing is written for a single job, the failed job list says it all.
+func writeJobOutcomes(sb *strings.Builder, run *WorkflowRun) {
+	if len(run.JobOutcomes) < 2 || run.RootFailingJob == "" {
+		return
+	}
+	outcomes := append([]JobOutcome(nil), run.JobOutcomes...)
+	// Root failing job, other failures, cascading jobs, successful jobs
+	rank := func(j JobOutcome) int {
+		switch {
+		case j.Name == run.RootFailingJob:
+			return 0
+		case j.genuineFailure():
+			return 1
+		case j.Cascading:
+			return 2
+		default:
+			return 3
+		}
+	}
+	sort.SliceStable(outcomes, func(a, b int) bool { return rank(outcomes[a]) < rank(outcomes[b]) })
+
+	sb.WriteString(fmt.Sprintf("ROOT FAILING JOB: %q - the first job that failed by itself. Jobs skipped or cancelled after it "+
+		"(e.g. dependent jobs, fail-fast matrix jobs) did not fail by themselves: do not analyze them as failures.\n", run.RootFailingJob))
+	sb.WriteString("Job outcomes:\n")
+	for i, j := range outcomes {
+		if i >= maxJobOutcomes {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(outcomes)-maxJobOutcomes))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  - %s\n", j))
+	}
+}
@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jobsJSON lists the jobs of a run like `gh run view --json jobs`: "build" fails, "deploy" needing
+// it is skipped and a matrix job is cancelled by fail-fast; "docs" was skipped by its `if:` before
+// anything failed and "e2e" failed later by itself. The downstream jobs are listed first.
+const jobsJSON = `{"jobs": [
+	{"name": "deploy", "status": "completed", "conclusion": "skipped",
+	 "startedAt": "2024-01-02T10:05:01Z", "completedAt": "2024-01-02T10:05:01Z", "steps": []},
+	{"name": "test (1.22)", "status": "completed", "conclusion": "cancelled",
+	 "startedAt": "2024-01-02T10:00:02Z", "completedAt": "2024-01-02T10:05:02Z",
+	 "steps": [{"name": "Run go test", "number": 2, "status": "completed", "conclusion": "cancelled"}]},
+	{"name": "docs", "status": "completed", "conclusion": "skipped",
+	 "startedAt": "2024-01-02T10:00:00Z", "completedAt": "2024-01-02T10:00:00Z", "steps": []},
+	{"name": "build", "status": "completed", "conclusion": "failure",
+	 "startedAt": "2024-01-02T10:00:00Z", "completedAt": "2024-01-02T10:05:00Z",
+	 "steps": [
+		{"name": "Set up job", "number": 1, "status": "completed", "conclusion": "success"},
+		{"name": "Run make", "number": 2, "status": "completed", "conclusion": "failure"}
+	 ]},
+	{"name": "e2e", "status": "completed", "conclusion": "failure",
+	 "startedAt": "2024-01-02T10:00:30Z", "completedAt": "2024-01-02T10:06:00Z",
+	 "steps": [{"name": "Run e2e", "number": 2, "status": "completed", "conclusion": "failure"}]}
+]}`
+
+func testJobs(t *testing.T) []ghJob {
+	var data struct {
+		Jobs []ghJob `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(jobsJSON), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data.Jobs
+}
+
+func TestJobOutcomes(t *testing.T) {
+	outcomes, root := jobOutcomes(testJobs(t))
+
+	// The upstream failure completing first is the root, not the skipped job listed before it
+	if root != "build" {
+		t.Errorf("root failing job = %q, want build", root)
+	}
+	want := []JobOutcome{
+		{Name: "deploy", Conclusion: "skipped", StartDelay: 5*time.Minute + time.Second, Cascading: true},
+		{Name: "test (1.22)", Conclusion: "cancelled", StartDelay: 2 * time.Second, Duration: 5 * time.Minute, Ran: true, Cascading: true},
+		{Name: "docs", Conclusion: "skipped"},
+		{Name: "build", Conclusion: "failure", Duration: 5 * time.Minute, Ran: true},
+		{Name: "e2e", Conclusion: "failure", StartDelay: 30 * time.Second, Duration: 5*time.Minute + 30*time.Second, Ran: true},
+	}
+	if !reflect.DeepEqual(outcomes, want) {
+		t.Errorf("outcomes =\n%+v\nwant\n%+v", outcomes, want)
+	}
+
+	var sb strings.Builder
+	writeJobOutcomes(&sb, &WorkflowRun{JobOutcomes: outcomes, RootFailingJob: root})
+	wantSection := `ROOT FAILING JOB: "build"`
+	wantList := "Job outcomes:\n" +
+		"  - \"build\": failure, started +0s, ran 5m0s\n" +
+		"  - \"e2e\": failure, started +30s, ran 5m30s\n" +
+		"  - \"deploy\": skipped, did not run (after the root failure)\n" +
+		"  - \"test (1.22)\": cancelled, started +2s, ran 5m0s (after the root failure)\n" +
+		"  - \"docs\": skipped, did not run\n"
+	if !strings.HasPrefix(sb.String(), wantSection) || !strings.HasSuffix(sb.String(), wantList) {
+		t.Errorf("writeJobOutcomes() =\n%s\nwant the root failing job and\n%s", sb.String(), wantList)
+	}
+}
+
+func TestJobOutcomesWithoutFailure(t *testing.T) {
+	jobs := testJobs(t)
+	var passing []ghJob
+	for _, job := range jobs {
+		if job.Conclusion != "failure" {
+			passing = append(passing, job)
+		}
+	}
+	outcomes, root := jobOutcomes(passing)
+	if root != "" {
+		t.Errorf("root failing job = %q, want none", root)
+	}
+	for _, o := range outcomes {
+		if o.Cascading {
+			t.Errorf("%q is cascading without a root failure", o.Name)
+		}
+	}
+
+	var sb strings.Builder
+	writeJobOutcomes(&sb, &WorkflowRun{JobOutcomes: outcomes})
+	if sb.Len() != 0 {
+		t.Errorf("writeJobOutcomes() = %q, want nothing without a root failing job", sb.String())
+	}
+}
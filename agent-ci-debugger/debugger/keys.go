@@ -0,0 +1,103 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// KeyProvider supplies the API key of the LLM provider, e.g. from a file or a secret manager
+// Implementations must never log the key.
+type KeyProvider interface {
+	// Name identifies the provider in logs, e.g. "file /run/secrets/openai"
+	Name() string
+	// APIKey returns the key, or "" without an error when the provider is not configured so the next
+	// provider is tried; an error stops the lookup
+	APIKey(ctx context.Context) (string, error)
+}
+
+// keyProviderRegistry holds the key providers registered with RegisterKeyProvider, tried in
+// registration order before OPENAI_API_KEY_FILE and the environment variables
+var keyProviderRegistry []KeyProvider
+
+// RegisterKeyProvider adds a key provider tried by ResolveAPIKey for all subsequent lookups
+func RegisterKeyProvider(provider KeyProvider) {
+	keyProviderRegistry = append(keyProviderRegistry, provider)
+}
+
+// FileKeyProvider reads the API key from a file, trimming surrounding whitespace and newlines
+type FileKeyProvider struct {
+	Path string
+}
+
+func (p FileKeyProvider) Name() string { return "file " + p.Path }
+
+func (p FileKeyProvider) APIKey(ctx context.Context) (string, error) {
+	if p.Path == "" {
+		return "", nil
+	}
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		logWarnf("API key file %s is accessible by other users (mode %s), restrict it with chmod 600", p.Path, info.Mode().Perm())
+	}
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", p.Path)
+	}
+	return key, nil
+}
+
+// envFileKeyProvider reads the API key from the file named by OPENAI_API_KEY_FILE
+type envFileKeyProvider struct{}
+
+func (envFileKeyProvider) Name() string { return "OPENAI_API_KEY_FILE" }
+
+func (envFileKeyProvider) APIKey(ctx context.Context) (string, error) {
+	return FileKeyProvider{Path: os.Getenv("OPENAI_API_KEY_FILE")}.APIKey(ctx)
+}
+
+// envKeyProvider reads the API key from the environment variables (see APIKeyFromEnv)
+type envKeyProvider struct{}
+
+func (envKeyProvider) Name() string { return "environment" }
+
+func (envKeyProvider) APIKey(ctx context.Context) (string, error) {
+	return APIKeyFromEnv()
+}
+
+// ResolveAPIKey returns the API key of the first key provider that has one: the registered providers
+// (e.g. a FileKeyProvider for --api-key-file), then the file named by OPENAI_API_KEY_FILE, then the
+// environment variables
+func ResolveAPIKey(ctx context.Context) (string, error) {
+	key, _, err := resolveAPIKey(ctx)
+	return key, err
+}
+
+// resolveAPIKey is ResolveAPIKey, also returning the name of the provider the key came from
+func resolveAPIKey(ctx context.Context) (key, source string, err error) {
+	providers := append(append([]KeyProvider{}, keyProviderRegistry...), envFileKeyProvider{}, envKeyProvider{})
+	for _, provider := range providers {
+		key, err := provider.APIKey(ctx)
+		if err != nil {
+			if _, isEnv := provider.(envKeyProvider); isEnv {
+				return "", "", fmt.Errorf("%w (or provide the key in a file with OPENAI_API_KEY_FILE or --api-key-file)", err)
+			}
+			return "", "", fmt.Errorf("API key from %s: %w", provider.Name(), err)
+		}
+		if key != "" {
+			logDebugf("Using the API key from %s", provider.Name())
+			return key, provider.Name(), nil
+		}
+	}
+	return "", "", errors.New("no API key found")
+}
@@ -0,0 +1,87 @@
+package debugger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileKeyProvider(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "trailing newline", path: write("newline", "sk-test\n"), want: "sk-test"},
+		{name: "windows newlines and blank lines", path: write("crlf", "sk-test\r\n\r\n"), want: "sk-test"},
+		{name: "surrounding whitespace", path: write("spaces", "  sk-test\t\n"), want: "sk-test"},
+		{name: "empty", path: write("empty", "\n\n"), wantErr: "is empty"},
+		{name: "missing", path: filepath.Join(dir, "missing"), wantErr: "failed to read API key file"},
+		{name: "not configured", path: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FileKeyProvider{Path: tt.path}.APIKey(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("APIKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("APIKey() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	saved := keyProviderRegistry
+	t.Cleanup(func() { keyProviderRegistry = saved })
+	keyProviderRegistry = nil
+	t.Setenv("AZURE_OPENAI_ENDPOINT", "")
+	t.Setenv("OPENAI_API_KEY", "sk-env")
+	t.Setenv("OPENAI_API_KEY_FILE", "")
+
+	if key, source, err := resolveAPIKey(context.Background()); key != "sk-env" || source != "environment" || err != nil {
+		t.Errorf("resolveAPIKey() = %q, %q, %v, want the environment variable", key, source, err)
+	}
+
+	envFile := filepath.Join(t.TempDir(), "env-key")
+	os.WriteFile(envFile, []byte("sk-env-file\n"), 0600)
+	t.Setenv("OPENAI_API_KEY_FILE", envFile)
+	if key, source, err := resolveAPIKey(context.Background()); key != "sk-env-file" || source != "OPENAI_API_KEY_FILE" || err != nil {
+		t.Errorf("resolveAPIKey() = %q, %q, %v, want OPENAI_API_KEY_FILE first", key, source, err)
+	}
+
+	flagFile := filepath.Join(t.TempDir(), "flag-key")
+	os.WriteFile(flagFile, []byte("sk-flag-file\n"), 0600)
+	RegisterKeyProvider(FileKeyProvider{Path: flagFile})
+	if key, source, err := resolveAPIKey(context.Background()); key != "sk-flag-file" || source != "file "+flagFile || err != nil {
+		t.Errorf("resolveAPIKey() = %q, %q, %v, want the registered provider first", key, source, err)
+	}
+
+	// A configured provider failing stops the lookup rather than falling back
+	keyProviderRegistry = []KeyProvider{FileKeyProvider{Path: filepath.Join(t.TempDir(), "missing")}}
+	if _, _, err := resolveAPIKey(context.Background()); err == nil || !strings.Contains(err.Error(), "API key from file") {
+		t.Errorf("resolveAPIKey() with a missing key file error = %v", err)
+	}
+
+	keyProviderRegistry = nil
+	t.Setenv("OPENAI_API_KEY_FILE", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, _, err := resolveAPIKey(context.Background()); err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY_FILE or --api-key-file") {
+		t.Errorf("resolveAPIKey() without a key error = %v, want the alternatives named", err)
+	}
+}
@@ -0,0 +1,168 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultKeywordsFile is read from the working directory when no keywords file is given
+const DefaultKeywordsFile = ".workflow-debugger-keywords"
+
+// CustomKeywordSeverity is the severity of lines containing an extra keyword (Options.ExtraKeywords)
+// A repository lists its own failure markers, e.g. "HEALTHCHECK FAILED", so they rank above generic errors.
+const CustomKeywordSeverity = 85
+
+// defaultErrorKeywords mark log lines that are kept first when the logs are filtered for the prompt
+var defaultErrorKeywords = []string{
+	"error", "failed", "fatal", "panic",
+	"timed out", "timeout",
+	"exit code",
+	"assertion", "expected", "actual",
+	"stack trace",
+	"FAIL:", "FAIL ", "✗", "❌",
+}
+
+// localeKeywords are the error words of localized tool output, by language code
+// Runners with a non-English locale print messages such as "Fehler" or "échec" that the
+// English keywords miss. All locales are matched unless Options.Locales selects some.
+var localeKeywords = map[string][]string{
+	"de": {"Fehler", "fehlgeschlagen", "Zeitüberschreitung", "Ausnahme", "Abbruch"},
+	"fr": {"erreur", "échec", "échoué", "délai d'attente dépassé"},
+	"es": {"fallo", "falló", "tiempo de espera agotado", "excepción"},
+	"it": {"errore", "fallito", "non riuscito", "eccezione"},
+	"pt": {"erro", "falha", "falhou", "tempo esgotado", "exceção"},
+}
+
+// RegisterLocaleKeywords adds error keywords for a locale, extending a built-in one
+func RegisterLocaleKeywords(locale string, keywords ...string) {
+	locale = strings.ToLower(locale)
+	localeKeywords[locale] = append(localeKeywords[locale], keywords...)
+}
+
+// KnownLocales returns the locales with error keywords, sorted
+func KnownLocales() []string {
+	locales := make([]string, 0, len(localeKeywords))
+	for locale := range localeKeywords {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// ParseLocales parses the comma-separated --locales value; "none" matches English keywords only
+func ParseLocales(value string) ([]string, error) {
+	locales := []string{}
+	for _, locale := range strings.Split(value, ",") {
+		locale = strings.ToLower(strings.TrimSpace(locale))
+		if locale == "" || locale == "none" {
+			continue
+		}
+		if _, ok := localeKeywords[locale]; !ok {
+			return nil, fmt.Errorf("unknown locale %q (available: %s)", locale, strings.Join(KnownLocales(), ", "))
+		}
+		locales = append(locales, locale)
+	}
+	return locales, nil
+}
+
+// LoadKeywordsFile reads extra error keywords from path, one per line, matched as case-insensitive substrings
+// Blank lines and lines starting with # are skipped. A missing file is not an error unless required is set.
+func LoadKeywordsFile(path string, required bool) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read keywords file: %w", err)
+	}
+	defer f.Close()
+
+	var keywords []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keyword := strings.TrimSpace(scanner.Text())
+		if keyword == "" || strings.HasPrefix(keyword, "#") {
+			continue
+		}
+		keywords = append(keywords, keyword)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keywords file: %w", err)
+	}
+
+	logDebugf("Loaded %d error keywords from %s", len(keywords), path)
+	return keywords, nil
+}
+
+// errorKeywords returns the lowercased keywords a relevant log line contains
+func (d *GitHubWorkflowDebugger) errorKeywords() []string {
+	locales := d.Options.Locales
+	if locales == nil {
+		locales = KnownLocales()
+	}
+
+	keywords := append([]string{}, defaultErrorKeywords...)
+	for _, locale := range locales {
+		keywords = append(keywords, localeKeywords[locale]...)
+	}
+	keywords = append(keywords, d.Options.ExtraKeywords...)
+
+	for i, keyword := range keywords {
+		keywords[i] = strings.ToLower(keyword)
+	}
+	return keywords
+}
+
+// matchesKeyword reports whether line contains one of the lowercased keywords, ignoring case
+func matchesKeyword(line string, keywords []string) bool {
+	lineLower := strings.ToLower(line)
+	for _, keyword := range keywords {
+		if strings.Contains(lineLower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// keywordFindings adds an error finding for every line containing an extra keyword that no detector
+// reported as an error, so the failure markers of the repository reach the error summary.
+// The findings are returned in log order.
+func (d *GitHubWorkflowDebugger) keywordFindings(lines []string, findings []Finding) []Finding {
+	if len(d.Options.ExtraKeywords) == 0 {
+		return findings
+	}
+	keywords := make([]string, 0, len(d.Options.ExtraKeywords))
+	for _, keyword := range d.Options.ExtraKeywords {
+		keywords = append(keywords, strings.ToLower(keyword))
+	}
+	reported := make(map[int]bool)
+	for _, finding := range findings {
+		if finding.Category == CategoryError {
+			reported[finding.Line] = true
+		}
+	}
+
+	added := 0
+	for i, line := range lines {
+		if reported[i] {
+			continue
+		}
+		// The job/step prefix of gh logs must not match a keyword
+		content := line
+		if _, _, rest, ok := splitLogPrefix(line); ok {
+			content = rest
+		}
+		if matchesKeyword(content, keywords) {
+			findings = append(findings, Finding{Detector: "keywords", Category: CategoryError, Message: strings.TrimSpace(line), Line: i})
+			added++
+		}
+	}
+	if added > 0 {
+		sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	}
+	return findings
+}
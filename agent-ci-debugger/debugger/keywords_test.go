@@ -0,0 +1,138 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// localizedLogs are logs of a German runner: the error line comes early, followed by lots of output
+func localizedLogs() string {
+	lines := []string{"build\tRun tests\t2024-01-01T00:00:00.0000000Z Fehler: Verbindung zur Datenbank fehlgeschlagen"}
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("build\tRun tests\t2024-01-01T00:00:01.0000000Z Schritt %d abgeschlossen", i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLocalizedKeywordsPromoted(t *testing.T) {
+	tests := []struct {
+		name     string
+		locales  []string
+		promoted bool
+	}{
+		{name: "all locales by default", locales: nil, promoted: true},
+		{name: "german", locales: []string{"de"}, promoted: true},
+		{name: "french only", locales: []string{"fr"}},
+		{name: "english only", locales: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{Options: Options{Locales: tt.locales}}
+			got, budget := d.filterRelevantLogs(localizedLogs(), 1000)
+			if promoted := strings.Contains(got, "Verbindung zur Datenbank fehlgeschlagen"); promoted != tt.promoted {
+				t.Errorf("error line kept = %v, want %v:\n%s", promoted, tt.promoted, got)
+			}
+			if (budget.RelevantLines > 0) != tt.promoted {
+				t.Errorf("RelevantLines = %d, want the error line relevant: %v", budget.RelevantLines, tt.promoted)
+			}
+		})
+	}
+}
+
+func TestParseLocales(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "de", want: []string{"de"}},
+		{value: " DE , fr ", want: []string{"de", "fr"}},
+		{value: "none", want: []string{}},
+		{value: "", want: []string{}},
+		{value: "de,xx", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLocales(tt.value)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "available: de, es, fr") {
+				t.Errorf("ParseLocales(%q) error = %v, want the known locales listed", tt.value, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLocales(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadKeywordsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultKeywordsFile)
+	content := "# failure markers of our test harness\n\nSMOKE CHECK RED\r\n  deploy gate closed  \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keywords, err := LoadKeywordsFile(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"SMOKE CHECK RED", "deploy gate closed"}; !reflect.DeepEqual(keywords, want) {
+		t.Errorf("keywords = %q, want %q", keywords, want)
+	}
+
+	missing := filepath.Join(dir, "missing")
+	if keywords, err := LoadKeywordsFile(missing, false); err != nil || keywords != nil {
+		t.Errorf("optional missing file = %q, %v, want no keywords", keywords, err)
+	}
+	if _, err := LoadKeywordsFile(missing, true); err == nil {
+		t.Error("required missing file loaded without an error")
+	}
+}
+
+// customMarkerLogs are logs whose only failure sign is a repository-specific marker early on
+func customMarkerLogs() string {
+	lines := []string{"smoke\tRun checks\t2024-01-01T00:00:00.0000000Z smoke check red: /api/health returned 503"}
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("smoke\tRun checks\t2024-01-01T00:00:01.0000000Z probe %d done", i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCustomKeywordPromoted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultKeywordsFile)
+	if err := os.WriteFile(path, []byte("SMOKE CHECK RED\nsmoke\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keywords, err := LoadKeywordsFile(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, extra := range [][]string{nil, keywords} {
+		d := &GitHubWorkflowDebugger{Options: Options{ExtraKeywords: extra}}
+		got, _ := d.filterRelevantLogs(customMarkerLogs(), 1000)
+		if promoted := strings.Contains(got, "/api/health returned 503"); promoted != (extra != nil) {
+			t.Errorf("keywords %q: marker line kept = %v", extra, promoted)
+		}
+	}
+
+	// "smoke" only matches the job name of the prefix of the probe lines, which are not errors
+	d := &GitHubWorkflowDebugger{Options: Options{ExtraKeywords: []string{"SMOKE CHECK RED"}}}
+	summary := d.parseErrorSummary(customMarkerLogs())
+	d.Options.ExtraKeywords = keywords
+	if got := d.parseErrorSummary(customMarkerLogs()); !reflect.DeepEqual(got.Findings, summary.Findings) {
+		t.Errorf("findings = %+v, want only the marker line", got.Findings)
+	}
+	if len(summary.Findings) != 1 {
+		t.Fatalf("findings = %+v, want the marker line", summary.Findings)
+	}
+	f := summary.Findings[0]
+	if f.Detector != "keywords" || f.Category != CategoryError || f.Line != 0 || f.Severity != CustomKeywordSeverity ||
+		!strings.Contains(f.Message, "/api/health returned 503") {
+		t.Errorf("finding = %+v, want an error of severity %d", f, CustomKeywordSeverity)
+	}
+}
@@ -0,0 +1,65 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoFailedRuns is returned by LatestFailedRun when the workflow has no failed runs
+var ErrNoFailedRuns = errors.New("no failed runs found")
+
+// FailedRun is a failed run found by LatestFailedRun
+type FailedRun struct {
+	Ref       RunRef
+	Title     string // commit or pull request title of the run
+	Branch    string
+	Event     string // event that triggered the run, e.g. "push"
+	CreatedAt time.Time
+}
+
+// LatestFailedRun finds the most recent failed run of a workflow with `gh run list`
+// workflow is the workflow file name (e.g. "ci.yml"), its name or its ID; branch may be empty to
+// search all branches.
+func LatestFailedRun(repo, workflow, branch string) (*FailedRun, error) {
+	if !repositoryRe.MatchString(repo) {
+		return nil, fmt.Errorf("invalid repository %q (expected owner/repo)", repo)
+	}
+	args := []string{"run", "list", "--repo", repo, "--workflow", workflow, "--status", "failure", "--limit", "1",
+		"--json", "databaseId,url,displayTitle,headBranch,event,createdAt"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	output, err := gh(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs of workflow %s: %w", workflow, err)
+	}
+
+	var runs []struct {
+		DatabaseID   int64     `json:"databaseId"`
+		URL          string    `json:"url"`
+		DisplayTitle string    `json:"displayTitle"`
+		HeadBranch   string    `json:"headBranch"`
+		Event        string    `json:"event"`
+		CreatedAt    time.Time `json:"createdAt"`
+	}
+	if err := json.Unmarshal(output, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse runs: %w", err)
+	}
+	if len(runs) == 0 {
+		if branch != "" {
+			return nil, fmt.Errorf("%w for workflow %s on branch %s in %s", ErrNoFailedRuns, workflow, branch, repo)
+		}
+		return nil, fmt.Errorf("%w for workflow %s in %s", ErrNoFailedRuns, workflow, repo)
+	}
+
+	r := runs[0]
+	return &FailedRun{
+		Ref:       RunRef{Repository: repo, RunID: fmt.Sprint(r.DatabaseID), URL: r.URL},
+		Title:     r.DisplayTitle,
+		Branch:    r.HeadBranch,
+		Event:     r.Event,
+		CreatedAt: r.CreatedAt,
+	}, nil
+}
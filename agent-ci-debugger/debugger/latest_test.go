@@ -0,0 +1,66 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatestFailedRun(t *testing.T) {
+	var query url.Values
+	runs := `{"workflow_runs":[{"id":42,"html_url":"https://github.com/o/r/actions/runs/42",` +
+		`"display_title":"Bump x/net","head_branch":"main","event":"push","created_at":"2024-05-01T10:00:00Z"}]}`
+	stubGitHubHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		switch {
+		case r.URL.Path != "/repos/o/r/actions/workflows/ci.yml/runs":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		case query.Get("branch") == "empty":
+			fmt.Fprint(w, `{"workflow_runs":[]}`)
+		default:
+			fmt.Fprint(w, runs)
+		}
+	}))
+
+	run, err := LatestFailedRun("o/r", "ci.yml", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FailedRun{
+		Ref:       RunRef{Repository: "o/r", RunID: "42", URL: "https://github.com/o/r/actions/runs/42"},
+		Title:     "Bump x/net",
+		Branch:    "main",
+		Event:     "push",
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if run.Ref != want.Ref || run.Title != want.Title || run.Branch != want.Branch || run.Event != want.Event ||
+		!run.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("LatestFailedRun = %+v, want %+v", *run, want)
+	}
+	if query.Get("status") != "failure" || query.Get("per_page") != "1" || query.Has("branch") {
+		t.Errorf("query = %v, want status=failure, per_page=1 and no branch", query)
+	}
+
+	if _, err := LatestFailedRun("o/r", "ci.yml", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("branch") != "main" {
+		t.Errorf("branch = %q, want main", query.Get("branch"))
+	}
+
+	_, err = LatestFailedRun("o/r", "ci.yml", "empty")
+	if !errors.Is(err, ErrNoFailedRuns) || !strings.Contains(err.Error(), "on branch empty in o/r") {
+		t.Errorf("error = %v, want ErrNoFailedRuns on branch empty", err)
+	}
+	if _, err := LatestFailedRun("o/r", "missing.yml", ""); err == nil || errors.Is(err, ErrNoFailedRuns) {
+		t.Errorf("error = %v, want the failed listing", err)
+	}
+	if _, err := LatestFailedRun("not-a-repo", "ci.yml", ""); err == nil || !strings.Contains(err.Error(), "invalid repository") {
+		t.Errorf("error = %v, want an invalid repository", err)
+	}
+}
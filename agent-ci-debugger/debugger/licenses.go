@@ -0,0 +1,126 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// LicenseViolation is a dependency rejected by a license or compliance scan rather than a failure of the code
+type LicenseViolation struct {
+	Tool    string // scanner reporting the violation, e.g. "go-licenses" or "fossa"
+	Package string // offending dependency, empty when the line does not name it
+	License string // offending license, empty when the line does not name it or it is unknown
+	Message string // the log line reporting the violation
+	Line    int    // 0-based index of the log line
+}
+
+func (v LicenseViolation) String() string {
+	var sb strings.Builder
+	switch {
+	case v.Package != "" && v.License != "":
+		sb.WriteString(fmt.Sprintf("%s: %s", v.Package, v.License))
+	case v.Package != "":
+		sb.WriteString(fmt.Sprintf("%s: unknown license", v.Package))
+	case v.License != "":
+		sb.WriteString(v.License)
+	default:
+		sb.WriteString(truncateText(v.Message, 300))
+	}
+	sb.WriteString(fmt.Sprintf(" (%s)", v.Tool))
+	return sb.String()
+}
+
+// licensePattern is a failure signature of a license scanner; its "package" and "license" groups,
+// when present, name the offending dependency and license
+type licensePattern struct {
+	tool string
+	re   *regexp.Regexp
+}
+
+// licensePatterns are tried in order, the first match describes the line
+var licensePatterns = []licensePattern{
+	// go-licenses check: "Forbidden license type GNU General Public License v3.0 for library github.com/foo/bar"
+	{"go-licenses", regexp.MustCompile(`Forbidden license type (?P<license>.+?) for library (?P<package>\S+)`)},
+	// go-licenses check --allowed_licenses: "Not allowed license GPL-3.0 found for library github.com/foo/bar"
+	{"go-licenses", regexp.MustCompile(`Not allowed license (?P<license>\S+) found for library (?P<package>\S+)`)},
+	// go-licenses: "Failed to find license for github.com/foo/bar: cannot find a known open source license ..."
+	{"go-licenses", regexp.MustCompile(`Failed to find license for (?P<package>[^\s:]+)`)},
+	// fossa test: "Test failed. Number of issues found: 2", "Test Failed! 2 issues found"
+	{"fossa", regexp.MustCompile(`(?i)test failed[.!]?\s*(?:number of issues found:\s*\d+|\d+ issues? found)`)},
+	// fossa test issue listing: "Denied by Policy: GPL-3.0 (npm+left-pad$1.3.0)"
+	{"fossa", regexp.MustCompile(`(?i)(?:denied|flagged) by (?:license )?policy(?::\s*(?P<license>[^\s(]+)(?:\s*\((?P<package>[^)\s]+)\))?)?`)},
+	// license-checker --failOn: `Found license defined by the --failOn flag: "GPL-3.0". Exiting.`
+	{"license-checker", regexp.MustCompile(`Found license defined by the --failOn flag: "(?P<license>[^"]+)"`)},
+	// cargo-deny check licenses: "error[rejected]: failed to satisfy license requirements"
+	{"cargo-deny", regexp.MustCompile(`error\[(?:rejected|unlicensed)\]: .*licen[cs]e`)},
+	// pip-licenses --fail-on: "fail-on license GPL-3.0 was found for package foo:1.2.3"
+	{"pip-licenses", regexp.MustCompile(`fail-on license (?P<license>.+?) was found for package (?P<package>[^\s:]+)`)},
+	// pip-licenses --allow-only: "license GPL-3.0 not in allow-only licenses was found for package foo:1.2.3"
+	{"pip-licenses", regexp.MustCompile(`license (?P<license>.+?) not in allow-only licenses was found for package (?P<package>[^\s:]+)`)},
+	// license-maven-plugin failOnBlacklist: "There are some forbidden licenses used, please check your dependencies."
+	{"license-maven-plugin", regexp.MustCompile(`There are some forbidden licenses used`)},
+}
+
+// licenseDetector recognizes failures of license and compliance scans (go-licenses, FOSSA,
+// license-checker, cargo-deny, pip-licenses, license-maven-plugin). These need a dependency or license policy decision instead of a
+// code change, so they are collected into LicenseViolations.
+type licenseDetector struct{}
+
+func (licenseDetector) Name() string { return "license" }
+
+func (licenseDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i, line := range lines {
+		content := strings.TrimSpace(logContent(line))
+		for _, p := range licensePatterns {
+			m := p.re.FindStringSubmatch(content)
+			if m == nil {
+				continue
+			}
+			violation := LicenseViolation{Tool: p.tool, Message: content, Line: i}
+			if g := p.re.SubexpIndex("package"); g >= 0 {
+				violation.Package = m[g]
+			}
+			if g := p.re.SubexpIndex("license"); g >= 0 {
+				violation.License = strings.TrimSpace(m[g])
+			}
+			findings = append(findings, Finding{Category: CategoryLicense, Message: content, Line: i, License: &violation})
+			break
+		}
+	}
+
+	return findings
+}
+
+// writeLicenseViolations steers the analysis toward the offending dependencies and the license policy
+func writeLicenseViolations(sb *strings.Builder, violations []LicenseViolation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	var tools []string
+	seen := make(map[string]bool)
+	for _, v := range violations {
+		if !seen[v.Tool] {
+			seen[v.Tool] = true
+			tools = append(tools, v.Tool)
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("LICENSE COMPLIANCE FAILURES (%d total, %s) - a license scan rejected dependencies. This needs a dependency "+
+		"or license policy decision, not an application code change:\n", len(violations), strings.Join(tools, ", ")))
+	sb.WriteString("  Propose replacing or removing the offending dependency, or upgrading it to a version under an allowed license. " +
+		"Only when the license is acceptable to the project, propose allowing it (or the package) in the scanner configuration " +
+		"(go-licenses --allowed_licenses/--ignore, the FOSSA policy or .fossa.yml, license-checker --failOn, deny.toml [licenses], " +
+		"pip-licenses --fail-on/--allow-only, the license-maven-plugin includedLicenses) " +
+		"and say that this is a legal decision for the maintainers.\n")
+	for i, v := range violations {
+		if i >= 5 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(violations)-5))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  - %s\n", v))
+	}
+}
@@ -0,0 +1,117 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLicenseDetector(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    LicenseViolation
+	}{
+		{
+			name:    "go-licenses forbidden",
+			content: "F0528 10:02:11.123456    2042 main.go:77] Forbidden license type GNU General Public License v3.0 for library github.com/foo/bar",
+			want:    LicenseViolation{Tool: "go-licenses", Package: "github.com/foo/bar", License: "GNU General Public License v3.0"},
+		},
+		{
+			name:    "go-licenses not allowed",
+			content: "E0528 10:02:11.123456    2042 check.go:91] Not allowed license AGPL-3.0 found for library github.com/foo/agpl",
+			want:    LicenseViolation{Tool: "go-licenses", Package: "github.com/foo/agpl", License: "AGPL-3.0"},
+		},
+		{
+			name:    "go-licenses unknown",
+			content: "E0528 10:02:11.123456    2042 library.go:101] Failed to find license for github.com/foo/nolicense: cannot find a known open source license for \"github.com/foo/nolicense\"",
+			want:    LicenseViolation{Tool: "go-licenses", Package: "github.com/foo/nolicense"},
+		},
+		{
+			name:    "fossa test",
+			content: "Test failed. Number of issues found: 2",
+			want:    LicenseViolation{Tool: "fossa"},
+		},
+		{
+			name:    "fossa policy",
+			content: "Denied by Policy: GPL-3.0 (npm+left-pad$1.3.0)",
+			want:    LicenseViolation{Tool: "fossa", Package: "npm+left-pad$1.3.0", License: "GPL-3.0"},
+		},
+		{
+			name:    "license-checker",
+			content: `Found license defined by the --failOn flag: "GPL-3.0". Exiting.`,
+			want:    LicenseViolation{Tool: "license-checker", License: "GPL-3.0"},
+		},
+		{
+			name:    "cargo-deny",
+			content: "error[rejected]: failed to satisfy license requirements",
+			want:    LicenseViolation{Tool: "cargo-deny"},
+		},
+		{
+			name:    "pip-licenses fail-on",
+			content: "fail-on license GNU General Public License v3 (GPLv3) was found for package mysql-connector:2.2.9",
+			want:    LicenseViolation{Tool: "pip-licenses", Package: "mysql-connector", License: "GNU General Public License v3 (GPLv3)"},
+		},
+		{
+			name:    "pip-licenses allow-only",
+			content: "license LGPL not in allow-only licenses was found for package chardet:3.0.4",
+			want:    LicenseViolation{Tool: "pip-licenses", Package: "chardet", License: "LGPL"},
+		},
+		{
+			name:    "license-maven-plugin",
+			content: "[ERROR] Failed to execute goal org.codehaus.mojo:license-maven-plugin:2.4.0:add-third-party (default) on project app: There are some forbidden licenses used, please check your dependencies.",
+			want:    LicenseViolation{Tool: "license-maven-plugin"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := licenseDetector{}.Detect(fixtureLines("licenses", "Run license scan", "ok", tt.content))
+			if len(findings) != 1 {
+				t.Fatalf("findings = %+v, want one", findings)
+			}
+			f := findings[0]
+			if f.Category != CategoryLicense || f.License == nil || f.Message != tt.content || f.Line != 1 {
+				t.Fatalf("finding = %+v, want a license failure at line 1", f)
+			}
+			want := tt.want
+			want.Message, want.Line = tt.content, 1
+			if *f.License != want {
+				t.Errorf("LicenseViolation = %+v, want %+v", *f.License, want)
+			}
+		})
+	}
+
+	for _, content := range []string{
+		"Forbidden license types: none",
+		"fossa test: 0 issues found",
+		"Checking licenses of 42 packages",
+		"--- FAIL: TestLicenseHeader (0.00s)",
+	} {
+		if findings := (licenseDetector{}).Detect(fixtureLines("licenses", "Run license scan", content)); len(findings) != 0 {
+			t.Errorf("Detect(%q) = %+v, want none", content, findings)
+		}
+	}
+}
+
+func TestLicenseViolationString(t *testing.T) {
+	tests := []struct {
+		violation LicenseViolation
+		want      string
+	}{
+		{LicenseViolation{Tool: "go-licenses", Package: "github.com/foo/bar", License: "GPL-3.0"}, "github.com/foo/bar: GPL-3.0 (go-licenses)"},
+		{LicenseViolation{Tool: "go-licenses", Package: "github.com/foo/bar"}, "github.com/foo/bar: unknown license (go-licenses)"},
+		{LicenseViolation{Tool: "license-checker", License: "GPL-3.0"}, "GPL-3.0 (license-checker)"},
+		{LicenseViolation{Tool: "fossa", Message: "Test failed. Number of issues found: 2"}, "Test failed. Number of issues found: 2 (fossa)"},
+	}
+	for _, tt := range tests {
+		if got := tt.violation.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+
+	var sb strings.Builder
+	writeLicenseViolations(&sb, []LicenseViolation{tests[0].violation, tests[2].violation, {Tool: "go-licenses", Package: "github.com/foo/agpl"}})
+	if !strings.Contains(sb.String(), "LICENSE COMPLIANCE FAILURES (3 total, go-licenses, license-checker)") ||
+		!strings.Contains(sb.String(), "  - github.com/foo/agpl: unknown license (go-licenses)\n") {
+		t.Errorf("writeLicenseViolations() =\n%s", sb.String())
+	}
+}
@@ -0,0 +1,166 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLLMDumpBytes caps each request and response body in the LLM debug dump (Options.DebugLLM)
+const maxLLMDumpBytes = 64 << 10
+
+// dumpSecretMargin is read beyond maxLLMDumpBytes of a response, so a secret crossing the cut is
+// still recognized and redacted before the body is truncated
+const dumpSecretMargin = 8 << 10
+
+// redactedSecret replaces the secrets in the LLM debug dump
+const redactedSecret = "[REDACTED]"
+
+// secretPatterns match credentials that may end up in the logs, and so in the prompt: GitHub and
+// OpenAI tokens, AWS access keys and private keys
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// credentialRe matches the credentials of Authorization values, keeping the scheme
+var credentialRe = regexp.MustCompile(`(?i)\b(bearer|basic|token)\s+[A-Za-z0-9._~+/=-]{16,}`)
+
+// minKnownSecretChars is the length below which known secret values are not redacted: replacing a
+// short value everywhere would garble the dump without protecting anything
+const minKnownSecretChars = 8
+
+// redactSecrets replaces the known secret values and anything looking like a credential in text
+func redactSecrets(text string, known ...string) string {
+	for _, secret := range known {
+		if len(secret) >= minKnownSecretChars {
+			text = strings.ReplaceAll(text, secret, redactedSecret)
+		}
+	}
+	for _, re := range secretPatterns {
+		text = re.ReplaceAllString(text, redactedSecret)
+	}
+	return credentialRe.ReplaceAllString(text, "${1} "+redactedSecret)
+}
+
+// llmDumpMu serializes the entries of the concurrent analyses of a batch
+var llmDumpMu sync.Mutex
+
+// llmDumpKey is the context key of the LLM debug dump of a request
+type llmDumpKey struct{}
+
+// llmDump writes the requests to and the raw responses of the LLM API to a file or stderr, to
+// diagnose prompts and unparseable answers without re-running the analysis. Headers are never
+// written, and the bodies are redacted and capped at maxLLMDumpBytes.
+type llmDump struct {
+	path    string   // file appended to, "-" for stderr
+	secrets []string // values redacted in addition to the secretPatterns
+}
+
+// llmDumper returns the LLM debug dump of Options.DebugLLM, nil when it is disabled
+func (d *GitHubWorkflowDebugger) llmDumper() *llmDump {
+	if d.Options.DebugLLM == "" {
+		return nil
+	}
+	return &llmDump{
+		path:    d.Options.DebugLLM,
+		secrets: []string{d.apiKey, os.Getenv("OPENAI_API_KEY"), os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN")},
+	}
+}
+
+// write appends an entry titled title with the redacted, truncated body
+func (l *llmDump) write(title string, body []byte, size int) {
+	text := redactSecrets(string(body), l.secrets...)
+	if len(text) > maxLLMDumpBytes {
+		cut := maxLLMDumpBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "\n... [truncated]"
+	}
+	entry := fmt.Sprintf("=== %s %s (%d bytes)\n%s\n", time.Now().UTC().Format(time.RFC3339), title, size, text)
+
+	llmDumpMu.Lock()
+	defer llmDumpMu.Unlock()
+	if l.path == "-" {
+		os.Stderr.WriteString(entry)
+		return
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		logWarnf("failed to write the LLM debug dump: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		logWarnf("failed to write the LLM debug dump: %v", err)
+	}
+}
+
+// llmDumpTransport writes the requests carrying an LLM debug dump in their context, and their responses,
+// to the dump. It is the innermost transport, so the dump shows the requests as sent.
+type llmDumpTransport struct {
+	base http.RoundTripper
+}
+
+func (t *llmDumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	dump, _ := req.Context().Value(llmDumpKey{}).(*llmDump)
+	if dump == nil {
+		return t.base.RoundTrip(req)
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	dump.write(fmt.Sprintf("request %s %s", req.Method, req.URL.Redacted()), body, len(body))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		dump.write("request failed", []byte(err.Error()), len(err.Error()))
+		return nil, err
+	}
+	resp.Body = &dumpedBody{ReadCloser: resp.Body, dump: dump, title: "response " + resp.Status}
+	return resp, nil
+}
+
+// dumpedBody is a response body written to the LLM debug dump when it is closed, so streamed
+// responses reach the client unchanged
+type dumpedBody struct {
+	io.ReadCloser
+	dump  *llmDump
+	title string
+	buf   bytes.Buffer
+	size  int
+	once  sync.Once
+}
+
+func (b *dumpedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+	if room := maxLLMDumpBytes + dumpSecretMargin - b.buf.Len(); room > 0 {
+		b.buf.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *dumpedBody) Close() error {
+	b.once.Do(func() { b.dump.write(b.title, b.buf.Bytes(), b.size) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,56 @@
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+)
+
+// logLevel is the minimum level of diagnostic output
+// Info by default, Debug with --verbose and Warn with --quiet.
+var logLevel = new(slog.LevelVar)
+
+// logger writes leveled diagnostic output to stderr
+var logger = slog.New(slog.NewTextHandler(stdLogWriter{}, &slog.HandlerOptions{Level: logLevel}))
+
+// stdLogWriter forwards to the standard log package output (stderr by default)
+// so diagnostic output can still be redirected with log.SetOutput, e.g. by the spinner.
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	return log.Writer().Write(p)
+}
+
+// SetLogLevel configures the log level from the --verbose and --quiet flags
+func SetLogLevel(verbose, quiet bool) {
+	switch {
+	case quiet:
+		logLevel.Set(slog.LevelWarn)
+	case verbose:
+		logLevel.Set(slog.LevelDebug)
+	default:
+		logLevel.Set(slog.LevelInfo)
+	}
+}
+
+// Logf logs a message at level, e.g. for programs embedding the debugger that share its log output
+func Logf(level slog.Level, format string, args ...any) {
+	ctx := context.Background()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.Log(ctx, level, fmt.Sprintf(format, args...))
+}
+
+// logDebugf logs detailed progress and statistics, shown with --verbose
+func logDebugf(format string, args ...any) { Logf(slog.LevelDebug, format, args...) }
+
+// logInfof logs the main stages of the analysis
+func logInfof(format string, args ...any) { Logf(slog.LevelInfo, format, args...) }
+
+// logWarnf logs recoverable problems
+func logWarnf(format string, args ...any) { Logf(slog.LevelWarn, format, args...) }
+
+// logErrorf logs failures
+func logErrorf(format string, args ...any) { Logf(slog.LevelError, format, args...) }
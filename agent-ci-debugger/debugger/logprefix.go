@@ -0,0 +1,62 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// promptLine is a log line as written to the prompt
+// Without the job/step prefix and timestamp of gh logs, the job and step are kept as the line's
+// group and written once as a header where the group changes.
+type promptLine struct {
+	group string // "<job> / <step>", empty when the prefix is kept or missing
+	text  string
+}
+
+// promptLines prepares log lines for the prompt
+// The "<job>\t<step>\t<timestamp>" prefix repeated on every line costs a large part of the
+// token budget, so it is stripped unless KeepLogPrefixes is set. The logs stored in the
+// WorkflowRun keep their prefixes for the step and job detection.
+func (d *GitHubWorkflowDebugger) promptLines(lines []string) []promptLine {
+	result := make([]promptLine, 0, len(lines))
+	stripped := 0
+	for _, line := range lines {
+		if d.Options.KeepLogPrefixes {
+			result = append(result, promptLine{text: line})
+			continue
+		}
+		pl := promptLine{text: logContent(line)}
+		if job, step, _, ok := splitLogPrefix(line); ok {
+			pl.group = job + " / " + step
+		}
+		stripped += len(line) - len(pl.text)
+		result = append(result, pl)
+	}
+	if stripped > 0 {
+		logDebugf("Stripped %d chars of job/step prefixes and timestamps", stripped)
+	}
+	return result
+}
+
+// header returns the group header written before the line, empty if the group continues
+func (l promptLine) header(prevGroup string) string {
+	if l.group == "" || l.group == prevGroup {
+		return ""
+	}
+	return fmt.Sprintf("==> %s <==\n", l.group)
+}
+
+// size returns the number of chars write adds after a line of prevGroup
+func (l promptLine) size(prevGroup string) int {
+	return len(l.header(prevGroup)) + len(l.text) + 1
+}
+
+// write writes the line, preceded by a header when the group changes, and returns the chars written
+func (l promptLine) write(sb *strings.Builder, group *string) int {
+	text := l.header(*group) + l.text + "\n"
+	sb.WriteString(text)
+	if l.group != "" {
+		*group = l.group
+	}
+	return len(text)
+}
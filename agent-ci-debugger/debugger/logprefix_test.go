@@ -0,0 +1,59 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+// prefixedLogs are logs as printed by gh run view --log
+const prefixedLogs = "build\tSet up job\t2024-05-02T10:00:00.1234567Z Current runner version: '2.316.0'\n" +
+	"build\tRun make\t2024-05-02T10:00:05.0000000Z gcc -c main.c\n" +
+	"build\tRun make\t2024-05-02T10:00:06.0000000Z main.c:3:10: fatal error: missing.h: No such file or directory\n" +
+	"build\tRun make\t2024-05-02T10:00:06.5000000Z make: *** [Makefile:2: all] Error 1\n" +
+	"test\tRun make check\t2024-05-02T10:01:00.0000000Z \tFAIL: test_parse"
+
+func TestPromptLinesStripPrefixes(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	var sb strings.Builder
+	group := ""
+	written := 0
+	for _, l := range d.promptLines(strings.Split(prefixedLogs, "\n")) {
+		size := l.size(group)
+		if n := l.write(&sb, &group); n != size {
+			t.Errorf("write() of %q = %d chars, size() = %d", l.text, n, size)
+		}
+		written += size
+	}
+	want := "==> build / Set up job <==\n" +
+		"Current runner version: '2.316.0'\n" +
+		"==> build / Run make <==\n" +
+		"gcc -c main.c\n" +
+		"main.c:3:10: fatal error: missing.h: No such file or directory\n" +
+		"make: *** [Makefile:2: all] Error 1\n" +
+		"==> test / Run make check <==\n" +
+		"\tFAIL: test_parse\n"
+	if sb.String() != want {
+		t.Errorf("prompt lines =\n%s\nwant\n%s", sb.String(), want)
+	}
+	if written != len(want) || written >= len(prefixedLogs) {
+		t.Errorf("%d chars written, want %d, fewer than the %d of the prefixed logs", written, len(want), len(prefixedLogs))
+	}
+}
+
+func TestPromptLinesKeepPrefixes(t *testing.T) {
+	d := &GitHubWorkflowDebugger{Options: Options{KeepLogPrefixes: true}}
+	lines := strings.Split(prefixedLogs, "\n")
+	for i, l := range d.promptLines(lines) {
+		if l.text != lines[i] || l.group != "" {
+			t.Errorf("line %d = %+v, want the line unchanged", i, l)
+		}
+	}
+}
+
+func TestPromptLinesUnprefixed(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	l := d.promptLines([]string{"2024-05-02T10:00:06.0000000Z Error: exit 1"})[0]
+	if l.text != "Error: exit 1" || l.header("") != "" {
+		t.Errorf("line = %+v, want the timestamp stripped and no header", l)
+	}
+}
@@ -0,0 +1,220 @@
+package debugger
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxLogsZipBytes bounds the uncompressed size of the logs read from a logs zip
+const maxLogsZipBytes = 512 << 20
+
+var (
+	// logsZipStepRe matches the step logs of a logs zip, e.g. "build (ubuntu-latest)/3_Run tests.txt"
+	logsZipStepRe = regexp.MustCompile(`^([^/]+)/(\d+)_(.+)\.txt$`)
+	// logsZipJobRe matches the whole-job logs at the root of a logs zip, e.g. "0_build (ubuntu-latest).txt"
+	logsZipJobRe = regexp.MustCompile(`^(\d+)_([^/]+)\.txt$`)
+)
+
+// zipJob is a job of a logs zip with its step logs, or only its whole log when the zip has no
+// directory for it
+type zipJob struct {
+	name  string
+	order int // position of the job's whole log at the root, -1 when missing
+	steps []zipStep
+	whole string
+}
+
+type zipStep struct {
+	number  int
+	name    string
+	content string
+}
+
+// LoadLogsZip reads the logs zip of a run downloaded from the Actions UI ("Download log archive")
+// The step logs in the per-job directories are joined with the "<job>\t<step>\t" prefix of gh logs, so
+// the steps and jobs are detected as for fetched logs. Only the jobs that reported an error make up
+// the failed logs, all jobs the full logs. Nothing is fetched: repo only names the repository in
+// the report. Options.JobName and Options.StepName select a job and step of the zip.
+func (d *GitHubWorkflowDebugger) LoadLogsZip(path, repo string) (*WorkflowRun, error) {
+	jobs, err := readLogsZip(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no job logs found in %s (expected the log archive of a run)", path)
+	}
+	logInfof("Read the logs of %d jobs from %s", len(jobs), path)
+
+	if d.Options.JobName != "" {
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.name)
+		}
+		name, err := resolveName("job", d.Options.JobName, names)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			logWarnf("no job matches %q, analyzing all failed jobs", d.Options.JobName)
+		} else {
+			for _, job := range jobs {
+				if job.name == name {
+					jobs = []zipJob{job}
+					break
+				}
+			}
+			logInfof("Selected job %q", name)
+		}
+	}
+
+	var full, failed []string
+	for _, job := range jobs {
+		logs := job.logs()
+		full = append(full, logs)
+		if strings.Contains(logs, "##[error]") {
+			failed = append(failed, logs)
+		}
+	}
+	if len(failed) == 0 {
+		logWarnf("no job in %s reported an error, analyzing the logs of all jobs", path)
+		failed = full
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	run := &WorkflowRun{
+		URL:          absPath,
+		Repository:   repo,
+		WorkflowName: filepath.Base(path),
+		Status:       "completed",
+		Conclusion:   "failure",
+		FailedLogs:   strings.Join(failed, "\n"),
+		FullLogs:     strings.Join(full, "\n"),
+	}
+
+	if d.Options.StepName != "" {
+		var names []string
+		for _, step := range splitSteps(run.FailedLogs) {
+			names = append(names, step.Name)
+		}
+		name, err := resolveName("step", d.Options.StepName, names)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			logWarnf("no step matches %q, analyzing the whole job logs", d.Options.StepName)
+		} else {
+			run.StepName = name
+			run.FailedLogs = stepLogs(run.FailedLogs, name)
+			logInfof("Selected step %q", name)
+		}
+	}
+
+	d.parseLogs(run)
+	return run, nil
+}
+
+// readLogsZip reads the jobs of a logs zip, in the order of their whole logs at the root
+func readLogsZip(path string) ([]zipJob, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logs zip: %w", err)
+	}
+	defer r.Close()
+
+	byName := make(map[string]*zipJob)
+	job := func(name string) *zipJob {
+		if byName[name] == nil {
+			byName[name] = &zipJob{name: name, order: -1}
+		}
+		return byName[name]
+	}
+
+	var total int64
+	for _, f := range r.File {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		stepMatch := logsZipStepRe.FindStringSubmatch(name)
+		jobMatch := logsZipJobRe.FindStringSubmatch(name)
+		if stepMatch == nil && jobMatch == nil {
+			continue
+		}
+
+		total += int64(f.UncompressedSize64)
+		if total > maxLogsZipBytes {
+			return nil, fmt.Errorf("logs zip %s holds more than %d MB of logs", path, maxLogsZipBytes>>20)
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from logs zip: %w", f.Name, err)
+		}
+
+		if stepMatch != nil {
+			number, _ := strconv.Atoi(stepMatch[2])
+			j := job(stepMatch[1])
+			j.steps = append(j.steps, zipStep{number: number, name: stepMatch[3], content: content})
+			continue
+		}
+		order, _ := strconv.Atoi(jobMatch[1])
+		j := job(jobMatch[2])
+		j.order, j.whole = order, content
+	}
+
+	jobs := make([]zipJob, 0, len(byName))
+	for _, j := range byName {
+		sort.Slice(j.steps, func(a, b int) bool { return j.steps[a].number < j.steps[b].number })
+		jobs = append(jobs, *j)
+	}
+	// Jobs with a whole log first in its order, then the others by name
+	sort.Slice(jobs, func(a, b int) bool {
+		oa, ob := jobs[a].order, jobs[b].order
+		if (oa < 0) != (ob < 0) {
+			return oa >= 0
+		}
+		if oa != ob {
+			return oa < ob
+		}
+		return jobs[a].name < jobs[b].name
+	})
+	return jobs, nil
+}
+
+// readZipFile returns the content of a zip entry, without the byte order mark of the Actions logs
+func readZipFile(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxLogsZipBytes))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(string(data), "\ufeff"), nil
+}
+
+// logs renders the job's logs like gh, every line prefixed with "<job>\t<step>\t"
+// Without step logs the whole log is attributed to an unknown step, whose steps splitSteps
+// recognizes by their "##[group]Run" markers.
+func (j zipJob) logs() string {
+	var lines []string
+	add := func(step, content string) {
+		for _, line := range strings.Split(strings.TrimRight(content, "\r\n"), "\n") {
+			lines = append(lines, j.name+"\t"+step+"\t"+strings.TrimSuffix(line, "\r"))
+		}
+	}
+	if len(j.steps) == 0 {
+		add(unknownStepName, j.whole)
+	}
+	for _, step := range j.steps {
+		add(step.name, step.content)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,123 @@
+package debugger
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLogsZip writes a logs zip of the files, by name in the zip, and returns its path
+func writeLogsZip(t *testing.T, files [][2]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "logs_42.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for _, file := range files {
+		fw, err := w.Create(file[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(file[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// testLogsZip is a run with a failed build job split in steps and a passing lint job with only its whole log
+func testLogsZip(t *testing.T) string {
+	return writeLogsZip(t, [][2]string{
+		{"0_build.txt", "whole build log, replaced by the step logs\n"},
+		{"1_lint.txt", "\ufeff##[group]Run golangci-lint run\r\nlint ok\r\n"},
+		{"build/10_Post checkout.txt", "cleanup\n"},
+		{"build/2_Run go test.txt", "\ufeff--- FAIL: TestParse\r\n##[error]Process completed with exit code 1.\r\n"},
+		{"build/1_Set up job.txt", "Runner image ubuntu-22.04\n"},
+		{"build/system.txt", "not a step log\n"},
+		{"README.md", "not a log\n"},
+	})
+}
+
+func TestLoadLogsZip(t *testing.T) {
+	d := New("test-key")
+	path := testLogsZip(t)
+	run, err := d.LoadLogsZip(path, "o/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Repository != "o/r" || run.WorkflowName != "logs_42.zip" || run.Conclusion != "failure" || !filepath.IsAbs(run.URL) {
+		t.Errorf("run = %+v", run)
+	}
+
+	wantFailed := "build\tSet up job\tRunner image ubuntu-22.04\n" +
+		"build\tRun go test\t--- FAIL: TestParse\n" +
+		"build\tRun go test\t##[error]Process completed with exit code 1.\n" +
+		"build\tPost checkout\tcleanup"
+	if run.FailedLogs != wantFailed {
+		t.Errorf("FailedLogs = %q, want %q", run.FailedLogs, wantFailed)
+	}
+	wantLint := "lint\t" + unknownStepName + "\t##[group]Run golangci-lint run\nlint\t" + unknownStepName + "\tlint ok"
+	if run.FullLogs != wantFailed+"\n"+wantLint {
+		t.Errorf("FullLogs = %q, want the build and lint logs", run.FullLogs)
+	}
+	if strings.Contains(run.FullLogs, "not a") || strings.Contains(run.FullLogs, "whole build log") {
+		t.Errorf("FullLogs holds entries that are not step logs: %q", run.FullLogs)
+	}
+}
+
+func TestLoadLogsZipSelection(t *testing.T) {
+	path := testLogsZip(t)
+
+	d := New("test-key")
+	d.Options.JobName = "lint"
+	run, err := d.LoadLogsZip(path, "o/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the lint job reported no error, so its logs are analyzed anyway
+	if !strings.HasPrefix(run.FailedLogs, "lint\t") || strings.Contains(run.FailedLogs, "build\t") {
+		t.Errorf("FailedLogs = %q, want only the lint job", run.FailedLogs)
+	}
+
+	d = New("test-key")
+	d.Options.StepName = "go test"
+	if run, err = d.LoadLogsZip(path, "o/r"); err != nil {
+		t.Fatal(err)
+	}
+	if run.StepName != "Run go test" || strings.Contains(run.FailedLogs, "Set up job") || !strings.Contains(run.FailedLogs, "--- FAIL: TestParse") {
+		t.Errorf("step %q logs = %q, want only Run go test", run.StepName, run.FailedLogs)
+	}
+}
+
+func TestLoadLogsZipErrors(t *testing.T) {
+	d := New("test-key")
+	notZip := filepath.Join(t.TempDir(), "logs.zip")
+	if err := os.WriteFile(notZip, []byte("plain text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"not a zip", notZip, "failed to open logs zip"},
+		{"no job logs", writeLogsZip(t, [][2]string{{"README.md", "hello"}}), "no job logs found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := d.LoadLogsZip(tt.path, "o/r"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package debugger
+
+import (
+	"bytes"
+)
+
+// DefaultMaxLogBytes caps the logs of a run kept in memory (Options.MaxLogBytes)
+const DefaultMaxLogBytes = 64 << 20
+
+// maxStderrBytes caps the error output of gh kept for error messages
+const maxStderrBytes = 64 << 10
+
+// tailBuffer is a writer keeping only the last max bytes written to it, in a ring. The logs of an
+// enormous run are streamed through it rather than read whole: failures are usually at the end,
+// and gigabytes of logs would not fit in memory.
+type tailBuffer struct {
+	max     int
+	buf     []byte // the kept bytes; a ring starting at next once it holds max bytes
+	next    int    // index in buf of the oldest byte, and of the next write, once buf is full
+	written int64  // bytes written in total
+}
+
+// newTailBuffer returns a tailBuffer keeping the last max bytes
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	t.written += int64(n)
+	if n >= t.max {
+		t.buf = append(t.buf[:0], p[n-t.max:]...)
+		t.next = 0
+		return n, nil
+	}
+	if room := t.max - len(t.buf); room > 0 {
+		if n <= room {
+			t.buf = append(t.buf, p...)
+			return n, nil
+		}
+		t.buf = append(t.buf, p[:room]...)
+		p = p[room:]
+	}
+	for len(p) > 0 {
+		c := copy(t.buf[t.next:], p)
+		p = p[c:]
+		t.next = (t.next + c) % t.max
+	}
+	return n, nil
+}
+
+// dropped returns the number of bytes written but no longer kept
+func (t *tailBuffer) dropped() int64 {
+	return t.written - int64(len(t.buf))
+}
+
+// Bytes returns the kept bytes in order. When bytes were dropped, the partial first line is dropped
+// too and a truncation marker takes its place, so the logs still start at a line.
+func (t *tailBuffer) Bytes() []byte {
+	out := make([]byte, 0, len(t.buf))
+	out = append(out, t.buf[t.next:]...)
+	out = append(out, t.buf[:t.next]...)
+	dropped := t.dropped()
+	if dropped == 0 {
+		return out
+	}
+	if i := bytes.IndexByte(out, '\n'); i >= 0 {
+		dropped += int64(i + 1)
+		out = out[i+1:]
+	}
+	marker := truncationMarker("dropped the first %d bytes of %d, only the end of the logs is kept", dropped, t.written)
+	return append([]byte(marker+"\n"), out...)
+}
@@ -0,0 +1,102 @@
+package debugger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// droppedMarker returns the line Bytes puts in place of the dropped logs
+func droppedMarker(dropped, written int) string {
+	return truncationMarker("dropped the first %d bytes of %d, only the end of the logs is kept", dropped, written) + "\n"
+}
+
+func TestTailBuffer(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{
+			name:   "within max",
+			writes: []string{"abc\n", "de"},
+			want:   "abc\nde",
+		},
+		{
+			name:   "exactly max",
+			writes: []string{"abcd\n", "efgh\n"},
+			want:   "abcd\nefgh\n",
+		},
+		{
+			// "ghij" fills the buffer, "\nkl" wraps around over "abc"
+			name:   "partial fill",
+			writes: []string{"abcdef", "ghij\nkl"},
+			want:   droppedMarker(11, 13) + "kl",
+		},
+		{
+			name:   "wraparound",
+			writes: []string{"l1\n", "l2\n", "l3\n", "l4\n", "l5\n", "l6\n", "l7\n", "l8\n", "l9\n"},
+			want:   droppedMarker(18, 27) + "l7\nl8\nl9\n",
+		},
+		{
+			name:   "write of max bytes",
+			writes: []string{"abc\n", "0123\n5678\n"},
+			want:   droppedMarker(9, 14) + "5678\n",
+		},
+		{
+			// The large write replaces the ring, the next one starts it again
+			name:   "write larger than max",
+			writes: []string{"abc\n", "01234", "0123456789\nxyz\n", "ab"},
+			want:   droppedMarker(20, 26) + "xyz\nab",
+		},
+		{
+			name:   "no line break kept",
+			writes: []string{"line\n", "0123456789", "abc"},
+			want:   droppedMarker(8, 18) + "3456789abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tail := newTailBuffer(10)
+			for _, w := range tt.writes {
+				if n, err := tail.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := string(tail.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTailBufferStreams writes streams larger than max in chunks of every size and checks the
+// ring keeps exactly the last max bytes
+func TestTailBufferStreams(t *testing.T) {
+	var stream strings.Builder
+	for i := 0; stream.Len() < 200; i++ {
+		stream.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	data := []byte(stream.String())
+
+	for _, max := range []int{1, 7, 16, 64} {
+		for chunk := 1; chunk <= max+3; chunk++ {
+			tail := newTailBuffer(max)
+			for p := data; len(p) > 0; {
+				c := min(chunk, len(p))
+				tail.Write(p[:c])
+				p = p[c:]
+			}
+
+			kept := append(append([]byte{}, tail.buf[tail.next:]...), tail.buf[:tail.next]...)
+			if want := data[len(data)-max:]; !bytes.Equal(kept, want) {
+				t.Fatalf("max %d, chunks of %d: kept %q, want %q", max, chunk, kept, want)
+			}
+			if got, want := tail.dropped(), int64(len(data)-max); got != want {
+				t.Errorf("max %d, chunks of %d: dropped() = %d, want %d", max, chunk, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package debugger
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineChars is the length beyond which a log line is shortened (Options.MaxLineChars)
+const DefaultMaxLineChars = 2000
+
+// capLongLines shortens the lines longer than maxChars, e.g. minified sources or JSON dumps on a
+// single line, so no line takes up the prompt: the start and end of the line are kept around a
+// truncationMarker. It returns the logs and the number of lines shortened.
+func capLongLines(logs string, maxChars int) (string, int) {
+	if maxChars <= 0 || len(logs) <= maxChars {
+		return logs, 0
+	}
+	lines := strings.Split(logs, "\n")
+	capped := 0
+	for i, line := range lines {
+		if len(line) <= maxChars {
+			continue
+		}
+		// Lines start with the "<job>\t<step>\t" prefix of gh, the head keeps it
+		head := runeBoundary(line, maxChars*2/3)
+		tail := runeBoundary(line, len(line)-maxChars/3)
+		lines[i] = line[:head] + " " + truncationMarker("line truncated, %d chars omitted", tail-head) + " " + line[tail:]
+		capped++
+	}
+	if capped == 0 {
+		return logs, 0
+	}
+	return strings.Join(lines, "\n"), capped
+}
+
+// runeBoundary moves i back to the start of the UTF-8 character it falls into
+func runeBoundary(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,81 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCapLongLines(t *testing.T) {
+	prefix := "build\tRun webpack\t"
+	enormous := prefix + "START" + strings.Repeat("minified();", 1<<20) + "END"
+	logs := "build\tRun webpack\tnpm run build\n" + enormous + "\nbuild\tRun webpack\t##[error]Process completed with exit code 1."
+
+	got, capped := capLongLines(logs, 2000)
+	if capped != 1 {
+		t.Errorf("capped = %d, want 1", capped)
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 || lines[0] != "build\tRun webpack\tnpm run build" || lines[2] != "build\tRun webpack\t##[error]Process completed with exit code 1." {
+		t.Fatalf("lines = %d, want the short lines unchanged", len(lines))
+	}
+	line := lines[1]
+	// the first two thirds and the last third of the limit are kept
+	marker := truncationMarker("line truncated, %d chars omitted", len(enormous)-1333-666)
+	if !strings.HasPrefix(line, prefix+"START") || !strings.HasSuffix(line, "END") || !strings.Contains(line, " "+marker+" ") {
+		t.Errorf("line = %.80q...%q, want the start and end around %q", line, line[len(line)-40:], marker)
+	}
+	if len(line) > 2000+len(marker)+2 {
+		t.Errorf("line is %d chars, want at most the limit and the marker", len(line))
+	}
+}
+
+func TestCapLongLinesRuneBoundary(t *testing.T) {
+	line := strings.Repeat("é", 100) // 200 bytes
+	got, capped := capLongLines(line, 51)
+	if capped != 1 || !utf8.ValidString(got) {
+		t.Fatalf("capLongLines() = %q, %d, want a valid shortened line", got, capped)
+	}
+	head, _, _ := strings.Cut(got, " ...[")
+	if head != strings.Repeat("é", 17) {
+		t.Errorf("head = %q, want 17 whole characters", head)
+	}
+}
+
+func TestCapLongLinesUnchanged(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+	tests := []struct {
+		name     string
+		logs     string
+		maxChars int
+	}{
+		{"short lines", "a\nb\nc", 2},
+		{"short logs", "abc", 10},
+		{"no limit", long, -1},
+		{"limit not set", long, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, capped := capLongLines(tt.logs, tt.maxChars); got != tt.logs || capped != 0 {
+				t.Errorf("capLongLines() changed the logs, capped %d", capped)
+			}
+		})
+	}
+}
+
+func TestParseLogsCapsLongLines(t *testing.T) {
+	d := New("test-key")
+	run := &WorkflowRun{FailedLogs: "build\tRun webpack\t" + strings.Repeat("x", 3000)}
+	run.FullLogs = run.FailedLogs
+	d.parseLogs(run)
+	if !strings.Contains(run.FailedLogs, "line truncated") || !strings.Contains(run.FullLogs, "line truncated") {
+		t.Errorf("the line was not shortened to DefaultMaxLineChars")
+	}
+
+	d.Options.MaxLineChars = -1
+	run = &WorkflowRun{FailedLogs: "build\tRun webpack\t" + strings.Repeat("x", 3000)}
+	d.parseLogs(run)
+	if strings.Contains(run.FailedLogs, "line truncated") {
+		t.Errorf("the line was shortened without a limit")
+	}
+}
@@ -0,0 +1,97 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// metricsPrefix is the common prefix of all exported metric names
+const metricsPrefix = "github_workflow_debugger_"
+
+// Metrics are collected during a debugging session for monitoring scheduled runs
+type Metrics struct {
+	Repository       string
+	Workflow         string
+	Success          bool
+	FetchDuration    time.Duration
+	AnalysisDuration time.Duration
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Confidence       string
+	ConfidenceScore  int
+	Timestamp        time.Time
+}
+
+// confidenceValue maps the confidence level onto a gauge value (0 = unknown, 1 = Low ... 3 = High)
+func confidenceValue(confidence string) int {
+	switch confidenceLevel(confidence) {
+	case "High":
+		return 3
+	case "Medium":
+		return 2
+	case "Low":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// escapeLabelValue escapes a label value for the Prometheus text format
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Format renders the metrics in the Prometheus text exposition format
+func (m *Metrics) Format() string {
+	labels := fmt.Sprintf(`{repository="%s",workflow="%s"}`, escapeLabelValue(m.Repository), escapeLabelValue(m.Workflow))
+	success := 0
+	if m.Success {
+		success = 1
+	}
+
+	var sb strings.Builder
+	gauge := func(name, help string, value any) {
+		sb.WriteString(fmt.Sprintf("# HELP %s%s %s\n", metricsPrefix, name, help))
+		sb.WriteString(fmt.Sprintf("# TYPE %s%s gauge\n", metricsPrefix, name))
+		sb.WriteString(fmt.Sprintf("%s%s%s %v\n", metricsPrefix, name, labels, value))
+	}
+	gauge("success", "Whether the last analysis completed (1) or failed (0).", success)
+	gauge("last_run_timestamp_seconds", "Unix time of the last analysis.", m.Timestamp.Unix())
+	gauge("fetch_duration_seconds", "Time spent fetching the workflow run and logs.", m.FetchDuration.Seconds())
+	gauge("analysis_duration_seconds", "Time spent waiting for the AI analysis.", m.AnalysisDuration.Seconds())
+	gauge("prompt_tokens", "Prompt tokens used by the last analysis.", m.PromptTokens)
+	gauge("completion_tokens", "Completion tokens used by the last analysis.", m.CompletionTokens)
+	gauge("cost_usd", "Estimated USD cost of the last analysis.", m.Cost)
+	gauge("confidence", "Confidence of the last analysis (0 unknown, 1 low, 2 medium, 3 high).", confidenceValue(m.Confidence))
+	gauge("confidence_score", "Self-reported confidence score (1-100) of the last analysis, 0 when the model gave none.", m.ConfidenceScore)
+	return sb.String()
+}
+
+// WriteMetricsFile writes the metrics to path for the node-exporter textfile collector
+// The file is replaced atomically so the collector never reads a partial file.
+func WriteMetricsFile(path string, m *Metrics) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(m.Format()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// promSample is a sample line of the Prometheus text format: name, label pairs and value
+var promSample = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)\{((?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\["\\n])*",?)*)\} (\S+)$`)
+
+// promLabel is a label pair of a sample
+var promLabel = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)="((?:[^"\\]|\\["\\n])*)"`)
+
+// parsePrometheusText parses the text exposition format strictly enough to catch malformed output,
+// returning the sample values by metric name and the labels of the samples
+func parsePrometheusText(t *testing.T, text string) (map[string]float64, map[string]string) {
+	t.Helper()
+	values := make(map[string]float64)
+	labels := make(map[string]string)
+	types := make(map[string]string)
+	for i, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# HELP "):
+			if len(strings.SplitN(line, " ", 4)) != 4 {
+				t.Fatalf("line %d: malformed HELP %q", i+1, line)
+			}
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			if len(fields) != 4 || fields[3] != "gauge" {
+				t.Fatalf("line %d: malformed TYPE %q", i+1, line)
+			}
+			types[fields[2]] = fields[3]
+		default:
+			m := promSample.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("line %d: malformed sample %q", i+1, line)
+			}
+			if _, ok := types[m[1]]; !ok {
+				t.Errorf("line %d: sample of %s without a TYPE", i+1, m[1])
+			}
+			value, err := strconv.ParseFloat(m[3], 64)
+			if err != nil {
+				t.Fatalf("line %d: invalid value %q", i+1, m[3])
+			}
+			values[m[1]] = value
+			for _, l := range promLabel.FindAllStringSubmatch(m[2], -1) {
+				labels[l[1]] = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n").Replace(l[2])
+			}
+		}
+	}
+	return values, labels
+}
+
+func TestMetricsFormatParses(t *testing.T) {
+	m := &Metrics{
+		Repository:       "o/r",
+		Workflow:         `CI "nightly" \ build` + "\nmatrix",
+		Success:          true,
+		FetchDuration:    1500 * time.Millisecond,
+		AnalysisDuration: 12 * time.Second,
+		PromptTokens:     12000,
+		CompletionTokens: 800,
+		Cost:             0.0042,
+		Confidence:       "High",
+		ConfidenceScore:  85,
+		Timestamp:        time.Unix(1700000000, 0),
+	}
+	values, labels := parsePrometheusText(t, m.Format())
+
+	want := map[string]float64{
+		"success":                    1,
+		"last_run_timestamp_seconds": 1700000000,
+		"fetch_duration_seconds":     1.5,
+		"analysis_duration_seconds":  12,
+		"prompt_tokens":              12000,
+		"completion_tokens":          800,
+		"cost_usd":                   0.0042,
+		"confidence":                 3,
+		"confidence_score":           85,
+	}
+	for name, value := range want {
+		if got, ok := values[metricsPrefix+name]; !ok || got != value {
+			t.Errorf("%s%s = %v (present %v), want %v", metricsPrefix, name, got, ok, value)
+		}
+	}
+	if len(values) != len(want) {
+		t.Errorf("got %d metrics, want %d", len(values), len(want))
+	}
+	if labels["repository"] != m.Repository || labels["workflow"] != m.Workflow {
+		t.Errorf("labels = %q, want the repository and the escaped workflow name back", labels)
+	}
+}
+
+func TestWriteMetricsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workflow_debugger.prom")
+	if err := os.WriteFile(path, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteMetricsFile(path, &Metrics{Repository: "o/r", Timestamp: time.Unix(1, 0)}); err != nil {
+		t.Fatalf("WriteMetricsFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, _ := parsePrometheusText(t, string(data))
+	if values[metricsPrefix+"success"] != 0 || values[metricsPrefix+"confidence"] != 0 {
+		t.Errorf("values = %v, want a failed analysis without confidence", values)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want the metrics file only", len(entries))
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644 for the collector", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,142 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Token counts of a typical analysis, used to estimate the cost of an analysis per model
+const (
+	typicalPromptTokens     = defaultLogBudget * 2 / 5 // the default log budget at ~2.5 chars/token
+	typicalCompletionTokens = 1500
+)
+
+// analysisBudgets are the per-analysis budgets (USD) the model list is grouped by
+var analysisBudgets = []float64{0.01, 0.05, 0.25}
+
+// ErrListModelsUnsupported is returned when the LLM endpoint cannot list its models
+var ErrListModelsUnsupported = errors.New("the LLM endpoint does not support listing models")
+
+// nonChatModelPrefixes are the model families the OpenAI API lists that cannot analyze a failure
+var nonChatModelPrefixes = []string{
+	"text-embedding", "whisper", "tts", "dall-e", "omni-moderation", "text-moderation", "babbage", "davinci",
+}
+
+// ModelInfo is a model offered by the LLM endpoint
+type ModelInfo struct {
+	ID            string
+	OwnedBy       string
+	Configured    bool    // the model analyses use (OPENAI_MODEL or the default)
+	ContextWindow int     // tokens, 0 when unknown
+	AnalysisCost  float64 // estimated USD cost of a typical analysis, 0 when the price is unknown
+}
+
+// ListModels returns the chat models of the LLM endpoint, sorted by ID
+// Azure OpenAI lists the models of a resource, not the deployments requests go to, so listing is
+// not supported there; neither is it by OpenAI-compatible servers without a models endpoint.
+func (d *GitHubWorkflowDebugger) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	if usingAzure() {
+		return nil, fmt.Errorf("%w: Azure OpenAI requests go to deployments, see the deployments of the resource in the Azure portal", ErrListModelsUnsupported)
+	}
+	listCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	list, err := d.openaiClient.ListModels(listCtx)
+	if err != nil {
+		var apiErr *openai.APIError
+		var reqErr *openai.RequestError
+		status := 0
+		switch {
+		case errors.As(err, &apiErr):
+			status = apiErr.HTTPStatusCode
+		case errors.As(err, &reqErr):
+			status = reqErr.HTTPStatusCode
+		}
+		if status == http.StatusNotFound || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+			return nil, fmt.Errorf("%w (HTTP %d)", ErrListModelsUnsupported, status)
+		}
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	var models []ModelInfo
+	for _, m := range list.Models {
+		if !chatModel(m.ID) {
+			continue
+		}
+		info := ModelInfo{ID: m.ID, OwnedBy: m.OwnedBy, Configured: m.ID == d.model}
+		info.ContextWindow, _ = contextWindow(m.ID)
+		if price, ok := d.priceFor(m.ID); ok {
+			info.AnalysisCost = price.cost(typicalPromptTokens, typicalCompletionTokens)
+		}
+		models = append(models, info)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// chatModel reports whether a listed model may be used for the analysis
+func chatModel(id string) bool {
+	for _, prefix := range nonChatModelPrefixes {
+		if strings.HasPrefix(id, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatModelList renders the models one per line, marking the configured model and the
+// smallest per-analysis budget each model fits
+// configured is the model analyses use; it is listed as missing when the endpoint does not offer it.
+func FormatModelList(models []ModelInfo, configured string) string {
+	var sb strings.Builder
+	width := 0
+	found := false
+	for _, m := range models {
+		width = max(width, len(m.ID))
+		found = found || m.Configured
+	}
+	for _, m := range models {
+		var details []string
+		if m.Configured {
+			details = append(details, "configured")
+		}
+		if m.ContextWindow > 0 {
+			details = append(details, fmt.Sprintf("%dk context", m.ContextWindow/1000))
+		}
+		if m.AnalysisCost > 0 {
+			details = append(details, fmt.Sprintf("~$%.4f per analysis%s", m.AnalysisCost, budgetLabel(m.AnalysisCost)))
+		}
+		marker := " "
+		if m.Configured {
+			marker = "*"
+		}
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%s %-*s  %s", marker, width, m.ID, strings.Join(details, ", ")), " "))
+		sb.WriteString("\n")
+	}
+	if !found {
+		sb.WriteString(fmt.Sprintf("The configured model %s is not offered by the endpoint, set OPENAI_MODEL to one of the models above.\n", configured))
+	}
+	sb.WriteString(fmt.Sprintf("Costs are estimated for a typical analysis of %d prompt and %d completion tokens; prices of unlisted models are unknown (see --prices-file).\n",
+		typicalPromptTokens, typicalCompletionTokens))
+	return sb.String()
+}
+
+// budgetLabel names the smallest of the analysisBudgets the cost fits, e.g. " (under $0.01)"
+func budgetLabel(cost float64) string {
+	for _, budget := range analysisBudgets {
+		if cost <= budget {
+			return fmt.Sprintf(" (under $%.2f)", budget)
+		}
+	}
+	return ""
+}
+
+// Model returns the model analyses use
+func (d *GitHubWorkflowDebugger) Model() string {
+	return d.model
+}
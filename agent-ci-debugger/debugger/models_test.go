@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testModelList = `{"object":"list","data":[
+	{"id":"text-embedding-3-small","object":"model","owned_by":"system"},
+	{"id":"my-local-model","object":"model","owned_by":"me"},
+	{"id":"gpt-4o-mini","object":"model","owned_by":"system"},
+	{"id":"whisper-1","object":"model","owned_by":"openai"},
+	{"id":"gpt-4o","object":"model","owned_by":"system"}]}`
+
+func TestListModels(t *testing.T) {
+	api := &mockOpenAI{routes: map[string]string{"/v1/models": testModelList}}
+	d := api.start(t)
+
+	models, err := d.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	if got := strings.Join(ids, ","); got != "gpt-4o,gpt-4o-mini,my-local-model" {
+		t.Fatalf("models = %s, want the chat models sorted", got)
+	}
+	gpt4o, mini, local := models[0], models[1], models[2]
+	if gpt4o.Configured || !mini.Configured || local.Configured {
+		t.Errorf("configured = %v, %v, %v, want only %s", gpt4o.Configured, mini.Configured, local.Configured, d.Model())
+	}
+	if mini.OwnedBy != "system" || mini.ContextWindow != 128000 || local.ContextWindow != 0 {
+		t.Errorf("models = %+v, want the owner and the known context windows", models)
+	}
+	if mini.AnalysisCost <= 0 || gpt4o.AnalysisCost <= mini.AnalysisCost || local.AnalysisCost != 0 {
+		t.Errorf("costs = %v, %v, %v, want gpt-4o above gpt-4o-mini and no price for the local model",
+			gpt4o.AnalysisCost, mini.AnalysisCost, local.AnalysisCost)
+	}
+}
+
+func TestListModelsUnsupported(t *testing.T) {
+	t.Run("no models endpoint", func(t *testing.T) {
+		d := (&mockOpenAI{}).start(t)
+		if _, err := d.ListModels(context.Background()); !errors.Is(err, ErrListModelsUnsupported) || !strings.Contains(err.Error(), "HTTP 404") {
+			t.Errorf("error = %v, want ErrListModelsUnsupported", err)
+		}
+	})
+	t.Run("server error", func(t *testing.T) {
+		(&mockOpenAI{}).start(t)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer srv.Close()
+		t.Setenv("OPENAI_BASE_URL", srv.URL+"/v1")
+		_, err := New("test-key").ListModels(context.Background())
+		if err == nil || errors.Is(err, ErrListModelsUnsupported) || !strings.Contains(err.Error(), "failed to list models") {
+			t.Errorf("error = %v, want the failed call", err)
+		}
+	})
+	t.Run("Azure OpenAI", func(t *testing.T) {
+		(&mockOpenAI{}).start(t)
+		t.Setenv("AZURE_OPENAI_ENDPOINT", "https://example.openai.azure.com")
+		t.Setenv("AZURE_OPENAI_DEPLOYMENT", "gpt-4o")
+		if _, err := New("test-key").ListModels(context.Background()); !errors.Is(err, ErrListModelsUnsupported) {
+			t.Errorf("error = %v, want ErrListModelsUnsupported", err)
+		}
+	})
+}
+
+func TestFormatModelList(t *testing.T) {
+	models := []ModelInfo{
+		{ID: "gpt-4o", ContextWindow: 128000, AnalysisCost: 0.04},
+		{ID: "gpt-4o-mini", Configured: true, ContextWindow: 128000, AnalysisCost: 0.0025},
+		{ID: "big-model", AnalysisCost: 0.5},
+		{ID: "local"},
+	}
+	footer := fmt.Sprintf("Costs are estimated for a typical analysis of %d prompt and %d completion tokens; prices of unlisted models are unknown (see --prices-file).\n",
+		typicalPromptTokens, typicalCompletionTokens)
+	want := "  gpt-4o       128k context, ~$0.0400 per analysis (under $0.05)\n" +
+		"* gpt-4o-mini  configured, 128k context, ~$0.0025 per analysis (under $0.01)\n" +
+		"  big-model    ~$0.5000 per analysis\n" +
+		"  local\n" + footer
+	if got := FormatModelList(models, "gpt-4o-mini"); got != want {
+		t.Errorf("FormatModelList() =\n%s\nwant\n%s", got, want)
+	}
+
+	missing := FormatModelList(models[:1], "gpt-4o-mini")
+	if !strings.Contains(missing, "The configured model gpt-4o-mini is not offered by the endpoint") {
+		t.Errorf("FormatModelList() = %q, want the configured model reported missing", missing)
+	}
+}
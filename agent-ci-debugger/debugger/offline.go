@@ -0,0 +1,265 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// heuristicRule maps a recognizable failure pattern onto a canned fix proposal
+type heuristicRule struct {
+	name    string
+	match   func(run *WorkflowRun) bool
+	propose func(run *WorkflowRun) *FixProposal
+}
+
+// Phrases of the logs that identify a failure class, matched case-insensitively
+var (
+	outOfMemoryPhrases = []string{
+		"out of memory", "oomkilled", "cannot allocate memory", "memoryerror",
+		"java.lang.outofmemoryerror", "heap out of memory",
+	}
+	commandNotFoundPhrases = []string{"command not found", "is not recognized as an internal or external command"}
+	dependencyPhrases      = []string{
+		"could not resolve host", "temporary failure in name resolution", "connection refused", "connection reset by peer",
+		"etimedout", "econnreset", "tls handshake timeout", "429 too many requests", "502 bad gateway", "503 service unavailable",
+		"npm err! network", "failed to download", "unable to access", "rate limit exceeded", "toomanyrequests",
+		"could not resolve dependencies", "no matching distribution found", "unable to resolve dependency tree",
+	}
+)
+
+// heuristicRules are evaluated in order by heuristicProposal, the first matching rule wins
+// Rules for infrastructure failures come first: their symptoms (killed tests, timeouts) would
+// otherwise be mistaken for code failures by the later rules.
+var heuristicRules = []heuristicRule{
+	{
+		name:  "out-of-memory",
+		match: func(run *WorkflowRun) bool { return hasExitCode(run, 137) || logsContain(run, outOfMemoryPhrases...) },
+		propose: func(run *WorkflowRun) *FixProposal {
+			return &FixProposal{
+				RootCause: "The job ran out of memory: a process was killed (exit code 137 / SIGKILL) or reported an out-of-memory error.",
+				Analysis: "Exit code 137 means the process received SIGKILL, which on CI runners is almost always the kernel's " +
+					"OOM killer; the runner may also have lost contact with the job under memory pressure.",
+				ProposedFix: "- Reduce the memory use of the failing step (fewer parallel test/build workers, e.g. `-p`/`--jobs`/`-j`)\n" +
+					"- Raise tool heap limits where relevant (e.g. `NODE_OPTIONS=--max-old-space-size`, `-Xmx`, `GOMEMLIMIT`)\n" +
+					"- Use a runner with more memory or split the job",
+				Confidence: "Medium",
+			}
+		},
+	},
+	{
+		name:  "disk-full",
+		match: func(run *WorkflowRun) bool { return hasResourceFailure(run, ResourceDiskFull) },
+		propose: func(run *WorkflowRun) *FixProposal {
+			return &FixProposal{
+				RootCause:   "The runner ran out of disk space.",
+				Analysis:    "The logs report that no space was left on the device, an infrastructure problem rather than a bug in the code.",
+				ProposedFix: "- Free disk space before the failing step (e.g. `docker system prune -af`, remove unused preinstalled toolchains)\n- Reduce build caches and artifacts written by the job\n- Use a runner with a larger disk",
+				Confidence:  "Medium",
+			}
+		},
+	},
+	{
+		name:  "permission",
+		match: func(run *WorkflowRun) bool { return len(run.ErrorSummary.PermissionFailures) > 0 },
+		propose: func(run *WorkflowRun) *FixProposal {
+			first := run.ErrorSummary.PermissionFailures[0]
+			fix := "Grant the missing permission in the `permissions:` block of the workflow or job (e.g. `contents: write`, `pull-requests: write`, `packages: write`), or use a token with the required scope."
+			switch first.Kind {
+			case PermissionPushProtection:
+				fix = "Remove the secret from the commit history (and rotate it), or fix the commit to satisfy the repository rules."
+			case PermissionProtectedRef:
+				fix = "Push to a new branch and open a pull request, or use a token or app allowed to bypass the branch protection."
+			}
+			return &FixProposal{
+				RootCause:   fmt.Sprintf("GitHub rejected an operation of the workflow: %s", truncateText(first.Message, 200)),
+				Analysis:    "This is a permission or repository configuration problem, not a bug in the code.",
+				ProposedFix: fix,
+				Confidence:  "Medium",
+			}
+		},
+	},
+	{
+		name:  "license",
+		match: func(run *WorkflowRun) bool { return len(run.ErrorSummary.LicenseViolations) > 0 },
+		propose: func(run *WorkflowRun) *FixProposal {
+			first := run.ErrorSummary.LicenseViolations[0]
+			return &FixProposal{
+				RootCause:   fmt.Sprintf("A license scan rejected a dependency: %s", first),
+				Analysis:    "This is a dependency license policy violation, not a bug in the code.",
+				ProposedFix: "- Replace or remove the offending dependency, or upgrade it to a version under an allowed license\n- If the license is acceptable to the project, allow it in the scanner configuration (a decision for the maintainers)",
+				Confidence:  "Medium",
+			}
+		},
+	},
+	{
+		name:  "compiler-error",
+		match: func(run *WorkflowRun) bool { return len(run.ErrorSummary.CompilerErrors) > 0 },
+		propose: func(run *WorkflowRun) *FixProposal {
+			first := run.ErrorSummary.CompilerErrors[0]
+			rootCause := fmt.Sprintf("The code does not compile: %s", first.String())
+			if first.Tool == "eslint" {
+				rootCause = fmt.Sprintf("Linting failed: %s", first.String())
+			}
+			analysis := fmt.Sprintf("The build reported %d compiler error(s); later errors are often consequences of the first one.", len(run.ErrorSummary.CompilerErrors))
+			if first.Cascade != nil {
+				analysis = fmt.Sprintf("The build reported %d compiler error(s); this one %s.",
+					len(run.ErrorSummary.CompilerErrors)+collapsedErrors(run.ErrorSummary.CompilerErrors), first.Cascade)
+			}
+			return &FixProposal{
+				RootCause:   rootCause,
+				Analysis:    analysis,
+				ProposedFix: "Fix the first compiler error and rebuild locally before pushing.",
+				Confidence:  "Medium",
+			}
+		},
+	},
+	{
+		name: "timeout",
+		match: func(run *WorkflowRun) bool {
+			return run.Conclusion == "timed_out" || len(run.ErrorSummary.Timeouts) > 0 || run.ErrorSummary.TimedOutStep != nil
+		},
+		propose: func(run *WorkflowRun) *FixProposal {
+			rootCause := "The job or a command in it timed out."
+			if t := run.ErrorSummary.TimedOutStep; t != nil {
+				rootCause = fmt.Sprintf("Step %q timed out after %s.", t.Step, t.Duration.Round(time.Second))
+			}
+			return &FixProposal{
+				RootCause: rootCause,
+				Analysis: "Timeouts are caused either by a hang (deadlock, waiting for input or an unavailable service) " +
+					"or by work that legitimately takes longer than the limit.",
+				ProposedFix: "- Check whether the step hangs (compare its duration with passing runs)\n" +
+					"- If the work is legitimately slow, increase `timeout-minutes` or the tool's timeout (e.g. `go test -timeout`)\n" +
+					"- Otherwise look for the test or command that never completes",
+				Confidence: "Low",
+			}
+		},
+	},
+	{
+		name:  "rate-limit",
+		match: func(run *WorkflowRun) bool { return logsContain(run, rateLimitPhrases...) },
+		propose: func(run *WorkflowRun) *FixProposal {
+			return &FixProposal{
+				RootCause:   "The job hit a rate limit of GitHub or a package registry.",
+				Analysis:    "The logs show requests rejected for exceeding a rate limit, an infrastructure problem rather than a bug in the code.",
+				ProposedFix: "- Re-run the job once the limit resets\n- Authenticate the requests (e.g. pass `GITHUB_TOKEN`, log in to Docker Hub) to get higher limits\n- Cache downloads and reduce the number of API calls of the job",
+				Confidence:  "Medium",
+			}
+		},
+	},
+	{
+		name:  "dependency",
+		match: func(run *WorkflowRun) bool { return logsContain(run, dependencyPhrases...) },
+		propose: func(run *WorkflowRun) *FixProposal {
+			return &FixProposal{
+				RootCause:   "Downloading dependencies or reaching a remote service failed.",
+				Analysis:    "The logs show network, registry or dependency resolution errors, which are often transient (outages, rate limits).",
+				ProposedFix: "- Re-run the job to rule out a transient outage\n- Cache dependencies (e.g. `actions/cache` or the setup action's cache option) to reduce downloads\n- Add retries to the download step and pin dependency versions",
+				Confidence:  "Low",
+			}
+		},
+	},
+	{
+		name: "command-not-found",
+		match: func(run *WorkflowRun) bool {
+			return hasExitCode(run, 127) || logsContain(run, commandNotFoundPhrases...)
+		},
+		propose: func(run *WorkflowRun) *FixProposal {
+			return &FixProposal{
+				RootCause:   "A command used by the workflow is not installed on the runner (exit code 127).",
+				Analysis:    "Exit code 127 means the shell could not find the command, e.g. a tool missing from the runner image or not on PATH.",
+				ProposedFix: "Install the tool in an earlier step (or with its setup action) or fix the command name/PATH.",
+				Confidence:  "Medium",
+			}
+		},
+	},
+	{
+		name:  "docker-build",
+		match: func(run *WorkflowRun) bool { return len(run.ErrorSummary.BuildFailures) > 0 },
+		propose: func(run *WorkflowRun) *FixProposal {
+			first := run.ErrorSummary.BuildFailures[0]
+			return &FixProposal{
+				RootCause:   fmt.Sprintf("The Docker build failed at `%s` (exit code %d).", truncateText(first.Instruction, 200), first.ExitCode),
+				Analysis:    "The failing Dockerfile instruction and its output are the starting point of the investigation.",
+				ProposedFix: "Reproduce the build locally with `docker build` and fix the failing instruction.",
+				Confidence:  "Low",
+			}
+		},
+	},
+	{
+		name: "test-failure",
+		match: func(run *WorkflowRun) bool {
+			return len(run.ErrorSummary.FailedTests) > 0 || len(run.ErrorSummary.Assertions) > 0 || len(run.ErrorSummary.DataRaces) > 0
+		},
+		propose: func(run *WorkflowRun) *FixProposal {
+			analysis := fmt.Sprintf("%d failed test line(s), %d assertion failure(s) and %d data race(s) were found in the logs.",
+				len(run.ErrorSummary.FailedTests), len(run.ErrorSummary.Assertions), len(run.ErrorSummary.DataRaces))
+			return &FixProposal{
+				RootCause:   "Tests failed.",
+				Analysis:    analysis,
+				ProposedFix: "Run the failing tests locally and fix the code or the test expectations.",
+				Confidence:  "Low",
+			}
+		},
+	},
+}
+
+// heuristicProposal triages a failure with heuristicRules instead of an AI model (Options.Offline)
+func heuristicProposal(run *WorkflowRun) *FixProposal {
+	var proposal *FixProposal
+	for _, rule := range heuristicRules {
+		if rule.match(run) {
+			logInfof("Offline analysis: matched rule %q", rule.name)
+			proposal = rule.propose(run)
+			break
+		}
+	}
+	if proposal == nil {
+		logInfof("Offline analysis: no rule matched")
+		proposal = &FixProposal{
+			RootCause:   "No known failure pattern was recognized in the logs.",
+			Analysis:    "The offline rules cover common failure classes only; this failure needs a manual look or an AI analysis.",
+			ProposedFix: fmt.Sprintf("Check the failed step in the logs: %s", run.URL),
+			Confidence:  "Low",
+		}
+	}
+	if step := run.ErrorSummary.FailedStep; step != "" {
+		proposal.Analysis += fmt.Sprintf("\n\nThe failing step is `%s`.", step)
+	}
+
+	proposal.Heuristic = true
+	proposal.addLocatedFiles(run)
+	proposal.Summary = oneLineSummary("", proposal)
+	return proposal
+}
+
+// hasExitCode reports whether the logs show the exit code
+func hasExitCode(run *WorkflowRun, code int) bool {
+	for _, c := range run.ErrorSummary.ExitCodes {
+		if c == code {
+			return true
+		}
+	}
+	return run.ErrorSummary.FailedStepExitCode == code
+}
+
+// hasResourceFailure reports whether a runner resource failure of the kind was detected
+func hasResourceFailure(run *WorkflowRun, kind string) bool {
+	for _, f := range run.ErrorSummary.ResourceFailures {
+		if f.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// logsContain reports whether the failed logs contain any of the lowercase phrases
+func logsContain(run *WorkflowRun, phrases ...string) bool {
+	logs := strings.ToLower(run.FailedLogs)
+	for _, phrase := range phrases {
+		if strings.Contains(logs, phrase) {
+			return true
+		}
+	}
+	return false
+}
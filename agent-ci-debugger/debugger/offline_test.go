@@ -0,0 +1,107 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+// matchedRule returns the name of the heuristic rule heuristicProposal picks for the run
+func matchedRule(run *WorkflowRun) string {
+	for _, rule := range heuristicRules {
+		if rule.match(run) {
+			return rule.name
+		}
+	}
+	return ""
+}
+
+func TestHeuristicRules(t *testing.T) {
+	// Each fixture is the log of a failure class and fires its rule before any other
+	tests := []struct {
+		rule       string
+		conclusion string
+		lines      []string
+		rootCause  string
+	}{
+		{rule: "out-of-memory", lines: fixtureLines("build", "Run go build", "fatal error: runtime: out of memory"), rootCause: "ran out of memory"},
+		{rule: "out-of-memory", lines: fixtureLines("test", "Run npm test", "Killed", "Error: Process completed with exit code 137."), rootCause: "ran out of memory"},
+		{rule: "disk-full", lines: fixtureLines("build", "Run make", "tar: write error: No space left on device"), rootCause: "out of disk space"},
+		{
+			rule:      "permission",
+			lines:     fixtureLines("release", "Run gh release create", "HTTP 403: Resource not accessible by integration"),
+			rootCause: "GitHub rejected an operation of the workflow: HTTP 403: Resource not accessible by integration",
+		},
+		{
+			rule:      "license",
+			lines:     fixtureLines("licenses", "Run go-licenses check ./...", "F0101 Forbidden license type GPL-3.0 for library github.com/foo/bar"),
+			rootCause: "A license scan rejected a dependency: github.com/foo/bar: GPL-3.0 (go-licenses)",
+		},
+		{
+			rule:      "compiler-error",
+			lines:     fixtureLines("build", "Run cargo build", "error[E0308]: mismatched types", "  --> src/config.rs:12:20"),
+			rootCause: "The code does not compile: src/config.rs:12:20: error[E0308]: mismatched types",
+		},
+		{rule: "timeout", conclusion: "timed_out", lines: fixtureLines("test", "Run go test", "=== RUN   TestSlow"), rootCause: "timed out"},
+		{
+			rule:      "rate-limit",
+			lines:     fixtureLines("setup", "Run actions/setup-go", "##[error]API rate limit exceeded for installation ID 4242."),
+			rootCause: "rate limit",
+		},
+		{
+			rule:      "dependency",
+			lines:     fixtureLines("build", "Run go mod download", "fatal: unable to access 'https://github.com/foo/bar/': Could not resolve host: github.com"),
+			rootCause: "Downloading dependencies",
+		},
+		{rule: "command-not-found", lines: fixtureLines("lint", "Run golangci-lint run", "/home/runner/work/_temp/1.sh: line 1: golangci-lint: command not found"), rootCause: "not installed"},
+		{
+			rule: "docker-build",
+			lines: fixtureLines("image", "Run docker build .",
+				`ERROR: failed to solve: process "/bin/sh -c make build" did not complete successfully: exit code: 2`),
+			rootCause: "The Docker build failed at `RUN make build` (exit code 2).",
+		},
+		{rule: "test-failure", lines: fixtureLines("test", "Run go test", "--- FAIL: TestParse (0.00s)", "FAIL"), rootCause: "Tests failed."},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{}
+			run := &WorkflowRun{Conclusion: tt.conclusion, FailedLogs: strings.Join(tt.lines, "\n")}
+			d.parseLogs(run)
+
+			if got := matchedRule(run); got != tt.rule {
+				t.Fatalf("matched rule = %q, want %q", got, tt.rule)
+			}
+			proposal := heuristicProposal(run)
+			if !proposal.Heuristic || !strings.Contains(proposal.RootCause, tt.rootCause) {
+				t.Errorf("proposal = %+v, want a heuristic root cause containing %q", proposal, tt.rootCause)
+			}
+		})
+		covered[tt.rule] = true
+	}
+	for _, rule := range heuristicRules {
+		if !covered[rule.name] {
+			t.Errorf("rule %q has no fixture", rule.name)
+		}
+	}
+}
+
+func TestHeuristicProposalNoMatch(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	run := &WorkflowRun{
+		URL:        "https://github.com/o/r/actions/runs/1",
+		FailedLogs: strings.Join(fixtureLines("build", "Run ./check.sh", "checking the release notes", "release notes are outdated"), "\n"),
+	}
+	d.parseLogs(run)
+
+	if got := matchedRule(run); got != "" {
+		t.Fatalf("matched rule = %q, want none", got)
+	}
+	proposal := heuristicProposal(run)
+	if proposal.RootCause != "No known failure pattern was recognized in the logs." || proposal.Confidence != "Low" || !proposal.Heuristic {
+		t.Errorf("proposal = %+v, want the fallback", proposal)
+	}
+	if !strings.Contains(proposal.ProposedFix, run.URL) {
+		t.Errorf("ProposedFix = %q, want the run URL", proposal.ProposedFix)
+	}
+}
@@ -0,0 +1,140 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// testAnswer is an AI response in the requested format
+const testAnswer = `## Root Cause
+The go.sum entry of golang.org/x/net is missing.
+
+## Detailed Analysis
+go build fails before compiling anything.
+
+## Proposed Fix
+Run go mod tidy and commit go.sum.
+
+## Files to Check
+- go.sum
+
+## Confidence Level
+High - the error names the module.`
+
+// mockOpenAI is an OpenAI-compatible API recording the chat completion requests it receives
+type mockOpenAI struct {
+	// reply answers a chat completion request; answer(testAnswer) when nil
+	reply func(req openai.ChatCompletionRequest) openai.ChatCompletionResponse
+	// routes are the bodies of other paths, such as "/v1/models"; anything else is a 404
+	routes map[string]string
+
+	mu      sync.Mutex
+	bodies  [][]byte            // raw bodies of the chat completion requests
+	posted  map[string][][]byte // raw bodies of the requests to routes, by path
+	headers []http.Header       // headers of all requests
+}
+
+// answer returns a reply with one choice per content
+func answer(contents ...string) func(openai.ChatCompletionRequest) openai.ChatCompletionResponse {
+	return func(openai.ChatCompletionRequest) openai.ChatCompletionResponse {
+		resp := openai.ChatCompletionResponse{Usage: openai.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100}}
+		for i, content := range contents {
+			resp.Choices = append(resp.Choices, openai.ChatCompletionChoice{
+				Index:        i,
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: openai.FinishReasonStop,
+			})
+		}
+		return resp
+	}
+}
+
+// start serves the API and returns a debugger created by New against it
+// The response cache of the test lives in a temporary directory.
+func (m *mockOpenAI) start(t *testing.T) *GitHubWorkflowDebugger {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.headers = append(m.headers, r.Header.Clone())
+		m.mu.Unlock()
+		if r.URL.Path != "/v1/chat/completions" {
+			body, ok := m.routes[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"message":"not found"}}`)
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			m.mu.Lock()
+			if m.posted == nil {
+				m.posted = make(map[string][][]byte)
+			}
+			m.posted[r.URL.Path] = append(m.posted[r.URL.Path], data)
+			m.mu.Unlock()
+			fmt.Fprint(w, body)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading the request: %v", err)
+			return
+		}
+		var req openai.ChatCompletionRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("invalid chat completion request: %v", err)
+		}
+		m.mu.Lock()
+		m.bodies = append(m.bodies, data)
+		m.mu.Unlock()
+		reply := m.reply
+		if reply == nil {
+			reply = answer(testAnswer)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reply(req))
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv("OPENAI_BASE_URL", srv.URL+"/v1")
+	t.Setenv("OPENAI_ALLOW_HTTP", "true")
+	for _, name := range []string{"OPENAI_MODEL", "OPENAI_ALLOWED_HOSTS", "OPENAI_CERT_SHA256", "OPENAI_ORG_ID", "OPENAI_PROJECT_ID",
+		"AZURE_OPENAI_ENDPOINT", "AZURE_OPENAI_API_KEY", "AZURE_OPENAI_DEPLOYMENT", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	return New("test-key")
+}
+
+// requests returns the chat completion requests received so far
+func (m *mockOpenAI) requests(t *testing.T) []openai.ChatCompletionRequest {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	requests := make([]openai.ChatCompletionRequest, len(m.bodies))
+	for i, data := range m.bodies {
+		if err := json.Unmarshal(data, &requests[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return requests
+}
+
+// testRun is a failed run with logs to analyze
+func testRun() *WorkflowRun {
+	return &WorkflowRun{
+		RunID:        "1",
+		Repository:   "o/r",
+		WorkflowName: "CI",
+		Conclusion:   "failure",
+		FailedLogs: "build\tRun go build\t2024-01-01T00:00:00Z go: downloading golang.org/x/net v0.20.0\n" +
+			"build\tRun go build\t2024-01-01T00:00:01Z main.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html\n" +
+			"build\tRun go build\t2024-01-01T00:00:01Z ##[error]Process completed with exit code 1.",
+	}
+}
@@ -0,0 +1,103 @@
+package debugger
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// runnerWorkspaceRe matches the checkout directory of GitHub-hosted runners and container jobs,
+// e.g. /home/runner/work/repo/repo/, /__w/repo/repo/ or D:/a/repo/repo/
+var runnerWorkspaceRe = regexp.MustCompile(`^(?:/home/runner/work|/Users/runner/work|/__w|[A-Za-z]:/a)/[^/]+/[^/]+/`)
+
+// workspacePrefixes removes the references to the workspace directory of the runner
+var workspacePrefixes = strings.NewReplacer("$GITHUB_WORKSPACE/", "", "${{ github.workspace }}/", "")
+
+// isAlphanumeric reports whether r is a letter or digit; a path without any is not usable
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// backtickRe matches the first inline code span of a list entry
+var backtickRe = regexp.MustCompile("`([^`]+)`")
+
+// normalizeFilePath turns a "Files to Check" entry into a clean repo-relative path
+// Descriptions, markdown, quotes and the runner workspace prefix are removed; a trailing
+// ":line" is kept. Returns false when the entry contains no usable path.
+func normalizeFilePath(entry string) (string, bool) {
+	// The workspace expression contains spaces, so it is removed before the entry is split into words
+	entry = strings.TrimSpace(workspacePrefixes.Replace(entry))
+	if m := backtickRe.FindStringSubmatch(entry); m != nil {
+		entry = m[1]
+	} else {
+		entry = strings.Trim(entry, "*_ ")
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			return "", false
+		}
+		// Prefer the first word that looks like a path, e.g. "go.mod" in "The go.mod file"
+		entry = fields[0]
+		for _, field := range fields {
+			if strings.ContainsAny(strings.TrimRight(field, ",.;:"), "./\\") {
+				entry = field
+				break
+			}
+		}
+	}
+
+	// Punctuation may follow the closing markup ("**main.go**:") or precede it ("`main.go`,")
+	path := strings.Trim(strings.TrimRight(entry, ",.;:"), "\"'*_`()[]")
+	path = strings.TrimRight(path, ",.;:")
+	path = strings.ReplaceAll(path, "\\", "/")
+	path = runnerWorkspaceRe.ReplaceAllString(path, "")
+	for strings.HasPrefix(path, "./") {
+		path = path[2:]
+	}
+	// Keep the line, drop a column ("foo.go:12:5")
+	if file, rest, ok := strings.Cut(path, ":"); ok {
+		line, _, _ := strings.Cut(rest, ":")
+		path = file + ":" + line
+	}
+
+	if !strings.ContainsFunc(path, isAlphanumeric) || strings.ContainsAny(path, " \t") {
+		return "", false
+	}
+	return path, true
+}
+
+// normalizeFilesToCheck normalizes and de-duplicates the files to check
+// A file listed both with and without a line number is kept once, preferring the line number.
+func normalizeFilesToCheck(files []string) []string {
+	var normalized []string
+	index := make(map[string]int)
+	for _, entry := range files {
+		path, ok := normalizeFilePath(entry)
+		if !ok {
+			logDebugf("Dropping file to check %q: no path found", entry)
+			continue
+		}
+		file, line, hasLine := strings.Cut(path, ":")
+		if i, seen := index[file]; seen {
+			if hasLine && line != "" && !strings.Contains(normalized[i], ":") {
+				normalized[i] = path
+			}
+			continue
+		}
+		index[file] = len(normalized)
+		normalized = append(normalized, path)
+	}
+	return normalized
+}
+
+// missingFiles returns the files to check that do not exist in the local checkout
+func missingFiles(files []string) []string {
+	var missing []string
+	for _, file := range files {
+		path, _, _ := strings.Cut(file, ":")
+		if _, err := os.Stat(path); err != nil {
+			missing = append(missing, file)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,66 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeFilePath(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  string // "" when no path is found
+	}{
+		{"pkg/api/handler.go", "pkg/api/handler.go"},
+		{"`pkg/api/handler.go:42` - the nil check is missing", "pkg/api/handler.go:42"},
+		{"**pkg/api/handler.go**: update the handler", "pkg/api/handler.go"},
+		{"The go.mod file, to bump the dependency", "go.mod"},
+		{"/home/runner/work/repo/repo/internal/db/db.go:17:5", "internal/db/db.go:17"},
+		{"/__w/repo/repo/src/index.ts", "src/index.ts"},
+		{"/Users/runner/work/app/app/Sources/App.swift:3", "Sources/App.swift:3"},
+		{`D:\a\repo\repo\cmd\main.go:9`, "cmd/main.go:9"},
+		{"$GITHUB_WORKSPACE/scripts/build.sh", "scripts/build.sh"},
+		{"${{ github.workspace }}/Makefile", "Makefile"},
+		{"./././.github/workflows/ci.yml", ".github/workflows/ci.yml"},
+		{`"setup.py",`, "setup.py"},
+		{"(tests/test_app.py)", "tests/test_app.py"},
+		{"Dockerfile", "Dockerfile"},
+		{"  ", ""},
+		{"`.`", ""},
+		{"`my file.go`", ""},
+	}
+	for _, tt := range tests {
+		got, ok := normalizeFilePath(tt.entry)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("normalizeFilePath(%q) = %q, %v, want %q", tt.entry, got, ok, tt.want)
+		}
+	}
+}
+
+func TestNormalizeFilesToCheck(t *testing.T) {
+	got := normalizeFilesToCheck([]string{
+		"main.go",
+		"`./main.go:12` - where the panic happens",
+		"/home/runner/work/r/r/main.go:30",
+		"go.sum",
+		"- nothing useful -",
+		"go.sum",
+	})
+	want := []string{"main.go:12", "go.sum"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeFilesToCheck() = %q, want %q", got, want)
+	}
+}
+
+func TestMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "exists.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	exists, gone := filepath.Join(dir, "exists.go"), filepath.Join(dir, "gone.go")
+	got := missingFiles([]string{exists + ":3", gone + ":7", exists})
+	if want := []string{gone + ":7"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingFiles() = %q, want %q", got, want)
+	}
+}
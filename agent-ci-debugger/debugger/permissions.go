@@ -0,0 +1,114 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of permission failures
+const (
+	PermissionToken          = "token"            // GITHUB_TOKEN or app token lacks a permission or scope
+	PermissionPushProtection = "push_protection"  // push rejected by secret scanning push protection or repository rules
+	PermissionProtectedRef   = "protected_branch" // push rejected by branch or tag protection
+)
+
+// PermissionFailure is GitHub rejecting an operation of the workflow for missing permissions or
+// repository protections rather than a failure of the code
+type PermissionFailure struct {
+	Kind    string // one of the Permission* constants
+	Message string // the log line reporting the failure
+	Line    int    // 0-based index of the log line
+}
+
+// tokenPermissionRe matches API and git errors of a token lacking permissions
+var tokenPermissionRe = regexp.MustCompile(`(?i)(resource not accessible by integration|` +
+	`refusing to allow an? (?:github app|oauth app|personal access token) to create or update workflow|` +
+	`permission to \S+ denied to (?:github-actions\[bot\]|\S+\[bot\])|` +
+	`the requested url returned error: 403|` +
+	`must have admin rights to repository|` +
+	`installation not allowed to (?:write|create) organization package|` +
+	`permission_denied: write_package|` +
+	`unable to get actions_id_token_request_url)`)
+
+// pushProtectionRe matches pushes rejected by secret scanning push protection or repository rules
+var pushProtectionRe = regexp.MustCompile(`(?i)(GH0(?:09|13): |` +
+	`push cannot contain secrets|` +
+	`secret scanning push protection|` +
+	`github push protection|` +
+	`repository rule violations found)`)
+
+// protectedRefRe matches pushes rejected by branch or tag protection
+var protectedRefRe = regexp.MustCompile(`(?i)(protected branch hook declined|` +
+	`GH006: protected branch update failed|` +
+	`cannot force-push to (?:this|a) protected branch|` +
+	`changes must be made through a pull request)`)
+
+// permissionDetector recognizes operations GitHub rejected for missing token permissions, secret
+// scanning or branch protection. These need a permissions/configuration fix instead of a code
+// change, so they are collected into PermissionFailures.
+type permissionDetector struct{}
+
+func (permissionDetector) Name() string { return "permission" }
+
+func (permissionDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i, line := range lines {
+		content := strings.TrimSpace(logContent(line))
+		kind := ""
+		switch {
+		case pushProtectionRe.MatchString(content):
+			kind = PermissionPushProtection
+		case protectedRefRe.MatchString(content):
+			kind = PermissionProtectedRef
+		case tokenPermissionRe.MatchString(content):
+			kind = PermissionToken
+		default:
+			continue
+		}
+		failure := PermissionFailure{Kind: kind, Message: content, Line: i}
+		findings = append(findings, Finding{Category: CategoryPermission, Message: content, Line: i, Permission: &failure})
+	}
+
+	return findings
+}
+
+// writePermissionFailures steers the analysis toward the workflow's permissions and token scopes
+func writePermissionFailures(sb *strings.Builder, failures []PermissionFailure, workflowPath string) {
+	if len(failures) == 0 {
+		return
+	}
+
+	kinds := make(map[string]bool)
+	for _, f := range failures {
+		kinds[f.Kind] = true
+	}
+	workflow := "the workflow file"
+	if workflowPath != "" {
+		workflow = workflowPath
+	}
+
+	sb.WriteString(fmt.Sprintf("PERMISSION FAILURES (%d total) - GitHub rejected an operation of the workflow. This needs a permissions "+
+		"or repository configuration fix, not an application code change:\n", len(failures)))
+	if kinds[PermissionToken] {
+		sb.WriteString(fmt.Sprintf("  The token lacks a permission: propose the minimal `permissions:` entry in %s (workflow or job level, "+
+			"e.g. `contents: write`, `pull-requests: write`, `packages: write`, `id-token: write`); note that tokens of pull requests "+
+			"from forks are read-only, and that changing workflow files needs a token with the `workflows` scope instead of GITHUB_TOKEN.\n", workflow))
+	}
+	if kinds[PermissionPushProtection] {
+		sb.WriteString("  Secret scanning or a repository rule blocked the push: the commit contains a secret or violates a ruleset. " +
+			"Propose removing the secret from the commit history and rotating it, never bypassing the protection.\n")
+	}
+	if kinds[PermissionProtectedRef] {
+		sb.WriteString("  Branch protection rejected the push: propose pushing to a new branch and opening a pull request, " +
+			"or a token/app allowed to bypass the protection.\n")
+	}
+	for i, f := range failures {
+		if i >= 3 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(failures)-3))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  - %s\n", truncateText(f.Message, 300)))
+	}
+}
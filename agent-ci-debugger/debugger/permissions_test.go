@@ -0,0 +1,73 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPermissionDetector(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		kind    string
+	}{
+		{"integration", "HttpError: Resource not accessible by integration", PermissionToken},
+		{"git 403", "fatal: unable to access 'https://github.com/o/r/': The requested URL returned error: 403", PermissionToken},
+		{"bot denied", "remote: Permission to o/r.git denied to github-actions[bot].", PermissionToken},
+		{"workflow scope", "! [remote rejected] ci -> ci (refusing to allow a GitHub App to create or update workflow `.github/workflows/ci.yml` without `workflows` permission)", PermissionToken},
+		{"packages", "denied: installation not allowed to Write organization package", PermissionToken},
+		{"oidc", "Error: Unable to get ACTIONS_ID_TOKEN_REQUEST_URL env variable", PermissionToken},
+		{"GH013", "remote: error: GH013: Repository rule violations found for refs/heads/main.", PermissionPushProtection},
+		{"GH009", "remote: error: GH009: Secrets detected! This push failed.", PermissionPushProtection},
+		{"push protection", "remote: - GITHUB PUSH PROTECTION", PermissionPushProtection},
+		{"GH006", "remote: error: GH006: Protected branch update failed for refs/heads/main.", PermissionProtectedRef},
+		{"hook declined", "! [remote rejected] main -> main (protected branch hook declined)", PermissionProtectedRef},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := permissionDetector{}.Detect(fixtureLines("release", "Run git push", "ok", tt.content))
+			if len(findings) != 1 {
+				t.Fatalf("findings = %+v, want one", findings)
+			}
+			f := findings[0]
+			if f.Category != CategoryPermission || f.Permission == nil || f.Message != tt.content {
+				t.Fatalf("finding = %+v, want a permission failure", f)
+			}
+			if *f.Permission != (PermissionFailure{Kind: tt.kind, Message: tt.content, Line: 1}) {
+				t.Errorf("PermissionFailure = %+v, want kind %q", *f.Permission, tt.kind)
+			}
+		})
+	}
+
+	for _, content := range []string{
+		"permissions: contents: write",
+		"Error: HTTP 404: Not Found (https://api.github.com/repos/o/r/releases)",
+		"--- FAIL: TestPermissionDenied (0.00s)",
+	} {
+		if findings := (permissionDetector{}).Detect(fixtureLines("release", "Run git push", content)); len(findings) != 0 {
+			t.Errorf("Detect(%q) = %+v, want none", content, findings)
+		}
+	}
+}
+
+func TestWritePermissionFailures(t *testing.T) {
+	failures := []PermissionFailure{
+		{Kind: PermissionToken, Message: "Resource not accessible by integration"},
+		{Kind: PermissionPushProtection, Message: "GH013: Repository rule violations found"},
+	}
+	var sb strings.Builder
+	writePermissionFailures(&sb, failures, ".github/workflows/release.yml")
+	for _, want := range []string{
+		"PERMISSION FAILURES (2 total)",
+		"`permissions:` entry in .github/workflows/release.yml",
+		"Secret scanning or a repository rule blocked the push",
+		"  - Resource not accessible by integration\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("prompt section does not contain %q:\n%s", want, sb.String())
+		}
+	}
+	if strings.Contains(sb.String(), "Branch protection") {
+		t.Errorf("prompt section mentions branch protection without such a failure:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,89 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultPersona is the persona used when none is selected
+const DefaultPersona = "devops"
+
+// Persona is a named system prompt setting the style of the analysis
+type Persona struct {
+	Name         string
+	Description  string // one line shown in the help
+	SystemPrompt string
+}
+
+// personaRegistry holds the personas selectable with --persona, in the order they are listed
+var personaRegistry = []Persona{
+	{
+		Name:         "devops",
+		Description:  "DevOps engineer giving a detailed, actionable analysis (default)",
+		SystemPrompt: "You are an expert DevOps engineer specializing in debugging CI/CD workflows and GitHub Actions failures. You provide detailed, actionable analysis and fixes.",
+	},
+	{
+		Name:        "terse",
+		Description: "short answers for experienced engineers, no background explanations",
+		SystemPrompt: "You are a senior engineer debugging CI/CD workflows and GitHub Actions failures for other senior engineers. " +
+			"Be terse: state the root cause and the fix directly, skip background explanations and generic advice.",
+	},
+	{
+		Name:        "mentor",
+		Description: "explains the failure and the fix step by step for less experienced engineers",
+		SystemPrompt: "You are a patient CI/CD mentor helping less experienced engineers understand GitHub Actions failures. " +
+			"Explain what the error messages mean, why the failure happened and why the proposed fix works, step by step.",
+	},
+	{
+		Name:        "security",
+		Description: "also reviews the failure for security issues (secrets, permissions, supply chain)",
+		SystemPrompt: "You are a DevSecOps engineer debugging CI/CD workflows and GitHub Actions failures. " +
+			"Besides the root cause and fix, point out security concerns visible in the logs or workflow, such as leaked secrets, " +
+			"overly broad permissions, unpinned actions or untrusted dependencies, and make sure the proposed fix does not weaken security.",
+	},
+}
+
+// RegisterPersona adds a persona selectable with --persona
+func RegisterPersona(persona Persona) {
+	personaRegistry = append(personaRegistry, persona)
+}
+
+// Personas returns the registered personas in the order they are listed
+func Personas() []Persona {
+	return append([]Persona{}, personaRegistry...)
+}
+
+// LookupPersona returns the persona with the given name
+func LookupPersona(name string) (Persona, error) {
+	var names []string
+	for _, p := range personaRegistry {
+		if p.Name == name {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return Persona{}, fmt.Errorf("unknown persona %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// LoadSystemPrompt reads a custom system prompt from path
+func LoadSystemPrompt(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read system prompt: %w", err)
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", fmt.Errorf("system prompt file %s is empty", path)
+	}
+	return prompt, nil
+}
+
+// systemPrompt returns the system message of the analysis
+func (d *GitHubWorkflowDebugger) systemPrompt() string {
+	if d.Options.SystemPrompt != "" {
+		return d.Options.SystemPrompt
+	}
+	persona, _ := LookupPersona(DefaultPersona)
+	return persona.SystemPrompt
+}
@@ -0,0 +1,78 @@
+package debugger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestPersonaSystemMessageSent(t *testing.T) {
+	terse, err := LookupPersona("terse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	devops, _ := LookupPersona(DefaultPersona)
+	tests := []struct {
+		name         string
+		systemPrompt string
+		want         string
+	}{
+		{name: "default persona", want: devops.SystemPrompt},
+		{name: "terse persona", systemPrompt: terse.SystemPrompt, want: terse.SystemPrompt},
+		{name: "custom system prompt", systemPrompt: "You only answer in haiku.", want: "You only answer in haiku."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &mockOpenAI{}
+			d := api.start(t)
+			d.Options = Options{Quiet: true, SystemPrompt: tt.systemPrompt}
+			if _, err := d.AnalyzeFailure(context.Background(), testRun()); err != nil {
+				t.Fatalf("AnalyzeFailure() error = %v", err)
+			}
+			requests := api.requests(t)
+			if len(requests) != 1 {
+				t.Fatalf("API received %d requests, want 1", len(requests))
+			}
+			first := requests[0].Messages[0]
+			if first.Role != openai.ChatMessageRoleSystem || first.Content != tt.want {
+				t.Errorf("first message = %s %q, want the system message %q", first.Role, first.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupPersona(t *testing.T) {
+	for _, p := range Personas() {
+		got, err := LookupPersona(p.Name)
+		if err != nil || got.SystemPrompt == "" {
+			t.Errorf("LookupPersona(%q) = %+v, %v", p.Name, got, err)
+		}
+	}
+	_, err := LookupPersona("pirate")
+	if err == nil || !strings.Contains(err.Error(), "devops, terse") {
+		t.Errorf("LookupPersona() of an unknown persona error = %v, want the available personas listed", err)
+	}
+}
+
+func TestLoadSystemPrompt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prompt.txt")
+	if err := os.WriteFile(path, []byte("\n  Be brief.\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := LoadSystemPrompt(path); err != nil || got != "Be brief." {
+		t.Errorf("LoadSystemPrompt() = %q, %v, want the trimmed prompt", got, err)
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte(" \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSystemPrompt(empty); err == nil {
+		t.Error("LoadSystemPrompt() of an empty file succeeded")
+	}
+}
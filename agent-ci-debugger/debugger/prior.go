@@ -0,0 +1,57 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxPriorSectionChars caps each section of a prior report included in the prompt
+const maxPriorSectionChars = 3000
+
+// PriorReport is the earlier analysis of a failure whose fix did not work (Options.PriorReport)
+type PriorReport struct {
+	Path        string
+	RootCause   string
+	ProposedFix string
+	CodeChanges string // the suggested diffs, as written in the report
+}
+
+// loadPriorReport reads the root cause, proposed fix and code changes of a Markdown report
+// Reports of this tool as well as saved raw AI responses are accepted, both use the same headers.
+func loadPriorReport(path string) (*PriorReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prior report: %w", err)
+	}
+	sections := splitSections(string(data))
+	prior := &PriorReport{
+		Path:        path,
+		RootCause:   sections[sectionRootCause],
+		ProposedFix: sections[sectionFix],
+		CodeChanges: sections[sectionCodeChanges],
+	}
+	if prior.RootCause == "" && prior.ProposedFix == "" {
+		return nil, fmt.Errorf("prior report %s has no Root Cause or Proposed Fix section (expected a Markdown report)", path)
+	}
+	logDebugf("Loaded prior report %s", path)
+	return prior, nil
+}
+
+// writePriorReport writes the prior analysis and asks the model not to repeat its fix
+func writePriorReport(sb *strings.Builder, prior *PriorReport) {
+	sb.WriteString("\n## Previous Analysis\n")
+	sb.WriteString("An earlier analysis of this failure proposed the fix below. It was tried and the workflow still fails, ")
+	sb.WriteString("so do not suggest it again: explain why it was not enough and propose a different fix. ")
+	sb.WriteString("If the logs show the fix was not actually applied, say so instead.\n")
+	for _, section := range []struct{ title, content string }{
+		{"Previous Root Cause", prior.RootCause},
+		{"Previous Proposed Fix", prior.ProposedFix},
+		{"Previous Code Changes", prior.CodeChanges},
+	} {
+		if section.content == "" {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n### %s\n%s\n", section.title, truncateText(section.content, maxPriorSectionChars)))
+	}
+}
@@ -0,0 +1,83 @@
+package debugger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPriorReport(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	proposal := reportProposal()
+	proposal.CodeChanges = []CodeChange{{File: "go.mod", Description: "Require x/net.", DiffSnippet: "+require golang.org/x/net v0.20.0"}}
+	report := write("report.md", New("test-key").GenerateReport(testRun(), proposal))
+	prior, err := loadPriorReport(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prior.RootCause != proposal.RootCause || prior.ProposedFix != proposal.ProposedFix ||
+		!strings.Contains(prior.CodeChanges, "+require golang.org/x/net v0.20.0") {
+		t.Errorf("prior = %+v, want the sections of the report", prior)
+	}
+
+	if _, err := loadPriorReport(write("notes.md", "# Notes\nTried go mod tidy.\n")); err == nil || !strings.Contains(err.Error(), "no Root Cause or Proposed Fix") {
+		t.Errorf("error = %v, want the missing sections", err)
+	}
+	if _, err := loadPriorReport(filepath.Join(dir, "missing.md")); err == nil {
+		t.Error("loadPriorReport() of a missing file succeeded")
+	}
+}
+
+func TestPriorReportInPrompt(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	path := filepath.Join(t.TempDir(), "report.md")
+	prior := "## Root Cause\nThe module cache is stale.\n\n## Proposed Fix\nClear the module cache.\n\n" +
+		"## Code Changes\n" + strings.Repeat("x", maxPriorSectionChars+10) + "\n"
+	if err := os.WriteFile(path, []byte(prior), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d.Options = Options{Quiet: true, PriorReport: path}
+
+	if _, err := d.debugRun(context.Background(), testRun(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	requests := api.requests(t)
+	if len(requests) != 1 {
+		t.Fatalf("%d requests, want 1", len(requests))
+	}
+	prompt := requests[0].Messages[len(requests[0].Messages)-1].Content
+	for _, want := range []string{
+		"## Previous Analysis", "do not suggest it again",
+		"### Previous Root Cause\nThe module cache is stale.",
+		"### Previous Proposed Fix\nClear the module cache.",
+		"### Previous Code Changes\n", "truncated 10 chars",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q", want)
+		}
+	}
+}
+
+func TestPriorReportMissing(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, PriorReport: filepath.Join(t.TempDir(), "missing.md")}
+	if _, err := d.debugRun(context.Background(), testRun(), time.Now()); err == nil || !strings.Contains(err.Error(), "failed to read prior report") {
+		t.Errorf("error = %v, want the prior report error", err)
+	}
+	if n := len(api.requests(t)); n != 0 {
+		t.Errorf("%d requests, want none", n)
+	}
+}
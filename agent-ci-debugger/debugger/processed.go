@@ -0,0 +1,107 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultProcessedTTL is how long the server remembers an analyzed run
+const DefaultProcessedTTL = 7 * 24 * time.Hour
+
+// ProcessedRuns is the persisted set of runs the server has analyzed, so redelivered webhooks of a
+// run are only analyzed (and commented) once
+// Runs are keyed by repository, run ID and attempt: a re-run of a failed run is a new attempt and is
+// analyzed again. Entries expire after the TTL.
+type ProcessedRuns struct {
+	path string
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu   sync.Mutex
+	runs map[string]time.Time // key -> when the run was claimed
+}
+
+// DefaultProcessedRunsPath returns the file of the processed runs in the user cache directory
+func DefaultProcessedRunsPath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(base, "github-workflow-debugger", "processed-runs.json"), nil
+}
+
+// OpenProcessedRuns loads the processed runs from path, which is created on the first claim
+func OpenProcessedRuns(path string, ttl time.Duration) (*ProcessedRuns, error) {
+	if ttl <= 0 {
+		ttl = DefaultProcessedTTL
+	}
+	p := &ProcessedRuns{path: path, ttl: ttl, now: time.Now, runs: make(map[string]time.Time)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read processed runs: %w", err)
+	}
+	if err := json.Unmarshal(data, &p.runs); err != nil {
+		return nil, fmt.Errorf("failed to parse processed runs %s: %w", path, err)
+	}
+	return p, nil
+}
+
+func processedKey(ref RunRef, attempt int) string {
+	if attempt <= 0 {
+		attempt = 1
+	}
+	return fmt.Sprintf("%s/%s/%d", ref.Repository, ref.RunID, attempt)
+}
+
+// claim marks the run as processed, returning false when it already was within the TTL
+func (p *ProcessedRuns) claim(ref RunRef, attempt int) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := processedKey(ref, attempt)
+	if at, ok := p.runs[key]; ok && p.now().Sub(at) < p.ttl {
+		return false, nil
+	}
+	p.runs[key] = p.now()
+	return true, p.save()
+}
+
+// release forgets the run, so a redelivery analyzes it again after a failed analysis
+func (p *ProcessedRuns) release(ref RunRef, attempt int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.runs, processedKey(ref, attempt))
+	return p.save()
+}
+
+// save writes the runs to the file, dropping the expired ones; the caller holds the lock
+func (p *ProcessedRuns) save() error {
+	for key, at := range p.runs {
+		if p.now().Sub(at) >= p.ttl {
+			delete(p.runs, key)
+		}
+	}
+	data, err := json.MarshalIndent(p.runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode processed runs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0700); err != nil {
+		return fmt.Errorf("failed to create processed runs directory: %w", err)
+	}
+	// Replace the file atomically, a crash must not lose the runs already processed
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write processed runs: %w", err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("failed to write processed runs: %w", err)
+	}
+	return nil
+}
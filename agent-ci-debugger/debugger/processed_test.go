@@ -0,0 +1,150 @@
+package debugger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessedRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "processed-runs.json")
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	p, err := OpenProcessedRuns(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.now = func() time.Time { return now }
+	ref := RunRef{Repository: "o/r", RunID: "1"}
+	claim := func(p *ProcessedRuns, attempt int, want bool) {
+		t.Helper()
+		claimed, err := p.claim(ref, attempt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed != want {
+			t.Errorf("claim(attempt %d) = %v, want %v", attempt, claimed, want)
+		}
+	}
+
+	claim(p, 1, true)
+	claim(p, 1, false)
+	claim(p, 0, false) // events without an attempt are the first attempt
+	claim(p, 2, true)  // a re-run is analyzed again
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("processed runs file = %v, %v, want mode 0600", info, err)
+	}
+
+	// the runs survive a restart
+	reopened, err := OpenProcessedRuns(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.now = p.now
+	claim(reopened, 1, false)
+
+	// a failed analysis is released for the next delivery
+	if err := reopened.release(ref, 2); err != nil {
+		t.Fatal(err)
+	}
+	claim(reopened, 2, true)
+
+	// entries expire after the TTL
+	now = now.Add(time.Hour)
+	claim(reopened, 1, true)
+}
+
+func TestOpenProcessedRunsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processed-runs.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenProcessedRuns(path, 0); err == nil || !strings.Contains(err.Error(), "failed to parse processed runs") {
+		t.Errorf("error = %v, want a parse error", err)
+	}
+}
+
+func TestHandleWebhookSkipsProcessedRuns(t *testing.T) {
+	routes := make(map[string]string)
+	batchRoutes(routes, 1, "failure")
+	stubGitHub(t, routes)
+	api := &mockOpenAI{}
+	api.start(t)
+	s := testServer(t, 1)
+	processed, err := OpenProcessedRuns(filepath.Join(t.TempDir(), "processed-runs.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Processed = processed
+
+	deliver := func(id int, want int) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, webhookRequest(testWebhookSecret, "workflow_run", workflowRunPayload(id, "failure")))
+		s.wg.Wait()
+		if w.Code != want {
+			t.Errorf("run %d: status = %d, want %d", id, w.Code, want)
+		}
+		return w.Body.String()
+	}
+
+	deliver(1, http.StatusAccepted)
+	if body := deliver(1, http.StatusOK); !strings.Contains(body, "already processed") {
+		t.Errorf("body = %q, want the redelivery skipped", body)
+	}
+	if n := len(api.requests(t)); n != 1 {
+		t.Errorf("API received %d requests, want the run analyzed once", n)
+	}
+
+	// run 2 cannot be fetched, so its redelivery is analyzed again
+	deliver(2, http.StatusAccepted)
+	deliver(2, http.StatusAccepted)
+}
+
+func TestHandleWebhookReleasesDroppedRuns(t *testing.T) {
+	routes := make(map[string]string)
+	batchRoutes(routes, 1, "failure")
+	stubGitHub(t, routes)
+	api := &mockOpenAI{}
+	api.start(t)
+	path := filepath.Join(t.TempDir(), "processed-runs.json")
+
+	deliver := func(s *Server, want int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, webhookRequest(testWebhookSecret, "workflow_run", workflowRunPayload(1, "failure")))
+		if w.Code != want {
+			t.Errorf("status = %d, want %d", w.Code, want)
+		}
+	}
+	serve := func() *Server {
+		s := testServer(t, 1)
+		processed, err := OpenProcessedRuns(path, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Processed = processed
+		return s
+	}
+
+	// The only slot is busy, so the run is queued when the server shuts down
+	s := serve()
+	s.slots <- struct{}{}
+	deliver(s, http.StatusAccepted)
+	s.cancel()
+	s.wg.Wait()
+	if n := len(api.requests(t)); n != 0 {
+		t.Fatalf("API received %d requests, want the queued run dropped", n)
+	}
+
+	// After the restart the redelivery is analyzed
+	s = serve()
+	deliver(s, http.StatusAccepted)
+	s.wg.Wait()
+	if n := len(api.requests(t)); n != 1 {
+		t.Errorf("API received %d requests, want the redelivered run analyzed", n)
+	}
+}
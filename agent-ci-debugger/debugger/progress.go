@@ -0,0 +1,133 @@
+package debugger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// spinnerFrames are the animation frames of the progress indicator
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressEnabled reports whether interactive progress output should be shown
+func (d *GitHubWorkflowDebugger) progressEnabled() bool {
+	return !d.Options.Quiet && isTerminal(os.Stdout) && isTerminal(os.Stderr)
+}
+
+// statusf prints a user-facing status message
+// Status goes to stdout unless stdout is reserved for the report itself.
+func (d *GitHubWorkflowDebugger) statusf(format string, args ...any) {
+	if d.Options.Quiet {
+		return
+	}
+	fmt.Fprintf(d.statusOutput(), format, args...)
+}
+
+// statusOutput returns the file status messages are written to
+func (d *GitHubWorkflowDebugger) statusOutput() *os.File {
+	if d.Options.StatusToStderr {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// spinner renders an animated progress indicator with elapsed time on stderr
+// While running it also acts as the log output, so log lines are printed on
+// their own line instead of being mixed into the spinner line.
+type spinner struct {
+	mu        sync.Mutex
+	out       io.Writer
+	logOutput io.Writer
+	message   string
+	start     time.Time
+	lineLen   int
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// startSpinner starts a spinner with the given message
+// Returns nil when disabled; calling Stop on a nil spinner is a no-op.
+func startSpinner(message string, enabled bool) *spinner {
+	if !enabled {
+		return nil
+	}
+
+	s := &spinner{
+		out:       os.Stderr,
+		logOutput: log.Writer(),
+		message:   message,
+		start:     time.Now(),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	log.SetOutput(s)
+
+	go s.run()
+	return s
+}
+
+func (s *spinner) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		s.mu.Lock()
+		s.clearLine()
+		line := fmt.Sprintf("%s %s... %ds", spinnerFrames[frame%len(spinnerFrames)], s.message,
+			int(time.Since(s.start).Seconds()))
+		fmt.Fprint(s.out, colorize(os.Stderr, colorCyan, line[:1])+line[1:])
+		s.lineLen = len(line)
+		s.mu.Unlock()
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// clearLine erases the spinner line; the caller must hold s.mu
+func (s *spinner) clearLine() {
+	if s.lineLen > 0 {
+		fmt.Fprintf(s.out, "\r%s\r", strings.Repeat(" ", s.lineLen))
+		s.lineLen = 0
+	}
+}
+
+// Write clears the spinner line before passing log output through
+func (s *spinner) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clearLine()
+	return s.logOutput.Write(p)
+}
+
+// Stop stops the spinner, clears its line and restores the log output
+func (s *spinner) Stop() {
+	if s == nil {
+		return
+	}
+	close(s.done)
+	<-s.stopped
+
+	s.mu.Lock()
+	s.clearLine()
+	s.mu.Unlock()
+	log.SetOutput(s.logOutput)
+}
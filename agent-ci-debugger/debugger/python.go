@@ -0,0 +1,124 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxTracebackLines caps the lines collected for a single Python traceback
+const maxTracebackLines = 200
+
+// StackFrame is a single frame of a stack trace
+type StackFrame struct {
+	File     string
+	Line     int
+	Function string
+}
+
+var (
+	pythonFrameRe     = regexp.MustCompile(`^\s*File "([^"]+)", line (\d+), in (.+)$`)
+	pythonExceptionRe = regexp.MustCompile(`^([A-Za-z_][\w.]*)(?::\s*(.*))?$`)
+	pytestSummaryRe   = regexp.MustCompile(`^(FAILED|ERROR) (\S+::\S+|\S+\.py)(?: - (.*))?$`)
+)
+
+// pythonDetector recognizes Python tracebacks and pytest failure summaries
+type pythonDetector struct{}
+
+func (pythonDetector) Name() string { return "python" }
+
+func (pythonDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(lines); i++ {
+		content := normalizeLogLine(lines[i])
+
+		// pytest short test summary: "FAILED tests/test_x.py::test_y - AssertionError: ..."
+		if m := pytestSummaryRe.FindStringSubmatch(content); m != nil {
+			findings = append(findings, Finding{Category: CategoryFailedTest, Message: content, Line: i, Exception: m[3]})
+			continue
+		}
+
+		if content != "Traceback (most recent call last):" {
+			continue
+		}
+
+		// Collect the frames up to the first unindented line, which names the exception
+		block := []string{content}
+		var frames []StackFrame
+		exception := ""
+		end := i
+		for j := i + 1; j < len(lines) && j-i < maxTracebackLines; j++ {
+			raw := logContent(lines[j])
+			line := strings.TrimSpace(raw)
+			block = append(block, strings.TrimRight(raw, " \r"))
+			end = j
+			if m := pythonFrameRe.FindStringSubmatch(raw); m != nil {
+				lineNo, _ := strconv.Atoi(m[2])
+				frames = append(frames, StackFrame{File: m[1], Line: lineNo, Function: m[3]})
+				continue
+			}
+			if line != "" && !strings.HasPrefix(raw, " ") && !strings.HasPrefix(raw, "\t") {
+				if pythonExceptionRe.MatchString(line) {
+					exception = line
+				}
+				break
+			}
+		}
+
+		findings = append(findings, Finding{
+			Category:  CategoryStackTrace,
+			Message:   strings.Join(block, "\n"),
+			Line:      i,
+			Exception: exception,
+			Frames:    frames,
+		})
+		i = end
+	}
+
+	return findings
+}
+
+// logContent returns a log line without the job/step prefix and timestamp, keeping indentation
+func logContent(line string) string {
+	if _, _, content, ok := splitLogPrefix(line); ok {
+		line = content
+	}
+	if first, rest, ok := strings.Cut(line, " "); ok && strings.HasSuffix(first, "Z") && strings.Contains(first, "T") {
+		line = rest
+	}
+	return line
+}
+
+// writeExceptions lists the exceptions of stack trace findings with their innermost frame
+func writeExceptions(sb *strings.Builder, findings []Finding) {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if f.Category != CategoryStackTrace || f.Exception == "" {
+			continue
+		}
+		line := truncateText(f.Exception, 300)
+		if len(f.Frames) > 0 {
+			frame := f.Frames[len(f.Frames)-1]
+			line += fmt.Sprintf(" (at %s:%d in %s)", frame.File, frame.Line, frame.Function)
+		}
+		if !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("Exceptions (%d total):\n", len(lines)))
+	for i, line := range lines {
+		if i >= 5 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(lines)-5))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  - %s\n", line))
+	}
+}
@@ -0,0 +1,90 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fixtureLines returns gh log lines of the step with the given contents, timestamped like the runner
+func fixtureLines(job, step string, contents ...string) []string {
+	lines := make([]string, len(contents))
+	for i, content := range contents {
+		lines[i] = job + "\t" + step + "\t2024-01-01T00:00:00.0000000Z " + content
+	}
+	return lines
+}
+
+func TestPythonTraceback(t *testing.T) {
+	lines := fixtureLines("test", "Run pytest",
+		"collected 3 items",
+		"Traceback (most recent call last):",
+		`  File "/home/runner/work/app/app/app/cli.py", line 12, in <module>`,
+		"    main()",
+		`  File "/home/runner/work/app/app/app/cli.py", line 8, in main`,
+		"    config = load(path)",
+		`  File "/home/runner/work/app/app/app/config.py", line 21, in load`,
+		"    return int(raw)",
+		"ValueError: invalid literal for int() with base 10: 'x'",
+		"Error: Process completed with exit code 1.",
+	)
+
+	findings := pythonDetector{}.Detect(lines)
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want the traceback", findings)
+	}
+	f := findings[0]
+	if f.Category != CategoryStackTrace || f.Line != 1 {
+		t.Errorf("category = %q, line = %d", f.Category, f.Line)
+	}
+	if f.Exception != "ValueError: invalid literal for int() with base 10: 'x'" {
+		t.Errorf("Exception = %q", f.Exception)
+	}
+	wantFrames := []StackFrame{
+		{File: "/home/runner/work/app/app/app/cli.py", Line: 12, Function: "<module>"},
+		{File: "/home/runner/work/app/app/app/cli.py", Line: 8, Function: "main"},
+		{File: "/home/runner/work/app/app/app/config.py", Line: 21, Function: "load"},
+	}
+	if !reflect.DeepEqual(f.Frames, wantFrames) {
+		t.Errorf("Frames = %+v, want %+v", f.Frames, wantFrames)
+	}
+	wantMessage := "Traceback (most recent call last):\n" +
+		`  File "/home/runner/work/app/app/app/cli.py", line 12, in <module>` + "\n" +
+		"    main()\n" +
+		`  File "/home/runner/work/app/app/app/cli.py", line 8, in main` + "\n" +
+		"    config = load(path)\n" +
+		`  File "/home/runner/work/app/app/app/config.py", line 21, in load` + "\n" +
+		"    return int(raw)\n" +
+		"ValueError: invalid literal for int() with base 10: 'x'"
+	if f.Message != wantMessage {
+		t.Errorf("Message =\n%s\nwant\n%s", f.Message, wantMessage)
+	}
+}
+
+func TestPytestSummary(t *testing.T) {
+	tests := []struct {
+		line      string
+		exception string
+	}{
+		{"FAILED tests/test_config.py::test_load - ValueError: invalid literal for int() with base 10: 'x'", "ValueError: invalid literal for int() with base 10: 'x'"},
+		{"FAILED tests/test_config.py::TestLoad::test_defaults[py3.12] - assert 1 == 2", "assert 1 == 2"},
+		{"FAILED tests/test_config.py::test_timeout", ""},
+		{"ERROR tests/test_db.py - ModuleNotFoundError: No module named 'psycopg'", "ModuleNotFoundError: No module named 'psycopg'"},
+	}
+	for _, tt := range tests {
+		findings := pythonDetector{}.Detect(fixtureLines("test", "Run pytest", tt.line))
+		if len(findings) != 1 {
+			t.Errorf("Detect(%q) = %+v, want one finding", tt.line, findings)
+			continue
+		}
+		f := findings[0]
+		if f.Category != CategoryFailedTest || f.Message != tt.line || f.Exception != tt.exception {
+			t.Errorf("Detect(%q) = %q %q %q, want the failed test and exception %q", tt.line, f.Category, f.Message, f.Exception, tt.exception)
+		}
+	}
+
+	for _, line := range []string{"tests/test_config.py::test_load PASSED", "FAILED (failures=1)", "= 1 failed, 2 passed in 0.12s ="} {
+		if findings := (pythonDetector{}).Detect(fixtureLines("test", "Run pytest", line)); len(findings) != 0 {
+			t.Errorf("Detect(%q) = %+v, want none", line, findings)
+		}
+	}
+}
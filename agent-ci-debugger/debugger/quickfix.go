@@ -0,0 +1,133 @@
+package debugger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// quickfixEntry is a location referenced by the analysis, in editor quickfix form
+type quickfixEntry struct {
+	file       string
+	line       int
+	col        int
+	message    string
+	level      string // workflow command level: error, warning or notice
+	annotation bool   // taken from a check-run annotation, which the Actions UI already shows
+}
+
+func (e quickfixEntry) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.file, e.line, e.col, e.message)
+}
+
+// quickfixPath makes a path repo-relative; paths outside the workspace (e.g. system libraries) are dropped
+func quickfixPath(path string) (string, bool) {
+	path = runnerWorkspaceRe.ReplaceAllString(strings.ReplaceAll(path, "\\", "/"), "")
+	path = strings.TrimPrefix(path, "./")
+	if path == "" || strings.HasPrefix(path, "/") || strings.HasPrefix(path, "<") {
+		return "", false
+	}
+	return path, true
+}
+
+// oneLine collapses a message onto a single line
+func oneLine(message string) string {
+	return truncateText(strings.Join(strings.Fields(message), " "), 300)
+}
+
+// QuickfixEntries lists every file location the analysis references, as "file:line:col: message"
+// lines understood by editor quickfix lists (vim :cfile, VS Code problem matchers).
+// Locations come from annotations, stack traces, assertions, failed tests and the files to check.
+func QuickfixEntries(run *WorkflowRun, proposal *FixProposal) []string {
+	var lines []string
+	seen := make(map[string]bool)
+	for _, e := range locatedEntries(run, proposal) {
+		s := e.String()
+		if !seen[s] {
+			seen[s] = true
+			lines = append(lines, s)
+		}
+	}
+	return lines
+}
+
+// locatedEntries collects the file locations the analysis references, in QuickfixEntries order
+func locatedEntries(run *WorkflowRun, proposal *FixProposal) []quickfixEntry {
+	var entries []quickfixEntry
+	// level and annotation apply to the entries added next
+	level, annotation := "error", true
+	add := func(path string, line, col int, message string) {
+		path, ok := quickfixPath(path)
+		if !ok {
+			return
+		}
+		if line < 1 {
+			line = 1
+		}
+		if col < 1 {
+			col = 1
+		}
+		entries = append(entries, quickfixEntry{file: path, line: line, col: col, message: oneLine(message), level: level, annotation: annotation})
+	}
+
+	for _, a := range run.ErrorSummary.Annotations {
+		if a.hasLocation() {
+			add(a.Path, a.StartLine, a.StartColumn, fmt.Sprintf("[%s] %s", a.Level, a.Message))
+		}
+	}
+	annotation = false
+
+	for _, f := range run.ErrorSummary.Findings {
+		switch {
+		case len(f.Frames) > 0:
+			message := f.Exception
+			if message == "" {
+				message = "stack trace"
+			}
+			for i := len(f.Frames) - 1; i >= 0; i-- {
+				frame := f.Frames[i]
+				add(frame.File, frame.Line, 0, fmt.Sprintf("%s (in %s)", message, frame.Function))
+			}
+		case f.CompilerError != nil && f.CompilerError.File != "":
+			add(f.CompilerError.File, f.CompilerError.Line, f.CompilerError.Column, f.CompilerError.Header())
+		case f.Assertion != nil && f.Assertion.Location != "":
+			path, line, ok := parseSourceLocation(f.Assertion.Location)
+			if ok {
+				add(path, line, 0, fmt.Sprintf("expected %s, actual %s", f.Assertion.Expected, f.Assertion.Actual))
+			}
+		case f.Category == CategoryFailedTest || f.Category == CategoryStackTrace || f.Category == CategoryError:
+			if path, line, ok := parseSourceLocation(f.Message); ok && line > 0 {
+				add(path, line, 0, f.Message)
+			}
+		}
+	}
+
+	if proposal != nil {
+		level = "notice"
+		message := "file to check"
+		if proposal.RootCause != "" {
+			message = "file to check: " + proposal.RootCause
+		}
+		for _, file := range proposal.FilesToCheck {
+			path, line, _ := strings.Cut(file, ":")
+			lineNo := 0
+			fmt.Sscanf(line, "%d", &lineNo)
+			add(path, lineNo, 0, message)
+		}
+	}
+	return entries
+}
+
+// WriteFindingsFile writes the quickfix entries of the analysis to path
+func WriteFindingsFile(path string, run *WorkflowRun, proposal *FixProposal) error {
+	lines := QuickfixEntries(run, proposal)
+	content := strings.Join(lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write findings file: %w", err)
+	}
+	logInfof("Wrote %d findings to %s", len(lines), path)
+	return nil
+}
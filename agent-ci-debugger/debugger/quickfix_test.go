@@ -0,0 +1,88 @@
+package debugger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// quickfixLineRe is the "file:line:col: message" format of vim's default errorformat
+var quickfixLineRe = regexp.MustCompile(`^[^:\s][^:]*:\d+:\d+: \S.*$`)
+
+// quickfixRun is a run whose error summary references files in every supported way
+func quickfixRun() *WorkflowRun {
+	return &WorkflowRun{ErrorSummary: ErrorSummary{
+		Annotations: []Annotation{
+			{Path: "pkg/api/handler.go", StartLine: 42, StartColumn: 7, Level: "failure", Message: "undefined: Foo"},
+			{Path: ".github", Level: "failure", Message: "Process completed with exit code 1."},
+		},
+		Findings: []Finding{
+			{Category: CategoryStackTrace, Exception: "ValueError: invalid literal", Frames: []StackFrame{
+				{File: "/home/runner/work/r/r/app/main.py", Line: 3, Function: "<module>"},
+				{File: "/usr/lib/python3.12/json/__init__.py", Line: 346, Function: "loads"},
+				{File: "app/parse.py", Line: 17, Function: "parse"},
+			}},
+			{Category: CategoryCompilerError, CompilerError: &CompilerError{Tool: "rustc", Code: "E0308", Message: "mismatched types", File: "src/lib.rs", Line: 9, Column: 5}},
+			{Category: CategoryAssertion, Assertion: &Assertion{Location: "format_test.go:28", Expected: `"UTC"`, Actual: `"Local"`}},
+			{Category: CategoryFailedTest, Message: "--- FAIL: TestParse (0.00s)\n    parse_test.go:12: unexpected\n    error"},
+		},
+	}}
+}
+
+func TestQuickfixEntries(t *testing.T) {
+	proposal := &FixProposal{RootCause: "the parser rejects empty input", FilesToCheck: []string{"app/parse.py:17", "go.mod"}}
+	got := QuickfixEntries(quickfixRun(), proposal)
+	want := []string{
+		"pkg/api/handler.go:42:7: [failure] undefined: Foo",
+		"app/parse.py:17:1: ValueError: invalid literal (in parse)",
+		"app/main.py:3:1: ValueError: invalid literal (in <module>)",
+		"src/lib.rs:9:5: error[E0308]: mismatched types",
+		`format_test.go:28:1: expected "UTC", actual "Local"`,
+		"parse_test.go:12:1: --- FAIL: TestParse (0.00s) parse_test.go:12: unexpected error",
+		"app/parse.py:17:1: file to check: the parser rejects empty input",
+		"go.mod:1:1: file to check: the parser rejects empty input",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QuickfixEntries() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, line := range got {
+		if !quickfixLineRe.MatchString(line) {
+			t.Errorf("%q is not in quickfix format", line)
+		}
+	}
+}
+
+func TestWriteFindingsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.txt")
+	if err := WriteFindingsFile(path, quickfixRun(), nil); err != nil {
+		t.Fatalf("WriteFindingsFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.HasSuffix(content, "\n") {
+		t.Errorf("findings file does not end with a newline: %q", content)
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if len(lines) != 6 {
+		t.Errorf("findings file has %d lines, want 6:\n%s", len(lines), content)
+	}
+	for _, line := range lines {
+		if !quickfixLineRe.MatchString(line) {
+			t.Errorf("%q is not in quickfix format", line)
+		}
+	}
+
+	// A run without locations gives an empty file, not a lone newline
+	if err := WriteFindingsFile(path, &WorkflowRun{}, nil); err != nil {
+		t.Fatalf("WriteFindingsFile() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("findings file = %q, want it empty", data)
+	}
+}
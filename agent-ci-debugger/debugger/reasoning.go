@@ -0,0 +1,154 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultReasoningEffort is the reasoning effort requested from reasoning models (Options.ReasoningEffort)
+const DefaultReasoningEffort = "medium"
+
+// ReasoningEfforts are the accepted values of Options.ReasoningEffort
+var ReasoningEfforts = []string{"low", "medium", "high"}
+
+// maxReasoningResponseTokens is the completion token limit of an analysis with a reasoning model
+// Reasoning models spend completion tokens on their hidden reasoning before they answer, so the limit
+// holds both; OpenAI recommends reserving 25k tokens for them.
+const maxReasoningResponseTokens = 25000
+
+// reasoningModelRe matches the reasoning model families: the o-series (o1, o3-mini, o4-mini, ...) and gpt-5
+var reasoningModelRe = regexp.MustCompile(`^(?:o\d+|gpt-5)(?:-|$)`)
+
+// reasoningModel reports whether model is a reasoning model, which rejects temperature and max_tokens
+// Provider prefixes such as "openai/o3-mini" are ignored; the gpt-5 chat variants are classic models.
+func reasoningModel(model string) bool {
+	name := strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	return reasoningModelRe.MatchString(name) && !strings.Contains(name, "-chat")
+}
+
+// ParseReasoningEffort validates the --reasoning-effort value
+func ParseReasoningEffort(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, effort := range ReasoningEfforts {
+		if value == effort {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid reasoning effort %q (available: %s)", value, strings.Join(ReasoningEfforts, ", "))
+}
+
+// responseTokens returns the completion token limit of an analysis with the model
+func (d *GitHubWorkflowDebugger) responseTokens() int {
+	if reasoningModel(d.model) {
+		return maxReasoningResponseTokens
+	}
+	return maxResponseTokens
+}
+
+// chatRequest builds the chat completion request of the model, shaped for its family
+// Classic models get max_tokens and a temperature. Reasoning models reject both: they take
+// max_completion_tokens, and the reasoning effort is added by requestContext. The first o1 models
+// (o1-mini, o1-preview) also reject system messages, so the system prompt is sent as a user message.
+func (d *GitHubWorkflowDebugger) chatRequest(messages []openai.ChatCompletionMessage, maxTokens int) openai.ChatCompletionRequest {
+	if !reasoningModel(d.model) {
+		return openai.ChatCompletionRequest{
+			Model:       d.model,
+			Messages:    messages,
+			MaxTokens:   maxTokens,
+			Temperature: 0.7,
+		}
+	}
+	if _, beta := openai.O1SeriesModels[d.model]; beta {
+		converted := make([]openai.ChatCompletionMessage, len(messages))
+		for i, m := range messages {
+			if m.Role == openai.ChatMessageRoleSystem {
+				m.Role = openai.ChatMessageRoleUser
+			}
+			converted[i] = m
+		}
+		messages = converted
+	}
+	return openai.ChatCompletionRequest{
+		Model:               d.model,
+		Messages:            messages,
+		MaxCompletionTokens: maxTokens,
+	}
+}
+
+// singleChoiceModel reports whether the model answers with one choice only (no samples)
+func (d *GitHubWorkflowDebugger) singleChoiceModel() bool {
+	_, beta := openai.O1SeriesModels[d.model]
+	return beta
+}
+
+// reasoningEffortKey is the context key of the reasoning effort of a request
+type reasoningEffortKey struct{}
+
+// requestContext returns the context of a chat completion request, carrying the reasoning effort for
+// reasoning models and the LLM debug dump when enabled
+func (d *GitHubWorkflowDebugger) requestContext(ctx context.Context) context.Context {
+	if effort := d.reasoningEffort(); effort != "" {
+		ctx = context.WithValue(ctx, reasoningEffortKey{}, effort)
+	}
+	if dump := d.llmDumper(); dump != nil {
+		ctx = context.WithValue(ctx, llmDumpKey{}, dump)
+	}
+	return ctx
+}
+
+// reasoningEffort returns the reasoning effort requested from the model, "" for classic models
+func (d *GitHubWorkflowDebugger) reasoningEffort() string {
+	if !reasoningModel(d.model) {
+		return ""
+	}
+	if d.Options.ReasoningEffort != "" {
+		return d.Options.ReasoningEffort
+	}
+	return DefaultReasoningEffort
+}
+
+// reasoningTransport adds the reasoning effort of the request context to chat completion requests
+// The OpenAI client has no setting for reasoning_effort, so it is added to the JSON body here.
+type reasoningTransport struct {
+	base http.RoundTripper
+}
+
+func (t *reasoningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	effort, _ := req.Context().Value(reasoningEffortKey{}).(string)
+	if effort == "" || req.Body == nil || req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		fields["reasoning_effort"], _ = json.Marshal(effort)
+		if shaped, err := json.Marshal(fields); err == nil {
+			body = shaped
+		}
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	return t.base.RoundTrip(req)
+}
+
+// reasoningTokens returns the completion tokens a reasoning model spent on its hidden reasoning
+func reasoningTokens(usage openai.Usage) int {
+	if usage.CompletionTokensDetails == nil {
+		return 0
+	}
+	return usage.CompletionTokensDetails.ReasoningTokens
+}
@@ -0,0 +1,98 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestReasoningModel(t *testing.T) {
+	tests := map[string]bool{
+		"gpt-4o-mini":       false,
+		"gpt-4.1":           false,
+		"o1":                true,
+		"o1-mini":           true,
+		"o3-mini":           true,
+		"O4-mini-high":      true,
+		"openai/o3":         true,
+		"gpt-5":             true,
+		"gpt-5-mini":        true,
+		"gpt-5-chat-latest": false,
+		"omni-model":        false,
+		"gpt-50":            false,
+	}
+	for model, want := range tests {
+		if got := reasoningModel(model); got != want {
+			t.Errorf("reasoningModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestParseReasoningEffort(t *testing.T) {
+	if got, err := ParseReasoningEffort(" HIGH "); err != nil || got != "high" {
+		t.Errorf("ParseReasoningEffort() = %q, %v, want high", got, err)
+	}
+	if _, err := ParseReasoningEffort("max"); err == nil {
+		t.Error("ParseReasoningEffort(max) succeeded")
+	}
+}
+
+func TestReasoningRequestShaping(t *testing.T) {
+	tests := []struct {
+		model      string
+		effort     string // Options.ReasoningEffort
+		maxField   string
+		maxTokens  float64
+		wantEffort string // "" for no reasoning_effort
+		systemRole string // role of the system prompt, "" when it is sent as is
+	}{
+		{model: "gpt-4o-mini", maxField: "max_tokens", maxTokens: maxResponseTokens},
+		{model: "gpt-5-chat-latest", maxField: "max_tokens", maxTokens: maxResponseTokens},
+		{model: "o3-mini", maxField: "max_completion_tokens", maxTokens: maxReasoningResponseTokens, wantEffort: DefaultReasoningEffort},
+		{model: "gpt-5", effort: "high", maxField: "max_completion_tokens", maxTokens: maxReasoningResponseTokens, wantEffort: "high"},
+		{model: "o1-mini", maxField: "max_completion_tokens", maxTokens: maxReasoningResponseTokens, wantEffort: DefaultReasoningEffort, systemRole: "user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			api := &mockOpenAI{}
+			api.start(t)
+			t.Setenv("OPENAI_MODEL", tt.model)
+			d := New("test-key")
+			d.Options = Options{Quiet: true, ReasoningEffort: tt.effort}
+			if _, err := d.AnalyzeFailure(context.Background(), testRun()); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(api.bodies) != 1 {
+				t.Fatalf("%d requests, want 1", len(api.bodies))
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(api.bodies[0], &body); err != nil {
+				t.Fatal(err)
+			}
+			var maxTokens float64
+			if err := json.Unmarshal(body[tt.maxField], &maxTokens); err != nil || maxTokens != tt.maxTokens {
+				t.Errorf("%s = %s, want %v", tt.maxField, body[tt.maxField], tt.maxTokens)
+			}
+			_, temperature := body["temperature"]
+			_, maxTokensField := body["max_tokens"]
+			if reasoning := tt.wantEffort != ""; reasoning == temperature || (reasoning && maxTokensField) {
+				t.Errorf("temperature sent = %v, max_tokens sent = %v for %s", temperature, maxTokensField, tt.model)
+			}
+			var effort string
+			json.Unmarshal(body["reasoning_effort"], &effort)
+			if effort != tt.wantEffort {
+				t.Errorf("reasoning_effort = %q, want %q", effort, tt.wantEffort)
+			}
+
+			messages := api.requests(t)[0].Messages
+			wantRole := "system"
+			if tt.systemRole != "" {
+				wantRole = tt.systemRole
+			}
+			if messages[0].Role != wantRole {
+				t.Errorf("first message role = %s, want %s", messages[0].Role, wantRole)
+			}
+		})
+	}
+}
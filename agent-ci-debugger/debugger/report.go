@@ -0,0 +1,125 @@
+package debugger
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultReportTemplate renders the Markdown report unless Options.ReportTemplate replaces it
+//
+//go:embed templates/report.md.tmpl
+var defaultReportTemplate string
+
+// diffOnlyReportTemplate renders the regression report of Options.DiffOnly: the changes and new errors
+// since the passing run with the targeted fix, without the full analysis
+//
+//go:embed templates/diffonly.md.tmpl
+var diffOnlyReportTemplate string
+
+// ReportData is the context of report templates
+type ReportData struct {
+	Run         *WorkflowRun
+	Proposal    *FixProposal
+	Model       string    // the model that produced the analysis
+	GeneratedAt time.Time // when the report was rendered
+}
+
+// reportFuncs are the helpers available in report templates in addition to the text/template builtins
+var reportFuncs = template.FuncMap{
+	// reusableWorkflows lists the reusable workflows with failed jobs, one "**Reusable Workflow**" line each
+	"reusableWorkflows": func(run *WorkflowRun) string {
+		var sb strings.Builder
+		writeReusableWorkflowsReport(&sb, run.ReusableWorkflows)
+		return sb.String()
+	},
+	// jobClusters lists the analyzed jobs of runs with more failed jobs than --max-jobs, with the jobs they stand for
+	"jobClusters": func(run *WorkflowRun) string {
+		var sb strings.Builder
+		writeJobClustersReport(&sb, run.JobClusters)
+		return sb.String()
+	},
+	// regression renders the "Regression" section of a run compared with --compare
+	"regression": func(run *WorkflowRun) string {
+		var sb strings.Builder
+		writeRegressionReport(&sb, run)
+		return sb.String()
+	},
+	// missing reports whether a file to check was not found in the local checkout (--verify-files)
+	"missing": func(proposal *FixProposal, file string) bool {
+		for _, f := range proposal.MissingFiles {
+			if f == file {
+				return true
+			}
+		}
+		return false
+	},
+	"inc":       func(i int) int { return i + 1 },
+	"sub":       func(a, b int) int { return a - b },
+	"trimRight": func(s string) string { return strings.TrimRight(s, "\n") },
+	"join":      strings.Join,
+	"shortSHA":  shortSHA,
+	// normalize strips the job/step prefix and timestamp of a log line
+	"normalize": func(line string) string { return truncateText(normalizeLogLine(line), 300) },
+}
+
+// parseReportTemplate parses a report template with the report helpers
+func parseReportTemplate(text string) (*template.Template, error) {
+	return template.New("report").Funcs(reportFuncs).Parse(text)
+}
+
+// LoadReportTemplate reads and validates a report template, e.g. for --template-file
+func LoadReportTemplate(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read report template: %w", err)
+	}
+	if _, err := parseReportTemplate(string(data)); err != nil {
+		return "", fmt.Errorf("invalid report template %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// renderTemplate executes the configured report template, falling back to the default one when
+// the custom template fails (e.g. it refers to a field that does not exist)
+func (d *GitHubWorkflowDebugger) renderTemplate(data ReportData) string {
+	if d.Options.DiffOnly && d.Options.ReportTemplate == "" {
+		if data.Run.Comparison != nil {
+			report, err := executeReportTemplate(diffOnlyReportTemplate, data)
+			if err != nil {
+				panic(fmt.Sprintf("diff-only report template: %v", err))
+			}
+			return report
+		}
+		logWarnf("no comparison with a passing run, writing the full report instead of the diff-only report")
+	}
+	if d.Options.ReportTemplate != "" {
+		report, err := executeReportTemplate(d.Options.ReportTemplate, data)
+		if err == nil {
+			return report
+		}
+		logWarnf("custom report template failed, using the default template: %v", err)
+	}
+	report, err := executeReportTemplate(defaultReportTemplate, data)
+	if err != nil {
+		// The default template is fixed, this is a programming error
+		panic(fmt.Sprintf("default report template: %v", err))
+	}
+	return report
+}
+
+// executeReportTemplate parses and renders a report template
+func executeReportTemplate(text string, data ReportData) (string, error) {
+	tmpl, err := parseReportTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,143 @@
+package debugger
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func reportProposal() *FixProposal {
+	return &FixProposal{
+		RootCause:    "The go.sum entry of golang.org/x/net is missing.",
+		ProposedFix:  "Run go mod tidy and commit go.sum.",
+		FilesToCheck: []string{"go.sum"},
+		Confidence:   "High - the error names the module.",
+	}
+}
+
+func TestGenerateReportTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     []string
+		unwanted []string
+	}{
+		{
+			name: "default",
+			want: []string{"# GitHub Workflow Failure Analysis Report", "**Run ID**: 1", "## Root Cause",
+				"The go.sum entry of golang.org/x/net is missing.", "Run go mod tidy and commit go.sum."},
+		},
+		{
+			name:     "custom",
+			template: "{{.Run.Repository}}#{{.Run.RunID}} by {{.Model}}: {{.Proposal.RootCause}}{{range .Proposal.FilesToCheck}} [{{.}}]{{end}}",
+			want:     []string{"o/r#1 by gpt-4o-mini: The go.sum entry of golang.org/x/net is missing. [go.sum]"},
+			unwanted: []string{"## Root Cause"},
+		},
+		{
+			name:     "failing custom falls back to the default",
+			template: "{{.Run.NoSuchField}}",
+			want:     []string{"# GitHub Workflow Failure Analysis Report", "## Root Cause"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{model: "gpt-4o-mini", Options: Options{ReportTemplate: tt.template}}
+			run := testRun()
+			run.RunID = "1"
+			d.parseLogs(run)
+			report := d.GenerateReport(run, reportProposal())
+			for _, want := range tt.want {
+				if !strings.Contains(report, want) {
+					t.Errorf("report does not contain %q:\n%s", want, report)
+				}
+			}
+			for _, unwanted := range tt.unwanted {
+				if strings.Contains(report, unwanted) {
+					t.Errorf("report contains %q:\n%s", unwanted, report)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadReportTemplate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.tmpl")
+	os.WriteFile(valid, []byte("{{.Proposal.RootCause}}"), 0644)
+	invalid := filepath.Join(dir, "invalid.tmpl")
+	os.WriteFile(invalid, []byte("{{if .Proposal.RootCause}}"), 0644)
+
+	if got, err := LoadReportTemplate(valid); err != nil || got != "{{.Proposal.RootCause}}" {
+		t.Errorf("LoadReportTemplate(valid) = %q, %v", got, err)
+	}
+	if _, err := LoadReportTemplate(invalid); err == nil || !strings.Contains(err.Error(), "invalid report template") {
+		t.Errorf("LoadReportTemplate(invalid) error = %v, want the parse error", err)
+	}
+	if _, err := LoadReportTemplate(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("LoadReportTemplate(missing) succeeded")
+	}
+}
+
+// update rewrites the golden files of the tests with the current output: go test ./debugger -run Golden -update
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with the golden file testdata/name
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (run with -update to accept it):\n%s", path, got)
+	}
+}
+
+func TestDiffOnlyReportGolden(t *testing.T) {
+	d := &GitHubWorkflowDebugger{model: "gpt-4o-mini", Options: Options{DiffOnly: true}}
+	run := &WorkflowRun{
+		URL:     "https://github.com/o/r/actions/runs/2",
+		RunID:   "2",
+		HeadSHA: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		Comparison: &Comparison{
+			BaselineURL:  "https://github.com/o/r/actions/runs/1",
+			BaselineSHA:  "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			TotalCommits: 2,
+			Commits: []ComparedCommit{
+				{SHA: "cccccccccccccccccccccccccccccccccccccccc", Author: "alice", Message: "Bump golang.org/x/net"},
+				{SHA: "dddddddddddddddddddddddddddddddddddddddd", Author: "bob", Message: "Parse HTML titles"},
+			},
+			ChangedFiles: []ChangedFile{
+				{Filename: "go.mod", Status: "modified", Additions: 1, Deletions: 1},
+				{Filename: "title.go", Status: "added", Additions: 20},
+			},
+			NewErrors:     []string{"build\tRun go build\t2024-01-01T00:00:01.0000000Z main.go:5:2: missing go.sum entry for module providing package golang.org/x/net/html"},
+			NewFailedJobs: []string{"build"},
+		},
+	}
+	proposal := reportProposal()
+	proposal.Summary = "go.sum lacks the bumped golang.org/x/net."
+	proposal.CodeChanges = []CodeChange{{File: "go.sum", Description: "Add the checksums.", DiffSnippet: "+golang.org/x/net v0.20.0 h1:..."}}
+
+	report := d.renderTemplate(ReportData{Run: run, Proposal: proposal, Model: "gpt-4o-mini",
+		GeneratedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	checkGolden(t, "diffonly.md", report)
+
+	// Without a passing run to compare with, the full report is written
+	run.Comparison = nil
+	if report := d.renderTemplate(ReportData{Run: run, Proposal: proposal}); !strings.HasPrefix(report, "# GitHub Workflow Failure Analysis Report") {
+		t.Errorf("report without a comparison =\n%s\nwant the full report", report)
+	}
+}
@@ -0,0 +1,409 @@
+package debugger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// restTimeout bounds a single REST API request; log downloads can be large
+const restTimeout = 2 * time.Minute
+
+var restClient = &http.Client{Timeout: restTimeout}
+
+// restGH serves the subset of gh commands this tool uses from the GitHub REST API
+// Outputs match what gh prints, so callers do not need to know which backend is in use.
+func restGH(args []string, input string) ([]byte, error) {
+	positional, flags := splitGHArgs(args)
+	command := strings.Join(positional[:min(2, len(positional))], " ")
+
+	switch {
+	case len(positional) == 2 && positional[0] == "api":
+		return restRequest(http.MethodGet, positional[1], flags["-H"], nil)
+	case command == "run view" && len(positional) > 2:
+		return restRunView(flags["--repo"], positional[2], flags)
+	case command == "run list":
+		return restRunList(flags["--repo"], flags)
+	case command == "issue list":
+		return restIssueList(flags["--repo"], flags)
+	case (command == "issue comment" || command == "pr comment") && len(positional) > 2:
+		return restIssueComment(flags["--repo"], positional[2], input)
+	case command == "issue create":
+		return restIssueCreate(flags["--repo"], flags, input)
+	case command == "pr create":
+		return restPRCreate(flags["--repo"], flags, input)
+	default:
+		return nil, fmt.Errorf("gh %s is not supported without the gh CLI", strings.Join(args, " "))
+	}
+}
+
+// splitGHArgs separates positional arguments from flags; boolean flags map to "true"
+func splitGHArgs(args []string) ([]string, map[string]string) {
+	var positional []string
+	flags := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--log" || arg == "--log-failed":
+			flags[arg] = "true"
+		case strings.HasPrefix(arg, "-") && arg != "-" && i+1 < len(args):
+			flags[arg] = args[i+1]
+			i++
+		default:
+			positional = append(positional, arg)
+		}
+	}
+	return positional, flags
+}
+
+// restAPIBase returns the REST API root, GITHUB_API_URL on GitHub Enterprise Server
+func restAPIBase() string {
+	if base := os.Getenv("GITHUB_API_URL"); base != "" {
+		return strings.TrimRight(base, "/")
+	}
+	return "https://api.github.com"
+}
+
+// restRequest performs an authenticated request against endpoint (relative to the API root)
+func restRequest(method, endpoint, accept string, body interface{}) ([]byte, error) {
+	resp, err := restDo(method, endpoint, accept, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: failed to read response: %w", method, endpoint, err)
+	}
+	return data, nil
+}
+
+// restDo performs an authenticated request against endpoint and returns the response of a 2xx
+// status for the caller to read and close
+func restDo(method, endpoint, accept string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, restAPIBase()+"/"+strings.TrimPrefix(endpoint, "/"), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+githubToken())
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if accept != "" {
+		// gh takes the header as "Accept: value"
+		req.Header.Set("Accept", strings.TrimSpace(strings.TrimPrefix(accept, "Accept:")))
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := restClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, endpoint, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxStderrBytes))
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return nil, fmt.Errorf("%s %s: HTTP %d: %s", method, endpoint, resp.StatusCode, apiErr.Message)
+	}
+	return resp, nil
+}
+
+// restJob is a job as returned by the REST API
+type restJob struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+	Steps       []struct {
+		Name       string `json:"name"`
+		Number     int    `json:"number"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"steps"`
+}
+
+// restRunJobs lists the jobs of the latest attempt of a run
+func restRunJobs(repo, runID string) ([]restJob, error) {
+	var jobs []restJob
+	for page := 1; ; page++ {
+		data, err := restRequest(http.MethodGet, fmt.Sprintf("repos/%s/actions/runs/%s/jobs?per_page=100&page=%d", repo, runID, page), "", nil)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			TotalCount int       `json:"total_count"`
+			Jobs       []restJob `json:"jobs"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse jobs: %w", err)
+		}
+		jobs = append(jobs, resp.Jobs...)
+		if len(resp.Jobs) == 0 || len(jobs) >= resp.TotalCount {
+			return jobs, nil
+		}
+	}
+}
+
+// restRunView emulates `gh run view` with --json, --log, --log-failed and --job
+func restRunView(repo, runID string, flags map[string]string) ([]byte, error) {
+	if fields, ok := flags["--json"]; ok {
+		return restRunJSON(repo, runID, strings.Split(fields, ","))
+	}
+
+	var buf bytes.Buffer
+	if err := restRunLogs(&buf, repo, runID, flags); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// restRunLogs writes the logs of the jobs of a run selected by --log-failed and --job to w in the
+// format of `gh run view --log`, streaming each job's log line by line
+func restRunLogs(w io.Writer, repo, runID string, flags map[string]string) error {
+	jobs, err := restRunJobs(repo, runID)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, job := range jobs {
+		if jobID := flags["--job"]; jobID != "" && strconv.FormatInt(job.ID, 10) != jobID {
+			continue
+		}
+		if flags["--log-failed"] != "" && job.Conclusion != "failure" {
+			continue
+		}
+		resp, err := restDo(http.MethodGet, fmt.Sprintf("repos/%s/actions/jobs/%d/logs", repo, job.ID), "", nil)
+		if err != nil {
+			return fmt.Errorf("failed to get logs of job %q: %w", job.Name, err)
+		}
+		// The REST API does not tell which step a line belongs to
+		r := bufio.NewReader(resp.Body)
+		for {
+			line, err := r.ReadString('\n')
+			if line = strings.TrimRight(line, "\n"); line != "" || err == nil {
+				bw.WriteString(job.Name + "\tUNKNOWN STEP\t" + strings.TrimPrefix(line, "\ufeff") + "\n")
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				resp.Body.Close()
+				return fmt.Errorf("failed to read logs of job %q: %w", job.Name, err)
+			}
+		}
+		resp.Body.Close()
+	}
+	return bw.Flush()
+}
+
+// restRunJSON emulates `gh run view --json` for the fields this tool requests
+func restRunJSON(repo, runID string, fields []string) ([]byte, error) {
+	data, err := restRequest(http.MethodGet, fmt.Sprintf("repos/%s/actions/runs/%s", repo, runID), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var run struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		Name       string `json:"name"`
+		WorkflowID int64  `json:"workflow_id"`
+		HeadSHA    string `json:"head_sha"`
+	}
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse run: %w", err)
+	}
+
+	out := make(map[string]interface{})
+	for _, field := range fields {
+		switch field {
+		case "status":
+			out[field] = run.Status
+		case "conclusion":
+			out[field] = run.Conclusion
+		case "workflowName":
+			out[field] = run.Name
+		case "workflowDatabaseId":
+			out[field] = run.WorkflowID
+		case "headSha":
+			out[field] = run.HeadSHA
+		case "jobs":
+			jobs, err := restRunJobs(repo, runID)
+			if err != nil {
+				return nil, err
+			}
+			ghJobs := make([]map[string]interface{}, 0, len(jobs))
+			for _, job := range jobs {
+				ghJobs = append(ghJobs, map[string]interface{}{
+					"databaseId":  job.ID,
+					"name":        job.Name,
+					"status":      job.Status,
+					"conclusion":  job.Conclusion,
+					"startedAt":   job.StartedAt,
+					"completedAt": job.CompletedAt,
+					"steps":       job.Steps,
+				})
+			}
+			out[field] = ghJobs
+		default:
+			return nil, fmt.Errorf("gh run view --json %s is not supported without the gh CLI", field)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// restRunList emulates `gh run list --json databaseId,url,displayTitle,headBranch,event,createdAt`
+// The REST API only finds a workflow by its file name or ID, not by its name.
+func restRunList(repo string, flags map[string]string) ([]byte, error) {
+	query := url.Values{}
+	for flag, param := range map[string]string{"--status": "status", "--branch": "branch", "--limit": "per_page"} {
+		if value := flags[flag]; value != "" {
+			query.Set(param, value)
+		}
+	}
+	endpoint := fmt.Sprintf("repos/%s/actions/runs?%s", repo, query.Encode())
+	if workflow := flags["--workflow"]; workflow != "" {
+		endpoint = fmt.Sprintf("repos/%s/actions/workflows/%s/runs?%s", repo, url.PathEscape(workflow), query.Encode())
+	}
+	data, err := restRequest(http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		WorkflowRuns []struct {
+			ID           int64  `json:"id"`
+			HTMLURL      string `json:"html_url"`
+			DisplayTitle string `json:"display_title"`
+			HeadBranch   string `json:"head_branch"`
+			Event        string `json:"event"`
+			CreatedAt    string `json:"created_at"`
+		} `json:"workflow_runs"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse runs: %w", err)
+	}
+	out := make([]map[string]interface{}, 0, len(resp.WorkflowRuns))
+	for _, run := range resp.WorkflowRuns {
+		out = append(out, map[string]interface{}{"databaseId": run.ID, "url": run.HTMLURL, "displayTitle": run.DisplayTitle,
+			"headBranch": run.HeadBranch, "event": run.Event, "createdAt": run.CreatedAt})
+	}
+	return json.Marshal(out)
+}
+
+// restIssueList emulates `gh issue list --json number,title,body`
+func restIssueList(repo string, flags map[string]string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("state", flags["--state"])
+	if label := flags["--label"]; label != "" {
+		query.Set("labels", label)
+	}
+	if limit := flags["--limit"]; limit != "" {
+		query.Set("per_page", limit)
+	}
+	data, err := restRequest(http.MethodGet, fmt.Sprintf("repos/%s/issues?%s", repo, query.Encode()), "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []struct {
+		Number      int             `json:"number"`
+		Title       string          `json:"title"`
+		Body        string          `json:"body"`
+		PullRequest json.RawMessage `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, fmt.Errorf("failed to parse issues: %w", err)
+	}
+	// The issues endpoint also returns pull requests, gh does not
+	out := make([]map[string]interface{}, 0, len(issues))
+	for _, issue := range issues {
+		if issue.PullRequest != nil {
+			continue
+		}
+		out = append(out, map[string]interface{}{"number": issue.Number, "title": issue.Title, "body": issue.Body})
+	}
+	return json.Marshal(out)
+}
+
+// restIssueComment emulates `gh issue comment` and `gh pr comment` with --body-file - and returns the comment URL
+func restIssueComment(repo, number, body string) ([]byte, error) {
+	data, err := restRequest(http.MethodPost, fmt.Sprintf("repos/%s/issues/%s/comments", repo, number), "",
+		map[string]string{"body": body})
+	if err != nil {
+		return nil, err
+	}
+	return htmlURL(data)
+}
+
+// restIssueCreate emulates `gh issue create --body-file -` and returns the issue URL
+func restIssueCreate(repo string, flags map[string]string, body string) ([]byte, error) {
+	issue := map[string]interface{}{"title": flags["--title"], "body": body}
+	if label := flags["--label"]; label != "" {
+		issue["labels"] = []string{label}
+	}
+	data, err := restRequest(http.MethodPost, fmt.Sprintf("repos/%s/issues", repo), "", issue)
+	if err != nil {
+		return nil, err
+	}
+	return htmlURL(data)
+}
+
+// restPRCreate opens a pull request of the --head branch, against the default branch unless --base is given
+func restPRCreate(repo string, flags map[string]string, body string) ([]byte, error) {
+	base := flags["--base"]
+	if base == "" {
+		data, err := restRequest(http.MethodGet, "repos/"+repo, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		var repository struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := json.Unmarshal(data, &repository); err != nil {
+			return nil, fmt.Errorf("failed to parse repository: %w", err)
+		}
+		base = repository.DefaultBranch
+	}
+	pr := map[string]interface{}{"title": flags["--title"], "head": flags["--head"], "base": base, "body": body}
+	data, err := restRequest(http.MethodPost, fmt.Sprintf("repos/%s/pulls", repo), "", pr)
+	if err != nil {
+		return nil, err
+	}
+	return htmlURL(data)
+}
+
+// htmlURL extracts the html_url of a created resource, which is what gh prints
+func htmlURL(data []byte) ([]byte, error) {
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return []byte(created.HTMLURL + "\n"), nil
+}
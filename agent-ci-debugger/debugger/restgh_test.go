@@ -0,0 +1,129 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withoutGH hides the gh CLI from the backend detection of the test
+func withoutGH(t *testing.T) {
+	t.Helper()
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	backendOnce, backend, backendErr = sync.Once{}, "", nil
+	t.Cleanup(func() { backendOnce, backend, backendErr = sync.Once{}, "", nil })
+}
+
+func TestGitHubBackendWithoutGHOrToken(t *testing.T) {
+	withoutGH(t)
+	if _, err := githubBackend(); !errors.Is(err, ErrNoGitHubAccess) {
+		t.Errorf("githubBackend() error = %v, want ErrNoGitHubAccess", err)
+	}
+	if _, err := gh("run", "view", "1", "--repo", "o/r", "--json", "status"); !errors.Is(err, ErrNoGitHubAccess) {
+		t.Errorf("gh() error = %v, want ErrNoGitHubAccess", err)
+	}
+}
+
+func TestGitHubBackendRESTWithoutGH(t *testing.T) {
+	withoutGH(t)
+	var mu sync.Mutex
+	var issue map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Bad credentials"}`)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/o/r/actions/runs/1":
+			fmt.Fprint(w, `{"status":"completed","conclusion":"failure","name":"CI","workflow_id":5,"head_sha":"abc"}`)
+		case "GET /repos/o/r/actions/runs/1/jobs":
+			fmt.Fprint(w, `{"total_count":2,"jobs":[{"id":6,"name":"lint","conclusion":"success"},
+				{"id":7,"name":"build","status":"completed","conclusion":"failure","steps":[{"name":"Run make","number":1}]}]}`)
+		case "GET /repos/o/r/actions/jobs/7/logs":
+			fmt.Fprint(w, "\ufeff2024-01-01T00:00:00.0000000Z make: *** [all] Error 2\n2024-01-01T00:00:01.0000000Z ##[error]Process completed with exit code 2.\n")
+		case "POST /repos/o/r/issues":
+			mu.Lock()
+			defer mu.Unlock()
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &issue)
+			fmt.Fprint(w, `{"html_url":"https://github.com/o/r/issues/3"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GH_TOKEN", "gh-token")
+
+	if b, err := githubBackend(); err != nil || b != backendREST {
+		t.Fatalf("githubBackend() = %q, %v, want the REST backend", b, err)
+	}
+
+	out, err := gh("run", "view", "1", "--repo", "o/r", "--json", "status,conclusion,workflowName,headSha,jobs")
+	if err != nil {
+		t.Fatalf("gh run view --json error = %v", err)
+	}
+	var run struct {
+		Status, Conclusion, WorkflowName, HeadSha string
+		Jobs                                      []struct {
+			DatabaseID int64 `json:"databaseId"`
+			Name       string
+		}
+	}
+	if err := json.Unmarshal(out, &run); err != nil {
+		t.Fatalf("gh run view --json output %s: %v", out, err)
+	}
+	if run.Status != "completed" || run.Conclusion != "failure" || run.WorkflowName != "CI" || run.HeadSha != "abc" ||
+		len(run.Jobs) != 2 || run.Jobs[1].DatabaseID != 7 {
+		t.Errorf("gh run view --json = %s", out)
+	}
+
+	out, err = gh("run", "view", "1", "--repo", "o/r", "--log-failed")
+	if err != nil {
+		t.Fatalf("gh run view --log-failed error = %v", err)
+	}
+	wantLogs := "build\tUNKNOWN STEP\t2024-01-01T00:00:00.0000000Z make: *** [all] Error 2\n" +
+		"build\tUNKNOWN STEP\t2024-01-01T00:00:01.0000000Z ##[error]Process completed with exit code 2.\n"
+	if string(out) != wantLogs {
+		t.Errorf("gh run view --log-failed =\n%q\nwant\n%q", out, wantLogs)
+	}
+
+	out, err = ghWithInput("the report", "issue", "create", "--repo", "o/r", "--title", "CI failure", "--label", "ci-failure", "--body-file", "-")
+	if err != nil || string(out) != "https://github.com/o/r/issues/3\n" {
+		t.Errorf("gh issue create = %q, %v, want the issue URL", out, err)
+	}
+	want := map[string]interface{}{"title": "CI failure", "body": "the report", "labels": []interface{}{"ci-failure"}}
+	mu.Lock()
+	if !reflect.DeepEqual(issue, want) {
+		t.Errorf("issue = %v, want %v", issue, want)
+	}
+	mu.Unlock()
+
+	if _, err := gh("run", "view", "2", "--repo", "o/r", "--json", "status"); err == nil || !strings.Contains(err.Error(), "HTTP 404: Not Found") {
+		t.Errorf("gh run view of a missing run error = %v, want the API error", err)
+	}
+	if _, err := gh("workflow", "run", "ci.yml"); err == nil || !strings.Contains(err.Error(), "not supported without the gh CLI") {
+		t.Errorf("unsupported command error = %v", err)
+	}
+}
+
+func TestSplitGHArgs(t *testing.T) {
+	positional, flags := splitGHArgs([]string{"run", "view", "1", "--repo", "o/r", "--log-failed", "--job", "7", "-"})
+	if want := []string{"run", "view", "1", "-"}; !reflect.DeepEqual(positional, want) {
+		t.Errorf("positional = %q, want %q", positional, want)
+	}
+	if want := map[string]string{"--repo": "o/r", "--log-failed": "true", "--job": "7"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags = %v, want %v", flags, want)
+	}
+}
@@ -0,0 +1,208 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// jobPathSeparator separates the caller jobs from the called job in names of reusable workflow jobs
+const jobPathSeparator = " / "
+
+// ReusableWorkflow is a called (reusable) workflow the run used
+type ReusableWorkflow struct {
+	Path       string   // "owner/repo/.github/workflows/build.yml"
+	Ref        string   // git ref the workflow was called with, e.g. "refs/heads/main" or "v2"
+	SHA        string   // commit the workflow was resolved to
+	CallerJobs []string // caller jobs (of the run's workflow) that use this workflow, when known
+	FailedJobs []string // failed jobs attributed to this workflow
+}
+
+// splitJobPath splits the name of a job in a reusable workflow ("caller / called / job") into
+// the chain of caller jobs and the job itself; callers is empty for ordinary jobs
+func splitJobPath(name string) (callers []string, job string) {
+	parts := strings.Split(name, jobPathSeparator)
+	return parts[:len(parts)-1], parts[len(parts)-1]
+}
+
+// fetchReusableWorkflows reads the workflows called by the run
+func fetchReusableWorkflows(run *WorkflowRun) ([]ReusableWorkflow, error) {
+	output, err := gh("api", fmt.Sprintf("repos/%s/actions/runs/%s", run.Repository, run.RunID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run: %w", err)
+	}
+
+	var data struct {
+		ReferencedWorkflows []struct {
+			Path string `json:"path"`
+			SHA  string `json:"sha"`
+			Ref  string `json:"ref"`
+		} `json:"referenced_workflows"`
+	}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse run: %w", err)
+	}
+
+	var workflows []ReusableWorkflow
+	for _, ref := range data.ReferencedWorkflows {
+		path, pathRef, _ := strings.Cut(ref.Path, "@")
+		w := ReusableWorkflow{Path: path, Ref: ref.Ref, SHA: ref.SHA}
+		if w.Ref == "" {
+			w.Ref = pathRef
+		}
+		workflows = append(workflows, w)
+	}
+	return workflows, nil
+}
+
+var (
+	// Jobs of a workflow file: "  <id>:" at two spaces of indentation below "jobs:"
+	workflowJobIDRe   = regexp.MustCompile(`^  ([A-Za-z_][\w-]*):\s*$`)
+	workflowJobNameRe = regexp.MustCompile(`^    name:\s*['"]?(.+?)['"]?\s*$`)
+	workflowJobUsesRe = regexp.MustCompile(`^    uses:\s*['"]?([^'"\s]+)['"]?`)
+)
+
+// callerJobs maps the job IDs and names of a workflow file onto the reusable workflow they call
+// (without the @ref). Only the conventional two-space indentation is recognized.
+func callerJobs(workflowYAML string) map[string]string {
+	callers := make(map[string]string)
+	inJobs := false
+	id, name := "", ""
+	for _, line := range strings.Split(workflowYAML, "\n") {
+		if !strings.HasPrefix(line, " ") && strings.TrimSpace(line) != "" {
+			inJobs = strings.HasPrefix(line, "jobs:")
+			continue
+		}
+		if !inJobs {
+			continue
+		}
+		if m := workflowJobIDRe.FindStringSubmatch(line); m != nil {
+			id, name = m[1], ""
+			continue
+		}
+		if m := workflowJobNameRe.FindStringSubmatch(line); m != nil {
+			name = m[1]
+			continue
+		}
+		if m := workflowJobUsesRe.FindStringSubmatch(line); m != nil && id != "" {
+			path, _, _ := strings.Cut(m[1], "@")
+			callers[id] = path
+			if name != "" {
+				callers[name] = path
+			}
+		}
+	}
+	return callers
+}
+
+// attributeReusableWorkflows assigns the failed jobs of reusable workflows to the called workflow
+// The caller job is looked up in the workflow definition when it was fetched; otherwise a run
+// calling a single reusable workflow is attributed to it.
+func attributeReusableWorkflows(run *WorkflowRun, workflows []ReusableWorkflow) []ReusableWorkflow {
+	callers := callerJobs(run.WorkflowYAML)
+	// Map order is random, sorting keeps CallerJobs (and the report) stable across runs
+	names := make([]string, 0, len(callers))
+	for caller := range callers {
+		names = append(names, caller)
+	}
+	sort.Strings(names)
+	for _, caller := range names {
+		path := callers[caller]
+		for i := range workflows {
+			if strings.HasSuffix(path, workflows[i].Path) || strings.HasSuffix(workflows[i].Path, strings.TrimPrefix(path, "./")) {
+				workflows[i].CallerJobs = appendUnique(workflows[i].CallerJobs, caller)
+			}
+		}
+	}
+
+	for _, job := range run.ErrorSummary.FailedJobs {
+		jobCallers, _ := splitJobPath(job)
+		if len(jobCallers) == 0 {
+			continue
+		}
+		caller := parseJobName(jobCallers[0]).BaseName
+		for i := range workflows {
+			matched := len(workflows) == 1
+			for _, c := range workflows[i].CallerJobs {
+				matched = matched || c == caller
+			}
+			if matched {
+				workflows[i].FailedJobs = appendUnique(workflows[i].FailedJobs, job)
+				break
+			}
+		}
+	}
+	return workflows
+}
+
+// appendUnique appends value unless list already contains it
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// writeReusableWorkflows writes the reusable workflows the failed jobs belong to, to the prompt
+func writeReusableWorkflows(sb *strings.Builder, workflows []ReusableWorkflow) {
+	sb.WriteString("Reusable Workflows called by this run (failed jobs named \"caller / job\" run in them; fix them there, not in the caller):\n")
+	for _, w := range workflows {
+		sb.WriteString(fmt.Sprintf("  - %s@%s", w.Path, w.Ref))
+		if len(w.FailedJobs) > 0 {
+			sb.WriteString(fmt.Sprintf(" - failed jobs: %s", strings.Join(w.FailedJobs, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeReusableWorkflowsReport lists the reusable workflows with failed jobs in the report header
+func writeReusableWorkflowsReport(sb *strings.Builder, workflows []ReusableWorkflow) {
+	for _, w := range workflows {
+		if len(w.FailedJobs) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("**Reusable Workflow**: `%s` @ `%s` (failed: %s)\n", w.Path, w.Ref, strings.Join(w.FailedJobs, ", ")))
+	}
+}
+
+// fetchNestedJobLogs reads the logs of failed reusable workflow jobs from the jobs API
+// `gh run view --log` cannot map the log archive entries of jobs named "caller / job" and
+// returns nothing for them, so each job's log is fetched directly and given the
+// "<job>\t<step>\t" prefix of gh logs. The step is unknown, as in gh's own output for such lines.
+// Like the logs of other jobs, only the last maxBytes are kept (DefaultMaxLogBytes when 0).
+func fetchNestedJobLogs(run *WorkflowRun, maxBytes int) (string, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxLogBytes
+	}
+	jobs, err := fetchJobs(run)
+	if err != nil {
+		return "", err
+	}
+
+	logs := newTailBuffer(maxBytes)
+	for _, job := range jobs {
+		if !strings.Contains(job.Name, jobPathSeparator) || job.Conclusion != "failure" {
+			continue
+		}
+		if run.JobID != "" && fmt.Sprint(job.DatabaseID) != run.JobID {
+			continue
+		}
+		logDebugf("Fetching logs of reusable workflow job %q (ID %d)", job.Name, job.DatabaseID)
+		output, err := ghLogs(maxBytes, "api", fmt.Sprintf("repos/%s/actions/jobs/%d/logs", run.Repository, job.DatabaseID))
+		if err != nil {
+			logWarnf("failed to get logs of job %q: %v", job.Name, err)
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+			logs.Write([]byte(job.Name + "\tUNKNOWN STEP\t" + strings.TrimPrefix(line, "\ufeff") + "\n"))
+		}
+	}
+	if logs.dropped() > 0 {
+		logWarnf("reusable workflow job logs are %d MB, only the last %d MB are kept (--max-log-mb)", logs.written>>20, maxBytes>>20)
+	}
+	return string(logs.Bytes()), nil
+}
@@ -0,0 +1,53 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFetchNestedJobLogs(t *testing.T) {
+	var big strings.Builder
+	for i := 0; big.Len() < 4096; i++ {
+		big.WriteString(fmt.Sprintf("2024-01-01T00:00:00.0000000Z line %d\n", i))
+	}
+	big.WriteString("2024-01-01T00:00:01.0000000Z ##[error]Process completed with exit code 2.\n")
+	stubGitHub(t, map[string]string{
+		"/repos/o/r/actions/runs/1": `{"status":"completed","conclusion":"failure","name":"CI"}`,
+		"/repos/o/r/actions/runs/1/jobs": `{"total_count":3,"jobs":[
+			{"id":6,"name":"lint","status":"completed","conclusion":"failure"},
+			{"id":7,"name":"call / build","status":"completed","conclusion":"failure"},
+			{"id":8,"name":"call / test","status":"completed","conclusion":"success"}]}`,
+		"/repos/o/r/actions/jobs/7/logs": big.String(),
+	})
+	run := &WorkflowRun{RunID: "1", Repository: "o/r"}
+
+	logs, err := fetchNestedJobLogs(run, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(logs, "call / build\tUNKNOWN STEP\t2024-01-01T00:00:00.0000000Z line 0\n") ||
+		strings.Count(logs, "\n") != strings.Count(big.String(), "\n") {
+		t.Errorf("logs = %q, want every line of the nested job prefixed", logs)
+	}
+
+	// A log larger than the cap keeps its end, starting at a line
+	logs, err = fetchNestedJobLogs(run, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) > 1024+200 {
+		t.Errorf("logs are %d bytes, want about 1024", len(logs))
+	}
+	if !strings.Contains(logs, "only the end of the logs is kept") || strings.Contains(logs, "line 0\n") {
+		t.Errorf("logs = %q, want the start dropped", logs)
+	}
+	if !strings.HasSuffix(logs, "call / build\tUNKNOWN STEP\t2024-01-01T00:00:01.0000000Z ##[error]Process completed with exit code 2.\n") {
+		t.Errorf("logs = %q, want the end of the job kept", logs)
+	}
+	for _, line := range strings.Split(strings.TrimRight(logs, "\n"), "\n")[1:] {
+		if !strings.HasPrefix(line, "call / build\tUNKNOWN STEP\t2024-") {
+			t.Errorf("line %q is not a whole prefixed log line", line)
+		}
+	}
+}
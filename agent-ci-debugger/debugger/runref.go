@@ -0,0 +1,80 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// repositoryRe validates an "owner/repo" repository name
+var repositoryRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// numericIDRe validates run and job IDs
+var numericIDRe = regexp.MustCompile(`^\d+$`)
+
+// RunRef identifies the workflow run (and optionally the job) to analyze
+type RunRef struct {
+	Repository string // "owner/repo", or the project path ("group/subgroup/project") on GitLab
+	RunID      string // run ID, or the pipeline ID on GitLab
+	JobID      string // optional
+	Step       int    // step number of a "#step:N:line" job URL anchor (0 = none)
+	StepLine   int    // log line of the anchor within the step (0 = none)
+	URL        string // URL the run was given as; synthesized when empty
+	Provider   string // ProviderGitHub (also when empty) or ProviderGitLab
+	Host       string // GitLab instance, e.g. "gitlab.com"
+}
+
+// NewRunRef validates a repository, run ID and optional job ID given separately
+func NewRunRef(repo, runID, jobID string) (RunRef, error) {
+	if !repositoryRe.MatchString(repo) {
+		return RunRef{}, fmt.Errorf("invalid repository %q (expected owner/repo)", repo)
+	}
+	if !numericIDRe.MatchString(runID) {
+		return RunRef{}, fmt.Errorf("invalid run ID %q (expected a number)", runID)
+	}
+	if jobID != "" && !numericIDRe.MatchString(jobID) {
+		return RunRef{}, fmt.Errorf("invalid job ID %q (expected a number)", jobID)
+	}
+	return RunRef{Repository: repo, RunID: runID, JobID: jobID}, nil
+}
+
+// ParseRunRef parses a workflow run or job URL
+// GitLab pipeline and job URLs select the GitLab backend, any other URL is a GitHub Actions URL.
+// The "#step:N:line" anchor of a job URL, as copied from the UI, selects the step and line.
+func ParseRunRef(url string) (RunRef, error) {
+	if ref, ok := parseGitLabURL(url); ok {
+		return ref, nil
+	}
+	repo, runID, jobID, err := ParseWorkflowURL(url)
+	if err != nil {
+		return RunRef{}, err
+	}
+	ref := RunRef{Repository: repo, RunID: runID, JobID: jobID, URL: url}
+	if jobID != "" {
+		ref.Step, ref.StepLine = parseStepAnchor(url)
+	}
+	return ref, nil
+}
+
+// Ref returns the reference of the analyzed run, e.g. to name its report after the run actually
+// analyzed rather than the command-line arguments
+func (run *WorkflowRun) Ref() RunRef {
+	return RunRef{Repository: run.Repository, RunID: run.RunID, JobID: run.JobID, URL: run.URL, Provider: run.Provider}
+}
+
+// WebURL returns the URL of the run (or job) on GitHub or GitLab
+func (r RunRef) WebURL() string {
+	if r.URL != "" {
+		return r.URL
+	}
+	if r.Provider == ProviderGitLab {
+		if r.JobID != "" {
+			return fmt.Sprintf("https://%s/%s/-/jobs/%s", r.Host, r.Repository, r.JobID)
+		}
+		return fmt.Sprintf("https://%s/%s/-/pipelines/%s", r.Host, r.Repository, r.RunID)
+	}
+	url := fmt.Sprintf("https://github.com/%s/actions/runs/%s", r.Repository, r.RunID)
+	if r.JobID != "" {
+		url += "/job/" + r.JobID
+	}
+	return url
+}
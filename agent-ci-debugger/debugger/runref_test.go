@@ -0,0 +1,96 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRunRef(t *testing.T) {
+	tests := []struct {
+		repo, runID, jobID string
+		wantError          string
+	}{
+		{repo: "konveyor/analyzer-lsp", runID: "123456789"},
+		{repo: "o/r.go", runID: "1", jobID: "42"},
+		{repo: "my_org/my-repo", runID: "7"},
+		{repo: "konveyor", runID: "1", wantError: "invalid repository"},
+		{repo: "o/r/extra", runID: "1", wantError: "invalid repository"},
+		{repo: "o/r; rm -rf /", runID: "1", wantError: "invalid repository"},
+		{repo: "", runID: "1", wantError: "invalid repository"},
+		{repo: "o/r", runID: "", wantError: "invalid run ID"},
+		{repo: "o/r", runID: "12a", wantError: "invalid run ID"},
+		{repo: "o/r", runID: "-1", wantError: "invalid run ID"},
+		{repo: "o/r", runID: "1", jobID: "job", wantError: "invalid job ID"},
+	}
+	for _, tt := range tests {
+		ref, err := NewRunRef(tt.repo, tt.runID, tt.jobID)
+		if tt.wantError != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("NewRunRef(%q, %q, %q) error = %v, want %q", tt.repo, tt.runID, tt.jobID, err, tt.wantError)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewRunRef(%q, %q, %q) error = %v", tt.repo, tt.runID, tt.jobID, err)
+			continue
+		}
+		if ref.Repository != tt.repo || ref.RunID != tt.runID || ref.JobID != tt.jobID {
+			t.Errorf("NewRunRef(%q, %q, %q) = %+v", tt.repo, tt.runID, tt.jobID, ref)
+		}
+	}
+}
+
+func TestRunRefWebURL(t *testing.T) {
+	tests := []struct {
+		ref  RunRef
+		want string
+	}{
+		{RunRef{Repository: "o/r", RunID: "1"}, "https://github.com/o/r/actions/runs/1"},
+		{RunRef{Repository: "o/r", RunID: "1", JobID: "2"}, "https://github.com/o/r/actions/runs/1/job/2"},
+		{RunRef{Repository: "o/r", RunID: "1", URL: "https://github.com/o/r/actions/runs/1/job/2#step:3:10"}, "https://github.com/o/r/actions/runs/1/job/2#step:3:10"},
+		{RunRef{Repository: "g/p", RunID: "5", Provider: ProviderGitLab, Host: "gitlab.com"}, "https://gitlab.com/g/p/-/pipelines/5"},
+		{RunRef{Repository: "g/p", RunID: "5", JobID: "6", Provider: ProviderGitLab, Host: "gitlab.example.com"}, "https://gitlab.example.com/g/p/-/jobs/6"},
+	}
+	for _, tt := range tests {
+		if got := tt.ref.WebURL(); got != tt.want {
+			t.Errorf("%+v.WebURL() = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestParseRunRef(t *testing.T) {
+	tests := []struct {
+		url       string
+		want      RunRef
+		wantError bool
+	}{
+		{url: "https://github.com/o/r/actions/runs/1",
+			want: RunRef{Repository: "o/r", RunID: "1", URL: "https://github.com/o/r/actions/runs/1"}},
+		{url: "https://github.com/o/r/actions/runs/1/job/2",
+			want: RunRef{Repository: "o/r", RunID: "1", JobID: "2", URL: "https://github.com/o/r/actions/runs/1/job/2"}},
+		{url: "https://github.com/o/r/actions/runs/1/job/2#step:3:10",
+			want: RunRef{Repository: "o/r", RunID: "1", JobID: "2", Step: 3, StepLine: 10, URL: "https://github.com/o/r/actions/runs/1/job/2#step:3:10"}},
+		{url: "https://github.com/o/r/actions/runs/1/job/2?pr=5#step:12:1",
+			want: RunRef{Repository: "o/r", RunID: "1", JobID: "2", Step: 12, StepLine: 1, URL: "https://github.com/o/r/actions/runs/1/job/2?pr=5#step:12:1"}},
+		{url: "https://github.com/o/r/actions/runs/1/job/2#step:4",
+			want: RunRef{Repository: "o/r", RunID: "1", JobID: "2", Step: 4, URL: "https://github.com/o/r/actions/runs/1/job/2#step:4"}},
+		// the anchor only applies to job URLs
+		{url: "https://github.com/o/r/actions/runs/1#step:3:10",
+			want: RunRef{Repository: "o/r", RunID: "1", URL: "https://github.com/o/r/actions/runs/1#step:3:10"}},
+		{url: "https://github.com/o/r/actions/runs/1/job/2#step:x:10",
+			want: RunRef{Repository: "o/r", RunID: "1", JobID: "2", URL: "https://github.com/o/r/actions/runs/1/job/2#step:x:10"}},
+		{url: "https://github.com/o/r/pull/5", wantError: true},
+	}
+	for _, tt := range tests {
+		ref, err := ParseRunRef(tt.url)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("ParseRunRef(%q) = %+v, want an error", tt.url, ref)
+			}
+			continue
+		}
+		if err != nil || ref != tt.want {
+			t.Errorf("ParseRunRef(%q) = %+v, %v, want %+v", tt.url, ref, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxCompilerErrorLookahead bounds how far below an error header its location and help lines are searched
+const maxCompilerErrorLookahead = 40
+
+// CompilerError is a compiler diagnostic with its source location
+type CompilerError struct {
+	Tool    string   // tool reporting the error: "rustc", "tsc", "eslint" or "go"
+	Code    string   // error code or lint rule, e.g. "E0277", "TS2322" or "no-unused-vars"; empty when none is given
+	Message string   // the diagnostic message, e.g. "mismatched types"
+	File    string   // source file, e.g. "src/main.rs"
+	Line    int      // 1-based source line, 0 when unknown
+	Column  int      // 1-based source column, 0 when unknown
+	Help    []string // help and note lines suggesting a fix
+	LogLine int      // 0-based index of the log line of the diagnostic
+	Cascade *Cascade // errors collapsed into this one as its likely consequences, see collapseCascades
+}
+
+// Location returns "file:line:col", or as much of it as is known
+func (e CompilerError) Location() string {
+	switch {
+	case e.File == "":
+		return ""
+	case e.Line == 0:
+		return e.File
+	case e.Column == 0:
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	}
+}
+
+// Header renders the error like the tool, e.g. "error[E0277]: mismatched types",
+// "error TS2322: Type 'string' is not ...", "error: 'x' is never used (no-unused-vars)" or "undefined: yaml"
+func (e CompilerError) Header() string {
+	switch {
+	case e.Tool == "go":
+		return e.Message
+	case e.Code == "":
+		return "error: " + e.Message
+	case e.Tool == "tsc":
+		return fmt.Sprintf("error %s: %s", e.Code, e.Message)
+	case e.Tool == "eslint":
+		return fmt.Sprintf("error: %s (%s)", e.Message, e.Code)
+	default:
+		return fmt.Sprintf("error[%s]: %s", e.Code, e.Message)
+	}
+}
+
+// String renders the header prefixed with the location
+func (e CompilerError) String() string {
+	if where := e.Location(); where != "" {
+		return where + ": " + e.Header()
+	}
+	return e.Header()
+}
+
+var (
+	// rustDiagnosticRe matches rustc diagnostic headers, e.g. "error[E0277]: the trait bound ... is not satisfied"
+	rustDiagnosticRe = regexp.MustCompile(`^(error|warning)(?:\[([A-Z]\d{4})\])?: (.+)$`)
+	// rustLocationRe matches the location line below a header, e.g. "  --> src/main.rs:12:5"
+	rustLocationRe = regexp.MustCompile(`^\s*--> (.+?):(\d+):(\d+)$`)
+	// rustHelpRe matches help and note lines, e.g. "= help: consider borrowing here" or "help: ..."
+	rustHelpRe = regexp.MustCompile(`^\s*(?:= )?(help|note): (.+)$`)
+	// cargoTestFailedRe matches failed tests of `cargo test`, e.g. "test tests::parses ... FAILED"
+	cargoTestFailedRe = regexp.MustCompile(`^test (\S+) \.\.\. FAILED$`)
+)
+
+// rustSummaryPrefixes start cargo's closing lines, which repeat that compilation failed rather than why
+var rustSummaryPrefixes = []string{
+	"could not compile",
+	"aborting due to",
+	"build failed",
+	"test failed",
+}
+
+// rustDetector recognizes rustc/cargo compiler errors and failed `cargo test` tests
+// Compiler errors are collected into CompilerErrors with their code and file:line:col.
+type rustDetector struct{}
+
+func (rustDetector) Name() string { return "rust" }
+
+func (rustDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+
+	for i := 0; i < len(lines); i++ {
+		content := strings.TrimRight(logContent(lines[i]), " \r")
+
+		if m := cargoTestFailedRe.FindStringSubmatch(content); m != nil {
+			findings = append(findings, Finding{Category: CategoryFailedTest, Message: strings.TrimSpace(content), Line: i})
+			continue
+		}
+
+		m := rustDiagnosticRe.FindStringSubmatch(content)
+		if m == nil || m[1] != "error" || isRustSummary(m[3]) {
+			continue
+		}
+
+		ce := CompilerError{Tool: "rustc", Code: m[2], Message: strings.TrimSpace(m[3]), LogLine: i}
+		for j := i + 1; j < len(lines) && j <= i+maxCompilerErrorLookahead; j++ {
+			next := strings.TrimRight(logContent(lines[j]), " \r")
+			if rustDiagnosticRe.MatchString(next) {
+				break
+			}
+			if lm := rustLocationRe.FindStringSubmatch(next); lm != nil && ce.File == "" {
+				ce.File = lm[1]
+				ce.Line, _ = strconv.Atoi(lm[2])
+				ce.Column, _ = strconv.Atoi(lm[3])
+				continue
+			}
+			if hm := rustHelpRe.FindStringSubmatch(next); hm != nil {
+				ce.Help = append(ce.Help, hm[1]+": "+strings.TrimSpace(hm[2]))
+			}
+		}
+
+		findings = append(findings, Finding{Category: CategoryCompilerError, Message: ce.String(), Line: i, CompilerError: &ce})
+	}
+
+	return findings
+}
+
+// isRustSummary reports whether an error message is one of cargo's closing summary lines
+func isRustSummary(message string) bool {
+	for _, prefix := range rustSummaryPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCompilerErrors puts the first compiler and lint errors in front of the model, grouped by file
+// The first error is usually the cause, later ones are often consequences of it.
+func writeCompilerErrors(sb *strings.Builder, compilerErrors []CompilerError, label string) {
+	if len(compilerErrors) == 0 {
+		return
+	}
+
+	// Files in the order of their first error
+	var files []string
+	byFile := make(map[string][]CompilerError)
+	for _, e := range compilerErrors {
+		if _, ok := byFile[e.File]; !ok {
+			files = append(files, e.File)
+		}
+		byFile[e.File] = append(byFile[e.File], e)
+	}
+
+	sb.WriteString(fmt.Sprintf("COMPILER ERRORS (%s in %d files) - the code does not compile or lint, fix the first errors first:\n", label, len(files)))
+	if n := collapsedErrors(compilerErrors); n > 0 {
+		sb.WriteString(fmt.Sprintf("  (%d cascading errors are collapsed into the errors likely causing them, which are listed first)\n", n))
+	}
+	for i, file := range files {
+		if i >= 5 {
+			sb.WriteString(fmt.Sprintf("  ... and %d more files\n", len(files)-5))
+			break
+		}
+		errs := byFile[file]
+		name := file
+		if name == "" {
+			name = "(no location)"
+		}
+		count := fmt.Sprintf("%d errors", len(errs))
+		if len(errs) == 1 {
+			count = "1 error"
+		}
+		sb.WriteString(fmt.Sprintf("  %s (%s):\n", name, count))
+		for j, e := range errs {
+			if j >= 3 {
+				sb.WriteString(fmt.Sprintf("    ... and %d more\n", len(errs)-3))
+				break
+			}
+			where := ""
+			if e.Line > 0 {
+				where = strings.TrimPrefix(e.Location(), e.File+":") + ": "
+			}
+			sb.WriteString(fmt.Sprintf("    - %s%s\n", where, truncateText(e.Header(), 300)))
+			if e.Cascade != nil {
+				sb.WriteString(fmt.Sprintf("      CASCADE: %s\n", e.Cascade))
+			}
+			for k, help := range e.Help {
+				if k >= 2 {
+					break
+				}
+				sb.WriteString(fmt.Sprintf("      %s\n", truncateText(help, 300)))
+			}
+		}
+	}
+}
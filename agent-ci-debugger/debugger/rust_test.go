@@ -0,0 +1,88 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cargoBuildLines is the output of a failed `cargo build` with two errors
+var cargoBuildLines = fixtureLines("rust", "cargo build",
+	"   Compiling app v0.1.0 (/home/runner/work/app/app)",
+	"error[E0308]: mismatched types",
+	"  --> src/config.rs:12:20",
+	"   |",
+	"12 |     let port: u16 = value;",
+	"   |               ---   ^^^^^ expected `u16`, found `&str`",
+	"   |               |",
+	"   |               expected due to this",
+	"   |",
+	"help: try using a conversion method: `.parse().unwrap()`",
+	"   = note: expected type `u16` found reference `&str`",
+	"",
+	"error: cannot find value `missing` in this scope",
+	" --> src/main.rs:3:13",
+	"  |",
+	"3 |     println!(\"{}\", missing);",
+	"  |                    ^^^^^^^ not found in this scope",
+	"",
+	"warning: unused import: `std::fs`",
+	" --> src/main.rs:1:5",
+	"error: could not compile `app` (bin \"app\") due to 2 previous errors",
+	"test parser::tests::parses_empty ... FAILED",
+)
+
+func TestRustDetector(t *testing.T) {
+	findings := rustDetector{}.Detect(cargoBuildLines)
+
+	var errs []CompilerError
+	var failedTests []string
+	for _, f := range findings {
+		switch f.Category {
+		case CategoryCompilerError:
+			if f.Message != f.CompilerError.String() {
+				t.Errorf("Message = %q, want %q", f.Message, f.CompilerError.String())
+			}
+			errs = append(errs, *f.CompilerError)
+		case CategoryFailedTest:
+			failedTests = append(failedTests, f.Message)
+		default:
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+
+	// The warning and cargo's "could not compile" line are not errors of their own
+	want := []CompilerError{
+		{
+			Tool: "rustc", Code: "E0308", Message: "mismatched types", File: "src/config.rs", Line: 12, Column: 20,
+			Help:    []string{"help: try using a conversion method: `.parse().unwrap()`", "note: expected type `u16` found reference `&str`"},
+			LogLine: 1,
+		},
+		{Tool: "rustc", Message: "cannot find value `missing` in this scope", File: "src/main.rs", Line: 3, Column: 13, LogLine: 12},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("compiler errors = %+v\nwant %+v", errs, want)
+	}
+	if got := errs[0].String(); got != "src/config.rs:12:20: error[E0308]: mismatched types" {
+		t.Errorf("String() = %q", got)
+	}
+	if want := []string{"test parser::tests::parses_empty ... FAILED"}; !reflect.DeepEqual(failedTests, want) {
+		t.Errorf("failed tests = %q, want %q", failedTests, want)
+	}
+}
+
+func TestRustDetectorWithoutLocation(t *testing.T) {
+	findings := rustDetector{}.Detect(fixtureLines("rust", "cargo build",
+		"error[E0463]: can't find crate for `core`",
+		"  |",
+		"  = note: the `thumbv7em-none-eabihf` target may not be installed",
+		"error: aborting due to 1 previous error",
+	))
+	if len(findings) != 1 {
+		t.Fatalf("findings = %+v, want one", findings)
+	}
+	ce := findings[0].CompilerError
+	if ce.File != "" || ce.Location() != "" || findings[0].Message != "error[E0463]: can't find crate for `core`" ||
+		!reflect.DeepEqual(ce.Help, []string{"note: the `thumbv7em-none-eabihf` target may not be installed"}) {
+		t.Errorf("compiler error = %+v", *ce)
+	}
+}
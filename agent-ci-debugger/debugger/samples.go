@@ -0,0 +1,69 @@
+package debugger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// minRootCauseSimilarity is the word overlap above which two sampled root causes count as agreeing
+const minRootCauseSimilarity = 0.5
+
+var wordRe = regexp.MustCompile(`[\p{L}\p{N}_./-]+`)
+
+// stopWords are left out when comparing root causes, they say nothing about the cause
+var stopWords = map[string]bool{
+	"the": true, "a": true, "an": true, "is": true, "are": true, "was": true, "were": true, "be": true,
+	"of": true, "to": true, "in": true, "on": true, "for": true, "and": true, "or": true, "by": true,
+	"with": true, "that": true, "this": true, "it": true, "as": true, "at": true, "from": true,
+	"because": true, "due": true, "failed": true, "failure": true, "fails": true, "error": true,
+}
+
+// rootCauseWords returns the distinctive lowercased words of a root cause
+func rootCauseWords(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range wordRe.FindAllString(strings.ToLower(text), -1) {
+		w = strings.Trim(w, "./-")
+		if len(w) > 1 && !stopWords[w] {
+			words[w] = true
+		}
+	}
+	return words
+}
+
+// rootCauseSimilarity is the Jaccard similarity of the distinctive words of two root causes
+func rootCauseSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// selectConsensus picks the modal root cause among sampled proposals
+// It returns the index of the proposal agreeing with the most other samples and the number of
+// samples (including itself) that agree with it; ties go to the earlier sample.
+func selectConsensus(proposals []*FixProposal) (best, agreeing int) {
+	words := make([]map[string]bool, len(proposals))
+	for i, p := range proposals {
+		words[i] = rootCauseWords(p.RootCause)
+	}
+
+	agreeing = 0
+	for i := range proposals {
+		count := 1
+		for j := range proposals {
+			if i != j && rootCauseSimilarity(words[i], words[j]) >= minRootCauseSimilarity {
+				count++
+			}
+		}
+		if count > agreeing {
+			best, agreeing = i, count
+		}
+	}
+	return best, agreeing
+}
@@ -0,0 +1,76 @@
+package debugger
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// sampleAnswer is testAnswer with another root cause
+func sampleAnswer(rootCause string) string {
+	return strings.Replace(testAnswer, "The go.sum entry of golang.org/x/net is missing.", rootCause, 1)
+}
+
+func TestSelectConsensus(t *testing.T) {
+	tests := []struct {
+		name       string
+		rootCauses []string
+		best       int
+		agreeing   int
+	}{
+		{
+			name:       "majority after an outlier",
+			rootCauses: []string{"The runner ran out of disk space.", "The go.sum entry of golang.org/x/net is missing.", "go.sum is missing the golang.org/x/net entry."},
+			best:       1,
+			agreeing:   2,
+		},
+		{
+			name:       "no agreement keeps the first",
+			rootCauses: []string{"The runner ran out of disk space.", "A flaky network test timed out.", "The Node version is unsupported."},
+			best:       0,
+			agreeing:   1,
+		},
+		{
+			name:       "stop words do not count as agreement",
+			rootCauses: []string{"The build failed because of an error.", "The test failed because of an error in the fixture.", "The lint failed because of an error."},
+			best:       0,
+			agreeing:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var proposals []*FixProposal
+			for _, rc := range tt.rootCauses {
+				proposals = append(proposals, &FixProposal{RootCause: rc})
+			}
+			if best, agreeing := selectConsensus(proposals); best != tt.best || agreeing != tt.agreeing {
+				t.Errorf("selectConsensus() = %d, %d, want %d, %d", best, agreeing, tt.best, tt.agreeing)
+			}
+		})
+	}
+}
+
+func TestAnalyzeFailureSamples(t *testing.T) {
+	api := &mockOpenAI{reply: answer(
+		sampleAnswer("The runner ran out of disk space."),
+		sampleAnswer("The go.sum entry of golang.org/x/net is missing."),
+		sampleAnswer("go.sum is missing the golang.org/x/net entry."),
+	)}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, Samples: 3}
+
+	proposal, err := d.AnalyzeFailure(context.Background(), testRun())
+	if err != nil {
+		t.Fatalf("AnalyzeFailure() error = %v", err)
+	}
+	requests := api.requests(t)
+	if len(requests) != 1 || requests[0].N != 3 {
+		t.Fatalf("API received %d requests, want one with N = 3: %+v", len(requests), requests)
+	}
+	if proposal.RootCause != "The go.sum entry of golang.org/x/net is missing." {
+		t.Errorf("RootCause = %q, want the one most samples agree with", proposal.RootCause)
+	}
+	if proposal.Samples != 3 || proposal.AgreeingSamples != 2 {
+		t.Errorf("Samples = %d, AgreeingSamples = %d, want 3 and 2", proposal.Samples, proposal.AgreeingSamples)
+	}
+}
@@ -0,0 +1,273 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// maxSARIFFindings caps the log findings exported, the AI result and annotations are always kept
+	maxSARIFFindings = 100
+)
+
+// Rule IDs of the SARIF results
+const (
+	ruleRootCause  = "ci/root-cause"
+	ruleAnnotation = "ci/annotation"
+	ruleDataRace   = "ci/data_race" // stack trace findings of the Go race detector
+)
+
+// sarifRuleDescriptions are the short descriptions of the rules used in SARIF output
+var sarifRuleDescriptions = map[string]string{
+	ruleRootCause:                   "Root cause of the workflow failure identified by AI analysis",
+	ruleAnnotation:                  "Check-run annotation reported by the failed job",
+	ruleDataRace:                    "Data race reported by the Go race detector",
+	"ci/" + CategoryError:           "Error reported in the workflow logs",
+	"ci/" + CategoryTimeout:         "Timeout reported in the workflow logs",
+	"ci/" + CategoryFailedTest:      "Failed test reported in the workflow logs",
+	"ci/" + CategoryStackTrace:      "Stack trace in the workflow logs",
+	"ci/" + CategoryExitCode:        "Nonzero exit code in the workflow logs",
+	"ci/" + CategoryAssertion:       "Test assertion failure in the workflow logs",
+	"ci/" + CategoryBuildFailure:    "Failed Docker build instruction in the workflow logs",
+	"ci/" + CategoryResourceFailure: "Runner out of disk space or memory, or filesystem failure, in the workflow logs",
+	"ci/" + CategoryCompilerError:   "Compiler error in the workflow logs",
+	"ci/" + CategoryPermission:      "Operation rejected for missing permissions in the workflow logs",
+	"ci/" + CategoryLicense:         "Dependency license rejected by a license scan in the workflow logs",
+}
+
+// SARIF 2.1.0 document, limited to the properties produced by the debugger
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	Results    []sarifResult  `json:"results"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sourceLocationRe matches a relative source file reference such as "pkg/foo/bar.go:42"
+var sourceLocationRe = regexp.MustCompile(`([A-Za-z0-9_.][A-Za-z0-9_./-]*\.[A-Za-z0-9]+)(?::(\d+))?`)
+
+// parseSourceLocation extracts the first file (and line) referenced in text
+// Absolute paths and URLs are ignored since they cannot be mapped onto the repository.
+func parseSourceLocation(text string) (path string, line int, ok bool) {
+	for _, m := range sourceLocationRe.FindAllStringSubmatchIndex(text, -1) {
+		start := m[2]
+		if start > 0 && strings.ContainsAny(text[start-1:start], "/:") {
+			continue
+		}
+		path = text[m[2]:m[3]]
+		if !strings.Contains(path, "/") && m[4] < 0 {
+			// A bare word with a dot ("v1.2", "e.g.") is only a file reference when a line follows
+			continue
+		}
+		if m[4] >= 0 {
+			line, _ = strconv.Atoi(text[m[4]:m[5]])
+		}
+		return path, line, true
+	}
+	return "", 0, false
+}
+
+// sarifLocationFor builds a SARIF location, omitting the region when the line is unknown
+func sarifLocationFor(path string, startLine, endLine int) sarifLocation {
+	location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: path},
+	}}
+	if startLine > 0 {
+		region := &sarifRegion{StartLine: startLine}
+		if endLine > startLine {
+			region.EndLine = endLine
+		}
+		location.PhysicalLocation.Region = region
+	}
+	return location
+}
+
+// sarifLevel maps finding categories and annotation levels onto SARIF result levels
+func sarifLevel(level string) string {
+	switch level {
+	case "warning":
+		return "warning"
+	case "notice":
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// GenerateSARIF converts the analysis into a SARIF 2.1.0 log for code scanning
+// The AI root cause and proposed fix become one result located at the files to check,
+// followed by the check-run annotations and the log findings that reference a source file.
+func (d *GitHubWorkflowDebugger) GenerateSARIF(run *WorkflowRun, proposal *FixProposal) ([]byte, error) {
+	var results []sarifResult
+
+	// AI analysis
+	if proposal != nil && proposal.RootCause != "" {
+		var sb strings.Builder
+		sb.WriteString(proposal.RootCause)
+		if proposal.ProposedFix != "" {
+			sb.WriteString("\n\nProposed fix:\n")
+			sb.WriteString(proposal.ProposedFix)
+		}
+		result := sarifResult{
+			RuleID:  ruleRootCause,
+			Level:   "error",
+			Message: sarifMessage{Text: sb.String()},
+			Properties: map[string]any{
+				"confidence": proposal.Confidence,
+				"runUrl":     run.URL,
+			},
+		}
+		seen := make(map[string]bool)
+		for _, file := range proposal.FilesToCheck {
+			path, line, ok := parseSourceLocation(file)
+			if !ok || seen[path] {
+				continue
+			}
+			seen[path] = true
+			result.Locations = append(result.Locations, sarifLocationFor(path, line, 0))
+		}
+		results = append(results, result)
+	}
+
+	// Check-run annotations
+	for _, a := range run.ErrorSummary.Annotations {
+		if !a.hasLocation() {
+			continue
+		}
+		message := strings.TrimSpace(a.Message)
+		if a.Title != "" {
+			message = a.Title + ": " + message
+		}
+		results = append(results, sarifResult{
+			RuleID:     ruleAnnotation,
+			Level:      sarifLevel(a.Level),
+			Message:    sarifMessage{Text: message},
+			Locations:  []sarifLocation{sarifLocationFor(a.Path, a.StartLine, a.EndLine)},
+			Properties: map[string]any{"job": a.Job},
+		})
+	}
+
+	// Log findings pointing at a source file
+	seen := make(map[string]bool)
+	exported := 0
+	for _, finding := range run.ErrorSummary.Findings {
+		if exported >= maxSARIFFindings {
+			break
+		}
+		path, line, ok := parseSourceLocation(finding.Message)
+		if !ok || line == 0 {
+			continue
+		}
+		ruleID := "ci/" + finding.Category
+		if finding.DataRace != nil {
+			ruleID = ruleDataRace
+		}
+		key := ruleID + "\x00" + finding.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		exported++
+		results = append(results, sarifResult{
+			RuleID:     ruleID,
+			Level:      sarifLevel(finding.Category),
+			Message:    sarifMessage{Text: finding.Message},
+			Locations:  []sarifLocation{sarifLocationFor(path, line, 0)},
+			Properties: map[string]any{"detector": finding.Detector},
+		})
+	}
+
+	// Rules referenced by the results, in order of first use
+	var rules []sarifRule
+	ruleIndex := make(map[string]int)
+	for i := range results {
+		id := results[i].RuleID
+		index, ok := ruleIndex[id]
+		if !ok {
+			index = len(rules)
+			ruleIndex[id] = index
+			rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: sarifRuleDescriptions[id]}})
+		}
+		results[i].RuleIndex = index
+	}
+
+	doc := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:  "github-workflow-debugger",
+				Rules: rules,
+			}},
+			Results: results,
+		}},
+	}
+	if run.LogBudget != nil {
+		doc.Runs[0].Properties = map[string]any{"logBudget": run.LogBudget}
+	}
+	if doc.Runs[0].Results == nil {
+		doc.Runs[0].Results = []sarifResult{}
+	}
+
+	output, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+	return append(output, '\n'), nil
+}
@@ -0,0 +1,195 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sarifResults maps the ruleId of each result to its messages
+type sarifResults map[string][]string
+
+// validateSARIF checks the properties SARIF 2.1.0 requires of the document and of the subset the
+// debugger produces: a run with a named tool, uniquely identified rules with a description, and
+// results whose ruleId and ruleIndex resolve to one of those rules
+func validateSARIF(t *testing.T, data []byte) sarifResults {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc["$schema"] != "https://json.schemastore.org/sarif-2.1.0.json" || doc["version"] != "2.1.0" {
+		t.Errorf("$schema = %v, version = %v", doc["$schema"], doc["version"])
+	}
+	runs, _ := doc["runs"].([]any)
+	if len(runs) == 0 {
+		t.Fatalf("runs = %v, want at least one", doc["runs"])
+	}
+
+	results := make(sarifResults)
+	for _, r := range runs {
+		run := r.(map[string]any)
+		driver, _ := run["tool"].(map[string]any)["driver"].(map[string]any)
+		if name, _ := driver["name"].(string); name == "" {
+			t.Errorf("tool.driver.name is missing: %v", run["tool"])
+		}
+		rules, _ := driver["rules"].([]any)
+		var ruleIDs []string
+		index := make(map[string]int)
+		for i, r := range rules {
+			rule := r.(map[string]any)
+			id, _ := rule["id"].(string)
+			if _, dup := index[id]; id == "" || dup {
+				t.Errorf("rule %d has a missing or duplicate id %q", i, id)
+			}
+			index[id] = i
+			ruleIDs = append(ruleIDs, id)
+			description, _ := rule["shortDescription"].(map[string]any)
+			if text, _ := description["text"].(string); text == "" {
+				t.Errorf("rule %q has no shortDescription text", id)
+			}
+		}
+
+		list, ok := run["results"].([]any)
+		if !ok {
+			t.Fatalf("results = %v, want an array", run["results"])
+		}
+		for _, r := range list {
+			result := r.(map[string]any)
+			id, _ := result["ruleId"].(string)
+			i, ok := index[id]
+			if !ok {
+				t.Errorf("ruleId %q does not resolve to one of the rules %q", id, ruleIDs)
+			} else if ruleIndex, _ := result["ruleIndex"].(float64); int(ruleIndex) != i {
+				t.Errorf("result of %q has ruleIndex %v, want %d", id, result["ruleIndex"], i)
+			}
+			switch result["level"] {
+			case "none", "note", "warning", "error":
+			default:
+				t.Errorf("result of %q has level %v", id, result["level"])
+			}
+			message, _ := result["message"].(map[string]any)
+			text, _ := message["text"].(string)
+			if text == "" {
+				t.Errorf("result of %q has no message text", id)
+			}
+			results[id] = append(results[id], text)
+
+			locations, _ := result["locations"].([]any)
+			for _, l := range locations {
+				physical, _ := l.(map[string]any)["physicalLocation"].(map[string]any)
+				artifact, _ := physical["artifactLocation"].(map[string]any)
+				if uri, _ := artifact["uri"].(string); uri == "" || strings.HasPrefix(uri, "/") {
+					t.Errorf("result of %q has location %v, want a relative URI", id, artifact)
+				}
+				if region, ok := physical["region"].(map[string]any); ok {
+					if start, _ := region["startLine"].(float64); start < 1 {
+						t.Errorf("result of %q has region %v, want startLine >= 1", id, region)
+					}
+				}
+			}
+		}
+	}
+	return results
+}
+
+func TestGenerateSARIF(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	d.Options.Quiet = true
+	run := testRun()
+	d.parseLogs(run)
+	result, err := d.debugRun(context.Background(), run, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Run.ErrorSummary.Annotations = append(result.Run.ErrorSummary.Annotations,
+		Annotation{Job: "build", Path: "main.go", StartLine: 5, EndLine: 6, Level: "failure", Title: "go build", Message: "missing go.sum entry"},
+		Annotation{Job: "build", Path: ".github", Level: "failure", Message: "Process completed with exit code 1."})
+
+	report, err := d.RenderReport(result, "sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := validateSARIF(t, []byte(report))
+
+	if got := results[ruleRootCause]; len(got) != 1 || !strings.HasPrefix(got[0], "The go.sum entry of golang.org/x/net is missing.\n\nProposed fix:\nRun go mod tidy") {
+		t.Errorf("root cause results = %q", got)
+	}
+	// Job-level annotations have no location to report
+	if got := results[ruleAnnotation]; len(got) != 1 || got[0] != "go build: missing go.sum entry" {
+		t.Errorf("annotation results = %q", got)
+	}
+	if got := results["ci/"+CategoryCompilerError]; len(got) != 1 || !strings.Contains(got[0], "main.go:5:2: missing go.sum entry") {
+		t.Errorf("compiler error results = %q", got)
+	}
+}
+
+func TestGenerateSARIFEmpty(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	data, err := d.GenerateSARIF(&WorkflowRun{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// results must be an empty array rather than null
+	if results := validateSARIF(t, data); len(results) != 0 {
+		t.Errorf("results = %v, want none", results)
+	}
+}
+
+func TestSARIFRuleDescriptions(t *testing.T) {
+	// Every Category constant of detectors.go, so that a new category cannot miss its rule
+	file, err := parser.ParseFile(token.NewFileSet(), "detectors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []string{ruleRootCause, ruleAnnotation, ruleDataRace}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				if !strings.HasPrefix(name.Name, "Category") || i >= len(value.Values) {
+					continue
+				}
+				if lit, ok := value.Values[i].(*ast.BasicLit); ok {
+					category, _ := strconv.Unquote(lit.Value)
+					rules = append(rules, "ci/"+category)
+				}
+			}
+		}
+	}
+	if len(rules) < 10 {
+		t.Fatalf("found the rules %q, want every category", rules)
+	}
+	for _, id := range rules {
+		if sarifRuleDescriptions[id] == "" {
+			t.Errorf("rule %q has no description", id)
+		}
+	}
+}
+
+func TestGenerateSARIFDataRace(t *testing.T) {
+	race := DataRace{Access: "Write at 0x00c0000b4010 by goroutine 8", Frames: []StackFrame{{File: "pkg/cache/cache.go", Line: 42}}}
+	run := &WorkflowRun{ErrorSummary: ErrorSummary{Findings: []Finding{
+		{Category: CategoryStackTrace, Message: "WARNING: DATA RACE\n  pkg/cache/cache.go:42 +0x1d", DataRace: &race},
+		{Category: CategoryStackTrace, Message: "panic: boom\n  main.go:7 +0x1d"},
+	}}}
+	data, err := (&GitHubWorkflowDebugger{}).GenerateSARIF(run, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := validateSARIF(t, data)
+	if len(results[ruleDataRace]) != 1 || len(results["ci/"+CategoryStackTrace]) != 1 {
+		t.Errorf("results = %q, want the race under its own rule", results)
+	}
+}
@@ -0,0 +1,148 @@
+package debugger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Canonical section names of the AI response
+const (
+	sectionRootCause   = "root_cause"
+	sectionAnalysis    = "analysis"
+	sectionFix         = "fix"
+	sectionFiles       = "files"
+	sectionCodeChanges = "code_changes"
+	sectionConfidence  = "confidence"
+	sectionSummary     = "summary"
+)
+
+// sectionSynonyms maps the (lowercase) header titles the models use onto the canonical sections
+var sectionSynonyms = map[string]string{
+	"root cause":             sectionRootCause,
+	"root cause analysis":    sectionRootCause,
+	"cause":                  sectionRootCause,
+	"probable cause":         sectionRootCause,
+	"likely cause":           sectionRootCause,
+	"detailed analysis":      sectionAnalysis,
+	"analysis":               sectionAnalysis,
+	"explanation":            sectionAnalysis,
+	"details":                sectionAnalysis,
+	"proposed fix":           sectionFix,
+	"proposed fixes":         sectionFix,
+	"fix":                    sectionFix,
+	"suggested fix":          sectionFix,
+	"recommended fix":        sectionFix,
+	"solution":               sectionFix,
+	"proposed solution":      sectionFix,
+	"remediation":            sectionFix,
+	"files to check":         sectionFiles,
+	"files to examine":       sectionFiles,
+	"files to modify":        sectionFiles,
+	"relevant files":         sectionFiles,
+	"affected files":         sectionFiles,
+	"files":                  sectionFiles,
+	"code changes":           sectionCodeChanges,
+	"suggested code changes": sectionCodeChanges,
+	"confidence level":       sectionConfidence,
+	"confidence":             sectionConfidence,
+	"tl;dr":                  sectionSummary,
+	"tldr":                   sectionSummary,
+	"summary":                sectionSummary,
+	"one-line summary":       sectionSummary,
+}
+
+var (
+	headingPrefixRe  = regexp.MustCompile(`^(#{1,6})\s*`)
+	numberedPrefixRe = regexp.MustCompile(`^\d+[.)]\s+`)
+)
+
+// sectionHeader recognizes a section header line
+// Markdown headings ("## Root Cause"), bold labels ("**Root Cause:** text"), numbered items
+// ("1. Root Cause") and plain labels ("Root Cause:") are accepted. level is the markdown heading
+// level (0 for other styles), marked is false for plain labels and inline is any text following
+// the header on the same line.
+func sectionHeader(line string) (title string, level int, marked bool, inline string, ok bool) {
+	text := strings.TrimSpace(line)
+	if m := headingPrefixRe.FindStringSubmatch(text); m != nil {
+		level = len(m[1])
+		text = text[len(m[0]):]
+	}
+	marked = level > 0
+	if m := numberedPrefixRe.FindString(text); m != "" {
+		text = text[len(m):]
+		marked = true
+	}
+	for _, marker := range []string{"**", "__"} {
+		if strings.HasPrefix(text, marker) {
+			end := strings.Index(text[len(marker):], marker)
+			if end < 0 {
+				return "", 0, false, "", false
+			}
+			title = text[len(marker) : len(marker)+end]
+			inline = text[len(marker)+end+len(marker):]
+			marked = true
+			break
+		}
+	}
+	if title == "" {
+		title, inline, _ = strings.Cut(text, ":")
+		if !marked && !strings.Contains(text, ":") {
+			return "", 0, false, "", false
+		}
+	}
+
+	title = strings.ToLower(strings.Trim(strings.TrimSpace(title), ":*_ "))
+	inline = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(inline), ":"))
+	return title, level, marked, inline, title != ""
+}
+
+// splitSections splits the AI response into its canonical sections
+// Unknown headings stay part of the current section (e.g. "### Step 1" within the fix),
+// unless they are at the same or a higher level than the section's own heading. Plain labels
+// only start a section for multi-word titles, and a section is never started twice, so
+// prose such as "Fix: ..." within the analysis is kept as content.
+func splitSections(response string) map[string]string {
+	sections := make(map[string]string)
+	current := ""
+	currentLevel := 0
+	var content []string
+
+	flush := func() {
+		if current != "" {
+			sections[current] = strings.TrimSpace(strings.Join(content, "\n"))
+		}
+		content = nil
+	}
+
+	inCode := false
+	for _, line := range strings.Split(response, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if !inCode {
+			if title, level, marked, inline, ok := sectionHeader(line); ok {
+				name, known := sectionSynonyms[title]
+				if _, seen := sections[name]; seen || name == current || (!marked && !strings.Contains(title, " ")) {
+					known = false
+				}
+				if known {
+					flush()
+					current, currentLevel = name, level
+					if inline != "" {
+						content = append(content, inline)
+					}
+					continue
+				}
+				if level > 0 && currentLevel > 0 && level <= currentLevel {
+					flush()
+					current, currentLevel = "", 0
+					continue
+				}
+			}
+		}
+		content = append(content, line)
+	}
+	flush()
+
+	return sections
+}
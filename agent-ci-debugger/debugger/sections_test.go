@@ -0,0 +1,141 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFixProposalFormats(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   string
+		rootCause  string
+		fix        string
+		files      []string
+		confidence string
+	}{
+		{
+			name: "markdown headings",
+			response: `## Root Cause
+The go.sum entry of golang.org/x/net is missing.
+
+## Detailed Analysis
+go build fails before compiling anything.
+
+## Proposed Fix
+Run go mod tidy and commit go.sum.
+
+## Files to Check
+- go.mod
+- go.sum
+
+## Confidence Level
+High - the error names the module.`,
+			rootCause:  "The go.sum entry of golang.org/x/net is missing.",
+			fix:        "Run go mod tidy and commit go.sum.",
+			files:      []string{"go.mod", "go.sum"},
+			confidence: "High",
+		},
+		{
+			name: "numbered bold labels with inline text",
+			response: `1. **Root Cause:** The test expects UTC timestamps.
+2. **Detailed Analysis:** TestFormat compares local time.
+3. **Proposed Fix:** Use time.UTC in the test.
+4. **Files to Check:**
+   1. pkg/format/format_test.go
+5. **Confidence Level:** 55 (Medium) - the timezone of the runner is not shown.`,
+			rootCause:  "The test expects UTC timestamps.",
+			fix:        "Use time.UTC in the test.",
+			files:      []string{"pkg/format/format_test.go"},
+			confidence: "Medium",
+		},
+		{
+			name: "plain labels and synonyms",
+			response: `Probable cause: the runner ran out of disk space.
+
+Detailed analysis:
+Docker layers filled /var/lib/docker.
+
+Suggested fix:
+Prune images before the build.
+
+Relevant files:
+* .github/workflows/ci.yml
+
+Confidence level: low`,
+			rootCause:  "the runner ran out of disk space.",
+			fix:        "Prune images before the build.",
+			files:      []string{".github/workflows/ci.yml"},
+			confidence: "Low",
+		},
+		{
+			name: "heading levels and nested steps",
+			response: `# Analysis of the failure
+### Root cause
+Lint fails on an unused import.
+### Fix
+#### Step 1
+Remove the import.
+#### Step 2
+Run golangci-lint locally.`,
+			rootCause: "Lint fails on an unused import.",
+			fix:       "#### Step 1\nRemove the import.\n#### Step 2\nRun golangci-lint locally.",
+		},
+		{
+			name:      "headers inside code blocks are content",
+			response:  "## Root Cause\nA bad flag.\n## Proposed Fix\n```yaml\n# Fix: pass the flag\nrun: make FLAGS=-v\n```",
+			rootCause: "A bad flag.",
+			fix:       "```yaml\n# Fix: pass the flag\nrun: make FLAGS=-v\n```",
+		},
+		{
+			// Single-word plain labels are too common in prose to start a section
+			name: "single-word plain labels are content",
+			response: `Root cause: a missing secret.
+Details: NPM_TOKEN is empty in forks.
+Proposed fix: skip the publish step on forks.`,
+			rootCause: "a missing secret.\nDetails: NPM_TOKEN is empty in forks.",
+			fix:       "skip the publish step on forks.",
+		},
+		{
+			name: "prose mentioning a section is not a header",
+			response: `## Root Cause
+The cache key changed. Fix: restore it.
+## Proposed Fix
+Pin the cache key.`,
+			rootCause: "The cache key changed. Fix: restore it.",
+			fix:       "Pin the cache key.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &GitHubWorkflowDebugger{}
+			p := d.parseFixProposal(tt.response, &WorkflowRun{})
+			if p.RootCause != tt.rootCause {
+				t.Errorf("RootCause = %q, want %q", p.RootCause, tt.rootCause)
+			}
+			if p.ProposedFix != tt.fix {
+				t.Errorf("ProposedFix = %q, want %q", p.ProposedFix, tt.fix)
+			}
+			if !reflect.DeepEqual(p.FilesToCheck, tt.files) {
+				t.Errorf("FilesToCheck = %q, want %q", p.FilesToCheck, tt.files)
+			}
+			if level := confidenceLevel(p.Confidence); level != tt.confidence {
+				t.Errorf("confidence level of %q = %q, want %q", p.Confidence, level, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestParseFixProposalWithoutSections(t *testing.T) {
+	response := "The build fails because the Makefile target was renamed; call make build instead of make all."
+	p := (&GitHubWorkflowDebugger{}).parseFixProposal("\n"+response+"\n", &WorkflowRun{})
+	if p.Analysis != response {
+		t.Errorf("Analysis = %q, want the whole response", p.Analysis)
+	}
+	if p.RootCause != "" || p.ProposedFix != "" {
+		t.Errorf("RootCause = %q, ProposedFix = %q, want them empty", p.RootCause, p.ProposedFix)
+	}
+	if p.Summary == "" {
+		t.Error("Summary is empty, want one derived from the analysis")
+	}
+}
@@ -0,0 +1,167 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// stepAnchorRe matches the "#step:N:line" anchor the GitHub UI adds to job URLs linking a step or a log line
+var stepAnchorRe = regexp.MustCompile(`#step:(\d+)(?::(\d+))?$`)
+
+// StepAnchor is the step, and the log line within it, a job URL links to
+type StepAnchor struct {
+	Step int    // 1-based step number of the job
+	Line int    // 1-based log line of the step (0 = the whole step)
+	Text string // content of the linked log line
+}
+
+// parseStepAnchor returns the step number and log line of the "#step:N:line" anchor of url, zeros without one
+func parseStepAnchor(url string) (step, line int) {
+	m := stepAnchorRe.FindStringSubmatch(url)
+	if m == nil {
+		return 0, 0
+	}
+	step, _ = strconv.Atoi(m[1])
+	line, _ = strconv.Atoi(m[2])
+	return step, line
+}
+
+// selectAnchoredStep scopes the analysis to the step a "#step:N:line" job URL links to, unless
+// --step selects a step explicitly. The step is looked up by its number among the steps of the job.
+func selectAnchoredStep(run *WorkflowRun, step int) {
+	jobs, err := fetchJobs(run)
+	if err != nil {
+		logWarnf("failed to resolve step %d of the URL, analyzing the whole job logs: %v", step, err)
+		return
+	}
+	for _, job := range jobs {
+		if fmt.Sprint(job.DatabaseID) != run.JobID {
+			continue
+		}
+		for _, s := range job.Steps {
+			if s.Number == step {
+				run.StepName = s.Name
+				logInfof("Selected step %q linked by the URL (#step:%d)", s.Name, step)
+				return
+			}
+		}
+	}
+	logWarnf("the job has no step %d, analyzing the whole job logs", step)
+}
+
+// anchorLineText returns the content of the 1-based line of the named step in logs, "" when the
+// logs have no such line
+func anchorLineText(logs, stepName string, line int) string {
+	for _, step := range splitSteps(logs) {
+		if strings.EqualFold(step.Name, stepName) && line >= 1 && line <= len(step.Lines) {
+			return strings.TrimSpace(logContent(step.Lines[line-1]))
+		}
+	}
+	return ""
+}
+
+// resolveName picks the candidate matching name, which is matched case-insensitively
+// An exact match wins, otherwise a single candidate containing name is accepted. Returns ""
+// when nothing matches and an error listing the candidates when the match is ambiguous.
+func resolveName(kind, name string, candidates []string) (string, error) {
+	var partial []string
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate, name) {
+			return candidate, nil
+		}
+		if strings.Contains(strings.ToLower(candidate), strings.ToLower(name)) && !seen[candidate] {
+			seen[candidate] = true
+			partial = append(partial, candidate)
+		}
+	}
+
+	switch len(partial) {
+	case 0:
+		return "", nil
+	case 1:
+		return partial[0], nil
+	default:
+		return "", fmt.Errorf("%s name %q is ambiguous, candidates:\n  - %s", kind, name, strings.Join(partial, "\n  - "))
+	}
+}
+
+// selectJobAndStep resolves the --job and --step names against the jobs of the run
+// A numeric --job is also matched against the job IDs.
+// The job ID of a single matching job is stored in run.JobID so only its logs are fetched.
+func (d *GitHubWorkflowDebugger) selectJobAndStep(run *WorkflowRun) error {
+	jobs, err := fetchJobs(run)
+	if err != nil {
+		return err
+	}
+
+	if d.Options.JobName != "" && run.JobID == "" {
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.Name)
+		}
+		name, err := resolveName("job", d.Options.JobName, names)
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			if fmt.Sprint(job.DatabaseID) == d.Options.JobName {
+				name = job.Name
+			}
+		}
+		if name == "" {
+			logWarnf("no job matches %q, analyzing all failed jobs", d.Options.JobName)
+		} else {
+			for _, job := range jobs {
+				if job.Name == name {
+					run.JobID = fmt.Sprint(job.DatabaseID)
+					break
+				}
+			}
+			logInfof("Selected job %q (ID %s)", name, run.JobID)
+		}
+	}
+
+	if d.Options.StepName != "" {
+		var names []string
+		for _, job := range jobs {
+			if run.JobID != "" && fmt.Sprint(job.DatabaseID) != run.JobID {
+				continue
+			}
+			for _, step := range job.Steps {
+				names = append(names, step.Name)
+			}
+		}
+		name, err := resolveName("step", d.Options.StepName, names)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			logWarnf("no step matches %q, analyzing the whole job logs", d.Options.StepName)
+		} else {
+			run.StepName = name
+			logInfof("Selected step %q", name)
+		}
+	}
+
+	return nil
+}
+
+// stepLogs keeps only the log lines of the named step, in all jobs that ran it
+// Returns the logs unchanged when no lines are attributed to the step.
+func stepLogs(logs, name string) string {
+	var kept []string
+	for _, step := range splitSteps(logs) {
+		if strings.EqualFold(step.Name, name) {
+			kept = append(kept, step.Lines...)
+		}
+	}
+	if len(kept) == 0 {
+		logWarnf("no log lines found for step %q, analyzing the whole job logs", name)
+		return logs
+	}
+	logDebugf("Scoped logs to step %q (%d lines)", name, len(kept))
+	return strings.Join(kept, "\n")
+}
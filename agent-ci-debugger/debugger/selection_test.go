@@ -0,0 +1,103 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveName(t *testing.T) {
+	candidates := []string{"Run unit tests", "Run integration tests", "Build", "build docs", "Lint", "Lint"}
+	tests := []struct {
+		name      string
+		want      string
+		ambiguous bool
+	}{
+		{name: "Build", want: "Build"},
+		{name: "build", want: "Build"}, // exact, case-insensitive, wins over "build docs"
+		{name: "unit", want: "Run unit tests"},
+		{name: "INTEGRATION", want: "Run integration tests"},
+		{name: "lint", want: "Lint"},
+		{name: "lin", want: "Lint"}, // duplicate candidates count once
+		{name: "deploy", want: ""},
+		{name: "tests", ambiguous: true},
+		{name: "run", ambiguous: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveName("step", tt.name, candidates)
+			if tt.ambiguous {
+				if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+					t.Fatalf("resolveName(%q) = %q, %v, want an ambiguity error", tt.name, got, err)
+				}
+				if !strings.Contains(err.Error(), "Run unit tests") || !strings.Contains(err.Error(), "Run integration tests") {
+					t.Errorf("error %q does not list the candidates", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("resolveName(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// selectionRoutes are the REST routes of run 1 of o/r with two jobs sharing a step name
+func selectionRoutes() map[string]string {
+	return map[string]string{
+		"/repos/o/r/actions/runs/1": `{"status":"completed","conclusion":"failure"}`,
+		"/repos/o/r/actions/runs/1/jobs": `{"total_count":2,"jobs":[
+			{"id":11,"name":"test (ubuntu-latest, 1.21)","conclusion":"failure",
+			 "steps":[{"name":"Checkout","number":1},{"name":"Run go test","number":2}]},
+			{"id":12,"name":"lint","conclusion":"success",
+			 "steps":[{"name":"Checkout","number":1},{"name":"Run golangci-lint","number":2}]}]}`,
+	}
+}
+
+func TestSelectJobAndStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		options   Options
+		wantJob   string
+		wantStep  string
+		wantError string
+	}{
+		{name: "job by partial name", options: Options{JobName: "ubuntu"}, wantJob: "11"},
+		{name: "job by ID", options: Options{JobName: "12"}, wantJob: "12"},
+		{name: "unknown job", options: Options{JobName: "deploy"}},
+		{name: "step of the selected job", options: Options{JobName: "lint", StepName: "checkout"}, wantJob: "12", wantStep: "Checkout"},
+		{name: "step by partial name", options: Options{StepName: "go test"}, wantStep: "Run go test"},
+		{name: "ambiguous step", options: Options{StepName: "run"}, wantError: "ambiguous"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubGitHub(t, selectionRoutes())
+			d := &GitHubWorkflowDebugger{Options: tt.options}
+			run := &WorkflowRun{RunID: "1", Repository: "o/r"}
+			err := d.selectJobAndStep(run)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("selectJobAndStep() error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectJobAndStep() error = %v", err)
+			}
+			if run.JobID != tt.wantJob || run.StepName != tt.wantStep {
+				t.Errorf("job %q, step %q, want job %q, step %q", run.JobID, run.StepName, tt.wantJob, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestStepLogs(t *testing.T) {
+	logs := "test\tCheckout\t2024-01-01T00:00:00Z cloning\n" +
+		"test\tRun go test\t2024-01-01T00:00:01Z --- FAIL: TestX\n" +
+		"test\tRun go test\t2024-01-01T00:00:02Z FAIL example.com/pkg"
+	if got, want := stepLogs(logs, "run go test"), strings.Join(strings.Split(logs, "\n")[1:], "\n"); got != want {
+		t.Errorf("stepLogs() = %q, want %q", got, want)
+	}
+	if got := stepLogs(logs, "Deploy"); got != logs {
+		t.Errorf("stepLogs() of an unknown step = %q, want the whole logs", got)
+	}
+}
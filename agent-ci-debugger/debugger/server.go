@@ -0,0 +1,272 @@
+package debugger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the webhook server
+const (
+	DefaultListenAddr   = ":8080"
+	maxWebhookBody      = 10 << 20 // GitHub caps payloads at 25 MB, workflow_run payloads are far smaller
+	maxCommentChars     = 60000    // GitHub rejects comments over 65536 characters
+	serverShutdownGrace = 30 * time.Second
+)
+
+// ErrNoWebhookSecret is returned when the server would accept unauthenticated webhooks
+var ErrNoWebhookSecret = errors.New("a webhook secret is required (GITHUB_WEBHOOK_SECRET)")
+
+// workflowRunEvent is the part of a workflow_run webhook payload the server uses
+type workflowRunEvent struct {
+	Action      string `json:"action"`
+	WorkflowRun struct {
+		ID           int64  `json:"id"`
+		RunAttempt   int    `json:"run_attempt"`
+		HTMLURL      string `json:"html_url"`
+		Conclusion   string `json:"conclusion"`
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+	} `json:"workflow_run"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// Server analyzes failed runs on GitHub workflow_run webhooks and comments the report on their pull requests
+// Analyses run in the background after the delivery is acknowledged, as GitHub times out deliveries after 10s.
+type Server struct {
+	newDebugger func() *GitHubWorkflowDebugger
+	secret      []byte
+	slots       chan struct{} // bounds the analyses running at once
+	wg          sync.WaitGroup
+	ctx         context.Context // of the analyses, cancelled when they outlast the shutdown grace period
+	cancel      context.CancelFunc
+
+	// Processed skips the runs already analyzed, e.g. on redelivered webhooks; nil analyzes every delivery
+	Processed *ProcessedRuns
+}
+
+// NewServer creates a webhook server verifying deliveries with secret
+// newDebugger is called once per analysis, so concurrent analyses share no state.
+func NewServer(newDebugger func() *GitHubWorkflowDebugger, secret string, concurrency int) (*Server, error) {
+	if secret == "" {
+		return nil, ErrNoWebhookSecret
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		newDebugger: newDebugger,
+		secret:      []byte(secret),
+		slots:       make(chan struct{}, concurrency),
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Handler returns the routes of the server: POST /webhook and GET /healthz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.handleWebhook)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// ListenAndServe serves on addr until ctx is cancelled, then stops accepting deliveries and
+// waits for the running analyses to finish, cancelling those still running after the grace period
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		logInfof("Listening for webhooks on %s", addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logInfof("Shutting down, waiting for running analyses...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownGrace)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	s.waitAnalyses(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	return nil
+}
+
+// waitAnalyses waits for the running and queued analyses, cancelling them once ctx is done
+func (s *Server) waitAnalyses(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	logWarnf("Cancelling the analyses still running after the shutdown grace period")
+	s.cancel()
+	<-done
+}
+
+// handleWebhook verifies and acknowledges a delivery and starts the analysis of failed runs
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if !validSignature(s.secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		logWarnf("Rejected webhook delivery %s: invalid signature", r.Header.Get("X-GitHub-Delivery"))
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Header.Get("X-GitHub-Event") {
+	case "ping":
+		fmt.Fprintln(w, "pong")
+		return
+	case "workflow_run":
+	default:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var event workflowRunEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if event.Action != "completed" || !analyzableConclusions[event.WorkflowRun.Conclusion] {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	ref, err := ParseRunRef(event.WorkflowRun.HTMLURL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	attempt := event.WorkflowRun.RunAttempt
+	if s.Processed != nil {
+		claimed, err := s.Processed.claim(ref, attempt)
+		if err != nil {
+			logWarnf("Failed to record %s as processed: %v", ref.WebURL(), err)
+		}
+		if !claimed {
+			logInfof("Skipping %s attempt %d: already processed (delivery %s)", ref.WebURL(), max(attempt, 1), r.Header.Get("X-GitHub-Delivery"))
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "already processed")
+			return
+		}
+	}
+
+	var prs []int
+	for _, pr := range event.WorkflowRun.PullRequests {
+		prs = append(prs, pr.Number)
+	}
+	logInfof("Accepted %s run %s (delivery %s)", event.WorkflowRun.Conclusion, ref.WebURL(), r.Header.Get("X-GitHub-Delivery"))
+	w.WriteHeader(http.StatusAccepted)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-s.ctx.Done():
+		}
+		// A slot freed by a cancelled analysis may win the select above
+		if s.ctx.Err() != nil {
+			logWarnf("Dropped the analysis of %s: shutting down", ref.WebURL())
+			s.release(ref, attempt)
+			return
+		}
+		s.analyze(s.ctx, ref, attempt, prs)
+	}()
+}
+
+// analyze debugs the run and comments the report on the run's pull requests
+func (s *Server) analyze(ctx context.Context, ref RunRef, attempt int, prs []int) {
+	d := s.newDebugger()
+	result, err := d.DebugRun(ctx, ref)
+	if errors.Is(err, ErrNothingToAnalyze) {
+		logInfof("Skipping %s: %v", ref.WebURL(), err)
+		return
+	}
+	if err != nil {
+		logErrorf("Analysis of %s failed: %v", ref.WebURL(), err)
+		s.release(ref, attempt)
+		return
+	}
+	if len(prs) == 0 {
+		logInfof("Analyzed %s, no pull request to comment on", ref.WebURL())
+		return
+	}
+
+	body := truncateText(result.Report, maxCommentChars)
+	for _, pr := range prs {
+		if _, err := ghWithInput(body, "pr", "comment", fmt.Sprint(pr), "--repo", ref.Repository, "--body-file", "-"); err != nil {
+			logErrorf("Failed to comment on %s#%d: %v", ref.Repository, pr, err)
+			continue
+		}
+		logInfof("Commented the analysis of %s on %s#%d", ref.WebURL(), ref.Repository, pr)
+	}
+}
+
+// release forgets the claim on a run attempt that was not analyzed, so that a redelivery of the run
+// gets another chance
+func (s *Server) release(ref RunRef, attempt int) {
+	if s.Processed == nil {
+		return
+	}
+	if err := s.Processed.release(ref, attempt); err != nil {
+		logWarnf("Failed to forget %s: %v", ref.WebURL(), err)
+	}
+}
+
+// validSignature checks the X-Hub-Signature-256 header, the hex HMAC-SHA256 of the body
+func validSignature(secret, body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package debugger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// webhookRequest is a delivery of event with payload, signed with secret
+func webhookRequest(secret, event, payload string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(payload))
+	r.Header.Set("X-GitHub-Event", event)
+	r.Header.Set("X-GitHub-Delivery", "delivery-1")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+// workflowRunPayload is a completed workflow_run event of run id of o/r
+func workflowRunPayload(id int, conclusion string) string {
+	return fmt.Sprintf(`{"action":"completed","workflow_run":{"id":%d,"run_attempt":1,
+		"html_url":"https://github.com/o/r/actions/runs/%[1]d","conclusion":%q,"pull_requests":[{"number":5}]},
+		"repository":{"full_name":"o/r"}}`, id, conclusion)
+}
+
+func testServer(t *testing.T, concurrency int) *Server {
+	t.Helper()
+	s, err := NewServer(func() *GitHubWorkflowDebugger {
+		d := New("test-key")
+		d.Options.Quiet = true
+		return d
+	}, testWebhookSecret, concurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestHandleWebhook(t *testing.T) {
+	routes := make(map[string]string)
+	batchRoutes(routes, 1, "failure")
+	var mu sync.Mutex
+	var comments []string
+	stubGitHubHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/repos/o/r/issues/5/comments" {
+			var comment struct{ Body string }
+			data, _ := io.ReadAll(r.Body)
+			json.Unmarshal(data, &comment)
+			mu.Lock()
+			comments = append(comments, comment.Body)
+			mu.Unlock()
+			fmt.Fprint(w, `{"html_url":"https://github.com/o/r/pull/5#issuecomment-1"}`)
+			return
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	api := &mockOpenAI{}
+	api.start(t)
+	s := testServer(t, 1)
+
+	tests := []struct {
+		name     string
+		request  *http.Request
+		status   int
+		analyzed bool
+	}{
+		{name: "invalid signature", request: webhookRequest("other-secret", "workflow_run", workflowRunPayload(1, "failure")), status: http.StatusUnauthorized},
+		{name: "ping", request: webhookRequest(testWebhookSecret, "ping", `{"zen":"hi"}`), status: http.StatusOK},
+		{name: "other event", request: webhookRequest(testWebhookSecret, "push", `{}`), status: http.StatusNoContent},
+		{name: "successful run", request: webhookRequest(testWebhookSecret, "workflow_run", workflowRunPayload(1, "success")), status: http.StatusNoContent},
+		{name: "failed run", request: webhookRequest(testWebhookSecret, "workflow_run", workflowRunPayload(1, "failure")), status: http.StatusAccepted, analyzed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, tt.request)
+			s.wg.Wait()
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if analyzed := len(comments) > 0; analyzed != tt.analyzed {
+				t.Fatalf("commented = %v, want %v", analyzed, tt.analyzed)
+			}
+			if tt.analyzed && !strings.Contains(comments[0], "go.sum entry of golang.org/x/net is missing") {
+				t.Errorf("comment = %q, want the report", comments[0])
+			}
+		})
+	}
+	if n := len(api.requests(t)); n != 1 {
+		t.Errorf("API received %d requests, want one for the failed run", n)
+	}
+}
+
+func TestServerShutdownCancelsAnalyses(t *testing.T) {
+	routes := make(map[string]string)
+	batchRoutes(routes, 1, "failure")
+	batchRoutes(routes, 2, "failure")
+	stubGitHub(t, routes)
+	(&mockOpenAI{}).start(t)
+	started := make(chan struct{}, 2)
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+	t.Setenv("OPENAI_BASE_URL", hanging.URL+"/v1")
+
+	// With one slot, the second analysis is queued behind the hanging first one
+	s := testServer(t, 1)
+	for _, id := range []int{1, 2} {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, webhookRequest(testWebhookSecret, "workflow_run", workflowRunPayload(id, "failure")))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusAccepted)
+		}
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		s.waitAnalyses(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("waitAnalyses() did not return after the grace period")
+	}
+	if len(started) != 0 {
+		t.Error("the queued analysis started after the shutdown")
+	}
+}
@@ -0,0 +1,169 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultSeverity is the severity of a relevant log line matching none of the weighted keywords
+const DefaultSeverity = 50
+
+// WarningSeverity caps the severity of lines logged at warning level, whatever keywords they contain
+const WarningSeverity = 10
+
+// SeverityWeights are the severities of log line keywords, matched case-insensitively; a line
+// gets the highest weight among its keywords. When the log budget is tight, the error lines of
+// the highest severity are kept first. Options.SeverityWeights overrides single entries.
+var SeverityWeights = map[string]int{
+	"panic:":             100,
+	"segmentation fault": 100,
+	"sigsegv":            100,
+	"fatal error":        95,
+	"out of memory":      95,
+	"oomkilled":          95,
+	"fatal":              90,
+	"--- fail":           80,
+	"traceback":          80,
+	"exception":          75,
+	"fail:":              75,
+	"assertion":          70,
+	"timed out":          70,
+	"timeout":            65,
+	"error":              50,
+	"failed":             50,
+	"exit code":          45,
+	"expected":           40,
+	"actual":             40,
+	"deprecat":           5,
+}
+
+// warningLevelRe matches lines logged at warning level, e.g. "WARNING: ...", "[warn] ...",
+// "::warning file=..." or "level=warn"
+var warningLevelRe = regexp.MustCompile(`(?i)^\s*(?:::warning\b|\[?warn(?:ing)?\]?[:\s]|.*\blevel=warn(?:ing)?\b)`)
+
+// ParseSeverityWeights parses the --severity value, comma-separated keyword=weight pairs
+// such as "panic:=100,deprecated=0"
+func ParseSeverityWeights(value string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		keyword, weight, ok := strings.Cut(pair, "=")
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if !ok || keyword == "" {
+			return nil, fmt.Errorf("invalid severity %q, expected keyword=weight", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight of severity %q: %w", pair, err)
+		}
+		weights[keyword] = n
+	}
+	return weights, nil
+}
+
+// severityWeights returns SeverityWeights with the extra keywords and Options.SeverityWeights applied
+func (d *GitHubWorkflowDebugger) severityWeights() map[string]int {
+	weights := make(map[string]int, len(SeverityWeights)+len(d.Options.SeverityWeights))
+	for keyword, weight := range SeverityWeights {
+		weights[strings.ToLower(keyword)] = weight
+	}
+	for _, keyword := range d.Options.ExtraKeywords {
+		if _, ok := weights[strings.ToLower(keyword)]; !ok {
+			weights[strings.ToLower(keyword)] = CustomKeywordSeverity
+		}
+	}
+	for keyword, weight := range d.Options.SeverityWeights {
+		weights[strings.ToLower(keyword)] = weight
+	}
+	return weights
+}
+
+// lineSeverity scores a log line by its most severe keyword
+func lineSeverity(line string, weights map[string]int) int {
+	lower := strings.ToLower(line)
+	severity, matched := 0, false
+	for keyword, weight := range weights {
+		if strings.Contains(lower, keyword) && (!matched || weight > severity) {
+			severity, matched = weight, true
+		}
+	}
+	if !matched {
+		severity = DefaultSeverity
+	}
+	if warningLevelRe.MatchString(line) {
+		severity = min(severity, WarningSeverity)
+	}
+	return severity
+}
+
+// errorWindow is a run of adjacent relevant log lines: error lines and their context
+type errorWindow struct {
+	lines      []int // indexes into the prompt lines, ascending
+	firstError int   // position in lines of the first error line, lines before it are context
+	severity   int   // severity of the most severe error line
+}
+
+// errorWindows groups the relevant lines into windows of adjacent lines
+func errorWindows(relevant []int, severities map[int]int) []errorWindow {
+	var windows []errorWindow
+	for _, i := range relevant {
+		if n := len(windows); n > 0 && windows[n-1].lines[len(windows[n-1].lines)-1] == i-1 {
+			windows[n-1].lines = append(windows[n-1].lines, i)
+		} else {
+			windows = append(windows, errorWindow{lines: []int{i}, firstError: -1})
+		}
+		w := &windows[len(windows)-1]
+		if s, ok := severities[i]; ok {
+			if w.firstError < 0 {
+				w.firstError = len(w.lines) - 1
+			}
+			w.severity = max(w.severity, s)
+		}
+	}
+	return windows
+}
+
+// selectWindows picks the lines of the windows that fit maxChars, the most severe windows first
+// and the most recent first among equally severe ones. Of a window that does not fit as a whole,
+// the lines from its first error line on are kept as far as they fit. size returns the prompt
+// size of a line.
+func selectWindows(windows []errorWindow, maxChars int, size func(i int, first bool) int) map[int]bool {
+	order := make([]int, len(windows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		wa, wb := windows[order[a]], windows[order[b]]
+		if wa.severity != wb.severity {
+			return wa.severity > wb.severity
+		}
+		return wa.lines[0] > wb.lines[0]
+	})
+
+	selected := make(map[int]bool)
+	used := 0
+	for _, w := range order {
+		lines := windows[w].lines
+		whole := 0
+		for n, i := range lines {
+			whole += size(i, n == 0)
+		}
+		if used+whole > maxChars {
+			lines = lines[max(windows[w].firstError, 0):]
+		}
+		for n, i := range lines {
+			lineSize := size(i, n == 0)
+			if used+lineSize > maxChars {
+				break
+			}
+			used += lineSize
+			selected[i] = true
+		}
+	}
+	return selected
+}
@@ -0,0 +1,73 @@
+package debugger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineSeverity(t *testing.T) {
+	d := &GitHubWorkflowDebugger{Options: Options{SeverityWeights: map[string]int{"DEPRECAT": 0}, ExtraKeywords: []string{"E_WIDGET"}}}
+	weights := d.severityWeights()
+	tests := []struct {
+		line string
+		want int
+	}{
+		{line: "panic: runtime error: index out of range", want: 100},
+		{line: "--- FAIL: TestParse (0.00s)", want: 80},
+		{line: "Error: something went wrong", want: 50},
+		{line: "the widget broke", want: DefaultSeverity},
+		{line: "WARNING: fatal flag is deprecated", want: WarningSeverity},
+		{line: "::warning file=a.go::panic: in a test helper", want: WarningSeverity},
+		{line: "this API is deprecated", want: 0},
+		{line: "e_widget reported a problem", want: CustomKeywordSeverity},
+	}
+	for _, tt := range tests {
+		if got := lineSeverity(tt.line, weights); got != tt.want {
+			t.Errorf("lineSeverity(%q) = %d, want %d", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseSeverityWeights(t *testing.T) {
+	got, err := ParseSeverityWeights(" Panic:=100, deprecated = 0,,")
+	if want := map[string]int{"panic:": 100, "deprecated": 0}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSeverityWeights() = %v, %v, want %v", got, err, want)
+	}
+	for _, value := range []string{"panic", "=5", "panic:=high"} {
+		if _, err := ParseSeverityWeights(value); err == nil {
+			t.Errorf("ParseSeverityWeights(%q) succeeded", value)
+		}
+	}
+}
+
+func TestSevereLinesSurviveSmallBudget(t *testing.T) {
+	// Error lines separated by other output, each is a window of its own
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("Error: minor problem %d", i), fmt.Sprintf("step %d done", i))
+	}
+	lines[10] = "panic: runtime error: invalid memory address or nil pointer dereference"
+	lines[100] = "--- FAIL: TestCheckout (0.01s)"
+
+	d := &GitHubWorkflowDebugger{Options: Options{ErrorContextLines: -1}}
+	got, budget := d.filterRelevantLogs(strings.Join(lines, "\n"), 150)
+	for _, want := range []string{lines[10], lines[100], "Error: minor problem 99"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "minor problem 0\n") {
+		t.Errorf("a less severe, earlier line was kept over the recent ones:\n%s", got)
+	}
+	if strings.Index(got, lines[10]) > strings.Index(got, lines[100]) {
+		t.Errorf("the kept lines are not in log order:\n%s", got)
+	}
+	if budget.RelevantLines != 100 || budget.RelevantIncluded >= 100 {
+		t.Errorf("RelevantIncluded = %d of %d, want lines dropped", budget.RelevantIncluded, budget.RelevantLines)
+	}
+	if !strings.Contains(got, "...[dropped ") {
+		t.Errorf("prompt does not mark the dropped lines:\n%s", got)
+	}
+}
@@ -0,0 +1,247 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// sinkTimeout bounds a delivery of the report to a remote sink (Slack, HTTP)
+const sinkTimeout = 30 * time.Second
+
+// maxSlackChars caps the text of a Slack message; Slack truncates messages over 40,000 characters
+const maxSlackChars = 3000
+
+// SinkNames are the sinks selectable with --sink
+var SinkNames = []string{"stdout", "file", "summary", "slack", "pr-comment", "http"}
+
+// Sink is a destination of the report of an analysis: the terminal, a file, the job summary, a chat
+// or pull request, or any HTTP endpoint. The command writes every analysis to the sinks selected
+// with --sink, see WriteSinks.
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Write delivers the report of the analysis of run
+	Write(ctx context.Context, run *WorkflowRun, proposal *FixProposal, report string) error
+}
+
+// WriteSinks writes the report to every sink, in order; a failing sink does not keep the report
+// from the others, and the errors of all failing sinks are returned together
+func WriteSinks(ctx context.Context, sinks []Sink, run *WorkflowRun, proposal *FixProposal, report string) error {
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, run, proposal, report); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StdoutSink prints the report
+type StdoutSink struct {
+	W io.Writer // os.Stdout when nil
+}
+
+func (StdoutSink) Name() string { return "stdout" }
+
+func (s StdoutSink) Write(_ context.Context, _ *WorkflowRun, _ *FixProposal, report string) error {
+	w := s.W
+	if w == nil {
+		w = os.Stdout
+	}
+	if !strings.HasSuffix(report, "\n") {
+		report += "\n"
+	}
+	_, err := io.WriteString(w, report)
+	return err
+}
+
+// FileSink saves the report to a file (see ReportPath), creating its directory if missing
+type FileSink struct {
+	Path   string
+	Status io.Writer // where "Report saved to: <path>" is printed, nowhere when nil
+	// New keeps an existing file at Path and saves to a numbered name instead (see SaveNewReport),
+	// for default report names; an explicit --output is overwritten
+	New bool
+}
+
+func (FileSink) Name() string { return "file" }
+
+func (s FileSink) Write(_ context.Context, _ *WorkflowRun, _ *FixProposal, report string) error {
+	logDebugf("Saving report to: %s", s.Path)
+	path := s.Path
+	var err error
+	if s.New {
+		path, err = SaveNewReport(s.Path, report)
+	} else {
+		err = SaveReport(s.Path, report)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save report to file: %w", err)
+	}
+	if s.Status != nil {
+		fmt.Fprintf(s.Status, "\nReport saved to: %s\n", path)
+	}
+	return nil
+}
+
+// StepSummarySink appends the report to the job summary of the current GitHub Actions step
+type StepSummarySink struct{}
+
+func (StepSummarySink) Name() string { return "summary" }
+
+func (StepSummarySink) Write(_ context.Context, _ *WorkflowRun, _ *FixProposal, report string) error {
+	return WriteStepSummary(report)
+}
+
+// SlackSink posts the summary and root cause of the analysis to a Slack incoming webhook
+// Slack renders its own mrkdwn rather than Markdown, so the message links the run instead of
+// carrying the whole report.
+type SlackSink struct {
+	WebhookURL string
+	Client     *http.Client // a client with sinkTimeout when nil
+}
+
+func (SlackSink) Name() string { return "slack" }
+
+func (s SlackSink) Write(ctx context.Context, run *WorkflowRun, proposal *FixProposal, _ string) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*CI failure analysis* of <%s|%s run %s>", run.URL, run.Repository, run.RunID))
+	if run.WorkflowName != "" {
+		sb.WriteString(fmt.Sprintf(" (%s)", run.WorkflowName))
+	}
+	sb.WriteString("\n")
+	if proposal.Summary != "" {
+		sb.WriteString(fmt.Sprintf("*Summary*: %s\n", proposal.Summary))
+	}
+	if proposal.RootCause != "" {
+		sb.WriteString(fmt.Sprintf("*Root cause*: %s\n", proposal.RootCause))
+	}
+	if proposal.Confidence != "" {
+		sb.WriteString(fmt.Sprintf("*Confidence*: %s\n", proposal.Confidence))
+	}
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, map[string]string{"text": truncateText(sb.String(), maxSlackChars)})
+}
+
+// PRCommentSink comments the report on the open pull requests of the run's head commit
+type PRCommentSink struct{}
+
+func (PRCommentSink) Name() string { return "pr-comment" }
+
+func (PRCommentSink) Write(_ context.Context, run *WorkflowRun, _ *FixProposal, report string) error {
+	if run.Provider == ProviderGitLab || run.Repository == "" || run.HeadSHA == "" {
+		return fmt.Errorf("pull request comments need a GitHub run with a head commit")
+	}
+	output, err := gh("api", fmt.Sprintf("repos/%s/commits/%s/pulls", run.Repository, run.HeadSHA))
+	if err != nil {
+		return fmt.Errorf("failed to find the pull requests of %s: %w", run.HeadSHA, err)
+	}
+	var prs []struct {
+		Number int    `json:"number"`
+		State  string `json:"state"`
+	}
+	if err := json.Unmarshal(output, &prs); err != nil {
+		return fmt.Errorf("failed to parse the pull requests of %s: %w", run.HeadSHA, err)
+	}
+
+	body := truncateText(report, maxCommentChars)
+	commented := 0
+	for _, pr := range prs {
+		if pr.State != "open" {
+			continue
+		}
+		if _, err := ghWithInput(body, "pr", "comment", fmt.Sprint(pr.Number), "--repo", run.Repository, "--body-file", "-"); err != nil {
+			return fmt.Errorf("failed to comment on %s#%d: %w", run.Repository, pr.Number, err)
+		}
+		logInfof("Commented the report on %s#%d", run.Repository, pr.Number)
+		commented++
+	}
+	if commented == 0 {
+		logInfof("No open pull request of %s to comment on", run.HeadSHA)
+	}
+	return nil
+}
+
+// HTTPSink posts the analysis as JSON to a URL, e.g. an internal dashboard or chat bridge
+type HTTPSink struct {
+	URL     string
+	Headers map[string]string // e.g. Authorization
+	Client  *http.Client      // a client with sinkTimeout when nil
+}
+
+// HTTPSinkPayload is the JSON body posted by HTTPSink
+type HTTPSinkPayload struct {
+	Repository   string   `json:"repository"`
+	RunID        string   `json:"run_id"`
+	JobID        string   `json:"job_id,omitempty"`
+	URL          string   `json:"url"`
+	Workflow     string   `json:"workflow,omitempty"`
+	Conclusion   string   `json:"conclusion,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	RootCause    string   `json:"root_cause"`
+	Confidence   string   `json:"confidence,omitempty"`
+	FilesToCheck []string `json:"files_to_check,omitempty"`
+	Heuristic    bool     `json:"heuristic,omitempty"` // the proposal comes from --offline rules, not the AI model
+	Report       string   `json:"report"`
+}
+
+func (HTTPSink) Name() string { return "http" }
+
+func (s HTTPSink) Write(ctx context.Context, run *WorkflowRun, proposal *FixProposal, report string) error {
+	return postJSON(ctx, s.Client, s.URL, s.Headers, HTTPSinkPayload{
+		Repository:   run.Repository,
+		RunID:        run.RunID,
+		JobID:        run.JobID,
+		URL:          run.URL,
+		Workflow:     run.WorkflowName,
+		Conclusion:   run.Conclusion,
+		Summary:      proposal.Summary,
+		RootCause:    proposal.RootCause,
+		Confidence:   proposal.Confidence,
+		FilesToCheck: proposal.FilesToCheck,
+		Heuristic:    proposal.Heuristic,
+		Report:       report,
+	})
+}
+
+// postJSON posts body as JSON to target and fails on a non-2xx status
+func postJSON(ctx context.Context, client *http.Client, target string, headers map[string]string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode the request: %w", err)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: sinkTimeout}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid sink URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("POST to %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		// The URL itself may be the credential (Slack webhooks), so only the host is reported
+		return fmt.Errorf("POST to %s: HTTP %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sinkRequest is a request received by a stubbed sink endpoint
+type sinkRequest struct {
+	path    string
+	headers http.Header
+	body    []byte
+}
+
+// stubSinkEndpoint serves an endpoint answering status and returns its requests so far
+func stubSinkEndpoint(t *testing.T, status int) (string, func() []sinkRequest) {
+	t.Helper()
+	var requests []sinkRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, sinkRequest{path: r.URL.Path, headers: r.Header.Clone(), body: body})
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			fmt.Fprint(w, "endpoint is down\n")
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, func() []sinkRequest { return requests }
+}
+
+func sinkRun() *WorkflowRun {
+	run := testRun()
+	run.JobID = "10"
+	run.URL = "https://github.com/o/r/actions/runs/1"
+	return run
+}
+
+func TestHTTPSink(t *testing.T) {
+	endpoint, requests := stubSinkEndpoint(t, http.StatusOK)
+	proposal := reportProposal()
+	proposal.Summary = "go.sum is missing an entry"
+	sink := HTTPSink{URL: endpoint + "/hooks/ci", Headers: map[string]string{"Authorization": "Bearer dashboard-token"}}
+
+	if err := sink.Write(context.Background(), sinkRun(), proposal, "# Report\n"); err != nil {
+		t.Fatal(err)
+	}
+	got := requests()
+	if len(got) != 1 || got[0].path != "/hooks/ci" {
+		t.Fatalf("requests = %+v, want one to /hooks/ci", got)
+	}
+	if ct, auth := got[0].headers.Get("Content-Type"), got[0].headers.Get("Authorization"); ct != "application/json" || auth != "Bearer dashboard-token" {
+		t.Errorf("Content-Type = %q, Authorization = %q", ct, auth)
+	}
+	var payload HTTPSinkPayload
+	if err := json.Unmarshal(got[0].body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := HTTPSinkPayload{
+		Repository:   "o/r",
+		RunID:        "1",
+		JobID:        "10",
+		URL:          "https://github.com/o/r/actions/runs/1",
+		Workflow:     "CI",
+		Conclusion:   "failure",
+		Summary:      "go.sum is missing an entry",
+		RootCause:    "The go.sum entry of golang.org/x/net is missing.",
+		Confidence:   "High - the error names the module.",
+		FilesToCheck: []string{"go.sum"},
+		Report:       "# Report\n",
+	}
+	if !reflect.DeepEqual(payload, want) {
+		t.Errorf("payload = %+v, want %+v", payload, want)
+	}
+}
+
+func TestHTTPSinkError(t *testing.T) {
+	endpoint, _ := stubSinkEndpoint(t, http.StatusBadGateway)
+	err := HTTPSink{URL: endpoint + "/hooks/secret-path"}.Write(context.Background(), sinkRun(), reportProposal(), "# Report\n")
+	host := strings.TrimPrefix(endpoint, "http://")
+	if err == nil || err.Error() != "POST to "+host+": HTTP 502: endpoint is down" {
+		t.Errorf("error = %v, want the status and body", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "secret-path") {
+		t.Errorf("error %q reveals the URL path", err)
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	endpoint, requests := stubSinkEndpoint(t, http.StatusOK)
+	proposal := reportProposal()
+	proposal.Summary = "go.sum is missing an entry"
+	if err := (SlackSink{WebhookURL: endpoint}).Write(context.Background(), sinkRun(), proposal, "# Report\n"); err != nil {
+		t.Fatal(err)
+	}
+	var message map[string]string
+	if err := json.Unmarshal(requests()[0].body, &message); err != nil {
+		t.Fatal(err)
+	}
+	want := "*CI failure analysis* of <https://github.com/o/r/actions/runs/1|o/r run 1> (CI)\n" +
+		"*Summary*: go.sum is missing an entry\n" +
+		"*Root cause*: The go.sum entry of golang.org/x/net is missing.\n" +
+		"*Confidence*: High - the error names the module.\n"
+	if message["text"] != want {
+		t.Errorf("text = %q, want %q", message["text"], want)
+	}
+}
+
+func TestWriteSinks(t *testing.T) {
+	endpoint, requests := stubSinkEndpoint(t, http.StatusOK)
+	down, _ := stubSinkEndpoint(t, http.StatusInternalServerError)
+	var stdout, status bytes.Buffer
+	path := filepath.Join(t.TempDir(), "reports", "report.md")
+	sinks := []Sink{
+		HTTPSink{URL: down},
+		StdoutSink{W: &stdout},
+		SlackSink{WebhookURL: "://not a url"},
+		FileSink{Path: path, Status: &status},
+		HTTPSink{URL: endpoint},
+	}
+
+	err := WriteSinks(context.Background(), sinks, sinkRun(), reportProposal(), "# Report")
+	// the failing sinks do not keep the report from the others
+	if stdout.String() != "# Report\n" {
+		t.Errorf("stdout = %q, want the report", stdout.String())
+	}
+	if data, readErr := os.ReadFile(path); readErr != nil || string(data) != "# Report" {
+		t.Errorf("file = %q, %v, want the report", data, readErr)
+	}
+	if status.String() != "\nReport saved to: "+path+"\n" {
+		t.Errorf("status = %q", status.String())
+	}
+	if len(requests()) != 1 {
+		t.Errorf("the last sink received %d requests, want 1", len(requests()))
+	}
+	if err == nil {
+		t.Fatal("WriteSinks() succeeded with failing sinks")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "http sink: ") || lines[1] != "slack sink: invalid sink URL" {
+		t.Errorf("error = %q, want the errors of the http and slack sinks", err)
+	}
+
+	if err := WriteSinks(context.Background(), sinks[1:2], sinkRun(), reportProposal(), "# Report\n"); err != nil {
+		t.Errorf("WriteSinks() = %v, want no error", err)
+	}
+}
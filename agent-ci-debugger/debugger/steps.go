@@ -0,0 +1,145 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// unknownStepName is what gh prints in the step column when it cannot attribute a line
+const unknownStepName = "UNKNOWN STEP"
+
+// StepLog is the log output of a single step of a job
+type StepLog struct {
+	Job      string
+	Name     string
+	Lines    []string
+	ExitCode int  // nonzero exit code reported for the step
+	Failed   bool // the step reported a nonzero exit code
+}
+
+var (
+	groupRunRe = regexp.MustCompile(`##\[group\](Run .*)$`)
+	// GitHub reports "##[error]Process completed with exit code 1", GitLab "ERROR: Job failed: exit code 1"
+	stepExitRe = regexp.MustCompile(`(?:##\[error\]Process completed with|ERROR: Job failed:) exit code (\d+)`)
+)
+
+// splitSteps attributes log lines to the job step that produced them
+// Steps are taken from the "<job>\t<step>\t" prefix of gh logs. When the step is unknown or the
+// prefix is missing, "##[group]Run ..." markers emitted at the start of each step are used instead.
+func splitSteps(logs string) []StepLog {
+	var steps []StepLog
+	var current *StepLog
+
+	for _, line := range strings.Split(logs, "\n") {
+		job, name, content, ok := splitLogPrefix(line)
+		if !ok || name == unknownStepName {
+			if current != nil {
+				if !ok {
+					job = current.Job
+				}
+				name = current.Name
+			}
+			if matches := groupRunRe.FindStringSubmatch(content); len(matches) > 1 {
+				name = strings.TrimSpace(matches[1])
+			}
+		}
+
+		if current == nil || current.Job != job || current.Name != name {
+			steps = append(steps, StepLog{Job: job, Name: name})
+			current = &steps[len(steps)-1]
+		}
+		current.Lines = append(current.Lines, line)
+
+		if matches := stepExitRe.FindStringSubmatch(content); len(matches) > 1 {
+			var code int
+			fmt.Sscanf(matches[1], "%d", &code)
+			if code != 0 {
+				current.ExitCode = code
+				current.Failed = true
+			}
+		}
+	}
+
+	return steps
+}
+
+// failingStepLeadInLines is the number of lines of the step before a failing step kept by
+// Options.FailingStepsOnly, e.g. the end of a build whose output the failing step relies on
+const failingStepLeadInLines = 10
+
+// failingStepsLogs keeps the steps that exited with a nonzero code and the last lines of the step
+// before each of them, dropping the output of the passing steps. Jobs without such a step (timed
+// out, cancelled, or logs without exit markers) are kept whole. It returns the logs and the number
+// of lines dropped.
+func failingStepsLogs(logs string) (string, int) {
+	steps := splitSteps(logs)
+	failingJobs := make(map[string]bool)
+	for _, step := range steps {
+		if step.Failed {
+			failingJobs[step.Job] = true
+		}
+	}
+	if len(failingJobs) == 0 {
+		return logs, 0
+	}
+
+	var kept []string
+	dropped, pending := 0, 0
+	flush := func() {
+		if pending > 0 {
+			kept = append(kept, truncationMarker("dropped %d lines of passing steps", pending))
+			dropped += pending
+			pending = 0
+		}
+	}
+	for i, step := range steps {
+		switch {
+		case step.Failed || !failingJobs[step.Job]:
+			flush()
+			kept = append(kept, step.Lines...)
+		case i+1 < len(steps) && steps[i+1].Job == step.Job && steps[i+1].Failed:
+			leadIn := min(len(step.Lines), failingStepLeadInLines)
+			pending += len(step.Lines) - leadIn
+			flush()
+			kept = append(kept, step.Lines[len(step.Lines)-leadIn:]...)
+		default:
+			pending += len(step.Lines)
+		}
+	}
+	flush()
+	return strings.Join(kept, "\n"), dropped
+}
+
+// failedStep returns the first step that exited with a nonzero code, or nil
+func failedStep(steps []StepLog) *StepLog {
+	for i := range steps {
+		if steps[i].Failed {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+// stepLabel returns a human readable "job / step" label
+func (s *StepLog) stepLabel() string {
+	if s.Job == "" {
+		return s.Name
+	}
+	if s.Name == "" {
+		return s.Job
+	}
+	return s.Job + " / " + s.Name
+}
+
+// otherStepsLogs joins the log lines of all steps except skip
+func otherStepsLogs(steps []StepLog, skip *StepLog) string {
+	var lines []string
+	for i := range steps {
+		if &steps[i] == skip {
+			continue
+		}
+		lines = append(lines, steps[i].Lines...)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,63 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// stepLines returns n output lines of a step, followed by its exit code line when exitCode is not 0
+func stepLines(job, step string, n, exitCode int) []string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("%s\t%s\t%s line %d", job, step, step, i))
+	}
+	if exitCode != 0 {
+		lines = append(lines, fmt.Sprintf("%s\t%s\t##[error]Process completed with exit code %d.", job, step, exitCode))
+	}
+	return lines
+}
+
+func TestFailingStepsLogs(t *testing.T) {
+	var logs, want []string
+	add := func(lines []string, kept ...string) {
+		logs = append(logs, lines...)
+		want = append(want, kept...)
+	}
+	compile := stepLines("build", "Compile", 15, 0)
+	test := stepLines("build", "Test", 4, 1)
+	lint := stepLines("lint", "Lint", 3, 0)
+	e2eSetup := stepLines("e2e", "Setup", 3, 0)
+	e2eRun := stepLines("e2e", "Run", 2, 2)
+	e2eWait := stepLines("e2e", "Wait", 20, 0)
+	e2eCleanup := stepLines("e2e", "Cleanup", 1, 1)
+
+	// build: the passing steps before the failing one are dropped but for the lead-in of the last of them
+	add(stepLines("build", "Checkout", 5, 0))
+	add(stepLines("build", "Setup Go", 3, 0))
+	add(compile, append([]string{truncationMarker("dropped %d lines of passing steps", 5+3+5)}, compile[5:]...)...)
+	add(test, test...)
+	add(stepLines("build", "Post", 2, 0))
+	// lint has no failing step and is kept whole
+	add(lint, append([]string{truncationMarker("dropped %d lines of passing steps", 2)}, lint...)...)
+	// e2e: two failing steps, each with the lead-in of the step before it
+	add(e2eSetup, e2eSetup...)
+	add(e2eRun, e2eRun...)
+	add(e2eWait, append([]string{truncationMarker("dropped %d lines of passing steps", 10)}, e2eWait[10:]...)...)
+	add(e2eCleanup, e2eCleanup...)
+
+	got, dropped := failingStepsLogs(strings.Join(logs, "\n"))
+	if dropped != 5+3+5+2+10 {
+		t.Errorf("dropped = %d, want %d", dropped, 5+3+5+2+10)
+	}
+	if wantLogs := strings.Join(want, "\n"); got != wantLogs {
+		t.Errorf("failingStepsLogs() =\n%s\nwant\n%s", got, wantLogs)
+	}
+}
+
+func TestFailingStepsLogsWithoutExitCode(t *testing.T) {
+	logs := strings.Join(append(stepLines("build", "Checkout", 5, 0), stepLines("build", "Test", 4, 0)...), "\n")
+	if got, dropped := failingStepsLogs(logs); got != logs || dropped != 0 {
+		t.Errorf("failingStepsLogs() dropped %d lines, want the logs kept whole", dropped)
+	}
+}
@@ -0,0 +1,54 @@
+package debugger
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxSummaryChars is the hard limit of the one-line summary, e.g. for a commit status description
+const MaxSummaryChars = 140
+
+var (
+	summaryMarkupRe   = regexp.MustCompile("[*_`#>]+")
+	summaryWhitespace = regexp.MustCompile(`\s+`)
+	sentenceEndRe     = regexp.MustCompile(`[.!?](\s|$)`)
+)
+
+// oneLineSummary returns the TL;DR of the proposal
+// The first line of the model's TL;DR section is used when present, otherwise the first sentence of the root cause
+// (or analysis). Markdown is removed and the result is cut to MaxSummaryChars characters.
+func oneLineSummary(tldr string, proposal *FixProposal) string {
+	text := ""
+	for _, line := range strings.Split(tldr, "\n") {
+		if text = strings.TrimSpace(line); text != "" {
+			break
+		}
+	}
+	if text == "" {
+		text = proposal.RootCause
+		if strings.TrimSpace(text) == "" {
+			text = proposal.Analysis
+		}
+		if loc := sentenceEndRe.FindStringIndex(text); loc != nil {
+			text = text[:loc[0]+1]
+		}
+	}
+	text = summaryMarkupRe.ReplaceAllString(text, "")
+	text = strings.TrimSpace(summaryWhitespace.ReplaceAllString(text, " "))
+	return truncateSummary(text)
+}
+
+// truncateSummary cuts text to MaxSummaryChars characters (not bytes), ending in "…" when cut
+func truncateSummary(text string) string {
+	if utf8.RuneCountInString(text) <= MaxSummaryChars {
+		return text
+	}
+	runes := []rune(text)[:MaxSummaryChars-1]
+	cut := strings.TrimRight(string(runes), " ,;:-")
+	// Prefer ending at a word boundary when one is close
+	if i := strings.LastIndex(cut, " "); i > len(cut)*3/4 {
+		cut = strings.TrimRight(cut[:i], " ,;:-")
+	}
+	return cut + "…"
+}
@@ -0,0 +1,57 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSummaryFromResponse(t *testing.T) {
+	response := testAnswer + "\n\n## TL;DR\n**go.sum** is missing the `golang.org/x/net` entry; run go mod tidy.\nA second line is ignored.\n"
+	p := (&GitHubWorkflowDebugger{}).parseFixProposal(response, &WorkflowRun{})
+	if want := "go.sum is missing the golang.org/x/net entry; run go mod tidy."; p.Summary != want {
+		t.Errorf("Summary = %q, want %q", p.Summary, want)
+	}
+
+	// Without a TL;DR section, the first sentence of the root cause is used
+	p = (&GitHubWorkflowDebugger{}).parseFixProposal(testAnswer, &WorkflowRun{})
+	if want := "The go.sum entry of golang.org/x/net is missing."; p.Summary != want {
+		t.Errorf("Summary = %q, want %q", p.Summary, want)
+	}
+}
+
+func TestOneLineSummaryBounded(t *testing.T) {
+	long := strings.Repeat("The integration test times out waiting for the database container ", 5)
+	tests := []struct {
+		name     string
+		tldr     string
+		proposal FixProposal
+		cut      bool
+	}{
+		{name: "long TL;DR", tldr: long, cut: true},
+		{name: "long root cause without a sentence end", proposal: FixProposal{RootCause: long}, cut: true},
+		{name: "multi-byte characters", tldr: strings.Repeat("Die Datenbank ist nicht erreichbar – Zeitüberschreitung ", 5), cut: true},
+		{name: "no word boundary", tldr: strings.Repeat("x", 500), cut: true},
+		{name: "analysis only", proposal: FixProposal{Analysis: "Line one\nstill the first sentence. Second sentence."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := oneLineSummary(tt.tldr, &tt.proposal)
+			if got == "" {
+				t.Fatal("summary is empty")
+			}
+			if n := utf8.RuneCountInString(got); n > MaxSummaryChars {
+				t.Errorf("summary has %d characters, want at most %d: %q", n, MaxSummaryChars, got)
+			}
+			if !utf8.ValidString(got) || strings.ContainsAny(got, "\n\t") {
+				t.Errorf("summary %q is not a valid single line", got)
+			}
+			if strings.HasSuffix(got, "…") != tt.cut {
+				t.Errorf("summary %q, want it cut with an ellipsis: %v", got, tt.cut)
+			}
+		})
+	}
+	if got := oneLineSummary("", &FixProposal{Analysis: "Line one\nstill the first sentence. Second sentence."}); got != "Line one still the first sentence." {
+		t.Errorf("oneLineSummary() = %q, want the first sentence on one line", got)
+	}
+}
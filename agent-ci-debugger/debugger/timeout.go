@@ -0,0 +1,135 @@
+package debugger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// minSilence is the shortest gap between two log lines reported as a silence of a timed-out step
+const minSilence = time.Minute
+
+// githubTimeoutRe matches the messages of GitHub's job and step timeouts, e.g.
+// "##[error]The job running on runner X has exceeded the maximum execution time of 360 minutes." or
+// "##[error]The action 'Run tests' has timed out after 10 minutes."
+var githubTimeoutRe = regexp.MustCompile(`(?i)has exceeded the maximum execution time of \d+ minutes|has timed out after \d+ minutes`)
+
+// StepTiming is how long the step that timed out ran, taken from the log timestamps
+type StepTiming struct {
+	Step     string // "job / step"
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+
+	// SilenceStart and Silence are the longest gap between two log lines of the step, the
+	// time the step printed nothing; Silence is 0 when no gap reached a minute
+	SilenceStart time.Time
+	Silence      time.Duration
+	SilenceAfter string // the last line printed before the silence
+}
+
+// Minutes returns the duration of the step in whole minutes, rounded down
+func (t *StepTiming) Minutes() int {
+	return int(t.Duration / time.Minute)
+}
+
+// String returns a one-line description such as
+// "test / Run tests ran 47m12s (10:02:11 to 10:49:23 UTC), silent for 45m after: Waiting for db..."
+func (t *StepTiming) String() string {
+	s := fmt.Sprintf("%s ran %s (%s to %s UTC)", t.Step, t.Duration.Round(time.Second),
+		t.Start.UTC().Format("15:04:05"), t.End.UTC().Format("15:04:05"))
+	if t.Silence > 0 {
+		s += fmt.Sprintf(", silent for %s after: %s", t.Silence.Round(time.Second), truncateText(t.SilenceAfter, 150))
+	}
+	return s
+}
+
+// logTimestamp returns the timestamp of a gh log line ("<job>\t<step>\t2024-01-02T10:02:11.1234567Z ...")
+func logTimestamp(line string) (time.Time, bool) {
+	if _, _, content, ok := splitLogPrefix(line); ok {
+		line = content
+	}
+	first, _, _ := strings.Cut(line, " ")
+	if !strings.HasSuffix(first, "Z") || !strings.Contains(first, "T") {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, first)
+	return ts, err == nil
+}
+
+// timedOutStep returns the step that timed out: the step of the first timeout message of GitHub or of
+// the logs, else the failed step, else the last step
+func timedOutStep(steps []StepLog) *StepLog {
+	var firstTimeout *StepLog
+	for i := range steps {
+		for _, line := range steps[i].Lines {
+			content := logContent(line)
+			if githubTimeoutRe.MatchString(content) {
+				return &steps[i]
+			}
+			if firstTimeout == nil && (strings.Contains(content, "Timed out") || strings.Contains(content, "timeout")) {
+				firstTimeout = &steps[i]
+			}
+		}
+	}
+	if firstTimeout != nil {
+		return firstTimeout
+	}
+	if step := failedStep(steps); step != nil {
+		return step
+	}
+	if len(steps) > 0 {
+		return &steps[len(steps)-1]
+	}
+	return nil
+}
+
+// stepTiming computes how long a step ran and its longest silence from the log timestamps,
+// or returns nil when the step has fewer than two timestamped lines
+func stepTiming(step *StepLog) *StepTiming {
+	timing := &StepTiming{Step: step.stepLabel()}
+	var prev time.Time
+	prevLine := ""
+	stamped := 0
+	for _, line := range step.Lines {
+		ts, ok := logTimestamp(line)
+		if !ok {
+			continue
+		}
+		if stamped == 0 {
+			timing.Start = ts
+		} else if gap := ts.Sub(prev); gap >= minSilence && gap > timing.Silence {
+			timing.Silence = gap
+			timing.SilenceStart = prev
+			timing.SilenceAfter = strings.TrimSpace(logContent(prevLine))
+		}
+		timing.End = ts
+		prev, prevLine = ts, line
+		stamped++
+	}
+	if stamped < 2 {
+		return nil
+	}
+	timing.Duration = timing.End.Sub(timing.Start)
+	return timing
+}
+
+// analyzeTimeout sets ErrorSummary.TimedOutStep when the run timed out (or force is set)
+func analyzeTimeout(run *WorkflowRun, force bool) {
+	timedOut := force || run.Conclusion == "timed_out" || len(run.ErrorSummary.Timeouts) > 0 ||
+		githubTimeoutRe.MatchString(run.FailedLogs)
+	if !timedOut {
+		return
+	}
+	step := timedOutStep(run.Steps)
+	if step == nil {
+		return
+	}
+	if timing := stepTiming(step); timing != nil {
+		run.ErrorSummary.TimedOutStep = timing
+		logInfof("Timed-out step: %s", timing)
+	} else {
+		logDebugf("No timestamps in the logs of %s, cannot tell how long it ran", step.stepLabel())
+	}
+}
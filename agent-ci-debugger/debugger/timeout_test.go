@@ -0,0 +1,74 @@
+package debugger
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// timedOutLines is a job cancelled by its 360 minute timeout while "Run go test" waited for a database
+var timedOutLines = []string{
+	"test\tSet up job\t2024-01-02T10:00:00.0000000Z Current runner version: '2.317.0'",
+	"test\tSet up job\t2024-01-02T10:00:05.0000000Z Complete job name: test",
+	"test\tRun go test\t2024-01-02T10:00:10.0000000Z ##[group]Run go test ./...",
+	"test\tRun go test\t2024-01-02T10:01:30.0000000Z === RUN   TestIntegration",
+	"test\tRun go test\t2024-01-02T10:01:31.0000000Z Waiting for db...",
+	"test\tRun go test\t2024-01-02T16:00:05.0000000Z ##[error]The job running on runner GitHub Actions 2 has exceeded the maximum execution time of 360 minutes.",
+	"test\tRun go test\t2024-01-02T16:00:05.5000000Z ##[error]The operation was canceled.",
+	"test\tPost Run actions/checkout@v4\t2024-01-02T16:00:06.0000000Z Post job cleanup.",
+	"test\tPost Run actions/checkout@v4\t2024-01-02T16:00:07.0000000Z Cleaning up orphan processes",
+}
+
+func TestTimedOutStep(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	run := &WorkflowRun{Conclusion: "cancelled", FailedLogs: strings.Join(timedOutLines, "\n")}
+	d.parseLogs(run)
+
+	// The cancelled step, not the cleanup after it, is the one that timed out
+	timing := run.ErrorSummary.TimedOutStep
+	if timing == nil {
+		t.Fatal("TimedOutStep = nil, want the cancelled step")
+	}
+	if timing.Step != "test / Run go test" {
+		t.Errorf("Step = %q, want the cancelled step", timing.Step)
+	}
+	if want := 5*time.Hour + 59*time.Minute + 55*time.Second + 500*time.Millisecond; timing.Duration != want || timing.Minutes() != 359 {
+		t.Errorf("Duration = %s, want %s", timing.Duration, want)
+	}
+	if timing.Silence != 5*time.Hour+58*time.Minute+34*time.Second || timing.SilenceAfter != "Waiting for db..." {
+		t.Errorf("Silence = %s after %q", timing.Silence, timing.SilenceAfter)
+	}
+	want := "test / Run go test ran 5h59m56s (10:00:10 to 16:00:05 UTC), silent for 5h58m34s after: Waiting for db..."
+	if got := timing.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	prompt := d.buildAnalysisPrompt(run, defaultLogBudget)
+	for _, want := range []string{"TIMED-OUT STEP: " + want, `What made step "test / Run go test" take more than 359 minutes?`} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt does not contain %q", want)
+		}
+	}
+	if got := heuristicProposal(run).RootCause; got != `Step "test / Run go test" timed out after 5h59m56s.` {
+		t.Errorf("offline RootCause = %q", got)
+	}
+}
+
+func TestTimedOutStepNotTimedOut(t *testing.T) {
+	d := &GitHubWorkflowDebugger{}
+	lines := append([]string{}, timedOutLines[:5]...)
+	lines = append(lines, "test\tRun go test\t2024-01-02T10:02:00.0000000Z ##[error]Process completed with exit code 1.")
+	run := &WorkflowRun{Conclusion: "failure", FailedLogs: strings.Join(lines, "\n")}
+	d.parseLogs(run)
+
+	if run.ErrorSummary.TimedOutStep != nil {
+		t.Errorf("TimedOutStep = %s, want nil for a run that did not time out", run.ErrorSummary.TimedOutStep)
+	}
+
+	// Without timestamps it cannot tell how long the step ran
+	run = &WorkflowRun{Conclusion: "timed_out", FailedLogs: "test\tRun go test\t=== RUN   TestIntegration\ntest\tRun go test\tWaiting for db..."}
+	d.parseLogs(run)
+	if run.ErrorSummary.TimedOutStep != nil {
+		t.Errorf("TimedOutStep = %s, want nil without timestamps", run.ErrorSummary.TimedOutStep)
+	}
+}
@@ -0,0 +1,318 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracing defaults
+const (
+	defaultServiceName = "github-workflow-debugger"
+	traceExportTimeout = 5 * time.Second
+)
+
+// Names of the spans of an analysis
+const (
+	SpanDebugRun    = "debug_run"
+	SpanFetch       = "fetch"
+	SpanParse       = "parse"
+	SpanBuildPrompt = "build_prompt"
+	SpanAPICall     = "api_call"
+)
+
+// OTLP span kinds and status codes
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusCodeError  = 2
+)
+
+// tracer exports the spans of analyses to an OpenTelemetry collector over OTLP/HTTP with JSON
+// encoding, which needs no dependencies. It is nil when no OTLP endpoint is configured, and then
+// no span is recorded at all.
+type tracer struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+}
+
+// tracerFromEnv configures the tracer from the standard OpenTelemetry environment variables:
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or OTEL_EXPORTER_OTLP_ENDPOINT, the _HEADERS of either,
+// OTEL_SERVICE_NAME and OTEL_SDK_DISABLED. It returns nil when tracing is not configured.
+func tracerFromEnv() *tracer {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil
+	}
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			return nil
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if protocol := os.Getenv(name); protocol != "" {
+			if protocol != "http/json" {
+				logWarnf("%s=%s is not supported, exporting traces as http/json", name, protocol)
+			}
+			break
+		}
+	}
+
+	headers := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	for k, v := range parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")) {
+		headers[k] = v
+	}
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	return &tracer{
+		endpoint:    endpoint,
+		headers:     headers,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: traceExportTimeout},
+	}
+}
+
+// parseOTLPHeaders parses the "key1=value1,key2=value2" format of OTEL_EXPORTER_OTLP_HEADERS,
+// whose values are URL-encoded
+func parseOTLPHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// trace collects the spans of one analysis
+// A debugger runs one analysis at a time, so the open spans form a stack: a new span is a child
+// of the innermost open one.
+type trace struct {
+	tracer *tracer
+	id     string
+	root   *span
+
+	mu    sync.Mutex
+	open  []*span
+	spans []*span
+}
+
+// span is a timed stage of an analysis; all its methods do nothing on a nil span
+type span struct {
+	trace    *trace
+	name     string
+	kind     int
+	id       string
+	parentID string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]any
+	err      error
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// startTrace starts the trace of the analysis of a run and returns its root span, nil when tracing
+// is not configured
+func (d *GitHubWorkflowDebugger) startTrace(repo, runID string) *span {
+	if d.tracer == nil {
+		return nil
+	}
+	d.trace = &trace{tracer: d.tracer, id: randomHex(16)}
+	d.trace.root = d.startSpan(SpanDebugRun, spanKindInternal)
+	d.trace.root.set("repository", repo)
+	d.trace.root.set("run.id", runID)
+	return d.trace.root
+}
+
+// rootSpan returns the root span of the current trace, nil without one
+func (d *GitHubWorkflowDebugger) rootSpan() *span {
+	if d.trace == nil {
+		return nil
+	}
+	return d.trace.root
+}
+
+// startSpan opens a span as a child of the innermost open span of the current trace
+func (d *GitHubWorkflowDebugger) startSpan(name string, kind int) *span {
+	t := d.trace
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &span{trace: t, name: name, kind: kind, id: randomHex(8), start: time.Now(), attrs: make(map[string]any)}
+	if len(t.open) > 0 {
+		s.parentID = t.open[len(t.open)-1].id
+	}
+	t.open = append(t.open, s)
+	t.spans = append(t.spans, s)
+	return s
+}
+
+// set adds an attribute: a string, bool, int or float64
+func (s *span) set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.attrs[key] = value
+}
+
+// fail marks the span as failed with err
+func (s *span) fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.err = err
+}
+
+// finish ends the span
+func (s *span) finish() {
+	if s == nil {
+		return
+	}
+	t := s.trace
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s.end = time.Now()
+	for i := len(t.open) - 1; i >= 0; i-- {
+		if t.open[i] == s {
+			t.open = append(t.open[:i], t.open[i+1:]...)
+			break
+		}
+	}
+}
+
+// endTrace finishes the open spans and exports the trace; export failures are only logged, they
+// must not fail the analysis
+func (d *GitHubWorkflowDebugger) endTrace(ctx context.Context) {
+	t := d.trace
+	if t == nil {
+		return
+	}
+	d.trace = nil
+	t.mu.Lock()
+	for _, s := range t.open {
+		s.end = time.Now()
+	}
+	t.open = nil
+	spans := t.spans
+	t.mu.Unlock()
+
+	if err := t.tracer.export(ctx, t.id, spans); err != nil {
+		logWarnf("failed to export trace: %v", err)
+		return
+	}
+	logDebugf("Exported trace %s (%d spans)", t.id, len(spans))
+}
+
+// otlpAttribute is a key-value attribute of the OTLP JSON encoding
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func otlpAttributes(attrs map[string]any) []otlpAttribute {
+	out := make([]otlpAttribute, 0, len(attrs))
+	for key, value := range attrs {
+		var v map[string]any
+		switch value := value.(type) {
+		case bool:
+			v = map[string]any{"boolValue": value}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(value)} // int64 values are strings in OTLP JSON
+		case float64:
+			v = map[string]any{"doubleValue": value}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(value)}
+		}
+		out = append(out, otlpAttribute{Key: key, Value: v})
+	}
+	return out
+}
+
+// export sends the spans as an OTLP ExportTraceServiceRequest
+func (t *tracer) export(ctx context.Context, traceID string, spans []*span) error {
+	var otlpSpans []map[string]any
+	for _, s := range spans {
+		o := map[string]any{
+			"traceId":           traceID,
+			"spanId":            s.id,
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attrs),
+		}
+		if s.parentID != "" {
+			o["parentSpanId"] = s.parentID
+		}
+		if s.err != nil {
+			o["status"] = map[string]any{"code": statusCodeError, "message": s.err.Error()}
+		}
+		otlpSpans = append(otlpSpans, o)
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": t.serviceName}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": defaultServiceName},
+				"spans": otlpSpans,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), traceExportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("POST %s: HTTP %d", t.endpoint, resp.StatusCode)
+	}
+	return nil
+}
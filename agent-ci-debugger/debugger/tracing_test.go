@@ -0,0 +1,197 @@
+package debugger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// otlpSpan is the part of an exported span the tests check
+type otlpSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Attributes   []struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+func (s otlpSpan) attr(key string) any {
+	for _, a := range s.Attributes {
+		if a.Key == key {
+			for _, v := range a.Value {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+// stubCollector serves an OTLP/HTTP collector and returns the spans it received
+func stubCollector(t *testing.T) func() ([]otlpSpan, http.Header) {
+	t.Helper()
+	var mu sync.Mutex
+	var spans []otlpSpan
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("invalid OTLP request: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		headers = r.Header.Clone()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL+"/")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20secret")
+	return func() ([]otlpSpan, http.Header) {
+		mu.Lock()
+		defer mu.Unlock()
+		return spans, headers
+	}
+}
+
+func TestTraceSpans(t *testing.T) {
+	routes := make(map[string]string)
+	batchRoutes(routes, 1, "failure")
+	stubGitHub(t, routes)
+	(&mockOpenAI{}).start(t)
+	collected := stubCollector(t)
+	d := New("test-key")
+	d.Options.Quiet = true
+
+	if _, err := d.DebugRun(context.Background(), RunRef{Repository: "o/r", RunID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	spans, headers := collected()
+	var names []string
+	byName := make(map[string]otlpSpan)
+	for _, s := range spans {
+		names = append(names, s.Name)
+		byName[s.Name] = s
+	}
+	want := []string{SpanDebugRun, SpanFetch, SpanParse, SpanBuildPrompt, SpanAPICall}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("spans = %v, want %v", names, want)
+	}
+	if got := headers.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the OTLP header", got)
+	}
+
+	root := byName[SpanDebugRun]
+	for _, s := range spans {
+		if s.TraceID != root.TraceID || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("span %s has IDs %s/%s, want one trace", s.Name, s.TraceID, s.SpanID)
+		}
+		if s.Status != nil {
+			t.Errorf("span %s failed: %s", s.Name, s.Status.Message)
+		}
+	}
+	parents := map[string]string{SpanDebugRun: "", SpanFetch: SpanDebugRun, SpanParse: SpanFetch, SpanBuildPrompt: SpanDebugRun, SpanAPICall: SpanDebugRun}
+	for name, parent := range parents {
+		wantID := ""
+		if parent != "" {
+			wantID = byName[parent].SpanID
+		}
+		if got := byName[name].ParentSpanID; got != wantID {
+			t.Errorf("parent of %s = %q, want %s", name, got, parent)
+		}
+	}
+	if root.attr("repository") != "o/r" || root.attr("run.id") != "1" || root.attr("model") != "gpt-4o-mini" || root.attr("heuristic") != false {
+		t.Errorf("root attributes = %+v", root.Attributes)
+	}
+	if call := byName[SpanAPICall]; call.Kind != spanKindClient || call.attr("gen_ai.request.model") != "gpt-4o-mini" || call.attr("gen_ai.usage.input_tokens") != "1000" {
+		t.Errorf("api_call = %+v", call)
+	}
+}
+
+func TestTraceFailedFetch(t *testing.T) {
+	stubGitHub(t, nil)
+	(&mockOpenAI{}).start(t)
+	collected := stubCollector(t)
+	d := New("test-key")
+	d.Options.Quiet = true
+
+	if _, err := d.DebugRun(context.Background(), RunRef{Repository: "o/r", RunID: "1"}); err == nil {
+		t.Fatal("DebugRun() of a missing run succeeded")
+	}
+	spans, _ := collected()
+	if len(spans) != 2 || spans[0].Name != SpanDebugRun || spans[1].Name != SpanFetch {
+		t.Fatalf("spans = %+v, want the root and fetch spans", spans)
+	}
+	for _, s := range spans {
+		if s.Status == nil || s.Status.Code != statusCodeError || !strings.Contains(s.Status.Message, "404") {
+			t.Errorf("span %s status = %+v, want the fetch error", s.Name, s.Status)
+		}
+	}
+}
+
+func TestTracerFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		endpoint string // "" for no tracer
+		service  string
+	}{
+		{"not configured", nil, "", ""},
+		{"base endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/"}, "http://collector:4318/v1/traces", defaultServiceName},
+		{"traces endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://traces:4318/custom",
+			"OTEL_SERVICE_NAME": "ci-debugger"}, "http://traces:4318/custom", "ci-debugger"},
+		{"disabled", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318", "OTEL_SDK_DISABLED": "TRUE"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_SERVICE_NAME", "OTEL_SDK_DISABLED"} {
+				t.Setenv(name, tt.env[name])
+			}
+			tr := tracerFromEnv()
+			if tt.endpoint == "" {
+				if tr != nil {
+					t.Errorf("tracer = %+v, want none", tr)
+				}
+				return
+			}
+			if tr == nil || tr.endpoint != tt.endpoint || tr.serviceName != tt.service {
+				t.Errorf("tracer = %+v, want endpoint %s and service %s", tr, tt.endpoint, tt.service)
+			}
+		})
+	}
+}
+
+func TestParseOTLPHeaders(t *testing.T) {
+	got := parseOTLPHeaders(" api-key = abc%3D ,x-team=ci,invalid,=empty")
+	want := map[string]string{"api-key": "abc=", "x-team": "ci"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOTLPHeaders() = %v, want %v", got, want)
+	}
+}
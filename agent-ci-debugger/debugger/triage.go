@@ -0,0 +1,128 @@
+package debugger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultTriageThreshold is the classification score from which a failure counts as a clear
+// infrastructure failure (Options.Triage)
+const DefaultTriageThreshold = 0.8
+
+// rateLimitPhrases identify failures caused by rate limits of GitHub or package registries
+var rateLimitPhrases = []string{
+	"api rate limit exceeded", "secondary rate limit", "rate limit exceeded", "429 too many requests",
+	"toomanyrequests", "you have reached your pull rate limit",
+}
+
+// infraClasses are the infrastructure failure classes of the pre-classification
+// rule names the heuristic rule proposing the fix, evidence counts the signs of the class in the run.
+var infraClasses = []struct {
+	rule     string
+	evidence func(run *WorkflowRun) int
+}{
+	{"out-of-memory", func(run *WorkflowRun) int {
+		n := countLogLines(run, outOfMemoryPhrases...)
+		if hasExitCode(run, 137) {
+			n++
+		}
+		return n
+	}},
+	{"disk-full", func(run *WorkflowRun) int {
+		n := 0
+		for _, f := range run.ErrorSummary.ResourceFailures {
+			if f.Kind == ResourceDiskFull {
+				n++
+			}
+		}
+		return n
+	}},
+	{"rate-limit", func(run *WorkflowRun) int { return countLogLines(run, rateLimitPhrases...) }},
+}
+
+// Classification is the result of pre-classifying a failure before the AI analysis
+type Classification struct {
+	Class string  // infrastructure failure class, e.g. "out-of-memory"
+	Score float64 // share of the failure evidence pointing at Class, from 0 to 1
+}
+
+// String describes the classification, e.g. "out-of-memory (score 0.75)"
+func (c Classification) String() string {
+	return fmt.Sprintf("%s (score %.2f)", c.Class, c.Score)
+}
+
+// classifyFailure pre-classifies a failure by its ErrorSummary, returning nil when no infrastructure
+// class matches
+// The score is the evidence of the best matching class over all evidence: signs of other infrastructure
+// classes and of code problems (compiler errors, assertions, Docker build steps, permissions, data races)
+// make the failure ambiguous.
+func classifyFailure(run *WorkflowRun) *Classification {
+	best, bestEvidence, total := "", 0, 0
+	for _, class := range infraClasses {
+		n := class.evidence(run)
+		if n > bestEvidence {
+			best, bestEvidence = class.rule, n
+		}
+		total += n
+	}
+	if bestEvidence == 0 {
+		return nil
+	}
+
+	s := run.ErrorSummary
+	total += len(s.CompilerErrors) + collapsedErrors(s.CompilerErrors) + len(s.Assertions) + len(s.BuildFailures) +
+		len(s.PermissionFailures) + len(s.LicenseViolations) + len(s.DataRaces)
+	return &Classification{Class: best, Score: float64(bestEvidence) / float64(total)}
+}
+
+// clearInfraFailure returns the classification of a clear infrastructure failure, or nil when the
+// failure needs the full analysis: no infrastructure class matches or its score is below the threshold
+func (d *GitHubWorkflowDebugger) clearInfraFailure(run *WorkflowRun) *Classification {
+	c := classifyFailure(run)
+	if c == nil {
+		logInfof("Pre-classification: no infrastructure failure recognized, escalating to the full analysis")
+		return nil
+	}
+	threshold := d.Options.TriageThreshold
+	if threshold == 0 {
+		threshold = DefaultTriageThreshold
+	}
+	if c.Score < threshold {
+		logInfof("Pre-classification: %s is below the threshold %.2f, escalating to the full analysis", c, threshold)
+		return nil
+	}
+	logInfof("Pre-classification: clear infrastructure failure %s", c)
+	return c
+}
+
+// triageProposal returns the canned proposal of the heuristic rule of a clear infrastructure failure
+func triageProposal(run *WorkflowRun, c *Classification) *FixProposal {
+	var proposal *FixProposal
+	for _, rule := range heuristicRules {
+		if rule.name == c.Class {
+			proposal = rule.propose(run)
+			break
+		}
+	}
+	proposal.Analysis += fmt.Sprintf("\n\nPre-classified as a clear infrastructure failure (%s), the AI analysis was skipped.", c)
+	if step := run.ErrorSummary.FailedStep; step != "" {
+		proposal.Analysis += fmt.Sprintf("\n\nThe failing step is `%s`.", step)
+	}
+	proposal.Heuristic = true
+	proposal.Summary = oneLineSummary("", proposal)
+	return proposal
+}
+
+// countLogLines returns the number of failed log lines containing any of the lowercase phrases
+func countLogLines(run *WorkflowRun, phrases ...string) int {
+	n := 0
+	for _, line := range strings.Split(strings.ToLower(run.FailedLogs), "\n") {
+		for _, phrase := range phrases {
+			if strings.Contains(line, phrase) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package debugger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// triageRun returns a failed run with the logs and their ErrorSummary
+func triageRun(d *GitHubWorkflowDebugger, lines ...string) *WorkflowRun {
+	run := testRun()
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString("build\tRun make\t2024-01-01T00:00:00Z " + line + "\n")
+	}
+	run.FailedLogs = sb.String()
+	run.ErrorSummary = d.parseErrorSummary(run.FailedLogs)
+	return run
+}
+
+func TestClassifyFailure(t *testing.T) {
+	d := New("test-key")
+	tests := []struct {
+		name  string
+		lines []string
+		want  string // classification, "" for none
+	}{
+		{"rate limit", []string{"API rate limit exceeded for installation", "##[error]Process completed with exit code 1."}, "rate-limit (score 1.00)"},
+		{"out of memory", []string{"fatal error: runtime: out of memory", "##[error]Process completed with exit code 137."}, "out-of-memory (score 1.00)"},
+		{"with compiler error", []string{"API rate limit exceeded for installation", "main.go:5:2: undefined: foo"}, "rate-limit (score 0.50)"},
+		{"code failure", []string{"main.go:5:2: undefined: foo", "##[error]Process completed with exit code 1."}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if c := classifyFailure(triageRun(d, tt.lines...)); c != nil {
+				got = c.String()
+			}
+			if got != tt.want {
+				t.Errorf("classifyFailure = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTriageSkipsClearInfraFailure(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, Triage: true}
+	run := triageRun(d, "Error: API rate limit exceeded for installation ID 1", "##[error]Process completed with exit code 1.")
+
+	result, err := d.debugRun(context.Background(), run, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(api.requests(t)); n != 0 {
+		t.Errorf("%d AI requests, want the analysis skipped", n)
+	}
+	p := result.Proposal
+	if !p.Heuristic || !strings.Contains(p.RootCause, "rate limit") || !strings.Contains(p.Analysis, "Pre-classified as a clear infrastructure failure (rate-limit (score 1.00))") {
+		t.Errorf("proposal = %+v, want the canned rate limit proposal", p)
+	}
+}
+
+func TestTriageEscalates(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold float64
+		lines     []string
+	}{
+		{"no infrastructure class", 0, []string{"main.go:5:2: undefined: foo", "##[error]Process completed with exit code 1."}},
+		{"below the default threshold", 0, []string{"API rate limit exceeded for installation", "main.go:5:2: undefined: foo"}},
+		{"below a custom threshold", 1.5, []string{"API rate limit exceeded for installation", "##[error]Process completed with exit code 1."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &mockOpenAI{}
+			d := api.start(t)
+			d.Options = Options{Quiet: true, Triage: true, TriageThreshold: tt.threshold}
+
+			result, err := d.debugRun(context.Background(), triageRun(d, tt.lines...), time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(api.requests(t)); n != 1 {
+				t.Errorf("%d AI requests, want the full analysis", n)
+			}
+			if p := result.Proposal; p.Heuristic || p.RootCause != "The go.sum entry of golang.org/x/net is missing." {
+				t.Errorf("proposal = %+v, want the AI analysis", p)
+			}
+		})
+	}
+}
+
+func TestTriageModel(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	d.Options = Options{Quiet: true, Triage: true, TriageModel: "gpt-4o-mini-triage"}
+	run := triageRun(d, "API rate limit exceeded for installation", "##[error]Process completed with exit code 1.")
+
+	result, err := d.debugRun(context.Background(), run, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := api.requests(t)
+	if len(requests) != 1 || requests[0].Model != "gpt-4o-mini-triage" {
+		t.Fatalf("requests = %d, want one with the triage model", len(requests))
+	}
+	if result.Proposal.Model != "gpt-4o-mini-triage" || !strings.Contains(result.Report, "gpt-4o-mini-triage") {
+		t.Errorf("Model = %q, want the triage model in the proposal and report", result.Proposal.Model)
+	}
+}
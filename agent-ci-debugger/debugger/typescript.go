@@ -0,0 +1,120 @@
+package debugger
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxTSContinuationLines bounds the indented detail lines of a tsc error kept as help
+const maxTSContinuationLines = 3
+
+var (
+	// tscParenRe matches plain tsc errors, e.g. "src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'."
+	tscParenRe = regexp.MustCompile(`^(\S.*?)\((\d+),(\d+)\): error (TS\d+): (.+)$`)
+	// tscPrettyRe matches tsc --pretty errors, e.g. "src/app.ts:12:5 - error TS2322: Type ..."
+	tscPrettyRe = regexp.MustCompile(`^(\S.*?):(\d+):(\d+) - error (TS\d+): (.+)$`)
+	// tscContinuationRe matches the indented detail lines below a tsc error
+	tscContinuationRe = regexp.MustCompile(`^\s{2,}(\S.*)$`)
+
+	// eslintFileRe matches the file header of ESLint's stylish output, a line holding only a source path
+	eslintFileRe = regexp.MustCompile(`^(\S+\.(?:[cm]?[jt]sx?|vue|svelte|astro))$`)
+	// eslintStylishRe matches an error below the file header, e.g. "  12:5  error  'x' is never used  no-unused-vars"
+	eslintStylishRe = regexp.MustCompile(`^\s+(\d+):(\d+)\s+error\s+(.+?)(?:\s{2,}(@?[\w./-]+))?\s*$`)
+	// eslintUnixRe matches ESLint's unix format, e.g. "src/app.ts:12:5: 'x' is never used [Error/no-unused-vars]"
+	eslintUnixRe = regexp.MustCompile(`^(\S+):(\d+):(\d+): (.+) \[Error/(\S+)\]$`)
+	// eslintCompactRe matches ESLint's compact format, e.g. "/src/app.ts: line 12, col 5, Error - 'x' is never used (no-unused-vars)"
+	eslintCompactRe = regexp.MustCompile(`^(\S+): line (\d+), col (\d+), Error - (.+?)(?: \((@?[\w./-]+)\))?$`)
+)
+
+// typescriptDetector recognizes TypeScript compiler (tsc) and ESLint errors
+// Both become compiler errors with their location and TS code or ESLint rule, so the prompt can
+// group them by file and the files end up in FilesToCheck. ESLint warnings are ignored.
+type typescriptDetector struct{}
+
+func (typescriptDetector) Name() string { return "typescript" }
+
+func (typescriptDetector) Detect(lines []string) []Finding {
+	var findings []Finding
+	add := func(ce CompilerError) {
+		findings = append(findings, Finding{Category: CategoryCompilerError, Message: ce.String(), Line: ce.LogLine, CompilerError: &ce})
+	}
+
+	eslintFile := ""
+	for i := 0; i < len(lines); i++ {
+		content := strings.TrimRight(stripANSI(logContent(lines[i])), " \r")
+
+		m := tscParenRe.FindStringSubmatch(content)
+		if m == nil {
+			m = tscPrettyRe.FindStringSubmatch(content)
+		}
+		if m != nil {
+			ce := tsError(m, i)
+			i = tsContinuation(lines, i, &ce)
+			add(ce)
+			continue
+		}
+
+		if m := eslintUnixRe.FindStringSubmatch(content); m != nil {
+			add(eslintError(m[1], m[2], m[3], m[4], m[5], i))
+			continue
+		}
+		if m := eslintCompactRe.FindStringSubmatch(content); m != nil {
+			add(eslintError(m[1], m[2], m[3], m[4], m[5], i))
+			continue
+		}
+
+		// Stylish output: the file on its own line, then its indented problems
+		if m := eslintFileRe.FindStringSubmatch(strings.TrimSpace(content)); m != nil && !strings.HasPrefix(content, " ") {
+			eslintFile = m[1]
+			continue
+		}
+		if eslintFile == "" {
+			continue
+		}
+		if m := eslintStylishRe.FindStringSubmatch(content); m != nil {
+			add(eslintError(eslintFile, m[1], m[2], m[3], m[4], i))
+		} else if strings.TrimSpace(content) == "" || !strings.HasPrefix(content, " ") {
+			// A blank or unindented line ends the file's block
+			eslintFile = ""
+		}
+	}
+
+	return findings
+}
+
+// tsError builds the compiler error of a tscParenRe or tscPrettyRe match
+func tsError(m []string, logLine int) CompilerError {
+	line, _ := strconv.Atoi(m[2])
+	column, _ := strconv.Atoi(m[3])
+	return CompilerError{Tool: "tsc", Code: m[4], Message: strings.TrimSpace(m[5]), File: workspacePath(m[1]),
+		Line: line, Column: column, LogLine: logLine}
+}
+
+// tsContinuation adds the indented detail lines below a tsc error (e.g. "Property 'x' is missing")
+// as help and returns the index of the last line consumed
+func tsContinuation(lines []string, i int, ce *CompilerError) int {
+	for i+1 < len(lines) && len(ce.Help) < maxTSContinuationLines {
+		next := strings.TrimRight(stripANSI(logContent(lines[i+1])), " \r")
+		m := tscContinuationRe.FindStringSubmatch(next)
+		if m == nil {
+			break
+		}
+		ce.Help = append(ce.Help, "note: "+m[1])
+		i++
+	}
+	return i
+}
+
+// eslintError builds the compiler error of an ESLint problem
+func eslintError(file, line, column, message, rule string, logLine int) CompilerError {
+	l, _ := strconv.Atoi(line)
+	c, _ := strconv.Atoi(column)
+	return CompilerError{Tool: "eslint", Code: rule, Message: strings.TrimSpace(message), File: workspacePath(file),
+		Line: l, Column: c, LogLine: logLine}
+}
+
+// workspacePath makes a path of the runner's checkout repo-relative
+func workspacePath(path string) string {
+	return strings.TrimPrefix(runnerWorkspaceRe.ReplaceAllString(strings.ReplaceAll(path, "\\", "/"), ""), "./")
+}
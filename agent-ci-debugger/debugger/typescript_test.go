@@ -0,0 +1,106 @@
+package debugger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTypescriptDetector(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []CompilerError
+	}{
+		{
+			name: "tsc",
+			lines: fixtureLines("build", "Run npx tsc --noEmit",
+				"src/app.ts(12,5): error TS2322: Type 'string' is not assignable to type 'number'.",
+				"/home/runner/work/app/app/src/user.ts(3,10): error TS2305: Module '\"./types\"' has no exported member 'User'.",
+				"Found 2 errors in 2 files."),
+			want: []CompilerError{
+				{Tool: "tsc", Code: "TS2322", Message: "Type 'string' is not assignable to type 'number'.", File: "src/app.ts", Line: 12, Column: 5},
+				{Tool: "tsc", Code: "TS2305", Message: "Module '\"./types\"' has no exported member 'User'.", File: "src/user.ts", Line: 3, Column: 10, LogLine: 1},
+			},
+		},
+		{
+			name: "tsc pretty",
+			lines: fixtureLines("build", "Run npm run build",
+				"\x1b[96msrc/app.ts\x1b[0m:\x1b[93m12\x1b[0m:\x1b[93m5\x1b[0m - \x1b[91merror\x1b[0m\x1b[90m TS2741: \x1b[0mProperty 'id' is missing in type '{}' but required in type 'User'.",
+				"",
+				"12   const user: User = {};",
+				"           ~~~~"),
+			want: []CompilerError{
+				{Tool: "tsc", Code: "TS2741", Message: "Property 'id' is missing in type '{}' but required in type 'User'.", File: "src/app.ts", Line: 12, Column: 5},
+			},
+		},
+		{
+			name: "tsc continuation",
+			lines: fixtureLines("build", "Run npx tsc --noEmit",
+				"src/app.ts(20,7): error TS2345: Argument of type '{ name: string; }' is not assignable to parameter of type 'User'.",
+				"  Property 'id' is missing in type '{ name: string; }' but required in type 'User'.",
+				"src/app.ts(30,1): error TS1005: ';' expected."),
+			want: []CompilerError{
+				{
+					Tool: "tsc", Code: "TS2345", Message: "Argument of type '{ name: string; }' is not assignable to parameter of type 'User'.",
+					File: "src/app.ts", Line: 20, Column: 7, Help: []string{"note: Property 'id' is missing in type '{ name: string; }' but required in type 'User'."},
+				},
+				{Tool: "tsc", Code: "TS1005", Message: "';' expected.", File: "src/app.ts", Line: 30, Column: 1, LogLine: 2},
+			},
+		},
+		{
+			name: "eslint stylish",
+			lines: fixtureLines("lint", "Run npx eslint .",
+				"",
+				"/home/runner/work/app/app/src/app.ts",
+				"  12:5   error    'x' is assigned a value but never used  @typescript-eslint/no-unused-vars",
+				"  14:1   warning  Unexpected console statement            no-console",
+				"  20:10  error    Missing return type on function",
+				"",
+				"src/util.js",
+				"  3:1  error  Parsing error: Unexpected token  ",
+				"",
+				"✖ 4 problems (3 errors, 1 warning)"),
+			want: []CompilerError{
+				{Tool: "eslint", Code: "@typescript-eslint/no-unused-vars", Message: "'x' is assigned a value but never used", File: "src/app.ts", Line: 12, Column: 5, LogLine: 2},
+				{Tool: "eslint", Message: "Missing return type on function", File: "src/app.ts", Line: 20, Column: 10, LogLine: 4},
+				{Tool: "eslint", Message: "Parsing error: Unexpected token", File: "src/util.js", Line: 3, Column: 1, LogLine: 7},
+			},
+		},
+		{
+			name: "eslint unix and compact",
+			lines: fixtureLines("lint", "Run npx eslint .",
+				"src/app.ts:12:5: 'x' is never used [Error/no-unused-vars]",
+				"src/app.ts:13:1: Unexpected console statement [Warning/no-console]",
+				"/home/runner/work/app/app/src/app.ts: line 14, col 2, Error - Missing semicolon. (semi)"),
+			want: []CompilerError{
+				{Tool: "eslint", Code: "no-unused-vars", Message: "'x' is never used", File: "src/app.ts", Line: 12, Column: 5},
+				{Tool: "eslint", Code: "semi", Message: "Missing semicolon.", File: "src/app.ts", Line: 14, Column: 2, LogLine: 2},
+			},
+		},
+		{
+			name: "no errors",
+			lines: fixtureLines("lint", "Run npx eslint .",
+				"src/app.ts",
+				"  14:1  warning  Unexpected console statement  no-console",
+				"",
+				"  12:5  error  not below a file header after the blank line",
+				"README.md"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []CompilerError
+			for _, f := range (typescriptDetector{}).Detect(tt.lines) {
+				if f.Category != CategoryCompilerError || f.CompilerError == nil || f.Message != f.CompilerError.String() || f.Line != f.CompilerError.LogLine {
+					t.Errorf("finding = %+v", f)
+					continue
+				}
+				got = append(got, *f.CompilerError)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compiler errors = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
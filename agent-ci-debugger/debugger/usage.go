@@ -0,0 +1,181 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// usageMu serializes appends of the analyses of a batch within this process
+var usageMu sync.Mutex
+
+// UsageEntry is one AI analysis in the usage ledger (Options.UsageLedger)
+type UsageEntry struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Repository       string    `json:"repository"`
+	RunID            string    `json:"run_id"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	ReasoningTokens  int       `json:"reasoning_tokens,omitempty"` // part of the completion tokens spent on hidden reasoning
+	Cost             float64   `json:"cost_usd"`                   // 0 when the model price is unknown
+}
+
+// UsageTotal is the usage of a model on a day (UTC)
+type UsageTotal struct {
+	Day              string // YYYY-MM-DD
+	Model            string
+	Analyses         int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// DefaultUsageLedgerPath returns the usage ledger in the user cache directory
+func DefaultUsageLedgerPath() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	return filepath.Join(base, "github-workflow-debugger", "usage.jsonl"), nil
+}
+
+// newUsageEntry records the tokens and cost of an AI analysis
+func (d *GitHubWorkflowDebugger) newUsageEntry(run *WorkflowRun, proposal *FixProposal) UsageEntry {
+	return UsageEntry{
+		Timestamp:        time.Now().UTC(),
+		Repository:       run.Repository,
+		RunID:            run.RunID,
+		Model:            d.proposalModel(proposal),
+		PromptTokens:     proposal.Usage.PromptTokens,
+		CompletionTokens: proposal.Usage.CompletionTokens,
+		ReasoningTokens:  reasoningTokens(proposal.Usage),
+		Cost:             proposal.Cost,
+	}
+}
+
+// AppendUsage appends the entry as one JSON line to the usage ledger at path, creating it and its directory
+// Like the audit log, each entry is a single append-mode write, so concurrent processes never interleave lines.
+func AppendUsage(path string, entry UsageEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create usage ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %w", err)
+	}
+	return nil
+}
+
+// LoadUsage reads the entries of the usage ledger at path
+// A missing ledger means no usage yet. Lines that cannot be parsed, e.g. cut short by a full disk,
+// are skipped with a warning.
+func LoadUsage(path string) ([]UsageEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []UsageEntry
+	skipped := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry UsageEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	if skipped > 0 {
+		logWarnf("skipped %d malformed lines of the usage ledger %s", skipped, path)
+	}
+	return entries, nil
+}
+
+// SummarizeUsage totals the entries by day (UTC) and model, oldest day first
+func SummarizeUsage(entries []UsageEntry) []UsageTotal {
+	index := make(map[[2]string]int)
+	var totals []UsageTotal
+	for _, e := range entries {
+		key := [2]string{e.Timestamp.UTC().Format("2006-01-02"), e.Model}
+		i, ok := index[key]
+		if !ok {
+			i = len(totals)
+			index[key] = i
+			totals = append(totals, UsageTotal{Day: key[0], Model: key[1]})
+		}
+		totals[i].Analyses++
+		totals[i].PromptTokens += e.PromptTokens
+		totals[i].CompletionTokens += e.CompletionTokens
+		totals[i].Cost += e.Cost
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Day != totals[j].Day {
+			return totals[i].Day < totals[j].Day
+		}
+		return totals[i].Model < totals[j].Model
+	})
+	return totals
+}
+
+// FormatUsage renders the totals as a table with a grand total line
+func FormatUsage(totals []UsageTotal) string {
+	if len(totals) == 0 {
+		return "No usage recorded yet.\n"
+	}
+	width := len("Model")
+	for _, t := range totals {
+		width = max(width, len(t.Model))
+	}
+	var sb strings.Builder
+	row := func(day, model string, analyses, prompt, completion int, cost float64) {
+		sb.WriteString(fmt.Sprintf("%-10s  %-*s  %8d  %12d  %12d  %10s\n", day, width, model, analyses, prompt, completion, fmt.Sprintf("$%.4f", cost)))
+	}
+	sb.WriteString(fmt.Sprintf("%-10s  %-*s  %8s  %12s  %12s  %10s\n", "Day", width, "Model", "Analyses", "Prompt", "Completion", "Cost"))
+	var sum UsageTotal
+	for _, t := range totals {
+		row(t.Day, t.Model, t.Analyses, t.PromptTokens, t.CompletionTokens, t.Cost)
+		sum.Analyses += t.Analyses
+		sum.PromptTokens += t.PromptTokens
+		sum.CompletionTokens += t.CompletionTokens
+		sum.Cost += t.Cost
+	}
+	row("Total", "", sum.Analyses, sum.PromptTokens, sum.CompletionTokens, sum.Cost)
+	sb.WriteString("Costs of models with an unknown price are counted as $0 (see --prices-file).\n")
+	return sb.String()
+}
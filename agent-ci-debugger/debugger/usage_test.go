@@ -0,0 +1,109 @@
+package debugger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUsageLedgerAccumulates(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	ledger := filepath.Join(t.TempDir(), "cache", "usage.jsonl")
+	d.Options = Options{Quiet: true, UsageLedger: ledger}
+
+	for _, id := range []string{"1", "2"} {
+		run := testRun()
+		run.RunID = id
+		if _, err := d.debugRun(context.Background(), run, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := LoadUsage(ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].RunID != "1" || entries[1].RunID != "2" {
+		t.Fatalf("entries = %+v, want one per run", entries)
+	}
+	e := entries[0]
+	if e.Repository != "o/r" || e.Model != "gpt-4o-mini" || e.PromptTokens != 1000 || e.CompletionTokens != 100 || e.Cost <= 0 {
+		t.Errorf("entry = %+v, want the tokens and cost of the analysis", e)
+	}
+
+	totals := SummarizeUsage(entries)
+	if len(totals) != 1 {
+		t.Fatalf("totals = %+v, want one day and model", totals)
+	}
+	if got := totals[0]; got.Analyses != 2 || got.PromptTokens != 2000 || got.CompletionTokens != 200 || got.Cost != entries[0].Cost+entries[1].Cost {
+		t.Errorf("total = %+v, want both analyses added up", got)
+	}
+}
+
+func TestUsageLedgerSkipsCachedAnalyses(t *testing.T) {
+	api := &mockOpenAI{}
+	d := api.start(t)
+	ledger := filepath.Join(t.TempDir(), "usage.jsonl")
+	d.Options = Options{Quiet: true, UsageLedger: ledger, CacheResponses: true}
+
+	for i := 0; i < 2; i++ {
+		if _, err := d.debugRun(context.Background(), testRun(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := LoadUsage(ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.requests(t)) != 1 || len(entries) != 1 {
+		t.Errorf("%d requests and %d ledger entries, want the cached analysis not recorded", len(api.requests(t)), len(entries))
+	}
+}
+
+func TestLoadUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	if entries, err := LoadUsage(path); err != nil || entries != nil {
+		t.Errorf("missing ledger = %+v, %v, want no usage", entries, err)
+	}
+	entry := UsageEntry{Timestamp: time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC), Repository: "o/r", RunID: "1", Model: "gpt-4o", PromptTokens: 10, Cost: 0.5}
+	if err := AppendUsage(path, entry); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("\n{\"timestamp\":\"2024-05-0\n")
+	f.Close()
+
+	entries, err := LoadUsage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, []UsageEntry{entry}) {
+		t.Errorf("entries = %+v, want the valid entry only", entries)
+	}
+}
+
+func TestFormatUsage(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 5, d, h, 0, 0, 0, time.UTC) }
+	totals := SummarizeUsage([]UsageEntry{
+		{Timestamp: day(2, 9), Model: "gpt-4o-mini", PromptTokens: 1000, CompletionTokens: 100, Cost: 0.0002},
+		{Timestamp: day(1, 23), Model: "gpt-4o", PromptTokens: 2000, CompletionTokens: 300, Cost: 0.008},
+		{Timestamp: day(2, 10), Model: "gpt-4o-mini", PromptTokens: 3000, CompletionTokens: 200, Cost: 0.0006},
+	})
+	want := "Day         Model        Analyses        Prompt    Completion        Cost\n" +
+		"2024-05-01  gpt-4o              1          2000           300     $0.0080\n" +
+		"2024-05-02  gpt-4o-mini         2          4000           300     $0.0008\n" +
+		"Total                           3          6000           600     $0.0088\n" +
+		"Costs of models with an unknown price are counted as $0 (see --prices-file).\n"
+	if got := FormatUsage(totals); got != want {
+		t.Errorf("FormatUsage() =\n%s\nwant\n%s", got, want)
+	}
+	if got := FormatUsage(nil); got != "No usage recorded yet.\n" {
+		t.Errorf("FormatUsage(nil) = %q", got)
+	}
+}
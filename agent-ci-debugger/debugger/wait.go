@@ -0,0 +1,72 @@
+package debugger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Defaults for waiting on runs that have not completed yet
+const (
+	DefaultWaitInterval = 30 * time.Second
+	DefaultWaitTimeout  = 30 * time.Minute
+)
+
+// ErrRunInProgress is returned when the run has not completed and waiting is not enabled
+// Logs of an unfinished run are partial, so analyzing them gives misleading results.
+var ErrRunInProgress = errors.New("run has not completed yet")
+
+// runStatus is the run metadata returned by gh run view
+type runStatus struct {
+	Status             string `json:"status"`
+	Conclusion         string `json:"conclusion"`
+	WorkflowName       string `json:"workflowName"`
+	WorkflowDatabaseID int64  `json:"workflowDatabaseId"`
+	HeadSHA            string `json:"headSha"`
+}
+
+// fetchRunStatus reads the status and metadata of a run
+func fetchRunStatus(repo, runID string) (*runStatus, error) {
+	output, err := gh("run", "view", runID, "--repo", repo,
+		"--json", "status,conclusion,workflowName,workflowDatabaseId,headSha")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow status: %w", err)
+	}
+
+	var status runStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse status: %w", err)
+	}
+	return &status, nil
+}
+
+// waitForCompletion polls the run status until the run completes or the wait timeout expires
+func (d *GitHubWorkflowDebugger) waitForCompletion(repo, runID string) (*runStatus, error) {
+	interval := d.Options.WaitInterval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+	timeout := d.Options.WaitTimeout
+	if timeout <= 0 {
+		timeout = DefaultWaitTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := fetchRunStatus(repo, runID)
+		if err != nil {
+			return nil, err
+		}
+		if status.Status == "completed" {
+			logInfof("Run %s completed with conclusion %q", runID, status.Conclusion)
+			return status, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("%w: still %q after waiting %s", ErrRunInProgress, status.Status, timeout)
+		}
+
+		d.statusf("Run is %s, checking again in %s...\n", status.Status, interval)
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,76 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// runStatusHandler serves run 1 of o/r, in progress for the first pending status requests
+func runStatusHandler(pending int32, requests *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/actions/runs/1":
+			if requests.Add(1) <= pending {
+				fmt.Fprint(w, `{"status":"in_progress","conclusion":null,"name":"CI"}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"completed","conclusion":"failure","name":"CI","head_sha":"abc123"}`)
+		case "/repos/o/r/actions/runs/1/jobs":
+			fmt.Fprint(w, `{"total_count":0,"jobs":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not Found"}`)
+		}
+	})
+}
+
+func TestFetchRunInProgress(t *testing.T) {
+	var requests atomic.Int32
+	stubGitHubHandler(t, runStatusHandler(1000, &requests))
+
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true}}
+	_, err := d.FetchRun(RunRef{Repository: "o/r", RunID: "1"})
+	if !errors.Is(err, ErrRunInProgress) {
+		t.Fatalf("FetchRun() error = %v, want ErrRunInProgress", err)
+	}
+	if !strings.Contains(err.Error(), `"in_progress"`) {
+		t.Errorf("error %q does not name the status", err)
+	}
+	// The logs of an unfinished run are never fetched
+	if n := requests.Load(); n != 1 {
+		t.Errorf("status was requested %d times, want once", n)
+	}
+}
+
+func TestFetchRunWaitTimeout(t *testing.T) {
+	var requests atomic.Int32
+	stubGitHubHandler(t, runStatusHandler(1000, &requests))
+
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true, Wait: true, WaitInterval: 10 * time.Millisecond, WaitTimeout: 50 * time.Millisecond}}
+	_, err := d.FetchRun(RunRef{Repository: "o/r", RunID: "1"})
+	if !errors.Is(err, ErrRunInProgress) || !strings.Contains(err.Error(), "after waiting") {
+		t.Fatalf("FetchRun() error = %v, want ErrRunInProgress after waiting", err)
+	}
+}
+
+func TestFetchRunWaitsForCompletion(t *testing.T) {
+	var requests atomic.Int32
+	stubGitHubHandler(t, runStatusHandler(3, &requests))
+
+	d := &GitHubWorkflowDebugger{Options: Options{Quiet: true, Wait: true, WaitInterval: time.Millisecond, WaitTimeout: time.Minute}}
+	run, err := d.FetchRun(RunRef{Repository: "o/r", RunID: "1"})
+	if err != nil {
+		t.Fatalf("FetchRun() error = %v", err)
+	}
+	if run.Status != "completed" || run.Conclusion != "failure" || run.HeadSHA != "abc123" {
+		t.Errorf("run = %s/%s %s, want the completed run", run.Status, run.Conclusion, run.HeadSHA)
+	}
+	if n := requests.Load(); n < 4 {
+		t.Errorf("status was requested %d times, want it polled until completed", n)
+	}
+}
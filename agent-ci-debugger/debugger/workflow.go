@@ -0,0 +1,66 @@
+package debugger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxWorkflowChars caps the workflow definition included in the prompt
+const maxWorkflowChars = 6000
+
+// fetchWorkflowDefinition reads the workflow file of the run at the run's commit
+// The workflow path is resolved from the workflow ID, then the file is read via the contents API.
+func fetchWorkflowDefinition(run *WorkflowRun) error {
+	if run.WorkflowID == 0 {
+		return fmt.Errorf("workflow ID of run %s is unknown", run.RunID)
+	}
+
+	workflowOutput, err := gh("api", fmt.Sprintf("repos/%s/actions/workflows/%d", run.Repository, run.WorkflowID))
+	if err != nil {
+		return fmt.Errorf("failed to get workflow: %w", err)
+	}
+
+	var workflow struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(workflowOutput, &workflow); err != nil {
+		return fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	run.WorkflowPath = workflow.Path
+
+	contentsURL := fmt.Sprintf("repos/%s/contents/%s", run.Repository, workflow.Path)
+	if run.HeadSHA != "" {
+		contentsURL += "?ref=" + run.HeadSHA
+	}
+	logDebugf("Fetching workflow definition %s at %s...", workflow.Path, run.HeadSHA)
+	content, err := gh("api", contentsURL, "-H", "Accept: application/vnd.github.raw")
+	if err != nil {
+		return fmt.Errorf("failed to get workflow file %s: %w", workflow.Path, err)
+	}
+
+	run.WorkflowYAML = string(content)
+	logDebugf("Fetched workflow definition (%d bytes)", len(content))
+	return nil
+}
+
+// trimWorkflowYAML drops comment-only and blank lines to save prompt space
+func trimWorkflowYAML(yaml string) string {
+	var kept []string
+	for _, line := range strings.Split(yaml, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.Join(kept, "\n")
+}
+
+// truncateText cuts text to maxChars, appending a marker with the number of dropped characters
+func truncateText(text string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+	return text[:maxChars] + "\n" + truncationMarker("truncated %d chars", len(text)-maxChars)
+}
@@ -0,0 +1,88 @@
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoStepSummary is returned by WriteStepSummary outside of GitHub Actions
+var ErrNoStepSummary = errors.New("GITHUB_STEP_SUMMARY is not set, the job summary is only available in GitHub Actions")
+
+// escapeCommandData escapes the message of a workflow command
+func escapeCommandData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeCommandProperty escapes a property value of a workflow command
+func escapeCommandProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// workflowCommand formats a GitHub Actions workflow command such as
+// "::error file=app.js,line=1,col=5,title=Failed test::message"; properties are "key=value"
+// pairs in order, empty values are left out
+func workflowCommand(command, message string, properties ...string) string {
+	var props []string
+	for _, p := range properties {
+		key, value, _ := strings.Cut(p, "=")
+		if value != "" {
+			props = append(props, key+"="+escapeCommandProperty(value))
+		}
+	}
+	if len(props) == 0 {
+		return fmt.Sprintf("::%s::%s", command, escapeCommandData(message))
+	}
+	return fmt.Sprintf("::%s %s::%s", command, strings.Join(props, ","), escapeCommandData(message))
+}
+
+// WorkflowCommands renders the analysis as GitHub Actions workflow commands, which the runner turns
+// into annotations when printed by a step: a notice with the root cause, then an ::error for every
+// located finding and a ::notice for every file to check. Locations of check-run annotations are
+// left out, the Actions UI shows them already.
+func WorkflowCommands(run *WorkflowRun, proposal *FixProposal) []string {
+	var commands []string
+	if proposal != nil {
+		summary := proposal.Summary
+		if summary == "" {
+			summary = proposal.RootCause
+		}
+		if summary != "" {
+			commands = append(commands, workflowCommand("notice", oneLine(summary), "title=Workflow failure analysis"))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range locatedEntries(run, proposal) {
+		if e.annotation || seen[e.String()] {
+			continue
+		}
+		seen[e.String()] = true
+		col := ""
+		if e.col > 1 {
+			col = fmt.Sprint(e.col)
+		}
+		commands = append(commands, workflowCommand(e.level, e.message,
+			"file="+e.file, fmt.Sprintf("line=%d", e.line), "col="+col, "title=Workflow debugger"))
+	}
+	return commands
+}
+
+// WriteStepSummary appends the report to the job summary of the current GitHub Actions step
+func WriteStepSummary(report string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return ErrNoStepSummary
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open the job summary: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strings.TrimRight(report, "\n") + "\n"); err != nil {
+		return fmt.Errorf("failed to write the job summary: %w", err)
+	}
+	logInfof("Wrote the report to the job summary")
+	return nil
+}
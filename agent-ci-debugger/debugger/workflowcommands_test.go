@@ -0,0 +1,74 @@
+package debugger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkflowCommand(t *testing.T) {
+	tests := []struct {
+		command    string
+		message    string
+		properties []string
+		want       string
+	}{
+		{command: "error", message: "plain", want: "::error::plain"},
+		{command: "error", message: "100% broken\r\nsecond line", want: "::error::100%25 broken%0D%0Asecond line"},
+		{
+			command:    "error",
+			message:    "a: b, c",
+			properties: []string{"file=dir/a,b.go", "line=3", "col=", "title=Step: build"},
+			want:       "::error file=dir/a%2Cb.go,line=3,title=Step%3A build::a: b, c",
+		},
+		{command: "notice", message: "m", properties: []string{"title="}, want: "::notice::m"},
+	}
+	for _, tt := range tests {
+		if got := workflowCommand(tt.command, tt.message, tt.properties...); got != tt.want {
+			t.Errorf("workflowCommand(%q, %q, %q) = %q, want %q", tt.command, tt.message, tt.properties, got, tt.want)
+		}
+	}
+}
+
+func TestWorkflowCommands(t *testing.T) {
+	proposal := &FixProposal{RootCause: "the parser rejects empty input", FilesToCheck: []string{"app/parse.py:17", "go.mod"}}
+	got := WorkflowCommands(quickfixRun(), proposal)
+	want := []string{
+		"::notice title=Workflow failure analysis::the parser rejects empty input",
+		"::error file=app/parse.py,line=17,title=Workflow debugger::ValueError: invalid literal (in parse)",
+		"::error file=app/main.py,line=3,title=Workflow debugger::ValueError: invalid literal (in <module>)",
+		"::error file=src/lib.rs,line=9,col=5,title=Workflow debugger::error[E0308]: mismatched types",
+		`::error file=format_test.go,line=28,title=Workflow debugger::expected "UTC", actual "Local"`,
+		"::error file=parse_test.go,line=12,title=Workflow debugger::--- FAIL: TestParse (0.00s) parse_test.go:12: unexpected error",
+		"::notice file=app/parse.py,line=17,title=Workflow debugger::file to check: the parser rejects empty input",
+		"::notice file=go.mod,line=1,title=Workflow debugger::file to check: the parser rejects empty input",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WorkflowCommands() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	proposal.Summary = "Empty input\nis rejected"
+	if got := WorkflowCommands(&WorkflowRun{}, proposal); len(got) != 3 || got[0] != "::notice title=Workflow failure analysis::Empty input is rejected" {
+		t.Errorf("WorkflowCommands() with a summary = %q, want the summary on one line", got)
+	}
+}
+
+func TestWriteStepSummary(t *testing.T) {
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if err := WriteStepSummary("report"); !errors.Is(err, ErrNoStepSummary) {
+		t.Errorf("WriteStepSummary() outside of Actions error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "summary.md")
+	os.WriteFile(path, []byte("# Earlier step\n"), 0644)
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+	if err := WriteStepSummary("# Report\n\n"); err != nil {
+		t.Fatalf("WriteStepSummary() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Earlier step\n# Report\n" {
+		t.Errorf("job summary = %q, want the report appended", data)
+	}
+}
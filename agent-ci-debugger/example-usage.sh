@@ -29,7 +29,7 @@ fi
 # Build the debugger if it doesn't exist
 if [ ! -f ./github-workflow-debugger ]; then
     echo "Building github-workflow-debugger..."
-    go build -o github-workflow-debugger github-workflow-debugger.go
+    go build -o github-workflow-debugger .
     if [ $? -ne 0 ]; then
         echo "Error: Failed to build github-workflow-debugger"
         exit 1
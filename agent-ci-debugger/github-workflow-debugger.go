@@ -2,659 +2,775 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/exec"
-	"regexp"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
-	openai "github.com/sashabaranov/go-openai"
+	"github.com/konveyor/github-workflow-debugger/debugger"
 )
 
-// WorkflowRun represents a GitHub Actions workflow run
-type WorkflowRun struct {
-	URL          string
-	RunID        string
-	Repository   string
-	Status       string
-	Conclusion   string
-	FailedLogs   string
-	FullLogs     string
-	ErrorSummary ErrorSummary
-}
+// The command logs through the debugger package so both share the level set by --verbose/--quiet
 
-// ErrorSummary contains structured information about the failure
-type ErrorSummary struct {
-	FailedJobs    []string
-	ErrorMessages []string
-	Timeouts      []string
-	FailedTests   []string
-	StackTraces   []string
-	ExitCodes     []int
-}
+func logDebugf(format string, args ...any) { debugger.Logf(slog.LevelDebug, format, args...) }
+
+func logInfof(format string, args ...any) { debugger.Logf(slog.LevelInfo, format, args...) }
 
-// FixProposal represents a proposed fix for the workflow failure
-type FixProposal struct {
-	RootCause    string
-	Analysis     string
-	ProposedFix  string
-	FilesToCheck []string
-	CodeChanges  []CodeChange
-	Confidence   string
+func logWarnf(format string, args ...any) { debugger.Logf(slog.LevelWarn, format, args...) }
+
+// exitConfidenceGate is the exit status of an analysis failing --fail-on-confidence, apart from
+// errors (1) and invalid flags (2)
+const exitConfidenceGate = 3
+
+// fatalf logs an error and exits with status 1
+func fatalf(format string, args ...any) {
+	debugger.Logf(slog.LevelError, format, args...)
+	os.Exit(1)
 }
 
-// CodeChange represents a suggested code modification
-type CodeChange struct {
-	File        string
-	Description string
-	DiffSnippet string
+// parseArgs parses flags from args, allowing them both before and after positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }
 
-// GitHubWorkflowDebugger is the main AI agent
-type GitHubWorkflowDebugger struct {
-	openaiClient *openai.Client
-	apiKey       string
-	model        string
+// fixBranchFlag is --fix-branch, a boolean flag that optionally takes the branch name (--fix-branch=name)
+type fixBranchFlag struct {
+	enabled *bool
+	name    *string
 }
 
-// NewGitHubWorkflowDebugger creates a new debugger agent
-func NewGitHubWorkflowDebugger(apiKey string) *GitHubWorkflowDebugger {
-	client := openai.NewClient(apiKey)
+func (f fixBranchFlag) IsBoolFlag() bool { return true }
 
-	// Check for model override from environment
-	model := os.Getenv("OPENAI_MODEL")
-	if model == "" {
-		model = openai.GPT4oMini // Default: GPT-4o-mini for cost efficiency
-		// Alternative models:
-		// openai.GPT4o           - Better quality, higher cost
-		// openai.GPT4Turbo       - GPT-4 Turbo
-		// "gpt-4o-mini"          - Use string directly for newer models
+func (f fixBranchFlag) String() string {
+	if f.name == nil {
+		return ""
 	}
+	return *f.name
+}
 
-	return &GitHubWorkflowDebugger{
-		openaiClient: client,
-		apiKey:       apiKey,
-		model:        model,
+func (f fixBranchFlag) Set(value string) error {
+	switch value {
+	case "true":
+		*f.enabled = true
+	case "false":
+		*f.enabled = false
+	default:
+		*f.enabled, *f.name = true, value
 	}
+	return nil
 }
 
-// ParseWorkflowURL extracts repository, run ID, and optional job ID from GitHub Actions URL
-// Supports both formats:
-// - https://github.com/{owner}/{repo}/actions/runs/{run_id}
-// - https://github.com/{owner}/{repo}/actions/runs/{run_id}/job/{job_id}
-func ParseWorkflowURL(url string) (repo, runID, jobID string, err error) {
-	log.Printf("Parsing URL: %s", url)
+// debugLLMFlag is --debug-llm, a boolean flag writing the LLM debug dump to stderr that optionally takes
+// a file instead (--debug-llm=file)
+type debugLLMFlag struct {
+	path *string
+}
 
-	// Try job URL format first (more specific)
-	jobRe := regexp.MustCompile(`github\.com/([^/]+/[^/]+)/actions/runs/(\d+)/job/(\d+)`)
-	matches := jobRe.FindStringSubmatch(url)
+func (f debugLLMFlag) IsBoolFlag() bool { return true }
 
-	if len(matches) == 4 {
-		log.Printf("Detected job URL - Repo: %s, Run ID: %s, Job ID: %s", matches[1], matches[2], matches[3])
-		return matches[1], matches[2], matches[3], nil
+func (f debugLLMFlag) String() string {
+	if f.path == nil {
+		return ""
 	}
+	return *f.path
+}
 
-	// Try workflow run URL format
-	runRe := regexp.MustCompile(`github\.com/([^/]+/[^/]+)/actions/runs/(\d+)`)
-	matches = runRe.FindStringSubmatch(url)
-
-	if len(matches) == 3 {
-		log.Printf("Detected workflow URL - Repo: %s, Run ID: %s", matches[1], matches[2])
-		return matches[1], matches[2], "", nil
+func (f debugLLMFlag) Set(value string) error {
+	switch value {
+	case "true":
+		*f.path = "-"
+	case "false":
+		*f.path = ""
+	default:
+		*f.path = value
 	}
-
-	log.Printf("ERROR: URL format not recognized")
-	return "", "", "", fmt.Errorf("invalid GitHub Actions URL format (expected workflow or job URL)")
+	return nil
 }
 
-// FetchWorkflowData retrieves workflow run data using GitHub CLI
-func (d *GitHubWorkflowDebugger) FetchWorkflowData(workflowURL string) (*WorkflowRun, error) {
-	log.Printf("Starting workflow data fetch...")
+// sinkFlag is --sink, repeatable and comma-separated (--sink file --sink slack, or --sink file,slack)
+type sinkFlag []string
 
-	repo, runID, jobID, err := ParseWorkflowURL(workflowURL)
-	if err != nil {
-		return nil, err
-	}
+func (f *sinkFlag) String() string { return strings.Join(*f, ",") }
 
-	run := &WorkflowRun{
-		URL:        workflowURL,
-		RunID:      runID,
-		Repository: repo,
+func (f *sinkFlag) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(debugger.SinkNames, name) {
+			return fmt.Errorf("unknown sink %q (available: %s)", name, strings.Join(debugger.SinkNames, ", "))
+		}
+		if !slices.Contains(*f, name) {
+			*f = append(*f, name)
+		}
 	}
+	return nil
+}
 
-	log.Printf("Fetching workflow status for run %s in repo %s...", runID, repo)
+// defaultSinks are the sinks of the report without --sink: printed and saved, only printed with
+// --output -, and with --format github written to the job summary and saved
+func defaultSinks(format, output string) []string {
+	switch {
+	case format == "github" && output == "-":
+		return []string{"summary"}
+	case format == "github":
+		return []string{"summary", "file"}
+	case output == "-":
+		return []string{"stdout"}
+	}
+	return []string{"stdout", "file"}
+}
 
-	// Get workflow run status
-	statusCmd := exec.Command("gh", "run", "view", runID, "--repo", repo, "--json", "status,conclusion")
-	statusOutput, err := statusCmd.Output()
+// runBatchMode analyzes the run URLs read from stdin and writes the reports and a summary CSV
+func runBatchMode(apiKey string, opts debugger.Options, batchOpts debugger.BatchOptions) {
+	urls, err := debugger.ReadBatchURLs(os.Stdin)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get workflow status: %w", err)
+		fatalf("%v", err)
 	}
-
-	var statusData map[string]string
-	if err := json.Unmarshal(statusOutput, &statusData); err != nil {
-		return nil, fmt.Errorf("failed to parse status: %w", err)
+	if len(urls) == 0 {
+		fatalf("--batch: no run URLs on stdin")
 	}
 
-	run.Status = statusData["status"]
-	run.Conclusion = statusData["conclusion"]
-
-	log.Printf("Workflow status: %s, conclusion: %s", run.Status, run.Conclusion)
-
-	// Get logs - either for specific job or all failed jobs
-	var failedLogsOutput []byte
-	if jobID != "" {
-		// Fetch logs for specific job
-		log.Printf("Fetching logs for specific job: %s", jobID)
-		jobLogsCmd := exec.Command("gh", "run", "view", runID, "--repo", repo, "--log", "--job", jobID)
-		failedLogsOutput, err = jobLogsCmd.Output()
-		if err != nil {
-			log.Printf("Warning: failed to get job logs: %v", err)
-			log.Printf("Falling back to all failed logs...")
-			// Fallback to failed logs
-			failedLogsCmd := exec.Command("gh", "run", "view", runID, "--repo", repo, "--log-failed")
-			failedLogsOutput, _ = failedLogsCmd.Output()
-		} else {
-			log.Printf("Successfully fetched job logs (%d bytes)", len(failedLogsOutput))
-		}
-	} else {
-		// Get all failed job logs
-		log.Printf("Fetching all failed job logs...")
-		failedLogsCmd := exec.Command("gh", "run", "view", runID, "--repo", repo, "--log-failed")
-		failedLogsOutput, err = failedLogsCmd.Output()
-		if err != nil {
-			log.Printf("Warning: failed to get failed logs: %v", err)
-		} else {
-			log.Printf("Successfully fetched failed logs (%d bytes)", len(failedLogsOutput))
-		}
+	// Concurrent spinners and status lines would interleave, progress is logged per run instead
+	opts.Quiet = true
+	newDebugger := func() *debugger.GitHubWorkflowDebugger {
+		d := debugger.New(apiKey)
+		d.Options = opts
+		return d
 	}
 
-	run.FailedLogs = string(failedLogsOutput)
-
-	// Parse error summary
-	log.Printf("Parsing error summary from logs...")
-	run.ErrorSummary = d.parseErrorSummary(run.FailedLogs)
-	log.Printf("Found %d failed jobs, %d error messages, %d timeouts, %d failed tests",
-		len(run.ErrorSummary.FailedJobs),
-		len(run.ErrorSummary.ErrorMessages),
-		len(run.ErrorSummary.Timeouts),
-		len(run.ErrorSummary.FailedTests))
-
-	return run, nil
-}
-
-// parseErrorSummary extracts structured error information from logs
-func (d *GitHubWorkflowDebugger) parseErrorSummary(logs string) ErrorSummary {
-	summary := ErrorSummary{
-		FailedJobs:    []string{},
-		ErrorMessages: []string{},
-		Timeouts:      []string{},
-		FailedTests:   []string{},
-		StackTraces:   []string{},
-		ExitCodes:     []int{},
-	}
-
-	lines := strings.Split(logs, "\n")
-
-	// Extract job names
-	jobRe := regexp.MustCompile(`^([^/]+) / ([^/]+)\s+`)
-	seenJobs := make(map[string]bool)
-
-	// Extract error patterns
-	for _, line := range lines {
-		// Job names
-		if matches := jobRe.FindStringSubmatch(line); len(matches) > 2 {
-			job := matches[1] + " / " + matches[2]
-			if !seenJobs[job] {
-				summary.FailedJobs = append(summary.FailedJobs, job)
-				seenJobs[job] = true
-			}
-		}
-
-		// Timeout messages
-		if strings.Contains(line, "Timed out") || strings.Contains(line, "timeout") {
-			summary.Timeouts = append(summary.Timeouts, strings.TrimSpace(line))
+	if batchOpts.OutputDir != "" {
+		if err := os.MkdirAll(batchOpts.OutputDir, 0755); err != nil {
+			fatalf("failed to create output directory: %v", err)
 		}
+	}
 
-		// Error messages
-		if strings.Contains(line, "Error:") || strings.Contains(line, "ERROR") {
-			summary.ErrorMessages = append(summary.ErrorMessages, strings.TrimSpace(line))
-		}
+	logInfof("Analyzing %d runs...", len(urls))
+	results := debugger.RunBatch(context.Background(), urls, newDebugger, batchOpts)
 
-		// Test failures
-		if strings.Contains(line, ".go:") && (strings.Contains(line, "FAIL") || strings.Contains(line, "Error")) {
-			summary.FailedTests = append(summary.FailedTests, strings.TrimSpace(line))
-		}
+	summaryFile := filepath.Join(batchOpts.OutputDir, debugger.BatchSummaryFile)
+	if err := debugger.WriteBatchSummary(summaryFile, results); err != nil {
+		fatalf("%v", err)
+	}
 
-		// Exit codes
-		exitCodeRe := regexp.MustCompile(`exit code (\d+)`)
-		if matches := exitCodeRe.FindStringSubmatch(line); len(matches) > 1 {
-			var code int
-			fmt.Sscanf(matches[1], "%d", &code)
-			summary.ExitCodes = append(summary.ExitCodes, code)
+	var failed int
+	var cost float64
+	for _, r := range results {
+		if r.Err != nil && !errors.Is(r.Err, debugger.ErrNothingToAnalyze) {
+			failed++
 		}
+		cost += r.Cost
 	}
-
-	return summary
+	fmt.Printf("Processed %d runs (%d failed, total cost $%.4f), summary saved to: %s\n", len(results), failed, cost, summaryFile)
 }
 
-// AnalyzeFailure uses OpenAI to analyze the workflow failure
-func (d *GitHubWorkflowDebugger) AnalyzeFailure(ctx context.Context, run *WorkflowRun) (*FixProposal, error) {
-	log.Printf("Building analysis prompt...")
-
-	// Build analysis prompt
-	prompt := d.buildAnalysisPrompt(run)
-
-	promptTokens := estimateTokens(prompt)
-	log.Printf("Prompt size: %d characters, estimated %d tokens", len(prompt), promptTokens)
-	log.Printf("Using AI model: %s", d.model)
-
-	// Call OpenAI API
-	log.Printf("Calling OpenAI API...")
-	resp, err := d.openaiClient.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: d.model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are an expert DevOps engineer specializing in debugging CI/CD workflows and GitHub Actions failures. You provide detailed, actionable analysis and fixes.",
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			MaxTokens:   8000,
-			Temperature: 0.7,
-		},
-	)
+// runServeMode analyzes failed runs on GitHub webhooks until interrupted
+func runServeMode(apiKey string, opts debugger.Options, addr string, concurrency int, processedTTL time.Duration) {
+	// Spinners and status lines of concurrent analyses would interleave, progress is logged instead
+	opts.Quiet = true
+	newDebugger := func() *debugger.GitHubWorkflowDebugger {
+		d := debugger.New(apiKey)
+		d.Options = opts
+		return d
+	}
 
+	server, err := debugger.NewServer(newDebugger, os.Getenv("GITHUB_WEBHOOK_SECRET"), concurrency)
 	if err != nil {
-		log.Printf("ERROR: OpenAI API call failed: %v", err)
-		return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+		fatalf("%v", err)
 	}
-
-	if len(resp.Choices) == 0 {
-		log.Printf("ERROR: No response from OpenAI API")
-		return nil, fmt.Errorf("no response from OpenAI API")
+	// --force analyzes every delivery, also redeliveries of runs already processed
+	if !opts.Force {
+		path, err := debugger.DefaultProcessedRunsPath()
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if server.Processed, err = debugger.OpenProcessedRuns(path, processedTTL); err != nil {
+			fatalf("%v", err)
+		}
+		logInfof("Skipping runs already processed (recorded in %s for %s)", path, processedTTL)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := server.ListenAndServe(ctx, addr); err != nil {
+		fatalf("%v", err)
 	}
-
-	responseText := resp.Choices[0].Message.Content
-	log.Printf("Received AI response (%d characters)", len(responseText))
-	log.Printf("API usage - Prompt tokens: %d, Completion tokens: %d, Total: %d",
-		resp.Usage.PromptTokens,
-		resp.Usage.CompletionTokens,
-		resp.Usage.TotalTokens)
-
-	// Parse the response into a structured fix proposal
-	log.Printf("Parsing fix proposal from AI response...")
-	proposal := d.parseFixProposal(responseText, run)
-
-	return proposal, nil
 }
 
-// estimateTokens estimates the number of tokens in a string
-// Conservative approximation: 1 token ~= 2.5 characters for code/logs
-// (English prose is ~4 chars/token, but logs/code are denser)
-func estimateTokens(text string) int {
-	return int(float64(len(text)) / 2.5)
+func usage() {
+	fmt.Println("Usage: github-workflow-debugger [options] <workflow-or-job-url>")
+	fmt.Println("       github-workflow-debugger [options] --repo <owner/repo> --run-id <id> [--job <id-or-name>]")
+	fmt.Println("       github-workflow-debugger [options] latest --repo <owner/repo> --workflow <file> [--branch <name>]")
+	fmt.Println("       github-workflow-debugger [options] --logs-zip <logs.zip> [--repo <owner/repo>]")
+	fmt.Println("       github-workflow-debugger [options] --batch < urls.txt")
+	fmt.Println("       github-workflow-debugger --check")
+	fmt.Println("       github-workflow-debugger --list-models")
+	fmt.Println("       github-workflow-debugger --usage")
+	fmt.Println("       github-workflow-debugger [options] serve [--listen :8080]")
+	fmt.Println("Examples:")
+	fmt.Println("  Workflow: github-workflow-debugger https://github.com/konveyor/ci/actions/runs/19353355807")
+	fmt.Println("  Job:      github-workflow-debugger https://github.com/konveyor/ci/actions/runs/19353355807/job/55364349255")
+	fmt.Println("  Flags:    github-workflow-debugger --repo konveyor/ci --run-id 19353355807")
+	fmt.Println("  Latest:   github-workflow-debugger latest --repo konveyor/ci --workflow ci.yml --branch main")
+	fmt.Println("Options:")
+	flag.CommandLine.SetOutput(os.Stdout)
+	flag.PrintDefaults()
+	fmt.Println("Personas (--persona):")
+	for _, p := range debugger.Personas() {
+		fmt.Printf("  %-10s %s\n", p.Name, p.Description)
+	}
 }
 
-// filterRelevantLogs extracts the most relevant parts of logs
-func (d *GitHubWorkflowDebugger) filterRelevantLogs(logs string, maxChars int) string {
-	log.Printf("Filtering logs - input: %d chars, max: %d chars", len(logs), maxChars)
-
-	lines := strings.Split(logs, "\n")
-
-	// Priority keywords that indicate important information
-	errorKeywords := []string{
-		"error", "ERROR", "Error:", "failed", "FAILED", "Failed",
-		"fatal", "FATAL", "panic", "PANIC",
-		"Timed out", "timeout", "TIMEOUT",
-		"exit code", "Exit code",
-		"assertion", "expected", "actual",
-		"stack trace", "Stack trace",
-		"FAIL:", "FAIL ", "✗", "❌",
+func main() {
+	var opts debugger.Options
+	flag.IntVar(&opts.TailLines, "tail-lines", 0, "analyze only the last N lines of the logs (0 = all)")
+	flag.BoolVar(&opts.SinceLastStep, "since-last-step", false, "analyze only the output of the last step in the logs")
+	maxLogMB := flag.Int("max-log-mb", debugger.DefaultMaxLogBytes>>20, "keep only the last N MB of the logs fetched from GitHub, so enormous runs do not exhaust memory")
+	flag.BoolVar(&opts.FailingStepsOnly, "failing-steps-only", false, "analyze only the steps that exited with a nonzero code and the last lines before them, dropping passing steps")
+	flag.BoolVar(&opts.CreateIssue, "create-issue", false, "create or update a GitHub issue with the report when confidence is High")
+	flag.StringVar(&opts.IssueLabel, "issue-label", debugger.DefaultIssueLabel, "label used to create and find tracking issues")
+	flag.BoolVar(&debugger.NoColor, "no-color", false, "disable colored terminal output (also disabled by NO_COLOR or when not writing to a terminal)")
+	flag.BoolVar(&opts.Quiet, "quiet", false, "only print the report, warnings and errors")
+	var verbose bool
+	flag.BoolVar(&verbose, "verbose", false, "print detailed debugging output")
+	flag.BoolVar(&verbose, "v", false, "shorthand for --verbose")
+	flag.Var(debugLLMFlag{&opts.DebugLLM}, "debug-llm", "write the requests to and raw responses of the AI model to stderr (--debug-llm=file to append them to a file), with secrets redacted and bodies truncated to 64 KiB")
+	flag.BoolVar(&opts.Force, "force", false, "analyze the run even if its conclusion is not a failure (e.g. cancelled); with serve, also analyze runs already processed")
+	flag.BoolVar(&opts.IncludeWorkflow, "include-workflow", false, "include the workflow definition file in the prompt")
+	flag.BoolVar(&opts.CacheResponses, "cache-responses", false, "replay AI responses for identical prompts from the local cache")
+	noCacheResponses := flag.Bool("no-cache-responses", false, "never use cached AI responses (overrides --cache-responses)")
+	flag.DurationVar(&opts.CacheTTL, "cache-ttl", debugger.DefaultCacheTTL, "how long cached AI responses are used")
+	flag.BoolVar(&opts.VerifyFiles, "verify-files", false, "flag files to check that do not exist in the current directory (run from a checkout of the repository)")
+	repoFlag := flag.String("repo", "", "repository (owner/repo) of the run, instead of a URL (requires --run-id)")
+	runIDFlag := flag.String("run-id", "", "ID of the run to analyze, instead of a URL (requires --repo)")
+	logsZip := flag.String("logs-zip", "", "analyze the log archive of a run downloaded from the Actions UI instead of fetching the logs (--repo optionally names the repository)")
+	workflowFlag := flag.String("workflow", "", "workflow file name (e.g. ci.yml) or ID whose latest failed run the latest subcommand analyzes")
+	branchFlag := flag.String("branch", "", "only consider runs on this branch with the latest subcommand (default all branches)")
+	flag.StringVar(&opts.JobName, "job", "", "analyze the job with this name (exact or unique partial match) or numeric ID")
+	flag.StringVar(&opts.StepName, "step", "", "analyze only the output of the step with this name (exact or unique partial match)")
+	flag.BoolVar(&opts.Wait, "wait", false, "wait for a queued or in-progress run to complete before analyzing it")
+	flag.DurationVar(&opts.WaitInterval, "wait-interval", debugger.DefaultWaitInterval, "how often to check the run status with --wait")
+	flag.DurationVar(&opts.WaitTimeout, "wait-timeout", debugger.DefaultWaitTimeout, "maximum time to wait with --wait")
+	flag.BoolVar(&opts.Anonymize, "anonymize", false, "replace the owner and repository names in the prompt with pseudonyms (mycorp/secret-svc -> org/repo-a), mapped back in the report")
+	flag.BoolVar(&opts.IncludeDiff, "include-diff", false, "include the diff of the commit that triggered the run in the prompt")
+	flag.StringVar(&opts.DiffBase, "since-commit", "", "include the diff from this commit, branch or tag to the run's head commit in the prompt, e.g. the last green commit (implies --include-diff)")
+	diffPaths := flag.String("diff-paths", "", "comma-separated globs or directories limiting the included diff, e.g. pkg/,*.go")
+	flag.IntVar(&opts.MaxDiffChars, "max-diff-chars", debugger.DefaultMaxDiffChars, "maximum size of the included diff in chars")
+	flag.IntVar(&opts.Samples, "samples", 1, "sample N analyses and keep the root cause most of them agree on, reporting the agreement (costs N responses)")
+	flag.Float64Var(&opts.MaxCost, "max-cost", 0, "abort if the estimated cost in USD exceeds this limit (0 = no limit)")
+	flag.BoolVar(&opts.Yes, "yes", false, "proceed even if the estimated cost exceeds --max-cost, and confirm --fix-branch")
+	flag.Var(fixBranchFlag{&opts.FixBranch, &opts.FixBranchName}, "fix-branch", "apply the proposed code changes on a branch (--fix-branch=name, default workflow-debugger/fix-run-<run-id>), push it and open a pull request; needs --yes and a clean checkout in the current directory")
+	templateFile := flag.String("template-file", "", "text/template file replacing the default Markdown report (fields: .Run, .Proposal, .Model, .GeneratedAt)")
+	pricesFile := flag.String("prices-file", "", "JSON file overriding the model price table, e.g. {\"gpt-4o\": {\"input\": 2.5, \"output\": 10}}")
+	persona := flag.String("persona", "", "system prompt style of the analysis (see Personas below, default "+debugger.DefaultPersona+")")
+	systemPromptFile := flag.String("system-prompt-file", "", "file with a custom system prompt (replaces --persona)")
+	showBudget := flag.Bool("show-budget", false, "after the analysis, print how much of the logs fit in the prompt: chars and lines included, relevant lines dropped, omitted middle section")
+	tldr := flag.Bool("tldr", false, fmt.Sprintf("print only a one-line summary (at most %d characters) instead of the report", debugger.MaxSummaryChars))
+	flag.BoolVar(&opts.Triage, "triage", false, "pre-classify the failure and skip the AI analysis of clear infrastructure failures (out of memory, disk full, rate limits), giving a canned proposal instead")
+	flag.Float64Var(&opts.TriageThreshold, "triage-threshold", debugger.DefaultTriageThreshold, "share of the failure evidence (0-1) that must point at one infrastructure class for --triage to skip the full analysis")
+	failOnConfidence := flag.String("fail-on-confidence", "", fmt.Sprintf("exit with status %d when the analysis finds a code failure (not an infrastructure one) with at least this confidence: high, medium or low", exitConfidenceGate))
+	reasoningEffort := flag.String("reasoning-effort", debugger.DefaultReasoningEffort, "reasoning effort of reasoning models (o1, o3-mini, gpt-5, ...): low, medium or high; more effort costs more completion tokens")
+	flag.StringVar(&opts.TriageModel, "triage-model", "", "with --triage, analyze clear infrastructure failures with this cheaper model instead of skipping the AI analysis")
+	flag.StringVar(&opts.BundleDir, "bundle-dir", "", "save the failed logs, the prompt, the raw AI response and the report of every analysis to a subdirectory per run of this directory")
+	flag.StringVar(&opts.AuditLog, "audit-log", "", "append metadata of every analysis (user, run, model, tokens, cost, confidence, report hash) as JSON lines to this file")
+	usageLedger := flag.String("usage-ledger", "", "file every AI analysis appends its tokens and cost to (default usage.jsonl in the user cache directory)")
+	noUsageLedger := flag.Bool("no-usage-ledger", false, "do not record the tokens and cost of analyses in the usage ledger")
+	showUsage := flag.Bool("usage", false, "print the tokens and cost of the analyses recorded in the usage ledger by day and model, then exit")
+	listen := flag.String("listen", debugger.DefaultListenAddr, "address the serve subcommand listens on for GitHub webhooks")
+	processedTTL := flag.Duration("processed-ttl", debugger.DefaultProcessedTTL, "how long serve remembers an analyzed run and skips its redelivered webhooks")
+	listModels := flag.Bool("list-models", false, "list the models the LLM endpoint offers, marking the configured one (OPENAI_MODEL) and the estimated cost of an analysis with each, then exit")
+	check := flag.Bool("check", false, "verify gh, its authentication, the API key and the model, then exit (also: doctor)")
+	batch := flag.Bool("batch", false, "analyze the run URLs read from stdin (one per line), writing one report per run and "+debugger.BatchSummaryFile+" to --output-dir")
+	concurrency := flag.Int("concurrency", debugger.DefaultBatchConcurrency, "number of runs analyzed in parallel with --batch or serve")
+	breakerThreshold := flag.Int("breaker-threshold", debugger.DefaultBreakerThreshold, "with --batch, skip the remaining runs after this many consecutive model API failures until a probe succeeds (negative = never)")
+	breakerCooldown := flag.Duration("breaker-cooldown", debugger.DefaultBreakerCooldown, "with --batch, how long runs are skipped before the model API is probed again")
+	batchMaxCost := flag.Float64("batch-max-cost", 0, "stop the batch once the total cost in USD exceeds this limit (0 = no limit)")
+	chat := flag.Bool("chat", false, "after the analysis, ask follow-up questions interactively (exit with /quit or EOF)")
+	output := flag.String("output", "", "report file path, or - to print the report to stdout only (default workflow-debug-<owner>-<repo>-<run-id>-<timestamp>.md or .sarif)")
+	outputDir := flag.String("output-dir", "", "directory for the report file, created if missing (default current directory)")
+	var sinks sinkFlag
+	flag.Var(&sinks, "sink", "where the report goes, repeatable: "+strings.Join(debugger.SinkNames, ", ")+" (default stdout and file); slack posts to SLACK_WEBHOOK_URL, http to --sink-url")
+	sinkURL := flag.String("sink-url", "", "URL the http sink posts the analysis to as JSON (with SINK_AUTHORIZATION as the Authorization header, if set)")
+	flag.StringVar(&opts.PriorReport, "prior-report", "", "report of an earlier analysis whose fix did not work: its root cause and fix are included in the prompt and the model is asked for a different fix")
+	contextFiles := flag.String("context-files", "", "comma-separated source files or globs to include in the prompt, e.g. pkg/api/*.go,main.go")
+	locales := flag.String("locales", "", "comma-separated locales whose error keywords are matched in the logs, e.g. de,fr, or none (default all: "+strings.Join(debugger.KnownLocales(), ",")+")")
+	flag.IntVar(&opts.MaxJobs, "max-jobs", debugger.DefaultMaxJobs, "when more jobs failed, group them by their top error message and analyze the logs of one job per group, the largest groups first (-1 = all jobs)")
+	flag.IntVar(&opts.MaxLineChars, "max-line-chars", debugger.DefaultMaxLineChars, "log lines longer than this are shortened to their start and end, so a minified file or JSON dump on one line does not take up the prompt (-1 = no limit)")
+	flag.IntVar(&opts.MaxErrors, "max-errors", debugger.DefaultMaxErrors, "findings kept per category (errors, timeouts, failed tests, ...), the rest are only counted (-1 = no limit)")
+	severity := flag.String("severity", "", "comma-separated keyword=weight pairs overriding the severities used to keep the most important error lines, e.g. panic:=100,deprecated=0")
+	flag.IntVar(&opts.ErrorContextLines, "error-context", debugger.DefaultErrorContextLines, "log lines kept before and after each error line in the prompt (-1 = only the error lines)")
+	flag.BoolVar(&opts.KeepANSI, "keep-ansi", false, "keep ANSI escape sequences (colors) in the logs instead of stripping them before analysis")
+	flag.BoolVar(&opts.KeepLogPrefixes, "keep-log-prefixes", false, "keep the job/step name and timestamp of every log line in the prompt (stripped by default to save tokens)")
+	flag.BoolVar(&opts.Offline, "offline", false, "triage the failure with built-in heuristic rules instead of an AI model (no API key needed, no data leaves the machine except GitHub API calls)")
+	flag.IntVar(&opts.FlakyRuns, "flaky-runs", 0, "look up the failed tests in this many earlier runs of the workflow to spot flaky tests (0 = off)")
+	flag.IntVar(&opts.FlakyConcurrency, "flaky-concurrency", debugger.DefaultFlakyConcurrency, "earlier runs fetched at the same time by --flaky-runs")
+	flag.BoolVar(&opts.TimeoutAnalysis, "timeout-analysis", false, "analyze the run as a timeout even without timeout messages: ask what made the failed step take so long (automatic when the logs report a timeout)")
+	flag.BoolVar(&opts.Explain, "explain", false, "number the log lines of the prompt and show the lines the model cites as evidence for the root cause in the report")
+	flag.BoolVar(&opts.UseHistory, "use-history", false, "include the fixes of similar past failures in the prompt and record this analysis (uses the embeddings API)")
+	flag.StringVar(&opts.HistoryFile, "history-file", "", "failure history file used by --use-history (default in the user cache directory)")
+	flag.IntVar(&opts.HistoryTopK, "history-top", debugger.DefaultHistoryTopK, "maximum number of similar past failures included with --use-history")
+	flag.StringVar(&opts.CompareURL, "compare", "", "URL of the last passing run; explain what changed between it and the failing run")
+	flag.BoolVar(&opts.DiffOnly, "diff-only", false, "with --compare, report only the regression: changed files, new errors and a targeted fix")
+	apiKeyFile := flag.String("api-key-file", "", "read the API key from this file instead of OPENAI_API_KEY (surrounding whitespace is trimmed)")
+	ignoreFile := flag.String("ignore-file", "", "file with log line patterns to drop before analysis (default .debugignore if present)")
+	keywordsFile := flag.String("keywords-file", "", "file with extra error keywords of the repository, one per line (default .workflow-debugger-keywords if present)")
+	findingsFile := flag.String("findings-file", "", "write every referenced file location as file:line:col: message lines (for editor quickfix lists)")
+	metricsFile := flag.String("metrics-file", "", "write Prometheus metrics (tokens, cost, durations, confidence) to this file")
+	format := flag.String("format", "markdown", "report format: markdown, sarif (SARIF 2.1.0 for code scanning) or github (workflow command annotations on stdout, the report in the job summary)")
+	flag.StringVar(format, "output-format", "markdown", "alias of --format")
+	flag.Usage = usage
+
+	args, err := parseArgs(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		os.Exit(2)
 	}
+	if len(args) > 1 {
+		usage()
+		os.Exit(1)
+	}
+	debugger.SetLogLevel(verbose, opts.Quiet)
 
-	var relevantLines []string
-	var normalLines []string
-
-	// Separate high-priority lines from normal lines
-	for _, line := range lines {
-		isRelevant := false
-		lineLower := strings.ToLower(line)
+	if *check || (len(args) == 1 && args[0] == "doctor") {
+		if !debugger.RunChecks(context.Background(), os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
 
-		for _, keyword := range errorKeywords {
-			if strings.Contains(lineLower, strings.ToLower(keyword)) {
-				isRelevant = true
-				break
+	if !*noUsageLedger {
+		opts.UsageLedger = *usageLedger
+		if opts.UsageLedger == "" {
+			if opts.UsageLedger, err = debugger.DefaultUsageLedgerPath(); err != nil {
+				logWarnf("usage is not recorded: %v", err)
 			}
 		}
-
-		if isRelevant {
-			relevantLines = append(relevantLines, line)
-		} else {
-			normalLines = append(normalLines, line)
+	}
+	if *showUsage {
+		if opts.UsageLedger == "" {
+			fatalf("no usage ledger, see --usage-ledger")
+		}
+		entries, err := debugger.LoadUsage(opts.UsageLedger)
+		if err != nil {
+			fatalf("%v", err)
 		}
+		fmt.Print(debugger.FormatUsage(debugger.SummarizeUsage(entries)))
+		return
 	}
 
-	var result strings.Builder
-	currentSize := 0
-
-	// Add all relevant lines first
-	for _, line := range relevantLines {
-		if currentSize+len(line)+1 > maxChars {
-			break
+	for _, pattern := range strings.Split(*contextFiles, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			opts.ContextFiles = append(opts.ContextFiles, pattern)
 		}
-		result.WriteString(line)
-		result.WriteString("\n")
-		currentSize += len(line) + 1
 	}
-
-	log.Printf("Added %d relevant/error lines (%d chars)", len(relevantLines), currentSize)
-
-	// Add context from end of logs (usually contains the actual failure)
-	remainingChars := maxChars - currentSize
-	if remainingChars > 0 && len(normalLines) > 0 {
-		// Take the last portion of normal lines
-		allNormalText := strings.Join(normalLines, "\n")
-		if len(allNormalText) > remainingChars {
-			result.WriteString("\n...[middle section omitted]...\n\n")
-			truncatedPart := allNormalText[len(allNormalText)-remainingChars:]
-			result.WriteString(truncatedPart)
-			log.Printf("Added %d chars from end of logs (truncated from %d chars)", len(truncatedPart), len(allNormalText))
-		} else {
-			result.WriteString(allNormalText)
-			log.Printf("Added all %d normal lines (%d chars)", len(normalLines), len(allNormalText))
+	if *maxLogMB <= 0 {
+		fatalf("--max-log-mb must be positive")
+	}
+	opts.MaxLogBytes = *maxLogMB << 20
+	if opts.DiffBase != "" {
+		if err := debugger.ValidateDiffBase(opts.DiffBase); err != nil {
+			fatalf("invalid --since-commit: %v", err)
 		}
+		opts.IncludeDiff = true
 	}
-
-	filteredResult := result.String()
-	log.Printf("Log filtering complete - output: %d chars (%.1f%% of input)",
-		len(filteredResult),
-		float64(len(filteredResult))/float64(len(logs))*100)
-
-	return filteredResult
-}
-
-// buildAnalysisPrompt creates the prompt for the AI
-func (d *GitHubWorkflowDebugger) buildAnalysisPrompt(run *WorkflowRun) string {
-	var sb strings.Builder
-
-	sb.WriteString("Analyze this GitHub Actions workflow failure and provide a comprehensive diagnosis.\n\n")
-	sb.WriteString(fmt.Sprintf("## Workflow Information\n"))
-	sb.WriteString(fmt.Sprintf("- URL: %s\n", run.URL))
-	sb.WriteString(fmt.Sprintf("- Repository: %s\n", run.Repository))
-	sb.WriteString(fmt.Sprintf("- Run ID: %s\n", run.RunID))
-	sb.WriteString(fmt.Sprintf("- Status: %s\n", run.Status))
-	sb.WriteString(fmt.Sprintf("- Conclusion: %s\n\n", run.Conclusion))
-
-	sb.WriteString("## Error Summary\n")
-	if len(run.ErrorSummary.FailedJobs) > 0 {
-		sb.WriteString(fmt.Sprintf("Failed Jobs (%d total):\n", len(run.ErrorSummary.FailedJobs)))
-		// Show only first 5 job names, not the full details
-		for i, job := range run.ErrorSummary.FailedJobs {
-			if i >= 5 {
-				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(run.ErrorSummary.FailedJobs)-5))
-				break
-			}
-			sb.WriteString(fmt.Sprintf("  - %s\n", job))
-		}
-	}
-
-	// Skip detailed lists - just show counts
-	if len(run.ErrorSummary.Timeouts) > 0 {
-		sb.WriteString(fmt.Sprintf("Timeout messages: %d\n", len(run.ErrorSummary.Timeouts)))
-	}
-	if len(run.ErrorSummary.FailedTests) > 0 {
-		sb.WriteString(fmt.Sprintf("Failed tests: %d\n", len(run.ErrorSummary.FailedTests)))
-	}
-	if len(run.ErrorSummary.ExitCodes) > 0 {
-		uniqueCodes := make(map[int]bool)
-		for _, code := range run.ErrorSummary.ExitCodes {
-			uniqueCodes[code] = true
-		}
-		codes := []int{}
-		for code := range uniqueCodes {
-			codes = append(codes, code)
-		}
-		sb.WriteString(fmt.Sprintf("Exit Codes: %v\n", codes))
-	}
-
-	// Calculate how much space we have for logs
-	// OpenAI limit: 128k tokens total
-	// Reserve for response: 8k tokens (MaxTokens setting)
-	// Available for input: 120k tokens
-	// 1 token ~= 2.5 chars for logs/code (conservative)
-	//
-	// Token budget breakdown:
-	// - System message: ~50 tokens
-	// - Workflow info: ~50 tokens
-	// - Error summary: ~500 tokens
-	// - Task instructions: ~200 tokens
-	// - Log overhead (formatting): ~200 tokens
-	// - Total overhead: ~1000 tokens
-	//
-	// Safe budget for actual logs: 118k - 1k = 117k tokens
-	// 117k tokens * 2.5 chars/token = ~292k chars
-	// Be very conservative: use 30k chars (~12k tokens) to ensure we stay safe
-	maxLogChars := 30000
-
-	currentPromptSize := sb.Len()
-	remainingChars := maxLogChars - currentPromptSize
-
-	sb.WriteString("\n## Failed Job Logs\n")
-	sb.WriteString("```\n")
-
-	filteredLogs := d.filterRelevantLogs(run.FailedLogs, remainingChars)
-	sb.WriteString(filteredLogs)
-
-	sb.WriteString("\n```\n\n")
-
-	sb.WriteString("## Task\n")
-	sb.WriteString("Please analyze this workflow failure and provide:\n\n")
-	sb.WriteString("1. **Root Cause**: What is the fundamental issue causing the failure?\n")
-	sb.WriteString("2. **Detailed Analysis**: Explain what went wrong, including:\n")
-	sb.WriteString("   - Which component/test failed\n")
-	sb.WriteString("   - Why it failed (timeout, assertion, error, etc.)\n")
-	sb.WriteString("   - Any relevant context from the logs\n")
-	sb.WriteString("3. **Proposed Fix**: Specific, actionable steps to resolve the issue\n")
-	sb.WriteString("4. **Files to Check**: Which files should be examined or modified\n")
-	sb.WriteString("5. **Code Changes**: If applicable, suggest specific code modifications\n")
-	sb.WriteString("6. **Confidence Level**: Rate your confidence in this diagnosis (High/Medium/Low)\n\n")
-	sb.WriteString("Format your response with clear markdown sections using the headers above.\n")
-
-	finalPrompt := sb.String()
-	estimatedTokens := estimateTokens(finalPrompt)
-
-	// Log token estimate for debugging
-	log.Printf("Estimated tokens: %d (max: 128000)", estimatedTokens)
-
-	return finalPrompt
-}
-
-// parseFixProposal extracts structured information from AI response
-func (d *GitHubWorkflowDebugger) parseFixProposal(response string, run *WorkflowRun) *FixProposal {
-	proposal := &FixProposal{}
-
-	// Extract sections using regex
-	rootCauseRe := regexp.MustCompile(`(?i)##?\s*Root Cause[:\s]*\n((?s:.*?))(?:\n##|\n\n##|\z)`)
-	analysisRe := regexp.MustCompile(`(?i)##?\s*Detailed Analysis[:\s]*\n((?s:.*?))(?:\n##|\n\n##|\z)`)
-	fixRe := regexp.MustCompile(`(?i)##?\s*Proposed Fix[:\s]*\n((?s:.*?))(?:\n##|\n\n##|\z)`)
-	filesRe := regexp.MustCompile(`(?i)##?\s*Files to Check[:\s]*\n((?s:.*?))(?:\n##|\n\n##|\z)`)
-	confidenceRe := regexp.MustCompile(`(?i)##?\s*Confidence Level[:\s]*\n?\s*([^\n]+)`)
-
-	if matches := rootCauseRe.FindStringSubmatch(response); len(matches) > 1 {
-		proposal.RootCause = strings.TrimSpace(matches[1])
+	for _, pattern := range strings.Split(*diffPaths, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			opts.DiffPaths = append(opts.DiffPaths, pattern)
+		}
 	}
-
-	if matches := analysisRe.FindStringSubmatch(response); len(matches) > 1 {
-		proposal.Analysis = strings.TrimSpace(matches[1])
+	if len(opts.DiffPaths) > 0 && !opts.IncludeDiff {
+		fatalf("--diff-paths needs --include-diff or --since-commit")
 	}
-
-	if matches := fixRe.FindStringSubmatch(response); len(matches) > 1 {
-		proposal.ProposedFix = strings.TrimSpace(matches[1])
+	if opts.MaxDiffChars <= 0 {
+		fatalf("--max-diff-chars must be positive")
 	}
-
-	if matches := filesRe.FindStringSubmatch(response); len(matches) > 1 {
-		filesText := strings.TrimSpace(matches[1])
-		// Extract file paths (look for lines starting with - or containing .go, .yaml, etc.)
-		fileLines := strings.Split(filesText, "\n")
-		for _, line := range fileLines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "*") {
-				file := strings.TrimPrefix(strings.TrimPrefix(line, "-"), "*")
-				file = strings.TrimSpace(file)
-				if file != "" {
-					proposal.FilesToCheck = append(proposal.FilesToCheck, file)
-				}
-			}
+	if *locales != "" {
+		selected, err := debugger.ParseLocales(*locales)
+		if err != nil {
+			fatalf("invalid --locales: %v", err)
 		}
+		opts.Locales = selected
 	}
-
-	if matches := confidenceRe.FindStringSubmatch(response); len(matches) > 1 {
-		proposal.Confidence = strings.TrimSpace(matches[1])
+	if *severity != "" {
+		weights, err := debugger.ParseSeverityWeights(*severity)
+		if err != nil {
+			fatalf("invalid --severity: %v", err)
+		}
+		opts.SeverityWeights = weights
 	}
 
-	return proposal
-}
-
-// GenerateReport creates a formatted report of the analysis
-func (d *GitHubWorkflowDebugger) GenerateReport(run *WorkflowRun, proposal *FixProposal) string {
-	var sb strings.Builder
-
-	sb.WriteString("# GitHub Workflow Failure Analysis Report\n\n")
-	sb.WriteString(fmt.Sprintf("**Workflow URL**: %s\n", run.URL))
-	sb.WriteString(fmt.Sprintf("**Repository**: %s\n", run.Repository))
-	sb.WriteString(fmt.Sprintf("**Run ID**: %s\n", run.RunID))
-	sb.WriteString(fmt.Sprintf("**Conclusion**: %s\n\n", run.Conclusion))
-
-	sb.WriteString("---\n\n")
-
-	sb.WriteString("## Root Cause\n\n")
-	sb.WriteString(proposal.RootCause)
-	sb.WriteString("\n\n")
-
-	sb.WriteString("## Detailed Analysis\n\n")
-	sb.WriteString(proposal.Analysis)
-	sb.WriteString("\n\n")
+	if opts.ReasoningEffort, err = debugger.ParseReasoningEffort(*reasoningEffort); err != nil {
+		fatalf("invalid --reasoning-effort: %v", err)
+	}
+	gateLevel := ""
+	if *failOnConfidence != "" {
+		if gateLevel, err = debugger.ParseConfidenceThreshold(*failOnConfidence); err != nil {
+			fatalf("invalid --fail-on-confidence: %v", err)
+		}
+	}
 
-	sb.WriteString("## Proposed Fix\n\n")
-	sb.WriteString(proposal.ProposedFix)
-	sb.WriteString("\n\n")
+	if *noCacheResponses {
+		opts.CacheResponses = false
+	}
+	if opts.TailLines < 0 {
+		fatalf("--tail-lines must not be negative")
+	}
+	if opts.FlakyRuns < 0 || opts.FlakyConcurrency < 1 {
+		fatalf("--flaky-runs must not be negative and --flaky-concurrency must be at least 1")
+	}
+	reportExt := "md"
+	switch *format {
+	case "markdown":
+	case "sarif":
+		reportExt = "sarif"
+	case "github":
+		// The markdown report is saved and written to the job summary
+	default:
+		fatalf("unknown --format %q (expected markdown, sarif or github)", *format)
+	}
+	if slices.Contains(sinks, "file") && *output == "-" {
+		fatalf("--sink file cannot be used with --output -")
+	}
+	if slices.Contains(sinks, "slack") && os.Getenv("SLACK_WEBHOOK_URL") == "" {
+		fatalf("--sink slack needs the incoming webhook URL in SLACK_WEBHOOK_URL")
+	}
+	if slices.Contains(sinks, "http") && *sinkURL == "" {
+		fatalf("--sink http needs --sink-url")
+	}
+	if slices.Contains(sinks, "pr-comment") && *format == "sarif" {
+		fatalf("--sink pr-comment needs the markdown report and cannot be used with --format sarif")
+	}
 
-	if len(proposal.FilesToCheck) > 0 {
-		sb.WriteString("## Files to Check\n\n")
-		for _, file := range proposal.FilesToCheck {
-			sb.WriteString(fmt.Sprintf("- %s\n", file))
+	if *systemPromptFile != "" {
+		if *persona != "" {
+			fatalf("give either --persona or --system-prompt-file, not both")
+		}
+		prompt, err := debugger.LoadSystemPrompt(*systemPromptFile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		opts.SystemPrompt = prompt
+	} else if *persona != "" {
+		p, err := debugger.LookupPersona(*persona)
+		if err != nil {
+			fatalf("%v", err)
 		}
-		sb.WriteString("\n")
+		opts.SystemPrompt = p.SystemPrompt
 	}
 
-	if len(proposal.CodeChanges) > 0 {
-		sb.WriteString("## Suggested Code Changes\n\n")
-		for i, change := range proposal.CodeChanges {
-			sb.WriteString(fmt.Sprintf("### Change %d: %s\n\n", i+1, change.File))
-			sb.WriteString(fmt.Sprintf("%s\n\n", change.Description))
-			if change.DiffSnippet != "" {
-				sb.WriteString("```diff\n")
-				sb.WriteString(change.DiffSnippet)
-				sb.WriteString("\n```\n\n")
-			}
+	if opts.TriageThreshold <= 0 || opts.TriageThreshold > 1 {
+		fatalf("--triage-threshold must be greater than 0 and at most 1")
+	}
+	if opts.TriageModel != "" && !opts.Triage {
+		fatalf("--triage-model needs --triage")
+	}
+	if opts.DiffOnly && opts.CompareURL == "" {
+		fatalf("--diff-only needs the passing run to compare with (--compare)")
+	}
+	if opts.DiffOnly && *templateFile != "" {
+		fatalf("--diff-only and --template-file both select the report template, use one of them")
+	}
+	if *templateFile != "" {
+		tmpl, err := debugger.LoadReportTemplate(*templateFile)
+		if err != nil {
+			fatalf("%v", err)
 		}
+		opts.ReportTemplate = tmpl
 	}
 
-	sb.WriteString(fmt.Sprintf("**Confidence Level**: %s\n\n", proposal.Confidence))
-
-	sb.WriteString("---\n\n")
-	sb.WriteString(fmt.Sprintf("*AI Model: %s*\n", d.model))
-	sb.WriteString(fmt.Sprintf("*Generated at %s*\n", time.Now().Format(time.RFC3339)))
-
-	return sb.String()
-}
-
-// Debug is the main entry point for the agent
-func (d *GitHubWorkflowDebugger) Debug(ctx context.Context, workflowURL string) (string, error) {
-	log.Printf("=== GitHub Workflow Debugger Started ===")
-	log.Printf("Workflow URL: %s", workflowURL)
+	if *pricesFile != "" {
+		prices, err := debugger.LoadPriceTable(*pricesFile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		opts.Prices = prices
+	}
 
-	fmt.Println("Fetching workflow data...")
-	run, err := d.FetchWorkflowData(workflowURL)
+	ignorePath := *ignoreFile
+	if ignorePath == "" {
+		ignorePath = debugger.DefaultIgnoreFile
+	}
+	ignore, err := debugger.LoadIgnoreFile(ignorePath, *ignoreFile != "")
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch workflow data: %w", err)
+		fatalf("%v", err)
 	}
+	opts.Ignore = ignore
 
-	fmt.Printf("Workflow Status: %s (%s)\n", run.Status, run.Conclusion)
-	log.Printf("Workflow data fetched successfully")
-
-	fmt.Printf("Analyzing failure with AI...\n")
-
-	proposal, err := d.AnalyzeFailure(ctx, run)
+	keywordsPath := *keywordsFile
+	if keywordsPath == "" {
+		keywordsPath = debugger.DefaultKeywordsFile
+	}
+	keywords, err := debugger.LoadKeywordsFile(keywordsPath, *keywordsFile != "")
 	if err != nil {
-		return "", fmt.Errorf("failed to analyze failure: %w", err)
+		fatalf("%v", err)
 	}
+	opts.ExtraKeywords = append(opts.ExtraKeywords, keywords...)
 
-	log.Printf("AI analysis completed successfully")
-	log.Printf("Generating final report...")
-
-	report := d.GenerateReport(run, proposal)
-
-	log.Printf("Report generated (%d characters)", len(report))
-	log.Printf("=== GitHub Workflow Debugger Completed Successfully ===")
+	if opts.FixBranch && !opts.Yes {
+		fatalf("%v", debugger.ErrFixBranchNotConfirmed)
+	}
+	if opts.Offline && (*chat || opts.UseHistory || opts.Explain) {
+		fatalf("--chat, --use-history and --explain need the AI model and cannot be used with --offline")
+	}
+	if opts.Anonymize && *chat {
+		fatalf("--chat cannot be used with --anonymize: the answers are streamed before their pseudonyms could be mapped back")
+	}
 
-	return report, nil
-}
+	// Get the API key from --api-key-file, OPENAI_API_KEY_FILE or the environment, the offline analysis does not need one
+	if *apiKeyFile != "" {
+		debugger.RegisterKeyProvider(debugger.FileKeyProvider{Path: *apiKeyFile})
+	}
+	apiKey, err := debugger.ResolveAPIKey(context.Background())
+	if err != nil && !opts.Offline {
+		fatalf("%v - run with --check to verify the setup", err)
+	}
+	// Refuse a plaintext or unlisted LLM endpoint before any logs are fetched
+	if !opts.Offline {
+		if err := debugger.New(apiKey).EndpointError(); err != nil {
+			fatalf("%v", err)
+		}
+	}
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: github-workflow-debugger <workflow-or-job-url>")
-		fmt.Println("Examples:")
-		fmt.Println("  Workflow: github-workflow-debugger https://github.com/konveyor/ci/actions/runs/19353355807")
-		fmt.Println("  Job:      github-workflow-debugger https://github.com/konveyor/ci/actions/runs/19353355807/job/55364349255")
-		os.Exit(1)
+	if *listModels {
+		d := debugger.New(apiKey)
+		d.Options = opts
+		models, err := d.ListModels(context.Background())
+		if errors.Is(err, debugger.ErrListModelsUnsupported) {
+			logWarnf("%v, set OPENAI_MODEL to a model name given by the provider", err)
+			return
+		}
+		if err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Print(debugger.FormatModelList(models, d.Model()))
+		return
 	}
 
-	workflowURL := os.Args[1]
+	if len(args) == 1 && args[0] == "serve" {
+		if opts.FixBranch || *logsZip != "" || gateLevel != "" || len(sinks) > 0 {
+			fatalf("--fix-branch, --logs-zip, --fail-on-confidence and --sink cannot be used with serve")
+		}
+		runServeMode(apiKey, opts, *listen, *concurrency, *processedTTL)
+		return
+	}
 
-	// Get API key from environment
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("OPENAI_API_KEY environment variable is required")
+	if *batch {
+		if len(args) > 0 || *repoFlag != "" || *runIDFlag != "" || *logsZip != "" {
+			fatalf("--batch reads the run URLs from stdin, do not give a URL, --repo/--run-id or --logs-zip")
+		}
+		if *chat || *output != "" || opts.FixBranch || gateLevel != "" || len(sinks) > 0 {
+			fatalf("--chat, --output, --fix-branch, --fail-on-confidence and --sink cannot be used with --batch")
+		}
+		if *format == "github" {
+			fatalf("--format github cannot be used with --batch")
+		}
+		runBatchMode(apiKey, opts, debugger.BatchOptions{
+			Concurrency:      *concurrency,
+			MaxTotalCost:     *batchMaxCost,
+			BreakerThreshold: *breakerThreshold,
+			BreakerCooldown:  *breakerCooldown,
+			OutputDir:        *outputDir,
+			Format:           *format,
+		})
+		return
+	}
+
+	if (*workflowFlag != "" || *branchFlag != "") && (len(args) != 1 || args[0] != "latest") {
+		fatalf("--workflow and --branch can only be used with the latest subcommand")
+	}
+
+	var ref debugger.RunRef
+	switch {
+	case *logsZip != "":
+		if len(args) > 0 || *runIDFlag != "" {
+			fatalf("--logs-zip analyzes the logs in the zip, do not give a URL, subcommand or --run-id")
+		}
+		if opts.CompareURL != "" || opts.CreateIssue || opts.FixBranch || opts.FlakyRuns > 0 || opts.Wait || slices.Contains(sinks, "pr-comment") {
+			fatalf("--compare, --create-issue, --fix-branch, --flaky-runs, --wait and --sink pr-comment need the run on GitHub and cannot be used with --logs-zip")
+		}
+		ref = debugger.RunRef{Repository: *repoFlag}
+	case len(args) == 1 && args[0] == "latest":
+		if *repoFlag == "" || *workflowFlag == "" {
+			fatalf("latest requires --repo and --workflow")
+		}
+		if *runIDFlag != "" {
+			fatalf("--run-id cannot be used with latest")
+		}
+		run, err := debugger.LatestFailedRun(*repoFlag, *workflowFlag, *branchFlag)
+		if errors.Is(err, debugger.ErrNoFailedRuns) {
+			logInfof("%v, nothing to analyze", err)
+			return
+		}
+		if err != nil {
+			fatalf("%v", err)
+		}
+		logInfof("Latest failed run: %s (%s on %s, %s, %s)", run.Ref.URL, run.Event, run.Branch,
+			run.CreatedAt.Local().Format("2006-01-02 15:04"), run.Title)
+		ref = run.Ref
+	case *repoFlag != "" || *runIDFlag != "":
+		if len(args) > 0 {
+			fatalf("give either a workflow URL or --repo/--run-id, not both")
+		}
+		jobID := ""
+		if _, err := strconv.ParseUint(opts.JobName, 10, 64); err == nil {
+			jobID, opts.JobName = opts.JobName, ""
+		}
+		if ref, err = debugger.NewRunRef(*repoFlag, *runIDFlag, jobID); err != nil {
+			fatalf("%v", err)
+		}
+	case len(args) == 1:
+		if ref, err = debugger.ParseRunRef(args[0]); err != nil {
+			fatalf("%v", err)
+		}
+	default:
+		usage()
+		os.Exit(1)
 	}
 
-	log.Printf("Initializing debugger...")
+	logInfof("Initializing debugger...")
 
 	// Create debugger
-	debugger := NewGitHubWorkflowDebugger(apiKey)
+	d := debugger.New(apiKey)
+	if *output == "-" || *tldr {
+		opts.StatusToStderr = true
+	}
+	d.Options = opts
 
 	modelUsed := os.Getenv("OPENAI_MODEL")
 	if modelUsed == "" {
 		modelUsed = "gpt-4o-mini (default)"
 	}
-	log.Printf("AI Model: %s", modelUsed)
+	logInfof("AI Model: %s", modelUsed)
+	if account := d.Account(); account != (debugger.OpenAIAccount{}) {
+		logInfof("OpenAI account: %s", account)
+	}
 
 	// Run analysis
 	ctx := context.Background()
-	report, err := debugger.Debug(ctx, workflowURL)
+	var result *debugger.Result
+	if *logsZip != "" {
+		result, err = d.DebugLogsZip(ctx, *logsZip, *repoFlag)
+	} else {
+		result, err = d.DebugRun(ctx, ref)
+	}
+	if *metricsFile != "" && !errors.Is(err, debugger.ErrNothingToAnalyze) {
+		metrics := &debugger.Metrics{Repository: ref.Repository, Timestamp: time.Now()}
+		if result != nil {
+			metrics = result.Metrics
+		}
+		if err := debugger.WriteMetricsFile(*metricsFile, metrics); err != nil {
+			logWarnf("%v", err)
+		}
+	}
+	if errors.Is(err, debugger.ErrNothingToAnalyze) {
+		logInfof("Skipping analysis: %v", err)
+		return
+	}
+	if errors.Is(err, debugger.ErrRunInProgress) {
+		fatalf("%v - wait for the run to finish, or use --wait", err)
+	}
 	if err != nil {
-		log.Fatalf("Error: %v", err)
+		fatalf("%v", err)
+	}
+	if *findingsFile != "" {
+		if err := debugger.WriteFindingsFile(*findingsFile, result.Run, result.Proposal); err != nil {
+			logWarnf("%v", err)
+		}
 	}
 
-	// Print report
-	fmt.Println("\n" + report)
+	if *showBudget {
+		// Status output, so it stays off stdout when stdout carries only the report or summary
+		out := os.Stdout
+		if *output == "-" || *tldr {
+			out = os.Stderr
+		}
+		if result.Run.LogBudget != nil {
+			fmt.Fprint(out, result.Run.LogBudget.Summary())
+		} else {
+			fmt.Fprintln(out, "Log budget: no prompt was built (offline analysis or no logs)")
+		}
+	}
 
-	// Save report to file
-	reportFile := fmt.Sprintf("workflow-debug-%s.md", time.Now().Format("20060102-150405"))
-	log.Printf("Saving report to: %s", reportFile)
-	if err := os.WriteFile(reportFile, []byte(report), 0644); err != nil {
-		log.Printf("Warning: failed to save report to file: %v", err)
-	} else {
-		fmt.Printf("\nReport saved to: %s\n", reportFile)
-		log.Printf("Report file saved successfully")
+	// With --fail-on-confidence, a confident diagnosis of a code failure fails the step once the
+	// report is out
+	checkGate := func() {
+		if gateLevel == "" {
+			return
+		}
+		fail, reason := d.ConfidenceGate(result, gateLevel)
+		if fail {
+			logWarnf("Failing the check (--fail-on-confidence %s): %s", strings.ToLower(gateLevel), reason)
+			os.Exit(exitConfidenceGate)
+		}
+		logInfof("Not failing the check (--fail-on-confidence %s): %s", strings.ToLower(gateLevel), reason)
+	}
+
+	// Only the one-line summary, e.g. for a commit status description
+	if *tldr {
+		fmt.Println(result.Proposal.Summary)
+		checkGate()
+		return
+	}
+
+	report, err := d.RenderReport(result, *format)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	if *format == "github" {
+		// The runner turns the workflow commands printed to stdout into annotations,
+		// the sinks get the markdown report
+		fmt.Print(report)
+		report = result.Report
+	}
+	if len(sinks) == 0 {
+		sinks = defaultSinks(*format, *output)
+	}
+	var outputs []debugger.Sink
+	for _, name := range sinks {
+		switch name {
+		case "stdout":
+			outputs = append(outputs, debugger.StdoutSink{})
+		case "file":
+			path := debugger.ReportPath(*output, *outputDir, reportExt, result.Run.Ref(), time.Now())
+			outputs = append(outputs, debugger.FileSink{Path: path, Status: os.Stdout, New: *output == ""})
+		case "summary":
+			outputs = append(outputs, debugger.StepSummarySink{})
+		case "slack":
+			outputs = append(outputs, debugger.SlackSink{WebhookURL: os.Getenv("SLACK_WEBHOOK_URL")})
+		case "pr-comment":
+			outputs = append(outputs, debugger.PRCommentSink{})
+		case "http":
+			sink := debugger.HTTPSink{URL: *sinkURL}
+			if auth := os.Getenv("SINK_AUTHORIZATION"); auth != "" {
+				sink.Headers = map[string]string{"Authorization": auth}
+			}
+			outputs = append(outputs, sink)
+		}
+	}
+	if err := debugger.WriteSinks(ctx, outputs, result.Run, result.Proposal, report); err != nil {
+		logWarnf("%v", err)
+	}
+
+	if *chat {
+		if err := d.Chat(ctx, os.Stdin, os.Stdout, result); err != nil {
+			fatalf("chat failed: %v", err)
+		}
 	}
+	checkGate()
 }